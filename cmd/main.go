@@ -13,18 +13,23 @@ import (
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	"github.com/codeready-toolchain/registration-service/pkg/auth"
 	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	"github.com/codeready-toolchain/registration-service/pkg/indexes"
 	"github.com/codeready-toolchain/registration-service/pkg/log"
 	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
 	"github.com/codeready-toolchain/registration-service/pkg/proxy"
 	"github.com/codeready-toolchain/registration-service/pkg/proxy/metrics"
 	"github.com/codeready-toolchain/registration-service/pkg/server"
+	"github.com/codeready-toolchain/registration-service/pkg/signup"
+	"github.com/codeready-toolchain/registration-service/pkg/signup/events"
 	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
 	commonconfig "github.com/codeready-toolchain/toolchain-common/pkg/configuration"
 	errs "github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap/zapcore"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/client-go/rest"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -58,6 +63,11 @@ func main() {
 		panic(fmt.Errorf("%s not set", commonconfig.WatchNamespaceEnvVar))
 	}
 
+	// read the settings which are not part of the ToolchainConfig resource
+	if err := configuration.LoadSettings(); err != nil {
+		panic(err.Error())
+	}
+
 	// Get a config to talk to the apiserver
 	cfg, err := config.GetConfig()
 	if err != nil {
@@ -66,11 +76,13 @@ func main() {
 
 	ctx := controllerruntime.SetupSignalHandler()
 
-	// create cached runtime client
-	cl, err := newCachedClient(ctx, cfg)
+	// create cached runtime client, which feeds the stream of signup events
+	signupEvents := events.NewBroadcaster()
+	hostCluster, err := newHostCluster(ctx, cfg, signupEvents)
 	if err != nil {
 		panic(err.Error())
 	}
+	cl := hostCluster.GetClient()
 
 	configuration.SetClient(cl)
 	crtConfig := configuration.GetRegistrationServiceConfig()
@@ -86,7 +98,16 @@ func main() {
 			panic(fmt.Sprintf("cannot set captcha credentials: %s", err.Error()))
 		}
 	}
-	nsClient := namespaced.NewClient(cl, configuration.Namespace())
+	nsClient := namespaced.NewClientWithAPIReader(cl, hostCluster.GetAPIReader(), configuration.Namespace())
+
+	// assign the tier chosen by the users to their MasterUserRecord once the host operator created it
+	murInformer, err := hostCluster.GetCache().GetInformer(ctx, &toolchainv1alpha1.MasterUserRecord{})
+	if err != nil {
+		panic(err.Error())
+	}
+	if _, err := murInformer.AddEventHandler(signup.NewTierAssigner(ctx, nsClient).EventHandler()); err != nil {
+		panic(err.Error())
+	}
 
 	app := server.NewInClusterApplication(nsClient)
 	// Initialize toolchain cluster cache service
@@ -122,7 +143,7 @@ func main() {
 	regsvcRegistry := prometheus.NewRegistry()
 	configuration.RegisterVersionMetrics(regsvcRegistry)
 	regsvcMetricsSrv, _ := server.StartMetricsServer(regsvcRegistry, server.RegSvcMetricsPort)
-	regsvcSrv := server.New(app)
+	regsvcSrv := server.New(app, server.WithSignupEvents(signupEvents))
 	err = regsvcSrv.SetupRoutes(proxy.DefaultPort, regsvcRegistry, nsClient)
 	if err != nil {
 		panic(err.Error())
@@ -162,7 +183,9 @@ func gracefulShutdown(ctx context.Context, timeout time.Duration, hs ...*http.Se
 	}
 }
 
-func newCachedClient(ctx context.Context, cfg *rest.Config) (client.Client, error) {
+// newHostCluster returns the started host cluster, whose client reads from the cache of the host-operator namespace and
+// whose API reader bypasses the cache
+func newHostCluster(ctx context.Context, cfg *rest.Config, signupEvents *events.Broadcaster) (runtimecluster.Cluster, error) {
 	scheme := runtime.NewScheme()
 	var AddToSchemes runtime.SchemeBuilder
 	addToSchemes := append(AddToSchemes,
@@ -173,14 +196,28 @@ func newCachedClient(ctx context.Context, cfg *rest.Config) (client.Client, erro
 		return nil, err
 	}
 
+	managedConfigMaps, err := labels.NewRequirement(configuration.ConfigMapLabelKey, selection.Exists, nil)
+	if err != nil {
+		return nil, err
+	}
+	configMapSelector := labels.NewSelector().Add(*managedConfigMaps)
+
 	hostCluster, err := runtimecluster.New(cfg, func(options *runtimecluster.Options) {
 		options.Scheme = scheme
 		// cache only in the host-operator namespace
 		options.Cache.DefaultNamespaces = map[string]cache.Config{configuration.Namespace(): {}}
+		// cache only the ConfigMaps created by the registration service
+		options.Cache.ByObject = map[client.Object]cache.ByObject{
+			&corev1.ConfigMap{}: {Label: configMapSelector},
+		}
 	})
 	if err != nil {
 		return nil, err
 	}
+	// register the field indexes before the informers are started
+	if err := indexes.Register(ctx, hostCluster.GetFieldIndexer()); err != nil {
+		return nil, err
+	}
 	go func() {
 		if err := hostCluster.Start(ctx); err != nil {
 			panic(fmt.Errorf("failed to create cached client: %w", err))
@@ -201,10 +238,12 @@ func newCachedClient(ctx context.Context, cfg *rest.Config) (client.Client, erro
 		"UserSignup":       &toolchainv1alpha1.UserSignupList{},
 		"ProxyPlugin":      &toolchainv1alpha1.ProxyPluginList{},
 		"NSTemplateTier":   &toolchainv1alpha1.NSTemplateTierList{},
+		"UserTier":         &toolchainv1alpha1.UserTierList{},
 		"ToolchainConfig":  &toolchainv1alpha1.ToolchainConfigList{},
 		"BannedUser":       &toolchainv1alpha1.BannedUserList{},
 		"ToolchainCluster": &toolchainv1alpha1.ToolchainClusterList{},
-		"Secret":           &corev1.SecretList{}}
+		"Secret":           &corev1.SecretList{},
+		"ConfigMap":        &corev1.ConfigMapList{}}
 
 	for resourceName := range objectsToList {
 		log.Infof(nil, "Syncing informer cache with %s resources", resourceName)
@@ -216,7 +255,18 @@ func newCachedClient(ctx context.Context, cfg *rest.Config) (client.Client, erro
 
 	log.Info(nil, "Informer caches synced")
 
-	return hostCluster.GetClient(), nil
+	// notify the changes of the resources which affect the status of the signups
+	for _, obj := range []client.Object{&toolchainv1alpha1.UserSignup{}, &toolchainv1alpha1.MasterUserRecord{}, &toolchainv1alpha1.Space{}} {
+		informer, err := hostCluster.GetCache().GetInformer(ctx, obj)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := informer.AddEventHandler(signupEvents.EventHandler()); err != nil {
+			return nil, err
+		}
+	}
+
+	return hostCluster, nil
 }
 
 func createCaptchaFileFromSecret(cfg configuration.RegistrationServiceConfig) error {
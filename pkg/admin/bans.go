@@ -0,0 +1,359 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/indexes"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/toolchain-common/pkg/hash"
+	"github.com/codeready-toolchain/toolchain-common/pkg/states"
+	signupcommon "github.com/codeready-toolchain/toolchain-common/pkg/usersignup"
+	"github.com/gin-gonic/gin"
+	"github.com/nyaruka/phonenumbers"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	bannedUserEmailNamePrefix = "banneduser-"
+	bannedUserPhoneNamePrefix = "banneduser-phone-"
+)
+
+var (
+	// ErrBanNotFound is returned when the BannedUser to delete does not exist
+	ErrBanNotFound = errors.New("BannedUser not found")
+	// ErrInvalidPhoneNumber is returned when the phone number to ban is not a valid phone number in the E.164 format
+	ErrInvalidPhoneNumber = errors.New("invalid phone number")
+
+	invalidLabelValueChars = regexp.MustCompile("[^A-Za-z0-9._-]")
+)
+
+// Ban represents a BannedUser
+type Ban struct {
+	// Name is the name of the BannedUser resource
+	Name string `json:"name"`
+	// Email is the banned email address, if any
+	Email string `json:"email,omitempty"`
+	// EmailHash is the hash of the banned email address, if any
+	EmailHash string `json:"emailHash,omitempty"`
+	// PhoneHash is the hash of the banned phone number, if any
+	PhoneHash string `json:"phoneHash,omitempty"`
+	// Reason is the reason of the ban
+	Reason string `json:"reason,omitempty"`
+	// BannedBy is the username of the administrator who banned the user
+	BannedBy string `json:"bannedBy,omitempty"`
+	// Created is the creation time of the BannedUser
+	Created time.Time `json:"created"`
+}
+
+// AffectedSignup is an active UserSignup whose user is banned
+type AffectedSignup struct {
+	// Name is the name of the UserSignup resource
+	Name string `json:"name"`
+	// Username is the preferred username of the user
+	Username string `json:"username"`
+	// CompliantUsername is the name of the MasterUserRecord of the user, if provisioned
+	CompliantUsername string `json:"compliantUsername,omitempty"`
+}
+
+// BanResult is the result of a ban
+type BanResult struct {
+	// Ban is the BannedUser which bans the user
+	Ban Ban `json:"ban"`
+	// Created is false if the user was already banned
+	Created bool `json:"created"`
+	// AffectedSignups are the active UserSignups matching the banned email address or phone number
+	AffectedSignups []AffectedSignup `json:"affectedSignups"`
+}
+
+// Bans creates, lists and deletes the BannedUsers
+type Bans struct {
+	namespaced.Client
+}
+
+// NewBans returns a new Bans instance
+func NewBans(nsClient namespaced.Client) *Bans {
+	return &Bans{
+		Client: nsClient,
+	}
+}
+
+// BanEmail bans the given email address. Banning an email address which is already banned has no effect.
+func (b *Bans) BanEmail(ctx *gin.Context, email, reason, bannedBy string) (*BanResult, error) {
+	return b.ban(ctx, email, hash.EncodeString(email), "", reason, bannedBy)
+}
+
+// BanPhone bans the given phone number, which must be in the E.164 format.
+// Banning a phone number which is already banned has no effect.
+func (b *Bans) BanPhone(ctx *gin.Context, phone, reason, bannedBy string) (*BanResult, error) {
+	number, err := phonenumbers.Parse(phone, "")
+	if err != nil || !phonenumbers.IsValidNumber(number) {
+		return nil, fmt.Errorf("%w '%s'", ErrInvalidPhoneNumber, phone)
+	}
+	return b.ban(ctx, "", "", hash.EncodeString(phonenumbers.Format(number, phonenumbers.E164)), reason, bannedBy)
+}
+
+// BanUser bans the email address and the phone number (if verified) of the user with the given username, which is either
+// the username used to sign up or the compliant username. If the email address is already banned, the phone number is
+// banned too. Returns ErrNotFound if there is no such user.
+func (b *Bans) BanUser(ctx *gin.Context, username, reason, bannedBy string) (*BanResult, error) {
+	userSignup, err := b.getUserSignup(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	email := userSignup.Spec.IdentityClaims.Email
+	emailHash := userSignup.Labels[toolchainv1alpha1.UserSignupUserEmailHashLabelKey]
+	if emailHash == "" {
+		emailHash = hash.EncodeString(email)
+	}
+	return b.ban(ctx, email, emailHash, userSignup.Labels[toolchainv1alpha1.UserSignupUserPhoneHashLabelKey], reason, bannedBy)
+}
+
+// List returns all the BannedUsers, sorted by name
+func (b *Bans) List(ctx *gin.Context) ([]Ban, error) {
+	bannedUsers := &toolchainv1alpha1.BannedUserList{}
+	if err := b.Client.List(ctx, bannedUsers, client.InNamespace(b.Namespace)); err != nil {
+		return nil, err
+	}
+	sort.Slice(bannedUsers.Items, func(i, j int) bool {
+		return bannedUsers.Items[i].Name < bannedUsers.Items[j].Name
+	})
+	bans := make([]Ban, 0, len(bannedUsers.Items))
+	for i := range bannedUsers.Items {
+		bans = append(bans, toBan(&bannedUsers.Items[i]))
+	}
+	return bans, nil
+}
+
+// Unban deletes the BannedUser with the given name. Returns ErrBanNotFound if there is no such BannedUser.
+func (b *Bans) Unban(ctx *gin.Context, name string) error {
+	bannedUser := &toolchainv1alpha1.BannedUser{}
+	if err := b.Get(ctx, b.NamespacedName(name), bannedUser); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ErrBanNotFound
+		}
+		return err
+	}
+	if err := b.Delete(ctx, bannedUser); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (b *Bans) ban(ctx *gin.Context, email, emailHash, phoneHash, reason, bannedBy string) (*BanResult, error) {
+	result := &BanResult{}
+	existing, err := b.findBannedUser(ctx, emailHash, phoneHash)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		bannedUser := b.newBannedUser(email, emailHash, phoneHash, reason, bannedBy)
+		if err := b.Create(ctx, bannedUser); err != nil {
+			if !apierrors.IsAlreadyExists(err) {
+				return nil, err
+			}
+			// banned concurrently
+			existing = &toolchainv1alpha1.BannedUser{}
+			if err := b.Get(ctx, b.NamespacedName(bannedUser.Name), existing); err != nil {
+				return nil, err
+			}
+		} else {
+			existing = bannedUser
+			result.Created = true
+		}
+	}
+	if emailHash != "" && phoneHash != "" {
+		if existing, err = b.banPhoneToo(ctx, existing, phoneHash, reason, bannedBy); err != nil {
+			return nil, err
+		}
+	}
+	result.Ban = toBan(existing)
+
+	result.AffectedSignups, err = b.affectedSignups(ctx, emailHash, phoneHash)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// banPhoneToo makes sure that the given phone hash is banned along with the email address banned by the given
+// BannedUser, which may have been created without the phone number (eg, by BanEmail): the phone hash label is added
+// to the BannedUser if it has none, otherwise the phone number is banned by another BannedUser.
+// Returns the latest version of the given BannedUser.
+func (b *Bans) banPhoneToo(ctx *gin.Context, bannedUser *toolchainv1alpha1.BannedUser, phoneHash, reason, bannedBy string) (*toolchainv1alpha1.BannedUser, error) {
+	switch bannedUser.Labels[toolchainv1alpha1.BannedUserPhoneNumberHashLabelKey] {
+	case phoneHash:
+		return bannedUser, nil
+	case "":
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			if err := b.APIReader.Get(ctx, b.NamespacedName(bannedUser.Name), bannedUser); err != nil {
+				return err
+			}
+			if current := bannedUser.Labels[toolchainv1alpha1.BannedUserPhoneNumberHashLabelKey]; current != "" {
+				// labelled in the meantime
+				return nil
+			}
+			if bannedUser.Labels == nil {
+				bannedUser.Labels = map[string]string{}
+			}
+			bannedUser.Labels[toolchainv1alpha1.BannedUserPhoneNumberHashLabelKey] = phoneHash
+			return b.Update(ctx, bannedUser)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if bannedUser.Labels[toolchainv1alpha1.BannedUserPhoneNumberHashLabelKey] == phoneHash {
+			return bannedUser, nil
+		}
+	}
+	// the BannedUser already bans another phone number
+	if _, err := b.ban(ctx, "", "", phoneHash, reason, bannedBy); err != nil {
+		return nil, err
+	}
+	return bannedUser, nil
+}
+
+// findBannedUser returns the BannedUser which already bans the given email address, or the given phone number
+// if no email address is given. Returns nil if there is none.
+func (b *Bans) findBannedUser(ctx *gin.Context, emailHash, phoneHash string) (*toolchainv1alpha1.BannedUser, error) {
+	bannedUsers := &toolchainv1alpha1.BannedUserList{}
+	var selector client.ListOption
+	if emailHash != "" {
+		// using the field index of the informer cache
+		selector = client.MatchingFields{indexes.BannedUserEmailHash: emailHash}
+	} else {
+		selector = client.MatchingLabels{toolchainv1alpha1.BannedUserPhoneNumberHashLabelKey: phoneHash}
+	}
+	if err := b.Client.List(ctx, bannedUsers, client.InNamespace(b.Namespace), selector); err != nil {
+		return nil, err
+	}
+	if len(bannedUsers.Items) == 0 {
+		return nil, nil
+	}
+	return &bannedUsers.Items[0], nil
+}
+
+// affectedSignups returns the UserSignups which are not deactivated and which match the given email or phone hash
+func (b *Bans) affectedSignups(ctx *gin.Context, emailHash, phoneHash string) ([]AffectedSignup, error) {
+	selectors := []client.ListOption{}
+	if emailHash != "" {
+		selectors = append(selectors, client.MatchingFields{indexes.UserSignupEmailHash: emailHash})
+	}
+	if phoneHash != "" {
+		selectors = append(selectors, client.MatchingLabels{toolchainv1alpha1.UserSignupUserPhoneHashLabelKey: phoneHash})
+	}
+	affected := map[string]AffectedSignup{}
+	for _, selector := range selectors {
+		userSignups := &toolchainv1alpha1.UserSignupList{}
+		if err := b.Client.List(ctx, userSignups, client.InNamespace(b.Namespace), selector); err != nil {
+			return nil, err
+		}
+		for i := range userSignups.Items {
+			userSignup := &userSignups.Items[i]
+			if states.Deactivated(userSignup) {
+				continue
+			}
+			affected[userSignup.Name] = AffectedSignup{
+				Name:              userSignup.Name,
+				Username:          userSignup.Spec.IdentityClaims.PreferredUsername,
+				CompliantUsername: userSignup.Status.CompliantUsername,
+			}
+		}
+	}
+	result := make([]AffectedSignup, 0, len(affected))
+	for _, signup := range affected {
+		result = append(result, signup)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// getUserSignup returns the UserSignup of the user with the given username or compliant username
+func (b *Bans) getUserSignup(ctx *gin.Context, username string) (*toolchainv1alpha1.UserSignup, error) {
+	userSignup := &toolchainv1alpha1.UserSignup{}
+	err := b.Get(ctx, b.NamespacedName(signupcommon.EncodeUserIdentifier(username)), userSignup)
+	if err == nil {
+		return userSignup, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	mur := &toolchainv1alpha1.MasterUserRecord{}
+	if err := b.Get(ctx, b.NamespacedName(username), mur); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	owner := mur.Labels[toolchainv1alpha1.MasterUserRecordOwnerLabelKey]
+	if err := b.Get(ctx, b.NamespacedName(owner), userSignup); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return userSignup, nil
+}
+
+func (b *Bans) newBannedUser(email, emailHash, phoneHash, reason, bannedBy string) *toolchainv1alpha1.BannedUser {
+	bannedUser := &toolchainv1alpha1.BannedUser{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: b.Namespace,
+			Labels: map[string]string{
+				toolchainv1alpha1.BannedByLabelKey: labelValue(bannedBy),
+			},
+			Annotations: map[string]string{
+				ActionByAnnotationKey: bannedBy,
+			},
+		},
+		Spec: toolchainv1alpha1.BannedUserSpec{
+			Email:  email,
+			Reason: reason,
+		},
+	}
+	if emailHash != "" {
+		bannedUser.Name = bannedUserEmailNamePrefix + emailHash
+		bannedUser.Labels[toolchainv1alpha1.BannedUserEmailHashLabelKey] = emailHash
+	} else {
+		bannedUser.Name = bannedUserPhoneNamePrefix + phoneHash
+	}
+	if phoneHash != "" {
+		bannedUser.Labels[toolchainv1alpha1.BannedUserPhoneNumberHashLabelKey] = phoneHash
+	}
+	return bannedUser
+}
+
+func toBan(bannedUser *toolchainv1alpha1.BannedUser) Ban {
+	bannedBy := bannedUser.Annotations[ActionByAnnotationKey]
+	if bannedBy == "" {
+		bannedBy = bannedUser.Labels[toolchainv1alpha1.BannedByLabelKey]
+	}
+	return Ban{
+		Name:      bannedUser.Name,
+		Email:     bannedUser.Spec.Email,
+		EmailHash: bannedUser.Labels[toolchainv1alpha1.BannedUserEmailHashLabelKey],
+		PhoneHash: bannedUser.Labels[toolchainv1alpha1.BannedUserPhoneNumberHashLabelKey],
+		Reason:    bannedUser.Spec.Reason,
+		BannedBy:  bannedBy,
+		Created:   bannedUser.CreationTimestamp.UTC(),
+	}
+}
+
+// labelValue returns the given value in a form which is valid as a label value
+func labelValue(value string) string {
+	value = invalidLabelValueChars.ReplaceAllString(value, "-")
+	if len(value) > 63 {
+		value = value[:63]
+	}
+	return strings.Trim(value, "-_.")
+}
@@ -0,0 +1,313 @@
+package admin_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/admin"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	testutil "github.com/codeready-toolchain/registration-service/test/util"
+	"github.com/codeready-toolchain/toolchain-common/pkg/hash"
+	"github.com/codeready-toolchain/toolchain-common/pkg/states"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	testusersignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const phoneNumber = "+442071838750"
+
+func (s *TestSignupsSuite) TestBans() {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	phoneHash := hash.EncodeString(phoneNumber)
+
+	newUserSignup := func(name, email string, modifiers ...testusersignup.Modifier) *toolchainv1alpha1.UserSignup {
+		return testusersignup.NewUserSignup(append([]testusersignup.Modifier{
+			testusersignup.WithEncodedName(name),
+			testusersignup.WithEmail(email),
+			testusersignup.WithLabel(toolchainv1alpha1.UserSignupUserEmailHashLabelKey, hash.EncodeString(email)),
+		}, modifiers...)...)
+	}
+	newBans := func(objs ...client.Object) (*commontest.FakeClient, *admin.Bans) {
+		fakeClient := testutil.NewFakeClient(s.T(), objs...)
+		return fakeClient, admin.NewBans(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+	}
+	listBannedUsers := func(cl client.Client) []toolchainv1alpha1.BannedUser {
+		bannedUsers := &toolchainv1alpha1.BannedUserList{}
+		require.NoError(s.T(), cl.List(context.TODO(), bannedUsers, client.InNamespace(commontest.HostOperatorNs)))
+		return bannedUsers.Items
+	}
+
+	s.Run("ban by email", func() {
+		// given
+		fakeClient, bans := newBans(
+			newUserSignup("johnny", "johnny@kubesaw.io", testusersignup.WithCompliantUsername("johnny")),
+			newUserSignup("johnny-old", "johnny@kubesaw.io", testusersignup.Deactivated()),
+			newUserSignup("jane", "jane@kubesaw.io"),
+		)
+
+		// when
+		result, err := bans.BanEmail(ctx, "johnny@kubesaw.io", "spam", "admin@kubesaw.io")
+
+		// then
+		require.NoError(s.T(), err)
+		assert.True(s.T(), result.Created)
+		assert.Equal(s.T(), "johnny@kubesaw.io", result.Ban.Email)
+		assert.Equal(s.T(), hash.EncodeString("johnny@kubesaw.io"), result.Ban.EmailHash)
+		assert.Equal(s.T(), "spam", result.Ban.Reason)
+		assert.Equal(s.T(), "admin@kubesaw.io", result.Ban.BannedBy)
+		require.Len(s.T(), result.AffectedSignups, 1)
+		assert.Equal(s.T(), "johnny", result.AffectedSignups[0].CompliantUsername)
+		bannedUsers := listBannedUsers(fakeClient)
+		require.Len(s.T(), bannedUsers, 1)
+		assert.Equal(s.T(), hash.EncodeString("johnny@kubesaw.io"), bannedUsers[0].Labels[toolchainv1alpha1.BannedUserEmailHashLabelKey])
+		assert.Equal(s.T(), "admin-kubesaw.io", bannedUsers[0].Labels[toolchainv1alpha1.BannedByLabelKey])
+
+		s.Run("banning twice has no effect", func() {
+			// when
+			result, err := bans.BanEmail(ctx, "johnny@kubesaw.io", "spam again", "other-admin")
+
+			// then
+			require.NoError(s.T(), err)
+			assert.False(s.T(), result.Created)
+			assert.Equal(s.T(), "spam", result.Ban.Reason)
+			assert.Len(s.T(), result.AffectedSignups, 1)
+			assert.Len(s.T(), listBannedUsers(fakeClient), 1)
+		})
+	})
+
+	s.Run("email already banned by hand", func() {
+		// given
+		bannedUser := &toolchainv1alpha1.BannedUser{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "banned-by-hand",
+				Namespace: commontest.HostOperatorNs,
+				Labels:    map[string]string{toolchainv1alpha1.BannedUserEmailHashLabelKey: hash.EncodeString("johnny@kubesaw.io")},
+			},
+			Spec: toolchainv1alpha1.BannedUserSpec{Email: "johnny@kubesaw.io"},
+		}
+		fakeClient, bans := newBans(bannedUser)
+
+		// when
+		result, err := bans.BanEmail(ctx, "johnny@kubesaw.io", "spam", "admin")
+
+		// then
+		require.NoError(s.T(), err)
+		assert.False(s.T(), result.Created)
+		assert.Equal(s.T(), "banned-by-hand", result.Ban.Name)
+		assert.Len(s.T(), listBannedUsers(fakeClient), 1)
+	})
+
+	s.Run("ban by phone", func() {
+		// given
+		fakeClient, bans := newBans(
+			newUserSignup("johnny", "johnny@kubesaw.io", testusersignup.WithLabel(toolchainv1alpha1.UserSignupUserPhoneHashLabelKey, phoneHash)),
+		)
+
+		// when
+		result, err := bans.BanPhone(ctx, phoneNumber, "fraud", "admin")
+
+		// then
+		require.NoError(s.T(), err)
+		assert.True(s.T(), result.Created)
+		assert.Equal(s.T(), phoneHash, result.Ban.PhoneHash)
+		assert.Empty(s.T(), result.Ban.EmailHash)
+		require.Len(s.T(), result.AffectedSignups, 1)
+		bannedUsers := listBannedUsers(fakeClient)
+		require.Len(s.T(), bannedUsers, 1)
+		assert.Equal(s.T(), phoneHash, bannedUsers[0].Labels[toolchainv1alpha1.BannedUserPhoneNumberHashLabelKey])
+		assert.NotContains(s.T(), bannedUsers[0].Labels, toolchainv1alpha1.BannedUserEmailHashLabelKey)
+
+		s.Run("banning twice has no effect", func() {
+			// when
+			result, err := bans.BanPhone(ctx, phoneNumber, "fraud", "admin")
+
+			// then
+			require.NoError(s.T(), err)
+			assert.False(s.T(), result.Created)
+			assert.Len(s.T(), listBannedUsers(fakeClient), 1)
+		})
+	})
+
+	s.Run("invalid phone number", func() {
+		// given
+		_, bans := newBans()
+
+		// when
+		_, err := bans.BanPhone(ctx, "12345", "fraud", "admin")
+
+		// then
+		require.ErrorIs(s.T(), err, admin.ErrInvalidPhoneNumber)
+	})
+
+	s.Run("ban by username", func() {
+		for name, username := range map[string]string{
+			"username":           "johnny@kubesaw",
+			"compliant username": "johnny",
+		} {
+			s.Run(name, func() {
+				// given
+				userSignup := newUserSignup("johnny@kubesaw", "johnny@kubesaw.io",
+					testusersignup.WithCompliantUsername("johnny"),
+					testusersignup.WithLabel(toolchainv1alpha1.UserSignupUserPhoneHashLabelKey, phoneHash))
+				mur := &toolchainv1alpha1.MasterUserRecord{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "johnny",
+						Namespace: commontest.HostOperatorNs,
+						Labels:    map[string]string{toolchainv1alpha1.MasterUserRecordOwnerLabelKey: userSignup.Name},
+					},
+				}
+				fakeClient, bans := newBans(userSignup, mur,
+					newUserSignup("johnny-2", "johnny.doe@kubesaw.io", testusersignup.WithLabel(toolchainv1alpha1.UserSignupUserPhoneHashLabelKey, phoneHash)))
+
+				// when
+				result, err := bans.BanUser(ctx, username, "abuse", "admin")
+
+				// then
+				require.NoError(s.T(), err)
+				assert.True(s.T(), result.Created)
+				assert.Equal(s.T(), "johnny@kubesaw.io", result.Ban.Email)
+				assert.Equal(s.T(), phoneHash, result.Ban.PhoneHash)
+				assert.Len(s.T(), result.AffectedSignups, 2) // same email or same phone number
+				bannedUsers := listBannedUsers(fakeClient)
+				require.Len(s.T(), bannedUsers, 1)
+				assert.Equal(s.T(), phoneHash, bannedUsers[0].Labels[toolchainv1alpha1.BannedUserPhoneNumberHashLabelKey])
+			})
+		}
+	})
+
+	s.Run("email already banned, then ban by username with phone", func() {
+		// given
+		fakeClient, bans := newBans(newUserSignup("johnny@kubesaw", "johnny@kubesaw.io",
+			testusersignup.WithLabel(toolchainv1alpha1.UserSignupUserPhoneHashLabelKey, phoneHash)))
+		_, err := bans.BanEmail(ctx, "johnny@kubesaw.io", "spam", "admin")
+		require.NoError(s.T(), err)
+
+		// when
+		result, err := bans.BanUser(ctx, "johnny@kubesaw", "abuse", "admin")
+
+		// then
+		require.NoError(s.T(), err)
+		assert.False(s.T(), result.Created)
+		assert.Equal(s.T(), phoneHash, result.Ban.PhoneHash)
+		bannedUsers := listBannedUsers(fakeClient)
+		require.Len(s.T(), bannedUsers, 1)
+		// the phone number is banned by the existing BannedUser
+		assert.Equal(s.T(), phoneHash, bannedUsers[0].Labels[toolchainv1alpha1.BannedUserPhoneNumberHashLabelKey])
+		assert.Equal(s.T(), hash.EncodeString("johnny@kubesaw.io"), bannedUsers[0].Labels[toolchainv1alpha1.BannedUserEmailHashLabelKey])
+	})
+
+	s.Run("email already banned with another phone, then ban by username with phone", func() {
+		// given
+		otherPhoneHash := hash.EncodeString("+12025550199")
+		bannedUser := &toolchainv1alpha1.BannedUser{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "banned-by-hand",
+				Namespace: commontest.HostOperatorNs,
+				Labels: map[string]string{
+					toolchainv1alpha1.BannedUserEmailHashLabelKey:       hash.EncodeString("johnny@kubesaw.io"),
+					toolchainv1alpha1.BannedUserPhoneNumberHashLabelKey: otherPhoneHash,
+				},
+			},
+			Spec: toolchainv1alpha1.BannedUserSpec{Email: "johnny@kubesaw.io"},
+		}
+		fakeClient, bans := newBans(bannedUser, newUserSignup("johnny@kubesaw", "johnny@kubesaw.io",
+			testusersignup.WithLabel(toolchainv1alpha1.UserSignupUserPhoneHashLabelKey, phoneHash)))
+
+		// when
+		result, err := bans.BanUser(ctx, "johnny@kubesaw", "abuse", "admin")
+
+		// then
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), "banned-by-hand", result.Ban.Name)
+		// both phone numbers are banned
+		phoneHashes := []string{}
+		for _, bannedUser := range listBannedUsers(fakeClient) {
+			phoneHashes = append(phoneHashes, bannedUser.Labels[toolchainv1alpha1.BannedUserPhoneNumberHashLabelKey])
+		}
+		assert.ElementsMatch(s.T(), []string{otherPhoneHash, phoneHash}, phoneHashes)
+	})
+
+	s.Run("unknown username", func() {
+		// given
+		_, bans := newBans()
+
+		// when
+		_, err := bans.BanUser(ctx, "johnny", "abuse", "admin")
+
+		// then
+		require.ErrorIs(s.T(), err, admin.ErrNotFound)
+	})
+
+	s.Run("list and unban", func() {
+		// given
+		fakeClient, bans := newBans()
+		_, err := bans.BanEmail(ctx, "johnny@kubesaw.io", "spam", "admin")
+		require.NoError(s.T(), err)
+		_, err = bans.BanPhone(ctx, phoneNumber, "fraud", "admin")
+		require.NoError(s.T(), err)
+
+		// when
+		result, err := bans.List(ctx)
+
+		// then
+		require.NoError(s.T(), err)
+		require.Len(s.T(), result, 2)
+
+		s.Run("unban", func() {
+			// when
+			err := bans.Unban(ctx, result[0].Name)
+
+			// then
+			require.NoError(s.T(), err)
+			assert.Len(s.T(), listBannedUsers(fakeClient), 1)
+		})
+
+		s.Run("unban unknown", func() {
+			// when
+			err := bans.Unban(ctx, "unknown")
+
+			// then
+			require.ErrorIs(s.T(), err, admin.ErrBanNotFound)
+		})
+	})
+
+	s.Run("create fails", func() {
+		// given
+		fakeClient, bans := newBans()
+		fakeClient.MockCreate = func(_ context.Context, _ client.Object, _ ...client.CreateOption) error {
+			return errors.New("oopsie woopsie")
+		}
+
+		// when
+		_, err := bans.BanEmail(ctx, "johnny@kubesaw.io", "spam", "admin")
+
+		// then
+		require.EqualError(s.T(), err, "oopsie woopsie")
+	})
+
+}
+
+func (s *TestSignupsSuite) TestBansDoNotAffectStates() {
+	// given
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	userSignup := testusersignup.NewUserSignup(testusersignup.WithEncodedName("johnny"), testusersignup.ApprovedManually(),
+		testusersignup.WithLabel(toolchainv1alpha1.UserSignupUserEmailHashLabelKey, hash.EncodeString("johnny@kubesaw.io")))
+	fakeClient := testutil.NewFakeClient(s.T(), userSignup)
+	bans := admin.NewBans(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+	// when
+	_, err := bans.BanEmail(ctx, "johnny@kubesaw.io", "spam", "admin")
+
+	// then
+	require.NoError(s.T(), err)
+	actual := &toolchainv1alpha1.UserSignup{}
+	require.NoError(s.T(), fakeClient.Get(context.TODO(), client.ObjectKeyFromObject(userSignup), actual))
+	// the host operator is in charge of deactivating the banned users
+	assert.True(s.T(), states.ApprovedManually(actual))
+}
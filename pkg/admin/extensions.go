@@ -0,0 +1,128 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/signup"
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrNoPendingTrialExtension is returned when deciding on the trial extension request of a user who has no pending request
+var ErrNoPendingTrialExtension = errors.New("no pending trial extension request")
+
+// TrialExtension is a trial extension request of a user, along with the details needed by the administrators to review it
+type TrialExtension struct {
+	// Name is the name of the UserSignup resource
+	Name string `json:"name"`
+	// Username is the preferred username of the user
+	Username string `json:"username"`
+	// Email is the email address of the user
+	Email string `json:"email,omitempty"`
+	// ScheduledDeactivation is the time at which the user is scheduled to be deactivated, in RFC3339 format
+	ScheduledDeactivation string `json:"scheduledDeactivation,omitempty"`
+	signup.TrialExtension
+}
+
+// TrialExtensions lists the pending trial extension requests and applies the decisions of the administrators on them
+type TrialExtensions struct {
+	namespaced.Client
+}
+
+// NewTrialExtensions returns a new TrialExtensions instance
+func NewTrialExtensions(nsClient namespaced.Client) *TrialExtensions {
+	return &TrialExtensions{
+		Client: nsClient,
+	}
+}
+
+// ListPending returns the pending trial extension requests, the oldest first
+func (t *TrialExtensions) ListPending(ctx *gin.Context) ([]TrialExtension, error) {
+	userSignups := &toolchainv1alpha1.UserSignupList{}
+	if err := t.Client.List(ctx, userSignups, client.InNamespace(t.Namespace),
+		client.MatchingLabels{signup.TrialExtensionLabelKey: signup.TrialExtensionPending}); err != nil {
+		return nil, err
+	}
+	maxExtensions := configuration.GetRegistrationServiceConfig().TrialExtensions().Max()
+	extensions := make([]TrialExtension, 0, len(userSignups.Items))
+	for i := range userSignups.Items {
+		extensions = append(extensions, toTrialExtension(&userSignups.Items[i], maxExtensions))
+	}
+	// the timestamps are in RFC3339 format, so they can be compared as strings
+	sort.SliceStable(extensions, func(i, j int) bool {
+		return extensions[i].Requested < extensions[j].Requested
+	})
+	return extensions, nil
+}
+
+// Decide approves or rejects the pending trial extension request of the UserSignup with the given name,
+// and records the username of the administrator who decided on it
+func (t *TrialExtensions) Decide(ctx *gin.Context, name string, action Action, by string) (*TrialExtension, error) {
+	if action != ActionApprove && action != ActionReject {
+		return nil, fmt.Errorf("unknown action '%s'", action)
+	}
+	cfg := configuration.GetRegistrationServiceConfig().TrialExtensions()
+	var result *TrialExtension
+	userSignup := &toolchainv1alpha1.UserSignup{}
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := t.Get(ctx, t.NamespacedName(name), userSignup); err != nil {
+			if apierrors.IsNotFound(err) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if userSignup.Labels[signup.TrialExtensionLabelKey] != signup.TrialExtensionPending {
+			if action == ActionApprove && signup.TrialExtensionUnapplied(userSignup) {
+				// the extension was approved but the trial was not extended, which is done again below
+				updated := toTrialExtension(userSignup, cfg.Max())
+				result = &updated
+				return nil
+			}
+			return ErrNoPendingTrialExtension
+		}
+		switch action {
+		case ActionApprove:
+			signup.ApproveTrialExtension(userSignup, cfg.Duration(), by, time.Now())
+		case ActionReject:
+			signup.RejectTrialExtension(userSignup, by)
+		}
+		if err := t.Update(ctx, userSignup); err != nil {
+			return err
+		}
+		updated := toTrialExtension(userSignup, cfg.Max())
+		result = &updated
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if action == ActionApprove {
+		if err := signup.ApplyTrialExtension(ctx, t.Client, userSignup); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func toTrialExtension(userSignup *toolchainv1alpha1.UserSignup, maxExtensions int) TrialExtension {
+	result := TrialExtension{
+		Name:     userSignup.Name,
+		Username: userSignup.Spec.IdentityClaims.PreferredUsername,
+		Email:    userSignup.Spec.IdentityClaims.Email,
+	}
+	if !userSignup.Status.ScheduledDeactivationTimestamp.IsZero() {
+		result.ScheduledDeactivation = userSignup.Status.ScheduledDeactivationTimestamp.UTC().Format(time.RFC3339)
+	}
+	if extension := signup.GetTrialExtension(userSignup, maxExtensions); extension != nil {
+		result.TrialExtension = *extension
+	}
+	return result
+}
@@ -0,0 +1,194 @@
+package admin_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/admin"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/signup"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test/masteruserrecord"
+	testusersignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newTrialExtensionRequest(name string, requested time.Time) *toolchainv1alpha1.UserSignup {
+	userSignup := newUserSignup(name, toolchainv1alpha1.UserSignupStateLabelValueApproved, requested,
+		testusersignup.WithCompliantUsername(name))
+	signup.RequestTrialExtension(userSignup, "finishing my project", requested)
+	return userSignup
+}
+
+func (s *TestSignupsSuite) TestTrialExtensions() {
+	// given
+	now := time.Now().Truncate(time.Second)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	userTier := masteruserrecord.DefaultUserTier()
+	newTrialExtensions := func() (*commontest.FakeClient, *admin.TrialExtensions) {
+		fakeClient := commontest.NewFakeClient(s.T(),
+			newTrialExtensionRequest("johnny", now),
+			newTrialExtensionRequest("jane", now.Add(-time.Hour)),
+			newUserSignup("ted", toolchainv1alpha1.UserSignupStateLabelValueApproved, now),
+			masteruserrecord.NewMasterUserRecord(s.T(), "jane", masteruserrecord.ProvisionedMur(&metav1.Time{Time: now.Add(-time.Hour)})),
+			&userTier,
+		)
+		return fakeClient, admin.NewTrialExtensions(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+	}
+
+	s.Run("list the pending requests", func() {
+		// given
+		_, extensions := newTrialExtensions()
+
+		// when
+		result, err := extensions.ListPending(ctx)
+
+		// then
+		require.NoError(s.T(), err)
+		require.Len(s.T(), result, 2)
+		assert.Equal(s.T(), "jane", result[0].Name) // oldest first
+		assert.Equal(s.T(), "johnny", result[1].Name)
+		assert.Equal(s.T(), signup.TrialExtensionPending, result[1].State)
+		assert.Equal(s.T(), "finishing my project", result[1].Justification)
+		assert.Equal(s.T(), 1, result[1].Remaining)
+	})
+
+	s.Run("approve a request", func() {
+		// given
+		fakeClient, extensions := newTrialExtensions()
+
+		// when
+		result, err := extensions.Decide(ctx, "jane", admin.ActionApprove, "admin")
+
+		// then
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), signup.TrialExtensionApproved, result.State)
+		assert.Equal(s.T(), 1, result.Extensions)
+		assert.NotEmpty(s.T(), result.EndDate)
+		userSignup := &toolchainv1alpha1.UserSignup{}
+		require.NoError(s.T(), fakeClient.Get(context.TODO(), client.ObjectKey{Namespace: commontest.HostOperatorNs, Name: "jane"}, userSignup))
+		assert.Equal(s.T(), "admin", userSignup.Annotations[signup.TrialExtensionDecidedByAnnotationKey])
+		assert.NotContains(s.T(), userSignup.Annotations, signup.TrialExtensionUnappliedAnnotationKey)
+		// the trial is extended by 30 days from now, and the deactivation timeout of the tier is 30 days
+		mur := &toolchainv1alpha1.MasterUserRecord{}
+		require.NoError(s.T(), fakeClient.Get(context.TODO(), client.ObjectKey{Namespace: commontest.HostOperatorNs, Name: "jane"}, mur))
+		assert.WithinDuration(s.T(), now, mur.Status.ProvisionedTime.Time, time.Minute)
+
+		s.Run("the request is no longer pending", func() {
+			// when
+			pending, err := extensions.ListPending(ctx)
+			require.NoError(s.T(), err)
+			_, decideErr := extensions.Decide(ctx, "jane", admin.ActionReject, "admin")
+
+			// then
+			require.Len(s.T(), pending, 1)
+			assert.Equal(s.T(), "johnny", pending[0].Name)
+			require.ErrorIs(s.T(), decideErr, admin.ErrNoPendingTrialExtension)
+		})
+	})
+
+	s.Run("approve a request again when the trial could not be extended", func() {
+		// given
+		fakeClient, extensions := newTrialExtensions()
+		fakeClient.MockStatusUpdate = func(_ context.Context, _ client.Object, _ ...client.SubResourceUpdateOption) error {
+			return errors.New("an error occurred")
+		}
+		_, err := extensions.Decide(ctx, "jane", admin.ActionApprove, "admin")
+		require.EqualError(s.T(), err, "an error occurred")
+		userSignup := &toolchainv1alpha1.UserSignup{}
+		require.NoError(s.T(), fakeClient.Get(context.TODO(), client.ObjectKey{Namespace: commontest.HostOperatorNs, Name: "jane"}, userSignup))
+		assert.Contains(s.T(), userSignup.Annotations, signup.TrialExtensionUnappliedAnnotationKey)
+		fakeClient.MockStatusUpdate = nil
+
+		// when
+		result, err := extensions.Decide(ctx, "jane", admin.ActionApprove, "admin")
+
+		// then
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), signup.TrialExtensionApproved, result.State)
+		assert.Equal(s.T(), 1, result.Extensions)
+		require.NoError(s.T(), fakeClient.Get(context.TODO(), client.ObjectKey{Namespace: commontest.HostOperatorNs, Name: "jane"}, userSignup))
+		assert.NotContains(s.T(), userSignup.Annotations, signup.TrialExtensionUnappliedAnnotationKey)
+		mur := &toolchainv1alpha1.MasterUserRecord{}
+		require.NoError(s.T(), fakeClient.Get(context.TODO(), client.ObjectKey{Namespace: commontest.HostOperatorNs, Name: "jane"}, mur))
+		assert.WithinDuration(s.T(), now, mur.Status.ProvisionedTime.Time, time.Minute)
+
+		s.Run("the applied extension cannot be approved again", func() {
+			// when
+			_, err := extensions.Decide(ctx, "jane", admin.ActionApprove, "admin")
+
+			// then
+			require.ErrorIs(s.T(), err, admin.ErrNoPendingTrialExtension)
+		})
+	})
+
+	s.Run("reject a request", func() {
+		// given
+		_, extensions := newTrialExtensions()
+
+		// when
+		result, err := extensions.Decide(ctx, "johnny", admin.ActionReject, "admin")
+
+		// then
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), signup.TrialExtensionRejected, result.State)
+		assert.Equal(s.T(), 0, result.Extensions)
+		assert.Empty(s.T(), result.EndDate)
+	})
+
+	s.Run("failures", func() {
+		s.Run("no pending request", func() {
+			// given
+			_, extensions := newTrialExtensions()
+
+			// when
+			_, err := extensions.Decide(ctx, "ted", admin.ActionApprove, "admin")
+
+			// then
+			require.ErrorIs(s.T(), err, admin.ErrNoPendingTrialExtension)
+		})
+
+		s.Run("unknown signup", func() {
+			// given
+			_, extensions := newTrialExtensions()
+
+			// when
+			_, err := extensions.Decide(ctx, "unknown", admin.ActionApprove, "admin")
+
+			// then
+			require.ErrorIs(s.T(), err, admin.ErrNotFound)
+		})
+
+		s.Run("unknown action", func() {
+			// given
+			_, extensions := newTrialExtensions()
+
+			// when
+			_, err := extensions.Decide(ctx, "jane", admin.ActionDeactivate, "admin")
+
+			// then
+			require.EqualError(s.T(), err, "unknown action 'deactivate'")
+		})
+
+		s.Run("update fails", func() {
+			// given
+			fakeClient, extensions := newTrialExtensions()
+			fakeClient.MockUpdate = func(_ context.Context, _ client.Object, _ ...client.UpdateOption) error {
+				return errors.New("mock error")
+			}
+
+			// when
+			_, err := extensions.Decide(ctx, "jane", admin.ActionApprove, "admin")
+
+			// then
+			require.EqualError(s.T(), err, "mock error")
+		})
+	})
+}
@@ -0,0 +1,217 @@
+// Package admin provides the operations behind the administrative endpoints, which allow the administrators to manage
+// the users without having access to the host cluster.
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/signup"
+	"github.com/codeready-toolchain/toolchain-common/pkg/states"
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ActionAnnotationKey is the annotation recording the last action of an administrator on a UserSignup
+	ActionAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "admin-action"
+	// ActionReasonAnnotationKey is the annotation recording the reason of the last action of an administrator on a UserSignup
+	ActionReasonAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "admin-action-reason"
+	// ActionByAnnotationKey is the annotation recording the username of the administrator who last acted on a UserSignup
+	ActionByAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "admin-action-by"
+	// ActionTimestampAnnotationKey is the annotation recording the time of the last action of an administrator on a UserSignup
+	ActionTimestampAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "admin-action-timestamp"
+)
+
+// Action is an action of an administrator on a UserSignup
+type Action string
+
+const (
+	// ActionApprove approves the UserSignup, so that the user gets provisioned (again, if the UserSignup was deactivated)
+	ActionApprove Action = "approve"
+	// ActionReject rejects the UserSignup, so that the user does not get provisioned
+	ActionReject Action = "reject"
+	// ActionDeactivate deactivates the UserSignup, so that the user gets deprovisioned
+	ActionDeactivate Action = "deactivate"
+)
+
+// States are the values of the state label by which the UserSignups can be listed
+var States = []string{
+	toolchainv1alpha1.UserSignupStateLabelValueNotReady,
+	toolchainv1alpha1.UserSignupStateLabelValuePending,
+	toolchainv1alpha1.UserSignupStateLabelValueApproved,
+	toolchainv1alpha1.UserSignupStateLabelValueRejected,
+	toolchainv1alpha1.UserSignupStateLabelValueDeactivated,
+	toolchainv1alpha1.UserSignupStateLabelValueBanned,
+}
+
+var (
+	// ErrNotFound is returned when the UserSignup to act on does not exist
+	ErrNotFound = errors.New("UserSignup not found")
+	// ErrInvalidState is returned when the UserSignups are listed by an unknown state
+	ErrInvalidState = errors.New("invalid state")
+)
+
+// Signup is the summary of a UserSignup, along with the details needed by the administrators to approve or reject it
+type Signup struct {
+	// Name is the name of the UserSignup resource
+	Name string `json:"name"`
+	// Username is the preferred username of the user
+	Username string `json:"username"`
+	// Email is the email address of the user
+	Email string `json:"email,omitempty"`
+	// CompliantUsername is the name of the MasterUserRecord of the user, once provisioned
+	CompliantUsername string `json:"compliantUsername,omitempty"`
+	// State is the value of the state label of the UserSignup
+	State string `json:"state,omitempty"`
+	// States are the states of the UserSignup
+	States []toolchainv1alpha1.UserSignupState `json:"states,omitempty"`
+	// Created is the creation time of the UserSignup
+	Created time.Time `json:"created"`
+	// CaptchaScore is the score of the last captcha assessment of the user
+	CaptchaScore string `json:"captchaScore,omitempty"`
+	// PhoneLookup contains the details of the lookup of the phone number of the user
+	PhoneLookup *PhoneLookup `json:"phoneLookup,omitempty"`
+	// AccountVerifierResult is the result returned by the account verifier service
+	AccountVerifierResult string `json:"accountVerifierResult,omitempty"`
+	// AccountVerifierReasons are the reasons returned by the account verifier service
+	AccountVerifierReasons string `json:"accountVerifierReasons,omitempty"`
+	// LastAction is the last action of an administrator on the UserSignup
+	LastAction *ActionRecord `json:"lastAction,omitempty"`
+}
+
+// PhoneLookup contains the details of the lookup of the phone number of a user
+type PhoneLookup struct {
+	CarrierRisk   string `json:"carrierRisk,omitempty"`
+	NumberBlocked string `json:"numberBlocked,omitempty"`
+	Details       string `json:"details,omitempty"`
+}
+
+// ActionRecord records an action of an administrator on a UserSignup
+type ActionRecord struct {
+	Action    Action `json:"action"`
+	Reason    string `json:"reason"`
+	By        string `json:"by"`
+	Timestamp string `json:"timestamp"`
+}
+
+// Signups lists the UserSignups and applies the actions of the administrators on them
+type Signups struct {
+	namespaced.Client
+}
+
+// NewSignups returns a new Signups instance
+func NewSignups(nsClient namespaced.Client) *Signups {
+	return &Signups{
+		Client: nsClient,
+	}
+}
+
+// List returns the UserSignups with the given state label value, sorted by creation time
+func (s *Signups) List(ctx *gin.Context, state string) ([]Signup, error) {
+	if !slices.Contains(States, state) {
+		return nil, fmt.Errorf("%w '%s'", ErrInvalidState, state)
+	}
+	userSignups := &toolchainv1alpha1.UserSignupList{}
+	if err := s.Client.List(ctx, userSignups, client.InNamespace(s.Namespace),
+		client.MatchingLabels{toolchainv1alpha1.UserSignupStateLabelKey: state}); err != nil {
+		return nil, err
+	}
+	sort.Slice(userSignups.Items, func(i, j int) bool {
+		return userSignups.Items[i].CreationTimestamp.Before(&userSignups.Items[j].CreationTimestamp)
+	})
+	signups := make([]Signup, 0, len(userSignups.Items))
+	for i := range userSignups.Items {
+		signups = append(signups, toSignup(&userSignups.Items[i]))
+	}
+	return signups, nil
+}
+
+// Apply applies the given action on the UserSignup with the given name, and records the reason of the action
+// along with the username of the administrator in the annotations of the UserSignup
+func (s *Signups) Apply(ctx *gin.Context, name string, action Action, reason, by string) (*Signup, error) {
+	if action != ActionApprove && action != ActionReject && action != ActionDeactivate {
+		return nil, fmt.Errorf("unknown action '%s'", action)
+	}
+	var result *Signup
+	err := signup.PollUpdateSignup(ctx, func() error {
+		userSignup := &toolchainv1alpha1.UserSignup{}
+		if err := s.Get(ctx, s.NamespacedName(name), userSignup); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		switch action {
+		case ActionApprove:
+			states.SetRejected(userSignup, false)
+			states.SetDeactivating(userSignup, false)
+			states.SetDeactivated(userSignup, false)
+			states.SetApprovedManually(userSignup, true)
+		case ActionReject:
+			states.SetApprovedManually(userSignup, false)
+			states.SetRejected(userSignup, true)
+		case ActionDeactivate:
+			states.SetDeactivated(userSignup, true)
+		}
+		if userSignup.Annotations == nil {
+			userSignup.Annotations = map[string]string{}
+		}
+		userSignup.Annotations[ActionAnnotationKey] = string(action)
+		userSignup.Annotations[ActionReasonAnnotationKey] = reason
+		userSignup.Annotations[ActionByAnnotationKey] = by
+		userSignup.Annotations[ActionTimestampAnnotationKey] = time.Now().UTC().Format(time.RFC3339)
+		if err := s.Update(ctx, userSignup); err != nil {
+			return err
+		}
+		updated := toSignup(userSignup)
+		result = &updated
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, ErrNotFound
+	}
+	return result, nil
+}
+
+func toSignup(userSignup *toolchainv1alpha1.UserSignup) Signup {
+	annotations := userSignup.Annotations
+	result := Signup{
+		Name:                   userSignup.Name,
+		Username:               userSignup.Spec.IdentityClaims.PreferredUsername,
+		Email:                  userSignup.Spec.IdentityClaims.Email,
+		CompliantUsername:      userSignup.Status.CompliantUsername,
+		State:                  userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey],
+		States:                 userSignup.Spec.States,
+		Created:                userSignup.CreationTimestamp.UTC(),
+		CaptchaScore:           annotations[toolchainv1alpha1.UserSignupCaptchaScoreAnnotationKey],
+		AccountVerifierResult:  annotations[toolchainv1alpha1.UserSignupAccountVerifierResultAnnotationKey],
+		AccountVerifierReasons: annotations[toolchainv1alpha1.UserSignupAccountVerifierReasonsAnnotationKey],
+	}
+	phoneLookup := PhoneLookup{
+		CarrierRisk:   annotations[toolchainv1alpha1.UserSignupPhoneLookupCarrierRiskAnnotationKey],
+		NumberBlocked: annotations[toolchainv1alpha1.UserSignupPhoneLookupNumberBlockedAnnotationKey],
+		Details:       annotations[toolchainv1alpha1.UserSignupPhoneLookupDetailsAnnotationKey],
+	}
+	if phoneLookup != (PhoneLookup{}) {
+		result.PhoneLookup = &phoneLookup
+	}
+	if action, found := annotations[ActionAnnotationKey]; found {
+		result.LastAction = &ActionRecord{
+			Action:    Action(action),
+			Reason:    annotations[ActionReasonAnnotationKey],
+			By:        annotations[ActionByAnnotationKey],
+			Timestamp: annotations[ActionTimestampAnnotationKey],
+		}
+	}
+	return result
+}
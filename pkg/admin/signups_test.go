@@ -0,0 +1,211 @@
+package admin_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/admin"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/states"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	testusersignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type TestSignupsSuite struct {
+	test.UnitTestSuite
+}
+
+func TestRunSignupsSuite(t *testing.T) {
+	suite.Run(t, &TestSignupsSuite{test.UnitTestSuite{}})
+}
+
+func newUserSignup(name, state string, created time.Time, modifiers ...testusersignup.Modifier) *toolchainv1alpha1.UserSignup {
+	userSignup := testusersignup.NewUserSignup(append([]testusersignup.Modifier{
+		testusersignup.WithName(name),
+		testusersignup.WithStateLabel(state),
+	}, modifiers...)...)
+	userSignup.CreationTimestamp = metav1.NewTime(created)
+	return userSignup
+}
+
+func (s *TestSignupsSuite) TestList() {
+	// given
+	now := time.Now().Truncate(time.Second)
+	fakeClient := commontest.NewFakeClient(s.T(),
+		newUserSignup("johnny", toolchainv1alpha1.UserSignupStateLabelValuePending, now,
+			testusersignup.WithAnnotation(toolchainv1alpha1.UserSignupCaptchaScoreAnnotationKey, "0.3"),
+			testusersignup.WithAnnotation(toolchainv1alpha1.UserSignupPhoneLookupCarrierRiskAnnotationKey, "high"),
+			testusersignup.WithAnnotation(toolchainv1alpha1.UserSignupAccountVerifierResultAnnotationKey, "deny"),
+			testusersignup.WithAnnotation(toolchainv1alpha1.UserSignupAccountVerifierReasonsAnnotationKey, `["disposable email"]`)),
+		newUserSignup("jane", toolchainv1alpha1.UserSignupStateLabelValuePending, now.Add(-time.Hour)),
+		newUserSignup("ted", toolchainv1alpha1.UserSignupStateLabelValueApproved, now),
+	)
+	signups := admin.NewSignups(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	s.Run("pending signups", func() {
+		// when
+		result, err := signups.List(ctx, toolchainv1alpha1.UserSignupStateLabelValuePending)
+
+		// then
+		require.NoError(s.T(), err)
+		require.Len(s.T(), result, 2)
+		assert.Equal(s.T(), "jane", result[0].Name) // oldest first
+		assert.Nil(s.T(), result[0].PhoneLookup)
+		assert.Equal(s.T(), "johnny", result[1].Name)
+		assert.Equal(s.T(), toolchainv1alpha1.UserSignupStateLabelValuePending, result[1].State)
+		assert.Equal(s.T(), "0.3", result[1].CaptchaScore)
+		assert.Equal(s.T(), &admin.PhoneLookup{CarrierRisk: "high"}, result[1].PhoneLookup)
+		assert.Equal(s.T(), "deny", result[1].AccountVerifierResult)
+		assert.Equal(s.T(), `["disposable email"]`, result[1].AccountVerifierReasons)
+	})
+
+	s.Run("no rejected signup", func() {
+		// when
+		result, err := signups.List(ctx, toolchainv1alpha1.UserSignupStateLabelValueRejected)
+
+		// then
+		require.NoError(s.T(), err)
+		assert.Empty(s.T(), result)
+	})
+
+	s.Run("invalid state", func() {
+		// when
+		_, err := signups.List(ctx, "unknown")
+
+		// then
+		require.ErrorIs(s.T(), err, admin.ErrInvalidState)
+	})
+}
+
+func (s *TestSignupsSuite) TestApply() {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	getUserSignup := func(cl client.Client, name string) *toolchainv1alpha1.UserSignup {
+		userSignup := &toolchainv1alpha1.UserSignup{}
+		require.NoError(s.T(), cl.Get(context.TODO(), client.ObjectKey{Namespace: commontest.HostOperatorNs, Name: name}, userSignup))
+		return userSignup
+	}
+
+	s.Run("approve", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(s.T(), newUserSignup("johnny", toolchainv1alpha1.UserSignupStateLabelValueRejected, time.Now(),
+			func(userSignup *toolchainv1alpha1.UserSignup) {
+				states.SetRejected(userSignup, true)
+			}))
+		signups := admin.NewSignups(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		result, err := signups.Apply(ctx, "johnny", admin.ActionApprove, "looks legit", "admin")
+
+		// then
+		require.NoError(s.T(), err)
+		userSignup := getUserSignup(fakeClient, "johnny")
+		assert.True(s.T(), states.ApprovedManually(userSignup))
+		assert.False(s.T(), states.Rejected(userSignup))
+		assert.Equal(s.T(), "approve", userSignup.Annotations[admin.ActionAnnotationKey])
+		assert.Equal(s.T(), "looks legit", userSignup.Annotations[admin.ActionReasonAnnotationKey])
+		assert.Equal(s.T(), "admin", userSignup.Annotations[admin.ActionByAnnotationKey])
+		assert.NotEmpty(s.T(), userSignup.Annotations[admin.ActionTimestampAnnotationKey])
+		require.NotNil(s.T(), result.LastAction)
+		assert.Equal(s.T(), admin.ActionApprove, result.LastAction.Action)
+		assert.Equal(s.T(), "looks legit", result.LastAction.Reason)
+	})
+
+	s.Run("approve deactivated", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(s.T(), newUserSignup("johnny", toolchainv1alpha1.UserSignupStateLabelValueDeactivated, time.Now(),
+			testusersignup.Deactivated()))
+		signups := admin.NewSignups(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		_, err := signups.Apply(ctx, "johnny", admin.ActionApprove, "appeal accepted", "admin")
+
+		// then
+		require.NoError(s.T(), err)
+		userSignup := getUserSignup(fakeClient, "johnny")
+		assert.True(s.T(), states.ApprovedManually(userSignup))
+		assert.False(s.T(), states.Deactivated(userSignup))
+		assert.False(s.T(), states.Deactivating(userSignup))
+	})
+
+	s.Run("reject", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(s.T(), newUserSignup("johnny", toolchainv1alpha1.UserSignupStateLabelValuePending, time.Now()))
+		signups := admin.NewSignups(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		_, err := signups.Apply(ctx, "johnny", admin.ActionReject, "spam", "admin")
+
+		// then
+		require.NoError(s.T(), err)
+		userSignup := getUserSignup(fakeClient, "johnny")
+		assert.True(s.T(), states.Rejected(userSignup))
+		assert.False(s.T(), states.ApprovedManually(userSignup))
+		assert.Equal(s.T(), "spam", userSignup.Annotations[admin.ActionReasonAnnotationKey])
+	})
+
+	s.Run("deactivate", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(s.T(), newUserSignup("johnny", toolchainv1alpha1.UserSignupStateLabelValueApproved, time.Now(),
+			testusersignup.ApprovedManually()))
+		signups := admin.NewSignups(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		_, err := signups.Apply(ctx, "johnny", admin.ActionDeactivate, "abuse", "admin")
+
+		// then
+		require.NoError(s.T(), err)
+		userSignup := getUserSignup(fakeClient, "johnny")
+		assert.True(s.T(), states.Deactivated(userSignup))
+		assert.False(s.T(), states.ApprovedManually(userSignup))
+	})
+
+	s.Run("not found", func() {
+		// given
+		signups := admin.NewSignups(namespaced.NewClient(commontest.NewFakeClient(s.T()), commontest.HostOperatorNs))
+
+		// when
+		_, err := signups.Apply(ctx, "johnny", admin.ActionApprove, "looks legit", "admin")
+
+		// then
+		require.ErrorIs(s.T(), err, admin.ErrNotFound)
+	})
+
+	s.Run("unknown action", func() {
+		// given
+		signups := admin.NewSignups(namespaced.NewClient(commontest.NewFakeClient(s.T()), commontest.HostOperatorNs))
+
+		// when
+		_, err := signups.Apply(ctx, "johnny", admin.Action("ban"), "", "admin")
+
+		// then
+		require.EqualError(s.T(), err, "unknown action 'ban'")
+	})
+
+	s.Run("update fails", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(s.T(), newUserSignup("johnny", toolchainv1alpha1.UserSignupStateLabelValuePending, time.Now()))
+		fakeClient.MockUpdate = func(_ context.Context, _ client.Object, _ ...client.UpdateOption) error {
+			return errors.New("oopsie woopsie")
+		}
+		signups := admin.NewSignups(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		_, err := signups.Apply(ctx, "johnny", admin.ActionApprove, "looks legit", "admin")
+
+		// then
+		require.EqualError(s.T(), err, "oopsie woopsie")
+	})
+}
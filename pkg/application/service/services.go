@@ -9,12 +9,15 @@ import (
 type SignupService interface {
 	Signup(ctx *gin.Context) (*toolchainv1alpha1.UserSignup, error)
 	GetSignup(ctx *gin.Context, username string, checkUserSignupCompleted bool) (*signup.Signup, error)
+	DeactivateSignup(ctx *gin.Context, username string) (bool, error)
+	RequestTrialExtension(ctx *gin.Context, username, justification string) (*signup.TrialExtension, error)
 }
 
 type VerificationService interface {
 	InitVerification(ctx *gin.Context, username, e164PhoneNumber, countryCode string) error
 	VerifyPhoneCode(ctx *gin.Context, username, code string) error
 	VerifyActivationCode(ctx *gin.Context, username, code string) error
+	CheckActivationCode(ctx *gin.Context, code string) (*signup.ActivationCode, error)
 }
 
 type Services interface {
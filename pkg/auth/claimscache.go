@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// ClaimsCache is a bounded cache of the claims of already validated tokens.
+// The entries are keyed by the hash of the token, so that the tokens themselves are not kept in memory,
+// and they expire after the configured TTL or when the token expires, whichever comes first.
+// When the cache is full, the least recently used entry is evicted.
+type ClaimsCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type claimsCacheEntry struct {
+	key       string
+	claims    *TokenClaims
+	expiresAt time.Time
+}
+
+// NewClaimsCache returns a new ClaimsCache holding at most `size` entries for at most `ttl`.
+// A cache with a size or a TTL lower or equal to 0 never caches anything.
+func NewClaimsCache(size int, ttl time.Duration) *ClaimsCache {
+	return &ClaimsCache{
+		size:    size,
+		ttl:     ttl,
+		entries: map[string]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+// Get returns the cached claims of the given token, if any
+func (c *ClaimsCache) Get(token string) (*TokenClaims, bool) {
+	key := hashToken(token)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, found := c.entries[key]
+	if !found {
+		return nil, false
+	}
+	entry := elem.Value.(*claimsCacheEntry)
+	if !time.Now().Before(entry.expiresAt) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.claims, true
+}
+
+// Add adds the claims of the given (validated) token to the cache
+func (c *ClaimsCache) Add(token string, claims *TokenClaims) {
+	if c.size <= 0 || c.ttl <= 0 {
+		return
+	}
+	expiresAt := time.Now().Add(c.ttl)
+	if claims.ExpiresAt != nil && claims.ExpiresAt.Before(expiresAt) {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	if !time.Now().Before(expiresAt) {
+		return
+	}
+	key := hashToken(token)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, found := c.entries[key]; found {
+		c.remove(elem)
+	}
+	for c.lru.Len() >= c.size {
+		c.remove(c.lru.Back())
+	}
+	c.entries[key] = c.lru.PushFront(&claimsCacheEntry{
+		key:       key,
+		claims:    claims,
+		expiresAt: expiresAt,
+	})
+}
+
+// Len returns the number of entries in the cache, including the expired ones which were not evicted yet
+func (c *ClaimsCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+func (c *ClaimsCache) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*claimsCacheEntry).key)
+}
+
+func hashToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,108 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codeready-toolchain/registration-service/pkg/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimsCache(t *testing.T) {
+	claims := func(username string, exp time.Time) *auth.TokenClaims {
+		return &auth.TokenClaims{
+			PreferredUsername: username,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(exp),
+			},
+		}
+	}
+
+	t.Run("hit and miss", func(t *testing.T) {
+		// given
+		cache := auth.NewClaimsCache(10, time.Minute)
+		cache.Add("token-a", claims("alice", time.Now().Add(time.Hour)))
+
+		// when
+		hit, foundHit := cache.Get("token-a")
+		_, foundMiss := cache.Get("token-b")
+
+		// then
+		require.True(t, foundHit)
+		assert.Equal(t, "alice", hit.PreferredUsername)
+		assert.False(t, foundMiss)
+	})
+
+	t.Run("entry expires after TTL", func(t *testing.T) {
+		// given
+		cache := auth.NewClaimsCache(10, 10*time.Millisecond)
+		cache.Add("token-a", claims("alice", time.Now().Add(time.Hour)))
+
+		// when
+		time.Sleep(20 * time.Millisecond)
+
+		// then
+		_, found := cache.Get("token-a")
+		assert.False(t, found)
+		assert.Equal(t, 0, cache.Len())
+	})
+
+	t.Run("entry expires with the token", func(t *testing.T) {
+		// given
+		cache := auth.NewClaimsCache(10, time.Hour)
+		cache.Add("token-a", claims("alice", time.Now().Add(10*time.Millisecond)))
+
+		// when
+		time.Sleep(20 * time.Millisecond)
+
+		// then
+		_, found := cache.Get("token-a")
+		assert.False(t, found)
+	})
+
+	t.Run("expired token is not cached", func(t *testing.T) {
+		// given
+		cache := auth.NewClaimsCache(10, time.Hour)
+
+		// when
+		cache.Add("token-a", claims("alice", time.Now().Add(-time.Second)))
+
+		// then
+		assert.Equal(t, 0, cache.Len())
+	})
+
+	t.Run("least recently used entry is evicted", func(t *testing.T) {
+		// given
+		cache := auth.NewClaimsCache(2, time.Minute)
+		cache.Add("token-a", claims("alice", time.Now().Add(time.Hour)))
+		cache.Add("token-b", claims("bob", time.Now().Add(time.Hour)))
+		_, found := cache.Get("token-a") // token-a becomes the most recently used
+		require.True(t, found)
+
+		// when
+		cache.Add("token-c", claims("carol", time.Now().Add(time.Hour)))
+
+		// then
+		assert.Equal(t, 2, cache.Len())
+		_, found = cache.Get("token-a")
+		assert.True(t, found)
+		_, found = cache.Get("token-b")
+		assert.False(t, found)
+		_, found = cache.Get("token-c")
+		assert.True(t, found)
+	})
+
+	t.Run("disabled cache", func(t *testing.T) {
+		// given
+		cache := auth.NewClaimsCache(0, time.Minute)
+
+		// when
+		cache.Add("token-a", claims("alice", time.Now().Add(time.Hour)))
+
+		// then
+		_, found := cache.Get("token-a")
+		assert.False(t, found)
+	})
+}
@@ -6,8 +6,11 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"slices"
 
 	"github.com/codeready-toolchain/registration-service/pkg/configuration"
 	"github.com/codeready-toolchain/registration-service/pkg/log"
@@ -37,6 +40,15 @@ type JSONKeys struct {
 // KeyManager manages the public keys for token validation.
 type KeyManager struct {
 	keyMap map[string]*rsa.PublicKey
+	// realms are the additional SSO realms which the keys belong to, by kid.
+	// The keys which are not in this map belong to the main realm.
+	realms map[string]ssoRealm
+}
+
+// ssoRealm is an additional SSO realm, along with the issuer of its tokens
+type ssoRealm struct {
+	name   string
+	issuer string
 }
 
 // NewKeyManager creates a new KeyManager and retrieves the public keys from the given URL.
@@ -45,6 +57,7 @@ func NewKeyManager() (*KeyManager, error) {
 	keysEndpointURL := cfg.Auth().AuthClientPublicKeysURL()
 	km := &KeyManager{
 		keyMap: make(map[string]*rsa.PublicKey),
+		realms: make(map[string]ssoRealm),
 	}
 	// fetch raw keys
 	if keysEndpointURL != "" {
@@ -66,6 +79,9 @@ func NewKeyManager() (*KeyManager, error) {
 			for _, key := range keys {
 				km.keyMap[key.KeyID] = key.Key
 			}
+			if err := km.fetchAdditionalRealmsKeys(cfg.Auth().AdditionalSSORealms()); err != nil {
+				return nil, err
+			}
 		}
 	} else {
 		log.Info(nil, "no public key url given, not fetching keys")
@@ -73,6 +89,31 @@ func NewKeyManager() (*KeyManager, error) {
 	return km, nil
 }
 
+// fetchAdditionalRealmsKeys fetches the public keys of the given additional SSO realms, so that the tokens issued by
+// these realms can be validated. Their keys are bound to their realm: a token signed with one of them is only valid if
+// it was issued by the same realm (see TokenParser.FromString). The keys whose kid is already known are ignored, so that
+// an additional realm cannot replace the keys of the main realm.
+func (km *KeyManager) fetchAdditionalRealmsKeys(additionalRealms map[string]string) error {
+	for _, realm := range slices.Sorted(maps.Keys(additionalRealms)) {
+		realmURL := fmt.Sprintf("%s/auth/realms/%s", additionalRealms[realm], realm)
+		keysURL := realmURL + "/protocol/openid-connect/certs"
+		log.Infof(nil, "fetching public keys of SSO realm '%s' from url: %s", realm, keysURL)
+		keys, err := km.fetchKeys(keysURL)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if _, found := km.keyMap[key.KeyID]; found {
+				log.Infof(nil, "ignoring the public key '%s' of SSO realm '%s', since a key with the same kid is already known", key.KeyID, realm)
+				continue
+			}
+			km.keyMap[key.KeyID] = key.Key
+			km.realms[key.KeyID] = ssoRealm{name: realm, issuer: realmURL}
+		}
+	}
+	return nil
+}
+
 // Key retrieves the public key for a given kid.
 func (km *KeyManager) Key(kid string) (*rsa.PublicKey, error) {
 	key, ok := km.keyMap[kid]
@@ -82,6 +123,12 @@ func (km *KeyManager) Key(kid string) (*rsa.PublicKey, error) {
 	return key, nil
 }
 
+// additionalRealm returns the additional SSO realm which the key with the given kid belongs to, if any
+func (km *KeyManager) additionalRealm(kid string) (ssoRealm, bool) {
+	realm, found := km.realms[kid]
+	return realm, found
+}
+
 // unmarshalKeys unmarshals keys from given JSON.
 func (km *KeyManager) unmarshalKeys(jsonData []byte) ([]*PublicKey, error) {
 	var keys []*PublicKey
@@ -242,6 +242,83 @@ func (s *TestKeyManagerSuite) TestKeyFetching() {
 	})
 }
 
+func (s *TestKeyManagerSuite) TestKeyFetchingForAdditionalRealms() {
+	restore := commontest.SetEnvVarAndRestore(s.T(), commonconfig.WatchNamespaceEnvVar, commontest.HostOperatorNs)
+	defer restore()
+
+	// create a key for the default realm and a key for each additional realm
+	tokengenerator := authsupport.NewTokenManager()
+	kid0 := uuid.NewString()
+	_, err := tokengenerator.AddPrivateKey(kid0)
+	require.NoError(s.T(), err)
+	keysEndpointURL := tokengenerator.NewKeyServer().URL
+
+	employeesTokengenerator := authsupport.NewTokenManager()
+	kid1 := uuid.NewString()
+	_, err = employeesTokengenerator.AddPrivateKey(kid1)
+	require.NoError(s.T(), err)
+	employeesKeys := employeesTokengenerator.NewKeyServer()
+	defer employeesKeys.Close()
+	var employeesRequests []string
+	employeesKeyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		employeesRequests = append(employeesRequests, r.URL.Path)
+		employeesKeys.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer employeesKeyServer.Close()
+
+	s.OverrideApplicationDefault(testconfig.RegistrationService().
+		Environment(configuration.DefaultEnvironment).
+		Auth().AuthClientPublicKeysURL(keysEndpointURL))
+
+	s.Run("keys of all realms are trusted", func() {
+		// given
+		test.SetSettings(s.T(), "auth: {additionalSSORealms: {employees: "+employeesKeyServer.URL+"}}")
+
+		// when
+		keyManager, err := auth.NewKeyManager()
+
+		// then
+		require.NoError(s.T(), err)
+		_, err = keyManager.Key(kid0)
+		require.NoError(s.T(), err)
+		_, err = keyManager.Key(kid1)
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), []string{"/auth/realms/employees/protocol/openid-connect/certs"}, employeesRequests)
+	})
+
+	s.Run("keys of an additional realm do not replace the keys of the main realm", func() {
+		// given
+		otherTokengenerator := authsupport.NewTokenManager()
+		_, err := otherTokengenerator.AddPrivateKey(kid0)
+		require.NoError(s.T(), err)
+		otherKeyServer := otherTokengenerator.NewKeyServer()
+		defer otherKeyServer.Close()
+		test.SetSettings(s.T(), "auth: {additionalSSORealms: {other: "+otherKeyServer.URL+"}}")
+
+		// when
+		keyManager, err := auth.NewKeyManager()
+
+		// then
+		require.NoError(s.T(), err)
+		key, err := keyManager.Key(kid0)
+		require.NoError(s.T(), err)
+		mainKey, err := tokengenerator.Key(kid0)
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), &mainKey.PublicKey, key)
+	})
+
+	s.Run("fail if the keys of a realm cannot be retrieved", func() {
+		// given
+		test.SetSettings(s.T(), "auth: {additionalSSORealms: {employees: "+employeesKeyServer.URL+", external: http://127.0.0.1:1}}")
+
+		// when
+		_, err := auth.NewKeyManager()
+
+		// then
+		require.Error(s.T(), err)
+	})
+}
+
 func (s *TestKeyManagerSuite) TestE2EKeyFetching() {
 	restore := commontest.SetEnvVarAndRestore(s.T(), commonconfig.WatchNamespaceEnvVar, commontest.HostOperatorNs)
 	defer restore()
@@ -12,20 +12,30 @@ const leeway = 5 * time.Second
 
 // TokenClaims represents access token claims
 type TokenClaims struct {
-	Name              string `json:"name"`
-	PreferredUsername string `json:"preferred_username"`
-	GivenName         string `json:"given_name"`
-	FamilyName        string `json:"family_name"`
-	Email             string `json:"email"`
-	EmailVerified     bool   `json:"email_verified"`
-	Company           string `json:"company"`
-	OriginalSub       string `json:"original_sub"`
-	UserID            string `json:"user_id"`
-	AccountID         string `json:"account_id"`
-	AccountNumber     string `json:"account_number,omitempty"`
+	Name              string      `json:"name"`
+	PreferredUsername string      `json:"preferred_username"`
+	GivenName         string      `json:"given_name"`
+	FamilyName        string      `json:"family_name"`
+	Email             string      `json:"email"`
+	EmailVerified     bool        `json:"email_verified"`
+	Company           string      `json:"company"`
+	OriginalSub       string      `json:"original_sub"`
+	UserID            string      `json:"user_id"`
+	AccountID         string      `json:"account_id"`
+	AccountNumber     string      `json:"account_number,omitempty"`
+	Groups            []string    `json:"groups,omitempty"`
+	RealmAccess       RealmAccess `json:"realm_access,omitempty"`
+	// SSORealm is the name of the additional SSO realm which issued the token, or empty if the token was issued by the
+	// main realm. It is not a claim: it is set from the key which signed the token.
+	SSORealm string `json:"-"`
 	jwt.RegisteredClaims
 }
 
+// RealmAccess represents the realm_access claim which holds the realm roles of the user
+type RealmAccess struct {
+	Roles []string `json:"roles,omitempty"`
+}
+
 // TokenParser represents a parser for JWT tokens.
 type TokenParser struct {
 	keyManager *KeyManager
@@ -83,6 +93,14 @@ func (tp *TokenParser) FromString(jwtEncoded string) (*TokenClaims, error) {
 		if claims.Subject == "" {
 			return nil, errors.New("token does not comply to expected claims: subject missing")
 		}
+		// the tokens signed with the keys of an additional SSO realm must have been issued by this realm
+		kid, _ := token.Header["kid"].(string)
+		if realm, found := tp.keyManager.additionalRealm(kid); found {
+			if claims.Issuer != realm.issuer {
+				return nil, fmt.Errorf("token issuer does not match the SSO realm '%s' of its signing key", realm.name)
+			}
+			claims.SSORealm = realm.name
+		}
 		return claims, nil
 	}
 	return nil, errors.New("token does not comply to expected claims")
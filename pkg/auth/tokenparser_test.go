@@ -396,3 +396,88 @@ func (s *TestTokenParserSuite) TestTokenParser() {
 		require.Equal(s.T(), "123456789", claims.AccountNumber)
 	})
 }
+
+func (s *TestTokenParserSuite) TestTokenParserForAdditionalRealms() {
+	restore := commontest.SetEnvVarAndRestore(s.T(), commonconfig.WatchNamespaceEnvVar, commontest.HostOperatorNs)
+	defer restore()
+
+	// create a key for the main realm and a key for the additional realm
+	tokengenerator := authsupport.NewTokenManager()
+	kid0 := uuid.NewString()
+	_, err := tokengenerator.AddPrivateKey(kid0)
+	require.NoError(s.T(), err)
+	keyServer := tokengenerator.NewKeyServer()
+	defer keyServer.Close()
+
+	employeesTokengenerator := authsupport.NewTokenManager()
+	kid1 := uuid.NewString()
+	_, err = employeesTokengenerator.AddPrivateKey(kid1)
+	require.NoError(s.T(), err)
+	employeesKeyServer := employeesTokengenerator.NewKeyServer()
+	defer employeesKeyServer.Close()
+
+	s.OverrideApplicationDefault(testconfig.RegistrationService().
+		Environment(configuration.UnitTestsEnvironment).
+		Auth().AuthClientPublicKeysURL(keyServer.URL))
+	test.SetSettings(s.T(), "auth: {additionalSSORealms: {employees: "+employeesKeyServer.URL+"}}")
+	keyManager, err := auth.NewKeyManager()
+	require.NoError(s.T(), err)
+	tokenParser, err := auth.NewTokenParser(keyManager)
+	require.NoError(s.T(), err)
+
+	identity := authsupport.Identity{
+		ID:       uuid.New(),
+		Username: uuid.NewString(),
+	}
+	issuer := func(iss string) authsupport.ExtraClaim {
+		return func(token *jwt.Token) {
+			token.Claims.(*authsupport.MyClaims).Issuer = iss
+		}
+	}
+
+	s.Run("token of the main realm", func() {
+		// given
+		token, err := tokengenerator.GenerateSignedToken(identity, kid0, authsupport.WithEmailClaim("johnny@email.tld"))
+		require.NoError(s.T(), err)
+
+		// when
+		claims, err := tokenParser.FromString(token)
+
+		// then
+		require.NoError(s.T(), err)
+		assert.Empty(s.T(), claims.SSORealm)
+	})
+
+	s.Run("token of the additional realm", func() {
+		// given
+		token, err := employeesTokengenerator.GenerateSignedToken(identity, kid1, authsupport.WithEmailClaim("johnny@email.tld"),
+			issuer(employeesKeyServer.URL+"/auth/realms/employees"))
+		require.NoError(s.T(), err)
+
+		// when
+		claims, err := tokenParser.FromString(token)
+
+		// then
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), "employees", claims.SSORealm)
+	})
+
+	s.Run("token of the additional realm with another issuer", func() {
+		for name, iss := range map[string]string{
+			"main realm": "https://sso.devsandbox.dev/auth/realms/sandbox-dev",
+			"no issuer":  "",
+		} {
+			s.Run(name, func() {
+				// given
+				token, err := employeesTokengenerator.GenerateSignedToken(identity, kid1, authsupport.WithEmailClaim("johnny@email.tld"), issuer(iss))
+				require.NoError(s.T(), err)
+
+				// when
+				_, err = tokenParser.FromString(token)
+
+				// then
+				require.EqualError(s.T(), err, "token issuer does not match the SSO realm 'employees' of its signing key")
+			})
+		}
+	})
+}
@@ -4,6 +4,7 @@ package configuration
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"strconv"
 	"strings"
@@ -41,6 +42,10 @@ const (
 	defaultScoreThreshold float32 = 0.9
 )
 
+// ConfigMapLabelKey is the key of the label set on all the ConfigMaps created by the registration service in the
+// host-operator namespace, whose value is the kind of data held by the ConfigMap. Only these ConfigMaps are cached.
+const ConfigMapLabelKey = toolchainv1alpha1.LabelKeyPrefix + "registration-service"
+
 var configurationClient client.Client
 
 func IsTestingMode() bool {
@@ -56,13 +61,13 @@ func Namespace() string {
 func GetRegistrationServiceConfig() RegistrationServiceConfig {
 	if configurationClient == nil {
 		logger.Error(fmt.Errorf("configuration client is not initialized"), "using default configuration")
-		return RegistrationServiceConfig{cfg: &toolchainv1alpha1.ToolchainConfigSpec{}}
+		return RegistrationServiceConfig{cfg: &toolchainv1alpha1.ToolchainConfigSpec{}, settings: currentSettings()}
 	}
 	config, secrets, err := commonconfig.LoadLatest(configurationClient, &toolchainv1alpha1.ToolchainConfig{})
 	if err != nil {
 		// return default config
 		logger.Error(err, "failed to retrieve RegistrationServiceConfig, using default configuration")
-		return RegistrationServiceConfig{cfg: &toolchainv1alpha1.ToolchainConfigSpec{}}
+		return RegistrationServiceConfig{cfg: &toolchainv1alpha1.ToolchainConfigSpec{}, settings: currentSettings()}
 	}
 	return NewRegistrationServiceConfig(config, secrets)
 }
@@ -73,23 +78,24 @@ func SetClient(cl client.Client) {
 }
 
 type RegistrationServiceConfig struct {
-	cfg     *toolchainv1alpha1.ToolchainConfigSpec
-	secrets map[string]map[string]string
+	cfg      *toolchainv1alpha1.ToolchainConfigSpec
+	secrets  map[string]map[string]string
+	settings *Settings
 }
 
 func NewRegistrationServiceConfig(config runtime.Object, secrets map[string]map[string]string) RegistrationServiceConfig {
 	if config == nil {
 		// return default config if there's no config resource
-		return RegistrationServiceConfig{cfg: &toolchainv1alpha1.ToolchainConfigSpec{}}
+		return RegistrationServiceConfig{cfg: &toolchainv1alpha1.ToolchainConfigSpec{}, settings: currentSettings()}
 	}
 
 	toolchaincfg, ok := config.(*toolchainv1alpha1.ToolchainConfig)
 	if !ok {
 		// return default config
 		logger.Error(fmt.Errorf("cache does not contain toolchainconfig resource type"), "failed to get ToolchainConfig from resource, using default configuration")
-		return RegistrationServiceConfig{cfg: &toolchainv1alpha1.ToolchainConfigSpec{}}
+		return RegistrationServiceConfig{cfg: &toolchainv1alpha1.ToolchainConfigSpec{}, settings: currentSettings()}
 	}
-	return RegistrationServiceConfig{cfg: &toolchaincfg.Spec, secrets: secrets}
+	return RegistrationServiceConfig{cfg: &toolchaincfg.Spec, secrets: secrets, settings: currentSettings()}
 }
 
 func (r RegistrationServiceConfig) Print() {
@@ -117,7 +123,7 @@ func (r RegistrationServiceConfig) Analytics() AnalyticsConfig {
 }
 
 func (r RegistrationServiceConfig) Auth() AuthConfig {
-	return AuthConfig{r.cfg.Host.RegistrationService.Auth}
+	return AuthConfig{c: r.cfg.Host.RegistrationService.Auth, s: r.settings.Auth}
 }
 
 func (r RegistrationServiceConfig) LogLevel() string {
@@ -157,6 +163,50 @@ func (r RegistrationServiceConfig) DisabledIntegrations() []string {
 	return disabledIntegrations
 }
 
+func (r RegistrationServiceConfig) Admin() AdminConfig {
+	return AdminConfig{r.settings.Admin}
+}
+
+func (r RegistrationServiceConfig) Idempotency() IdempotencyConfig {
+	return IdempotencyConfig{r.settings.Idempotency}
+}
+
+func (r RegistrationServiceConfig) Namespaces() NamespacesConfig {
+	return NamespacesConfig{r.settings.Namespaces}
+}
+
+func (r RegistrationServiceConfig) Organizers() OrganizersConfig {
+	return OrganizersConfig{r.settings.Organizers}
+}
+
+func (r RegistrationServiceConfig) Proxy() ProxyConfig {
+	return ProxyConfig{r.settings.Proxy}
+}
+
+func (r RegistrationServiceConfig) RateLimits() RateLimitsConfig {
+	return RateLimitsConfig{r.settings.RateLimits}
+}
+
+func (r RegistrationServiceConfig) SignupEvents() SignupEventsConfig {
+	return SignupEventsConfig{r.settings.SignupEvents}
+}
+
+func (r RegistrationServiceConfig) Tiers() TiersConfig {
+	return TiersConfig{c: r.cfg.Host.Tiers, s: r.settings.Tiers}
+}
+
+func (r RegistrationServiceConfig) TrialExtensions() TrialExtensionsConfig {
+	return TrialExtensionsConfig{r.settings.TrialExtensions}
+}
+
+func (r RegistrationServiceConfig) Usernames() UsernamesConfig {
+	return UsernamesConfig{r.settings.Usernames}
+}
+
+func (r RegistrationServiceConfig) Users() UsersConfig {
+	return UsersConfig{r.cfg.Host.Users}
+}
+
 type AnalyticsConfig struct {
 	c toolchainv1alpha1.RegistrationServiceAnalyticsConfig
 }
@@ -171,6 +221,7 @@ func (r AnalyticsConfig) DevSpacesSegmentWriteKey() string {
 
 type AuthConfig struct {
 	c toolchainv1alpha1.RegistrationServiceAuthConfig
+	s AuthSettings
 }
 
 func (r AuthConfig) AuthClientLibraryURL() string {
@@ -197,6 +248,29 @@ func (r AuthConfig) SSORealm() string {
 	return commonconfig.GetString(r.c.SSORealm, "sandbox-dev")
 }
 
+// SSORealms returns the base URLs of the SSO realms supported by the proxy, indexed by realm.
+// It contains the SSORealm at the SSOBaseURL, along with the AdditionalSSORealms.
+func (r AuthConfig) SSORealms() map[string]string {
+	realms := r.AdditionalSSORealms()
+	realms[r.SSORealm()] = r.SSOBaseURL()
+	return realms
+}
+
+// AdditionalSSORealms returns the base URLs of the SSO realms supported by the proxy besides the SSORealm, indexed by realm
+func (r AuthConfig) AdditionalSSORealms() map[string]string {
+	realms := make(map[string]string, len(r.s.AdditionalSSORealms))
+	for name, baseURL := range r.s.AdditionalSSORealms {
+		realms[name] = strings.TrimSuffix(baseURL, "/")
+	}
+	return realms
+}
+
+// RevokedTokenTTL returns how long a token revoked without its expiry time stays revoked. The revocations of the
+// tokens are deleted once the tokens expired, so that they do not pile up.
+func (r AuthConfig) RevokedTokenTTL() time.Duration {
+	return commonconfig.GetDuration(r.s.RevokedTokenTTL, 24*time.Hour)
+}
+
 type VerificationConfig struct {
 	c       toolchainv1alpha1.RegistrationServiceVerificationConfig
 	secrets map[string]map[string]string
@@ -339,3 +413,302 @@ func (r VerificationConfig) PhoneLookupMode() toolchainv1alpha1.PhoneLookupMode
 func (r VerificationConfig) PhoneLookupExcludedCountries() []string {
 	return r.c.PhoneLookupExcludedCountries
 }
+
+// AdminConfig holds the settings of the administrative endpoints
+type AdminConfig struct {
+	s AdminSettings
+}
+
+// Groups returns the names of the groups (as found in the `groups` claim of the token) whose members are
+// allowed to call the administrative endpoints
+func (r AdminConfig) Groups() []string {
+	return r.s.Groups
+}
+
+// Roles returns the names of the realm roles (as found in the `realm_access.roles` claim of the token) which
+// allow their holders to call the administrative endpoints
+func (r AdminConfig) Roles() []string {
+	return r.s.Roles
+}
+
+// IdempotencyConfig holds the settings of the idempotency keys of the requests
+type IdempotencyConfig struct {
+	s IdempotencySettings
+}
+
+// TTL returns how long the outcome of a request with an idempotency key is replayed to the requests with the same key.
+// A value lower or equal to 0 disables the idempotency keys.
+func (r IdempotencyConfig) TTL() time.Duration {
+	return commonconfig.GetDuration(r.s.TTL, 24*time.Hour)
+}
+
+// Persisted returns true if the outcomes of the requests are also recorded in the UserSignup of the user, so that they
+// are replayed by all the replicas of the service and after its restarts
+func (r IdempotencyConfig) Persisted() bool {
+	return commonconfig.GetBool(r.s.Persisted, false)
+}
+
+// NamespacesConfig holds the settings of the endpoints about the namespaces of the user
+type NamespacesConfig struct {
+	s NamespacesSettings
+}
+
+// UsageCacheTTL returns how long the usage of the namespaces of a user is cached, so that refreshing the usage does not
+// hit the member cluster every time. A value lower or equal to 0 disables the cache.
+func (r NamespacesConfig) UsageCacheTTL() time.Duration {
+	return commonconfig.GetDuration(r.s.UsageCacheTTL, 30*time.Second)
+}
+
+// ResetJobTTL returns how long the progress of a namespaces reset can be queried. The expired jobs are deleted when
+// another reset is requested.
+func (r NamespacesConfig) ResetJobTTL() time.Duration {
+	return commonconfig.GetDuration(r.s.ResetJobTTL, 24*time.Hour)
+}
+
+// OrganizersConfig holds the settings of the endpoints of the event organizers
+type OrganizersConfig struct {
+	s OrganizersSettings
+}
+
+// Groups returns the names of the groups (as found in the `groups` claim of the token) whose members are
+// allowed to manage their own social events
+func (r OrganizersConfig) Groups() []string {
+	return r.s.Groups
+}
+
+// Roles returns the names of the realm roles (as found in the `realm_access.roles` claim of the token) which
+// allow their holders to manage their own social events
+func (r OrganizersConfig) Roles() []string {
+	return r.s.Roles
+}
+
+// MaxAttendees returns the maximum number of attendees of the events managed by the organizers.
+// It does not apply to the administrators.
+func (r OrganizersConfig) MaxAttendees() int {
+	return commonconfig.GetInt(r.s.MaxAttendees, 500)
+}
+
+// TiersConfig holds the settings of the tiers, as configured for the host operator
+type TiersConfig struct {
+	c toolchainv1alpha1.TiersConfig
+	s TiersSettings
+}
+
+// DefaultUserTier returns the name of the tier assigned to the new users
+func (r TiersConfig) DefaultUserTier() string {
+	return commonconfig.GetString(r.c.DefaultUserTier, "deactivate30")
+}
+
+// DefaultSpaceTier returns the name of the tier assigned to the new spaces
+func (r TiersConfig) DefaultSpaceTier() string {
+	return commonconfig.GetString(r.c.DefaultSpaceTier, "base1ns")
+}
+
+// SelectableTier is a UserTier which the users can choose when they sign up
+type SelectableTier struct {
+	// Name is the name of the UserTier resource
+	Name string `json:"name"`
+	// DisplayName is the name of the tier shown to the users
+	DisplayName string `json:"displayName,omitempty"`
+	// EmailDomains restricts the tier to the users with an email address in one of the domains, if not empty
+	EmailDomains []string `json:"emailDomains,omitempty"`
+	// SocialEvents restricts the tier to the users who joined one of the SocialEvents, if not empty.
+	// A user who is eligible through either their email domain or a SocialEvent can choose the tier.
+	SocialEvents []string `json:"socialEvents,omitempty"`
+}
+
+// Selectable returns the tiers which the users can choose when they sign up. No tier can be chosen by default.
+func (r TiersConfig) Selectable() []SelectableTier {
+	if r.s.Selectable == nil {
+		return []SelectableTier{}
+	}
+	return r.s.Selectable
+}
+
+// ProxyConfig holds the settings of the API proxy
+type ProxyConfig struct {
+	s ProxySettings
+}
+
+// PublicURL returns the URL of the proxy as seen by the clients, without trailing slash.
+// An empty value means that the URL is derived from the host and the scheme of each request, ignoring the
+// `X-Forwarded-*` headers which could be set by any client.
+func (r ProxyConfig) PublicURL() string {
+	return strings.TrimSuffix(commonconfig.GetString(r.s.PublicURL, ""), "/")
+}
+
+// TokenCacheSize returns the maximum number of validated tokens kept in the cache of the proxy.
+// A value of 0 disables the cache.
+func (r ProxyConfig) TokenCacheSize() int {
+	return commonconfig.GetInt(r.s.TokenCacheSize, 10000)
+}
+
+// TokenCacheTTL returns how long a validated token is kept in the cache of the proxy.
+// A token is never kept in the cache after its expiration time, regardless of this value.
+func (r ProxyConfig) TokenCacheTTL() time.Duration {
+	return commonconfig.GetDuration(r.s.TokenCacheTTL, time.Minute)
+}
+
+// WellKnownRewriteEndpoints returns the keys of the SSO discovery document (`.well-known/openid-configuration`)
+// whose URLs are rewritten to point at the proxy
+func (r ProxyConfig) WellKnownRewriteEndpoints() []string {
+	endpoints := r.s.WellKnownRewriteEndpoints
+	if len(endpoints) == 0 {
+		return []string{
+			"authorization_endpoint",
+			"token_endpoint",
+			"device_authorization_endpoint",
+			"userinfo_endpoint",
+			"end_session_endpoint",
+			"jwks_uri",
+			"introspection_endpoint",
+			"revocation_endpoint",
+		}
+	}
+	return endpoints
+}
+
+// WellKnownCacheTTL returns how long the SSO discovery document is cached by the proxy
+func (r ProxyConfig) WellKnownCacheTTL() time.Duration {
+	return commonconfig.GetDuration(r.s.WellKnownCacheTTL, 30*time.Second)
+}
+
+// RateLimitsConfig holds the settings of the rate limits of the endpoints
+type RateLimitsConfig struct {
+	s RateLimitsSettings
+}
+
+// RateLimit is the number of requests per minute allowed for a single caller of an endpoint, with bursts of up to
+// Burst requests. A PerMinute value of 0 disables the rate limiting.
+type RateLimit struct {
+	PerMinute int `json:"perMinute"`
+	Burst     int `json:"burst"`
+}
+
+// defaultRouteRateLimits are the built-in rate limits of the endpoints which differ from the defaults
+var defaultRouteRateLimits = map[string]RateLimit{
+	"GET /health":                          {}, // not limited, so that the probes are never rejected
+	"PUT /signup/verification":             {PerMinute: 5, Burst: 3},
+	"POST /signup/verification/phone-code": {PerMinute: 10, Burst: 5}, // shared with `GET /signup/verification/:code` of v1
+	"GET /activation-codes/:code":          {PerMinute: 10, Burst: 5}, // prevents the brute-forcing of the codes
+	"GET /usernames/:username":             {PerMinute: 10, Burst: 5}, // prevents the enumeration of the users
+	"GET /usernames/availability":          {PerMinute: 10, Burst: 5},
+}
+
+// Secured returns the default rate limit of each user on the secured endpoints
+func (r RateLimitsConfig) Secured() RateLimit {
+	if r.s.Secured != nil {
+		return *r.s.Secured
+	}
+	return RateLimit{PerMinute: 120, Burst: 30}
+}
+
+// Unsecured returns the default rate limit of each client IP address on the unsecured endpoints. The client IP
+// addresses are only limited when trusted proxies are configured (see ClientIPsLimited).
+func (r RateLimitsConfig) Unsecured() RateLimit {
+	if r.s.Unsecured != nil {
+		return *r.s.Unsecured
+	}
+	return RateLimit{PerMinute: 60, Burst: 20}
+}
+
+// Routes returns the rate limits of the endpoints which differ from the defaults, by method and route relative to the
+// version of the API (eg, `PUT /signup/verification`): the built-in limits, overridden by the configured ones.
+func (r RateLimitsConfig) Routes() map[string]RateLimit {
+	routes := maps.Clone(defaultRouteRateLimits)
+	maps.Copy(routes, r.s.Routes)
+	return routes
+}
+
+// TrustedProxies returns the IP addresses or CIDR ranges of the reverse proxies whose `X-Forwarded-For` header
+// identifies the client IP address. By default, no proxy is trusted and the client IP address is the remote address
+// of the connection.
+func (r RateLimitsConfig) TrustedProxies() []string {
+	return r.s.TrustedProxies
+}
+
+// ClientIPsLimited returns true if the requests on the unsecured endpoints are limited by client IP address, which is
+// only the case when trusted proxies are configured: behind a proxy (eg, the OpenShift router), the remote address of
+// the requests is the one of the proxy, so all the clients would otherwise share the same limit.
+func (r RateLimitsConfig) ClientIPsLimited() bool {
+	return len(r.s.TrustedProxies) > 0
+}
+
+// SignupEventsConfig holds the settings of the stream of signup events
+type SignupEventsConfig struct {
+	s SignupEventsSettings
+}
+
+// HeartbeatInterval returns the interval between two heartbeats sent on the stream of signup events,
+// which keep the connection open when the signup does not change
+func (r SignupEventsConfig) HeartbeatInterval() time.Duration {
+	if interval := commonconfig.GetDuration(r.s.HeartbeatInterval, 15*time.Second); interval > 0 {
+		return interval
+	}
+	return 15 * time.Second
+}
+
+// TrialExtensionsConfig holds the settings of the trial extension requests
+type TrialExtensionsConfig struct {
+	s TrialExtensionsSettings
+}
+
+// Max returns the maximum number of trial extensions that can be granted to a user
+func (r TrialExtensionsConfig) Max() int {
+	return commonconfig.GetInt(r.s.Max, 1)
+}
+
+// Duration returns by how long the trial of a user is extended when an extension is granted
+func (r TrialExtensionsConfig) Duration() time.Duration {
+	return commonconfig.GetDuration(r.s.Duration, 30*24*time.Hour)
+}
+
+// AutoApprovedEmailDomains returns the email domains of the users whose trial extension requests are approved
+// automatically
+func (r TrialExtensionsConfig) AutoApprovedEmailDomains() []string {
+	return r.s.AutoApprovedEmailDomains
+}
+
+// AutoApproveEventAttendees returns true if the trial extension requests of the users who joined through a
+// SocialEvent are approved automatically
+func (r TrialExtensionsConfig) AutoApproveEventAttendees() bool {
+	return commonconfig.GetBool(r.s.AutoApproveEventAttendees, false)
+}
+
+// UsernamesConfig holds the settings of the usernames endpoints
+type UsernamesConfig struct {
+	s UsernamesSettings
+}
+
+// Suggestions returns the maximum number of alternative usernames suggested when a username is not available
+func (r UsernamesConfig) Suggestions() int {
+	return commonconfig.GetInt(r.s.Suggestions, 3)
+}
+
+// UsersConfig holds the settings shared with the host operator about the users
+type UsersConfig struct {
+	c toolchainv1alpha1.UsersConfig
+}
+
+// ForbiddenUsernamePrefixes returns the prefixes that a compliant username may not have. The host operator prepends
+// a compliance prefix to the usernames with one of these prefixes.
+func (r UsersConfig) ForbiddenUsernamePrefixes() []string {
+	return splitList(commonconfig.GetString(r.c.ForbiddenUsernamePrefixes, "openshift,kube,default,redhat,sandbox"))
+}
+
+// ForbiddenUsernameSuffixes returns the suffixes that a compliant username may not have. The host operator appends
+// a compliance suffix to the usernames with one of these suffixes.
+func (r UsersConfig) ForbiddenUsernameSuffixes() []string {
+	return splitList(commonconfig.GetString(r.c.ForbiddenUsernameSuffixes, "admin"))
+}
+
+// splitList returns the non-empty values of the given comma-separated list
+func splitList(list string) []string {
+	values := []string{}
+	for _, v := range strings.Split(list, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -2,6 +2,7 @@ package configuration_test
 
 import (
 	"testing"
+	"time"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	"github.com/codeready-toolchain/registration-service/pkg/configuration"
@@ -53,6 +54,9 @@ func TestRegistrationService(t *testing.T) {
 		assert.Equal(t, "https://sso.devsandbox.dev/auth/realms/sandbox-dev/protocol/openid-connect/certs", regServiceCfg.Auth().AuthClientPublicKeysURL())
 		assert.Equal(t, "https://sso.devsandbox.dev", regServiceCfg.Auth().SSOBaseURL())
 		assert.Equal(t, "sandbox-dev", regServiceCfg.Auth().SSORealm())
+		assert.Empty(t, regServiceCfg.Auth().AdditionalSSORealms())
+		assert.Equal(t, map[string]string{"sandbox-dev": "https://sso.devsandbox.dev"}, regServiceCfg.Auth().SSORealms())
+		assert.Equal(t, 24*time.Hour, regServiceCfg.Auth().RevokedTokenTTL())
 		assert.False(t, regServiceCfg.Verification().Enabled())
 		assert.Equal(t, 5, regServiceCfg.Verification().DailyLimit())
 		assert.Equal(t, 3, regServiceCfg.Verification().AttemptsAllowed())
@@ -169,6 +173,44 @@ func TestRegistrationService(t *testing.T) {
 	})
 }
 
+func TestSSORealmsConfiguration(t *testing.T) {
+	t.Run("additional realms", func(t *testing.T) {
+		// given
+		test.SetSettings(t, `
+auth:
+  additionalSSORealms:
+    employees: https://sso.employees.org/
+    external: https://sso.external.org`)
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t, testconfig.RegistrationService().Auth().SSOBaseURL("https://sso.test.org").Auth().SSORealm("my-realm"))
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Equal(t, map[string]string{
+			"employees": "https://sso.employees.org",
+			"external":  "https://sso.external.org",
+		}, regServiceCfg.Auth().AdditionalSSORealms())
+		assert.Equal(t, map[string]string{
+			"my-realm":  "https://sso.test.org",
+			"employees": "https://sso.employees.org",
+			"external":  "https://sso.external.org",
+		}, regServiceCfg.Auth().SSORealms())
+	})
+}
+
+func TestRevokedTokenTTLConfiguration(t *testing.T) {
+	// given
+	test.SetSettings(t, "auth: {revokedTokenTTL: 12h}")
+	cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+	// when
+	regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+	// then
+	assert.Equal(t, 12*time.Hour, regServiceCfg.Auth().RevokedTokenTTL())
+}
+
 func TestPublicViewerConfiguration(t *testing.T) {
 	tt := map[string]struct {
 		name               string
@@ -204,3 +246,381 @@ func TestPublicViewerConfiguration(t *testing.T) {
 		})
 	}
 }
+
+func TestIdempotencyConfiguration(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		// given
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Equal(t, 24*time.Hour, regServiceCfg.Idempotency().TTL())
+		assert.False(t, regServiceCfg.Idempotency().Persisted())
+	})
+
+	t.Run("non-default", func(t *testing.T) {
+		// given
+		test.SetSettings(t, "idempotency: {ttl: 10m, persisted: true}")
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Equal(t, 10*time.Minute, regServiceCfg.Idempotency().TTL())
+		assert.True(t, regServiceCfg.Idempotency().Persisted())
+	})
+}
+
+func TestNamespacesConfiguration(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		// given
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Equal(t, 30*time.Second, regServiceCfg.Namespaces().UsageCacheTTL())
+		assert.Equal(t, 24*time.Hour, regServiceCfg.Namespaces().ResetJobTTL())
+	})
+
+	t.Run("non-default", func(t *testing.T) {
+		// given
+		test.SetSettings(t, "namespaces: {usageCacheTTL: 2m, resetJobTTL: 1h}")
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Equal(t, 2*time.Minute, regServiceCfg.Namespaces().UsageCacheTTL())
+		assert.Equal(t, time.Hour, regServiceCfg.Namespaces().ResetJobTTL())
+	})
+}
+
+func TestTrialExtensionsConfiguration(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		// given
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Equal(t, 1, regServiceCfg.TrialExtensions().Max())
+		assert.Equal(t, 720*time.Hour, regServiceCfg.TrialExtensions().Duration())
+		assert.Empty(t, regServiceCfg.TrialExtensions().AutoApprovedEmailDomains())
+		assert.False(t, regServiceCfg.TrialExtensions().AutoApproveEventAttendees())
+	})
+
+	t.Run("non-default", func(t *testing.T) {
+		// given
+		test.SetSettings(t, `
+trialExtensions:
+  max: 3
+  duration: 168h
+  autoApprovedEmailDomains: [redhat.com, example.edu]
+  autoApproveEventAttendees: true`)
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Equal(t, 3, regServiceCfg.TrialExtensions().Max())
+		assert.Equal(t, 168*time.Hour, regServiceCfg.TrialExtensions().Duration())
+		assert.Equal(t, []string{"redhat.com", "example.edu"}, regServiceCfg.TrialExtensions().AutoApprovedEmailDomains())
+		assert.True(t, regServiceCfg.TrialExtensions().AutoApproveEventAttendees())
+	})
+}
+
+func TestAdminConfiguration(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		// given
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Empty(t, regServiceCfg.Admin().Groups())
+		assert.Empty(t, regServiceCfg.Admin().Roles())
+	})
+
+	t.Run("non-default", func(t *testing.T) {
+		// given
+		test.SetSettings(t, "admin: {groups: [sandbox-admins, sre], roles: [sandbox-admin]}")
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Equal(t, []string{"sandbox-admins", "sre"}, regServiceCfg.Admin().Groups())
+		assert.Equal(t, []string{"sandbox-admin"}, regServiceCfg.Admin().Roles())
+	})
+}
+
+func TestOrganizersConfiguration(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		// given
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Empty(t, regServiceCfg.Organizers().Groups())
+		assert.Empty(t, regServiceCfg.Organizers().Roles())
+		assert.Equal(t, 500, regServiceCfg.Organizers().MaxAttendees())
+	})
+
+	t.Run("non-default", func(t *testing.T) {
+		// given
+		test.SetSettings(t, "organizers: {groups: [event-organizers], roles: [sandbox-organizer, devrel], maxAttendees: 100}")
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Equal(t, []string{"event-organizers"}, regServiceCfg.Organizers().Groups())
+		assert.Equal(t, []string{"sandbox-organizer", "devrel"}, regServiceCfg.Organizers().Roles())
+		assert.Equal(t, 100, regServiceCfg.Organizers().MaxAttendees())
+	})
+}
+
+func TestTiersConfiguration(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		// given
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Equal(t, "deactivate30", regServiceCfg.Tiers().DefaultUserTier())
+		assert.Equal(t, "base1ns", regServiceCfg.Tiers().DefaultSpaceTier())
+		assert.Empty(t, regServiceCfg.Tiers().Selectable())
+	})
+
+	t.Run("non-default", func(t *testing.T) {
+		// given
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t, testconfig.Tiers().DefaultUserTier("deactivate90").DefaultSpaceTier("base"))
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Equal(t, "deactivate90", regServiceCfg.Tiers().DefaultUserTier())
+		assert.Equal(t, "base", regServiceCfg.Tiers().DefaultSpaceTier())
+	})
+
+	t.Run("selectable tiers", func(t *testing.T) {
+		// given
+		test.SetSettings(t, `
+tiers:
+  selectable:
+  - name: deactivate30
+    displayName: 30 days
+  - name: ai
+    emailDomains: [redhat.com]
+    socialEvents: [summit]`)
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Equal(t, []configuration.SelectableTier{
+			{Name: "deactivate30", DisplayName: "30 days"},
+			{Name: "ai", EmailDomains: []string{"redhat.com"}, SocialEvents: []string{"summit"}},
+		}, regServiceCfg.Tiers().Selectable())
+	})
+}
+
+func TestProxyConfiguration(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		// given
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Empty(t, regServiceCfg.Proxy().PublicURL())
+		assert.Equal(t, 10000, regServiceCfg.Proxy().TokenCacheSize())
+		assert.Equal(t, time.Minute, regServiceCfg.Proxy().TokenCacheTTL())
+		assert.Contains(t, regServiceCfg.Proxy().WellKnownRewriteEndpoints(), "authorization_endpoint")
+		assert.Contains(t, regServiceCfg.Proxy().WellKnownRewriteEndpoints(), "token_endpoint")
+		assert.NotContains(t, regServiceCfg.Proxy().WellKnownRewriteEndpoints(), "issuer")
+		assert.Equal(t, 30*time.Second, regServiceCfg.Proxy().WellKnownCacheTTL())
+	})
+
+	t.Run("non-default", func(t *testing.T) {
+		// given
+		test.SetSettings(t, `
+proxy:
+  publicURL: https://api-proxy.example.com/
+  tokenCacheSize: 0
+  tokenCacheTTL: 30s
+  wellKnownRewriteEndpoints: [token_endpoint, jwks_uri]
+  wellKnownCacheTTL: 5s`)
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Equal(t, "https://api-proxy.example.com", regServiceCfg.Proxy().PublicURL())
+		assert.Equal(t, 0, regServiceCfg.Proxy().TokenCacheSize())
+		assert.Equal(t, 30*time.Second, regServiceCfg.Proxy().TokenCacheTTL())
+		assert.Equal(t, []string{"token_endpoint", "jwks_uri"}, regServiceCfg.Proxy().WellKnownRewriteEndpoints())
+		assert.Equal(t, 5*time.Second, regServiceCfg.Proxy().WellKnownCacheTTL())
+	})
+}
+
+func TestRateLimitsConfiguration(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		// given
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Equal(t, configuration.RateLimit{PerMinute: 120, Burst: 30}, regServiceCfg.RateLimits().Secured())
+		assert.Equal(t, configuration.RateLimit{PerMinute: 60, Burst: 20}, regServiceCfg.RateLimits().Unsecured())
+		routes := regServiceCfg.RateLimits().Routes()
+		assert.Equal(t, configuration.RateLimit{}, routes["GET /health"])
+		assert.Equal(t, configuration.RateLimit{PerMinute: 5, Burst: 3}, routes["PUT /signup/verification"])
+		assert.Equal(t, configuration.RateLimit{PerMinute: 10, Burst: 5}, routes["POST /signup/verification/phone-code"])
+		assert.Equal(t, configuration.RateLimit{PerMinute: 10, Burst: 5}, routes["GET /activation-codes/:code"])
+		assert.Equal(t, configuration.RateLimit{PerMinute: 10, Burst: 5}, routes["GET /usernames/:username"])
+		assert.Equal(t, configuration.RateLimit{PerMinute: 10, Burst: 5}, routes["GET /usernames/availability"])
+		assert.Empty(t, regServiceCfg.RateLimits().TrustedProxies())
+		assert.False(t, regServiceCfg.RateLimits().ClientIPsLimited())
+	})
+
+	t.Run("non-default", func(t *testing.T) {
+		// given
+		test.SetSettings(t, `
+rateLimits:
+  secured: {perMinute: 300, burst: 50}
+  unsecured: {perMinute: 0, burst: 1}
+  routes:
+    PUT /signup/verification: {perMinute: 2, burst: 1}
+    GET /uiconfig: {perMinute: 20, burst: 5}
+  trustedProxies: [10.128.0.0/14, 192.168.1.1]`)
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Equal(t, configuration.RateLimit{PerMinute: 300, Burst: 50}, regServiceCfg.RateLimits().Secured())
+		assert.Equal(t, configuration.RateLimit{PerMinute: 0, Burst: 1}, regServiceCfg.RateLimits().Unsecured())
+		routes := regServiceCfg.RateLimits().Routes()
+		assert.Equal(t, configuration.RateLimit{PerMinute: 2, Burst: 1}, routes["PUT /signup/verification"])
+		assert.Equal(t, configuration.RateLimit{PerMinute: 20, Burst: 5}, routes["GET /uiconfig"])
+		assert.Equal(t, configuration.RateLimit{PerMinute: 10, Burst: 5}, routes["GET /usernames/:username"]) // built-in
+		assert.Equal(t, []string{"10.128.0.0/14", "192.168.1.1"}, regServiceCfg.RateLimits().TrustedProxies())
+		assert.True(t, regServiceCfg.RateLimits().ClientIPsLimited())
+	})
+}
+
+func TestSignupEventsConfiguration(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		// given
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Equal(t, 15*time.Second, regServiceCfg.SignupEvents().HeartbeatInterval())
+	})
+
+	t.Run("non-default", func(t *testing.T) {
+		// given
+		test.SetSettings(t, "signupEvents: {heartbeatInterval: 1m}")
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Equal(t, time.Minute, regServiceCfg.SignupEvents().HeartbeatInterval())
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		// given
+		test.SetSettings(t, "signupEvents: {heartbeatInterval: 0s}")
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Equal(t, 15*time.Second, regServiceCfg.SignupEvents().HeartbeatInterval())
+	})
+}
+
+func TestUsernamesConfiguration(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		// given
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Equal(t, 3, regServiceCfg.Usernames().Suggestions())
+	})
+
+	t.Run("non-default", func(t *testing.T) {
+		// given
+		test.SetSettings(t, "usernames: {suggestions: 5}")
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Equal(t, 5, regServiceCfg.Usernames().Suggestions())
+	})
+}
+
+func TestUsersConfiguration(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		// given
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Equal(t, []string{"openshift", "kube", "default", "redhat", "sandbox"}, regServiceCfg.Users().ForbiddenUsernamePrefixes())
+		assert.Equal(t, []string{"admin"}, regServiceCfg.Users().ForbiddenUsernameSuffixes())
+		assert.Equal(t, 3, regServiceCfg.Usernames().Suggestions())
+	})
+
+	t.Run("non-default", func(t *testing.T) {
+		// given
+		test.SetSettings(t, "usernames: {suggestions: 5}")
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t, testconfig.Users().
+			ForbiddenUsernamePrefixes("kube, openshift").
+			ForbiddenUsernameSuffixes("admin,owner"))
+
+		// when
+		regServiceCfg := configuration.NewRegistrationServiceConfig(cfg, map[string]map[string]string{})
+
+		// then
+		assert.Equal(t, []string{"kube", "openshift"}, regServiceCfg.Users().ForbiddenUsernamePrefixes())
+		assert.Equal(t, []string{"admin", "owner"}, regServiceCfg.Users().ForbiddenUsernameSuffixes())
+		assert.Equal(t, 5, regServiceCfg.Usernames().Suggestions())
+	})
+}
@@ -0,0 +1,245 @@
+package configuration
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// SettingsFileEnvVar is the environment variable holding the path of the settings document
+	SettingsFileEnvVar = "REGISTRATION_SERVICE_SETTINGS_FILE"
+	// DefaultSettingsFile is the path of the settings document when SettingsFileEnvVar is not set
+	DefaultSettingsFile = "/etc/registration-service/settings.yaml"
+)
+
+// Settings holds the settings of the registration service which are not part of the ToolchainConfig resource.
+// They are read once, when the service starts, from a YAML document (usually mounted from a ConfigMap).
+// Like in the ToolchainConfig resource, an unset field means that the default value applies.
+type Settings struct {
+	Admin           AdminSettings           `json:"admin,omitempty"`
+	Auth            AuthSettings            `json:"auth,omitempty"`
+	Idempotency     IdempotencySettings     `json:"idempotency,omitempty"`
+	Namespaces      NamespacesSettings      `json:"namespaces,omitempty"`
+	Organizers      OrganizersSettings      `json:"organizers,omitempty"`
+	Proxy           ProxySettings           `json:"proxy,omitempty"`
+	RateLimits      RateLimitsSettings      `json:"rateLimits,omitempty"`
+	SignupEvents    SignupEventsSettings    `json:"signupEvents,omitempty"`
+	Tiers           TiersSettings           `json:"tiers,omitempty"`
+	TrialExtensions TrialExtensionsSettings `json:"trialExtensions,omitempty"`
+	Usernames       UsernamesSettings       `json:"usernames,omitempty"`
+}
+
+// AdminSettings are the settings of the administrative endpoints
+type AdminSettings struct {
+	// Groups are the names of the groups whose members are allowed to call the administrative endpoints
+	Groups []string `json:"groups,omitempty"`
+	// Roles are the names of the realm roles which allow their holders to call the administrative endpoints
+	Roles []string `json:"roles,omitempty"`
+}
+
+// AuthSettings are the settings of the authentication which complement those of the ToolchainConfig resource
+type AuthSettings struct {
+	// AdditionalSSORealms are the base URLs of the SSO realms supported by the proxy besides the main one, by realm
+	AdditionalSSORealms map[string]string `json:"additionalSSORealms,omitempty"`
+	// RevokedTokenTTL is how long a token revoked without its expiry time stays revoked (eg, `24h`)
+	RevokedTokenTTL *string `json:"revokedTokenTTL,omitempty"`
+}
+
+// IdempotencySettings are the settings of the idempotency keys of the requests
+type IdempotencySettings struct {
+	// TTL is how long the outcome of a request is replayed to the requests with the same key (eg, `24h`)
+	TTL *string `json:"ttl,omitempty"`
+	// Persisted tells if the outcomes are also recorded in the UserSignup of the user
+	Persisted *bool `json:"persisted,omitempty"`
+}
+
+// NamespacesSettings are the settings of the endpoints about the namespaces of the user
+type NamespacesSettings struct {
+	// UsageCacheTTL is how long the usage of the namespaces of a user is cached (eg, `30s`)
+	UsageCacheTTL *string `json:"usageCacheTTL,omitempty"`
+	// ResetJobTTL is how long the progress of a namespaces reset can be queried (eg, `24h`)
+	ResetJobTTL *string `json:"resetJobTTL,omitempty"`
+}
+
+// OrganizersSettings are the settings of the endpoints of the event organizers
+type OrganizersSettings struct {
+	// Groups are the names of the groups whose members are allowed to manage their own social events
+	Groups []string `json:"groups,omitempty"`
+	// Roles are the names of the realm roles which allow their holders to manage their own social events
+	Roles []string `json:"roles,omitempty"`
+	// MaxAttendees is the maximum number of attendees of the events managed by the organizers
+	MaxAttendees *int `json:"maxAttendees,omitempty"`
+}
+
+// ProxySettings are the settings of the API proxy
+type ProxySettings struct {
+	// PublicURL is the URL of the proxy as seen by the clients (eg, `https://api-toolchain-host-operator.apps.example.com`)
+	PublicURL *string `json:"publicURL,omitempty"`
+	// TokenCacheSize is the maximum number of validated tokens kept in the cache of the proxy
+	TokenCacheSize *int `json:"tokenCacheSize,omitempty"`
+	// TokenCacheTTL is how long a validated token is kept in the cache of the proxy (eg, `1m`)
+	TokenCacheTTL *string `json:"tokenCacheTTL,omitempty"`
+	// WellKnownRewriteEndpoints are the keys of the SSO discovery document whose URLs are rewritten
+	WellKnownRewriteEndpoints []string `json:"wellKnownRewriteEndpoints,omitempty"`
+	// WellKnownCacheTTL is how long the SSO discovery document is cached by the proxy (eg, `30s`)
+	WellKnownCacheTTL *string `json:"wellKnownCacheTTL,omitempty"`
+}
+
+// RateLimitsSettings are the settings of the rate limits of the endpoints
+type RateLimitsSettings struct {
+	// Secured is the default rate limit of each user on the secured endpoints
+	Secured *RateLimit `json:"secured,omitempty"`
+	// Unsecured is the default rate limit of each client IP address on the unsecured endpoints. It only applies when
+	// TrustedProxies are configured.
+	Unsecured *RateLimit `json:"unsecured,omitempty"`
+	// Routes are the rate limits of the endpoints which differ from the defaults, by method and route relative to
+	// the version of the API (eg, `PUT /signup/verification`). They override the built-in limits of the same routes.
+	Routes map[string]RateLimit `json:"routes,omitempty"`
+	// TrustedProxies are the IP addresses or CIDR ranges of the reverse proxies (eg, the OpenShift router) whose
+	// `X-Forwarded-For` header identifies the client IP address. The header is ignored for the other callers.
+	// The unsecured endpoints are not rate limited when no proxy is trusted.
+	TrustedProxies []string `json:"trustedProxies,omitempty"`
+}
+
+// SignupEventsSettings are the settings of the stream of signup events
+type SignupEventsSettings struct {
+	// HeartbeatInterval is the interval between two heartbeats sent on the stream (eg, `15s`)
+	HeartbeatInterval *string `json:"heartbeatInterval,omitempty"`
+}
+
+// TiersSettings are the settings of the tiers which complement those of the ToolchainConfig resource
+type TiersSettings struct {
+	// Selectable are the tiers which the users can choose when they sign up
+	Selectable []SelectableTier `json:"selectable,omitempty"`
+}
+
+// TrialExtensionsSettings are the settings of the trial extension requests
+type TrialExtensionsSettings struct {
+	// Max is the maximum number of trial extensions that can be granted to a user
+	Max *int `json:"max,omitempty"`
+	// Duration is by how long the trial of a user is extended (eg, `720h`)
+	Duration *string `json:"duration,omitempty"`
+	// AutoApprovedEmailDomains are the email domains of the users whose requests are approved automatically
+	AutoApprovedEmailDomains []string `json:"autoApprovedEmailDomains,omitempty"`
+	// AutoApproveEventAttendees tells if the requests of the users who joined through a SocialEvent are approved
+	// automatically
+	AutoApproveEventAttendees *bool `json:"autoApproveEventAttendees,omitempty"`
+}
+
+// UsernamesSettings are the settings of the usernames endpoints
+type UsernamesSettings struct {
+	// Suggestions is the maximum number of alternative usernames suggested when a username is not available
+	Suggestions *int `json:"suggestions,omitempty"`
+}
+
+// settings are the current settings, which are the default ones until LoadSettings or SetSettings is called
+var settings atomic.Pointer[Settings]
+
+func init() {
+	settings.Store(&Settings{})
+}
+
+// LoadSettings reads the settings from the document at the path given in the SettingsFileEnvVar environment variable,
+// or at the DefaultSettingsFile. The default settings apply if there is no document.
+// An error is returned if the document is invalid, so that the service does not start with unexpected settings.
+func LoadSettings() error {
+	path := DefaultSettingsFile
+	if p, found := os.LookupEnv(SettingsFileEnvVar); found {
+		path = p
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		logger.Info("no settings document found, using the default settings", "path", path)
+		SetSettings(&Settings{})
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read the settings document '%s': %w", path, err)
+	}
+	s, err := ParseSettings(data)
+	if err != nil {
+		return fmt.Errorf("invalid settings document '%s': %w", path, err)
+	}
+	SetSettings(s)
+	return nil
+}
+
+// ParseSettings returns the settings of the given YAML document. Unknown fields and invalid values are rejected.
+func ParseSettings(data []byte) (*Settings, error) {
+	s := &Settings{}
+	if err := yaml.UnmarshalStrict(data, s); err != nil {
+		return nil, err
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SetSettings replaces the current settings, and returns the previous ones
+func SetSettings(s *Settings) *Settings {
+	return settings.Swap(s)
+}
+
+// currentSettings returns the current settings
+func currentSettings() *Settings {
+	return settings.Load()
+}
+
+// validate checks the values which cannot be checked when the document is unmarshalled
+func (s *Settings) validate() error {
+	var errs []error
+	for name, value := range map[string]*string{
+		"auth.revokedTokenTTL":           s.Auth.RevokedTokenTTL,
+		"idempotency.ttl":                s.Idempotency.TTL,
+		"namespaces.resetJobTTL":         s.Namespaces.ResetJobTTL,
+		"namespaces.usageCacheTTL":       s.Namespaces.UsageCacheTTL,
+		"proxy.tokenCacheTTL":            s.Proxy.TokenCacheTTL,
+		"proxy.wellKnownCacheTTL":        s.Proxy.WellKnownCacheTTL,
+		"signupEvents.heartbeatInterval": s.SignupEvents.HeartbeatInterval,
+		"trialExtensions.duration":       s.TrialExtensions.Duration,
+	} {
+		if value == nil {
+			continue
+		}
+		if _, err := time.ParseDuration(*value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	if s.Proxy.PublicURL != nil {
+		if u, err := url.Parse(*s.Proxy.PublicURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("proxy.publicURL: invalid absolute URL '%s'", *s.Proxy.PublicURL))
+		}
+	}
+	if s.Organizers.MaxAttendees != nil && *s.Organizers.MaxAttendees <= 0 {
+		errs = append(errs, fmt.Errorf("organizers.maxAttendees: the maximum number of attendees must be positive"))
+	}
+	for realm, baseURL := range s.Auth.AdditionalSSORealms {
+		if realm == "" || baseURL == "" {
+			errs = append(errs, fmt.Errorf("auth.additionalSSORealms: invalid SSO realm '%s=%s'", realm, baseURL))
+		}
+	}
+	for name, limit := range map[string]*RateLimit{"rateLimits.secured": s.RateLimits.Secured, "rateLimits.unsecured": s.RateLimits.Unsecured} {
+		if limit != nil && (limit.PerMinute < 0 || limit.Burst < 0) {
+			errs = append(errs, fmt.Errorf("%s: the limit and the burst must not be negative", name))
+		}
+	}
+	for route, limit := range s.RateLimits.Routes {
+		if limit.PerMinute < 0 || limit.Burst < 0 {
+			errs = append(errs, fmt.Errorf("rateLimits.routes[%s]: the limit and the burst must not be negative", route))
+		}
+	}
+	for _, proxy := range s.RateLimits.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			errs = append(errs, fmt.Errorf("rateLimits.trustedProxies: invalid IP address or CIDR range '%s'", proxy))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,117 @@
+package configuration_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	commonconfig "github.com/codeready-toolchain/toolchain-common/pkg/configuration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSettings(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		// when
+		s, err := configuration.ParseSettings([]byte(`
+admin:
+  groups: [sandbox-admins]
+idempotency:
+  ttl: 1h
+rateLimits:
+  routes:
+    GET /uiconfig: {perMinute: 20, burst: 5}
+`))
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"sandbox-admins"}, s.Admin.Groups)
+		require.NotNil(t, s.Idempotency.TTL)
+		assert.Equal(t, "1h", *s.Idempotency.TTL)
+		assert.Equal(t, map[string]configuration.RateLimit{"GET /uiconfig": {PerMinute: 20, Burst: 5}}, s.RateLimits.Routes)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		// when
+		s, err := configuration.ParseSettings([]byte(""))
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, &configuration.Settings{}, s)
+	})
+
+	for name, document := range map[string]string{
+		"unknown field":             "admin: {teams: [sre]}",
+		"wrong type":                "trialExtensions: {autoApproveEventAttendees: maybe}",
+		"invalid duration":          "proxy: {tokenCacheTTL: forever}",
+		"invalid revoked token TTL": "auth: {revokedTokenTTL: 1 day}",
+		"relative public URL":       "proxy: {publicURL: api-proxy.example.com}",
+		"negative rate limit":       "rateLimits: {secured: {perMinute: -1, burst: 1}}",
+		"no attendees":              "organizers: {maxAttendees: 0}",
+		"negative route limit":      "rateLimits: {routes: {GET /uiconfig: {perMinute: 1, burst: -1}}}",
+		"invalid trusted proxy":     "rateLimits: {trustedProxies: [router.example.com]}",
+		"empty SSO realm URL":       `auth: {additionalSSORealms: {employees: ""}}`,
+		"invalid selectable tier":   "tiers: {selectable: deactivate30}",
+	} {
+		t.Run(name, func(t *testing.T) {
+			// when
+			_, err := configuration.ParseSettings([]byte(document))
+
+			// then
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestLoadSettings(t *testing.T) {
+	newRegistrationServiceConfig := func(t *testing.T) configuration.RegistrationServiceConfig {
+		return configuration.NewRegistrationServiceConfig(commonconfig.NewToolchainConfigObjWithReset(t), map[string]map[string]string{})
+	}
+	t.Cleanup(func() {
+		configuration.SetSettings(&configuration.Settings{})
+	})
+
+	t.Run("no document", func(t *testing.T) {
+		// given
+		configuration.SetSettings(&configuration.Settings{Admin: configuration.AdminSettings{Groups: []string{"sre"}}})
+		t.Setenv(configuration.SettingsFileEnvVar, filepath.Join(t.TempDir(), "settings.yaml"))
+
+		// when
+		err := configuration.LoadSettings()
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, newRegistrationServiceConfig(t).Admin().Groups())
+	})
+
+	t.Run("valid document", func(t *testing.T) {
+		// given
+		path := filepath.Join(t.TempDir(), "settings.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("admin: {groups: [sre]}"), 0600))
+		t.Setenv(configuration.SettingsFileEnvVar, path)
+
+		// when
+		err := configuration.LoadSettings()
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"sre"}, newRegistrationServiceConfig(t).Admin().Groups())
+	})
+
+	t.Run("invalid document", func(t *testing.T) {
+		// given
+		configuration.SetSettings(&configuration.Settings{})
+		path := filepath.Join(t.TempDir(), "settings.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("admin: {groups: sre}"), 0600))
+		t.Setenv(configuration.SettingsFileEnvVar, path)
+
+		// when
+		err := configuration.LoadSettings()
+
+		// then
+		require.ErrorContains(t, err, "invalid settings document")
+		// the current settings are kept
+		assert.Empty(t, newRegistrationServiceConfig(t).Admin().Groups())
+	})
+}
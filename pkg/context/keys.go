@@ -31,6 +31,10 @@ const (
 	PublicViewerEnabled = "publicViewerEnabled"
 	// ImpersonateUser is the context key for the impersonated user in proxied call
 	ImpersonateUser = "impersonateUser"
+	// AdminKey is the context key for the boolean value indicating whether the user is an administrator
+	AdminKey = "admin"
 	// SocialEvent is the context key for the activation code provided in UI
 	SocialEvent = "socialEvent"
+	// ProblemDetailsKey is the context key for the boolean value indicating whether the errors are returned as RFC 7807 problem details
+	ProblemDetailsKey = "problemDetails"
 )
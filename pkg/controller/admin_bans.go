@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/codeready-toolchain/registration-service/pkg/admin"
+	"github.com/codeready-toolchain/registration-service/pkg/context"
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/gin-gonic/gin"
+)
+
+// BanRequest is the payload of a ban request.
+// Exactly one of Username, Email or Phone must be provided.
+type BanRequest struct {
+	// Username is the username (or compliant username) of the user whose email address and phone number are banned
+	Username string `json:"username"`
+	// Email is the email address to ban
+	Email string `json:"email"`
+	// Phone is the phone number to ban, in the E.164 format
+	Phone string `json:"phone"`
+	// Reason is the reason of the ban
+	Reason string `json:"reason" binding:"required"`
+}
+
+// AdminBans implements the admin endpoints to ban and unban the users
+type AdminBans struct {
+	bans *admin.Bans
+}
+
+// NewAdminBans returns a new AdminBans instance.
+func NewAdminBans(nsClient namespaced.Client) *AdminBans {
+	return &AdminBans{
+		bans: admin.NewBans(nsClient),
+	}
+}
+
+// PostHandler bans a user by username, email address or phone number. The response contains the active signups
+// affected by the ban. Banning a user who is already banned returns the existing ban.
+func (a *AdminBans) PostHandler(ctx *gin.Context) {
+	req := BanRequest{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error(ctx, err, "error validating ban request")
+		crterrors.AbortWithError(ctx, http.StatusBadRequest, err, "error reading request body")
+		return
+	}
+	provided := 0
+	for _, v := range []string{req.Username, req.Email, req.Phone} {
+		if v != "" {
+			provided++
+		}
+	}
+	if provided != 1 {
+		crterrors.AbortWithError(ctx, http.StatusBadRequest, errors.New("invalid ban request"), "exactly one of 'username', 'email' or 'phone' must be provided")
+		return
+	}
+	bannedBy := ctx.GetString(context.UsernameKey)
+
+	var result *admin.BanResult
+	var err error
+	switch {
+	case req.Username != "":
+		result, err = a.bans.BanUser(ctx, req.Username, req.Reason, bannedBy)
+	case req.Email != "":
+		result, err = a.bans.BanEmail(ctx, req.Email, req.Reason, bannedBy)
+	default:
+		result, err = a.bans.BanPhone(ctx, req.Phone, req.Reason, bannedBy)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, admin.ErrNotFound):
+			crterrors.AbortWithError(ctx, http.StatusNotFound, err, "")
+		case errors.Is(err, admin.ErrInvalidPhoneNumber):
+			crterrors.AbortWithError(ctx, http.StatusBadRequest, err, "")
+		default:
+			log.Error(ctx, err, "error banning user")
+			crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, "error banning user")
+		}
+		return
+	}
+	if !result.Created {
+		log.Infof(ctx, "user already banned by '%s'", result.Ban.Name)
+		ctx.JSON(http.StatusOK, result)
+		return
+	}
+	log.WithValues(map[string]interface{}{
+		"reason":           req.Reason,
+		"affected_signups": len(result.AffectedSignups),
+	}).Infof(ctx, "BannedUser '%s' created by admin '%s'", result.Ban.Name, bannedBy)
+	ctx.JSON(http.StatusCreated, result)
+}
+
+// ListHandler returns all the bans
+func (a *AdminBans) ListHandler(ctx *gin.Context) {
+	bans, err := a.bans.List(ctx)
+	if err != nil {
+		log.Error(ctx, err, "error listing BannedUser resources")
+		crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, "error listing BannedUser resources")
+		return
+	}
+	ctx.JSON(http.StatusOK, bans)
+}
+
+// DeleteHandler unbans by deleting the BannedUser with the name given in the path
+func (a *AdminBans) DeleteHandler(ctx *gin.Context) {
+	name := ctx.Param("name")
+	if err := a.bans.Unban(ctx, name); err != nil {
+		if errors.Is(err, admin.ErrBanNotFound) {
+			crterrors.AbortWithError(ctx, http.StatusNotFound, err, "")
+			return
+		}
+		log.Error(ctx, err, "error deleting BannedUser resource")
+		crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, "error deleting BannedUser resource")
+		return
+	}
+	log.Infof(ctx, "BannedUser '%s' deleted by admin '%s'", name, ctx.GetString(context.UsernameKey))
+	ctx.Status(http.StatusNoContent)
+	ctx.Writer.WriteHeaderNow()
+}
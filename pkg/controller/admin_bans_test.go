@@ -0,0 +1,183 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/admin"
+	rcontext "github.com/codeready-toolchain/registration-service/pkg/context"
+	"github.com/codeready-toolchain/registration-service/pkg/controller"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/test"
+	testutil "github.com/codeready-toolchain/registration-service/test/util"
+	"github.com/codeready-toolchain/toolchain-common/pkg/hash"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	testusersignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type TestAdminBansSuite struct {
+	test.UnitTestSuite
+}
+
+func TestRunAdminBansSuite(t *testing.T) {
+	suite.Run(t, &TestAdminBansSuite{test.UnitTestSuite{}})
+}
+
+func (s *TestAdminBansSuite) newController(objs ...client.Object) (*commontest.FakeClient, *controller.AdminBans) {
+	fakeClient := testutil.NewFakeClient(s.T(), objs...)
+	return fakeClient, controller.NewAdminBans(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+}
+
+func (s *TestAdminBansSuite) TestPostHandler() {
+	post := func(ctrl *controller.AdminBans, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/admin/banned-users", bytes.NewBufferString(body))
+		require.NoError(s.T(), err)
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = req
+		ctx.Set(rcontext.UsernameKey, "admin")
+		ctrl.PostHandler(ctx)
+		return rr
+	}
+
+	s.Run("ban by username", func() {
+		// given
+		_, ctrl := s.newController(testusersignup.NewUserSignup(
+			testusersignup.WithEncodedName("johnny"),
+			testusersignup.WithEmail("johnny@kubesaw.io"),
+			testusersignup.WithLabel(toolchainv1alpha1.UserSignupUserEmailHashLabelKey, hash.EncodeString("johnny@kubesaw.io"))))
+
+		// when
+		rr := post(ctrl, `{"username":"johnny","reason":"spam"}`)
+
+		// then
+		require.Equal(s.T(), http.StatusCreated, rr.Code)
+		result := &admin.BanResult{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), result))
+		assert.Equal(s.T(), "johnny@kubesaw.io", result.Ban.Email)
+		assert.Equal(s.T(), "admin", result.Ban.BannedBy)
+		assert.Len(s.T(), result.AffectedSignups, 1)
+
+		s.Run("already banned", func() {
+			// when
+			rr := post(ctrl, `{"email":"johnny@kubesaw.io","reason":"spam"}`)
+
+			// then
+			require.Equal(s.T(), http.StatusOK, rr.Code)
+			result := &admin.BanResult{}
+			require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), result))
+			assert.False(s.T(), result.Created)
+		})
+	})
+
+	s.Run("unknown username", func() {
+		// given
+		_, ctrl := s.newController()
+
+		// when
+		rr := post(ctrl, `{"username":"johnny","reason":"spam"}`)
+
+		// then
+		test.AssertError(s.T(), rr, http.StatusNotFound, "UserSignup not found", "")
+	})
+
+	s.Run("invalid phone number", func() {
+		// given
+		_, ctrl := s.newController()
+
+		// when
+		rr := post(ctrl, `{"phone":"12345","reason":"fraud"}`)
+
+		// then
+		assert.Equal(s.T(), http.StatusBadRequest, rr.Code)
+	})
+
+	s.Run("invalid requests", func() {
+		for name, body := range map[string]string{
+			"nothing to ban":      `{"reason":"spam"}`,
+			"too many to ban":     `{"email":"johnny@kubesaw.io","phone":"+442071838750","reason":"spam"}`,
+			"username with email": `{"username":"johnny","email":"johnny@kubesaw.io","reason":"spam"}`,
+		} {
+			s.Run(name, func() {
+				// given
+				_, ctrl := s.newController()
+
+				// when
+				rr := post(ctrl, body)
+
+				// then
+				test.AssertError(s.T(), rr, http.StatusBadRequest, "invalid ban request", "exactly one of 'username', 'email' or 'phone' must be provided")
+			})
+		}
+
+		s.Run("missing reason", func() {
+			// given
+			_, ctrl := s.newController()
+
+			// when
+			rr := post(ctrl, `{"email":"johnny@kubesaw.io"}`)
+
+			// then
+			assert.Equal(s.T(), http.StatusBadRequest, rr.Code)
+		})
+	})
+}
+
+func (s *TestAdminBansSuite) TestListAndDeleteHandlers() {
+	// given
+	_, ctrl := s.newController(
+		&toolchainv1alpha1.BannedUser{
+			ObjectMeta: metav1.ObjectMeta{Name: "banneduser-1", Namespace: commontest.HostOperatorNs},
+			Spec:       toolchainv1alpha1.BannedUserSpec{Email: "johnny@kubesaw.io", Reason: "spam"},
+		},
+	)
+	call := func(method, name string, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, "/api/v1/admin/banned-users/"+name, nil)
+		require.NoError(s.T(), err)
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = req
+		ctx.Params = gin.Params{{Key: "name", Value: name}}
+		handler(ctx)
+		return rr
+	}
+
+	s.Run("list", func() {
+		// when
+		rr := call(http.MethodGet, "", ctrl.ListHandler)
+
+		// then
+		require.Equal(s.T(), http.StatusOK, rr.Code)
+		bans := []admin.Ban{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), &bans))
+		require.Len(s.T(), bans, 1)
+		assert.Equal(s.T(), "spam", bans[0].Reason)
+	})
+
+	s.Run("delete", func() {
+		// when
+		rr := call(http.MethodDelete, "banneduser-1", ctrl.DeleteHandler)
+
+		// then
+		assert.Equal(s.T(), http.StatusNoContent, rr.Code)
+
+		s.Run("not found", func() {
+			// when
+			rr := call(http.MethodDelete, "banneduser-1", ctrl.DeleteHandler)
+
+			// then
+			assert.Equal(s.T(), http.StatusNotFound, rr.Code)
+		})
+	})
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/admin"
+	"github.com/codeready-toolchain/registration-service/pkg/context"
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/gin-gonic/gin"
+)
+
+// StateKey is the query key for specifying the state of the UserSignups to list
+const StateKey = "state"
+
+// AdminSignupActionRequest is the payload of an action of an administrator on a UserSignup
+type AdminSignupActionRequest struct {
+	// Reason is the reason of the action, recorded on the UserSignup
+	Reason string `json:"reason" binding:"required"`
+}
+
+// AdminSignups implements the admin endpoints to review the UserSignups and approve, reject or deactivate them
+type AdminSignups struct {
+	signups *admin.Signups
+}
+
+// NewAdminSignups returns a new AdminSignups instance.
+func NewAdminSignups(nsClient namespaced.Client) *AdminSignups {
+	return &AdminSignups{
+		signups: admin.NewSignups(nsClient),
+	}
+}
+
+// ListHandler returns the UserSignups with the state given in the `state` query parameter (`pending` by default)
+func (a *AdminSignups) ListHandler(ctx *gin.Context) {
+	signups, err := a.signups.List(ctx, ctx.DefaultQuery(StateKey, toolchainv1alpha1.UserSignupStateLabelValuePending))
+	if err != nil {
+		if errors.Is(err, admin.ErrInvalidState) {
+			crterrors.AbortWithError(ctx, http.StatusBadRequest, err, "invalid '"+StateKey+"' query parameter")
+			return
+		}
+		log.Error(ctx, err, "error listing UserSignup resources")
+		crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, "error listing UserSignup resources")
+		return
+	}
+	ctx.JSON(http.StatusOK, signups)
+}
+
+// ActionHandler returns the handler applying the given action on the UserSignup with the name given in the path
+func (a *AdminSignups) ActionHandler(action admin.Action) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := AdminSignupActionRequest{}
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			log.Error(ctx, err, "error validating admin action request")
+			crterrors.AbortWithError(ctx, http.StatusBadRequest, err, "error reading request body")
+			return
+		}
+		name := ctx.Param("name")
+		by := ctx.GetString(context.UsernameKey)
+
+		signup, err := a.signups.Apply(ctx, name, action, req.Reason, by)
+		if err != nil {
+			if errors.Is(err, admin.ErrNotFound) {
+				crterrors.AbortWithError(ctx, http.StatusNotFound, err, "")
+				return
+			}
+			log.Errorf(ctx, err, "error applying action '%s' on UserSignup '%s'", string(action), name)
+			crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, "error updating UserSignup resource")
+			return
+		}
+		log.WithValues(map[string]interface{}{
+			"action": string(action),
+			"reason": req.Reason,
+		}).Infof(ctx, "UserSignup '%s' updated by admin '%s'", name, by)
+		ctx.JSON(http.StatusOK, signup)
+	}
+}
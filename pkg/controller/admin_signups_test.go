@@ -0,0 +1,138 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/admin"
+	rcontext "github.com/codeready-toolchain/registration-service/pkg/context"
+	"github.com/codeready-toolchain/registration-service/pkg/controller"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/states"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	testusersignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type TestAdminSignupsSuite struct {
+	test.UnitTestSuite
+}
+
+func TestRunAdminSignupsSuite(t *testing.T) {
+	suite.Run(t, &TestAdminSignupsSuite{test.UnitTestSuite{}})
+}
+
+func (s *TestAdminSignupsSuite) TestListHandler() {
+	fakeClient := commontest.NewFakeClient(s.T(),
+		testusersignup.NewUserSignup(testusersignup.WithName("johnny"), testusersignup.WithStateLabel(toolchainv1alpha1.UserSignupStateLabelValuePending)),
+		testusersignup.NewUserSignup(testusersignup.WithName("jane"), testusersignup.WithStateLabel(toolchainv1alpha1.UserSignupStateLabelValueRejected)),
+	)
+	ctrl := controller.NewAdminSignups(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+	list := func(query string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/admin/signups"+query, nil)
+		require.NoError(s.T(), err)
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = req
+		ctrl.ListHandler(ctx)
+		return rr
+	}
+
+	for name, tc := range map[string]struct {
+		query    string
+		expected string
+	}{
+		"pending by default": {query: "", expected: "johnny"},
+		"rejected":           {query: "?state=rejected", expected: "jane"},
+	} {
+		s.Run(name, func() {
+			// when
+			rr := list(tc.query)
+
+			// then
+			require.Equal(s.T(), http.StatusOK, rr.Code)
+			signups := []admin.Signup{}
+			require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), &signups))
+			require.Len(s.T(), signups, 1)
+			assert.Equal(s.T(), tc.expected, signups[0].Name)
+		})
+	}
+
+	s.Run("invalid state", func() {
+		// when
+		rr := list("?state=unknown")
+
+		// then
+		test.AssertError(s.T(), rr, http.StatusBadRequest, "invalid state 'unknown'", "invalid 'state' query parameter")
+	})
+}
+
+func (s *TestAdminSignupsSuite) TestActionHandler() {
+	act := func(ctrl *controller.AdminSignups, action admin.Action, name, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/admin/signups/"+name+"/"+string(action), bytes.NewBufferString(body))
+		require.NoError(s.T(), err)
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = req
+		ctx.Params = gin.Params{{Key: "name", Value: name}}
+		ctx.Set(rcontext.UsernameKey, "admin")
+		ctrl.ActionHandler(action)(ctx)
+		return rr
+	}
+
+	s.Run("approve", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(s.T(), testusersignup.NewUserSignup(testusersignup.WithName("johnny")))
+		ctrl := controller.NewAdminSignups(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		rr := act(ctrl, admin.ActionApprove, "johnny", `{"reason":"looks legit"}`)
+
+		// then
+		require.Equal(s.T(), http.StatusOK, rr.Code)
+		signup := &admin.Signup{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), signup))
+		require.NotNil(s.T(), signup.LastAction)
+		assert.Equal(s.T(), "admin", signup.LastAction.By)
+		userSignup := &toolchainv1alpha1.UserSignup{}
+		require.NoError(s.T(), fakeClient.Get(s.T().Context(), client.ObjectKey{Namespace: commontest.HostOperatorNs, Name: "johnny"}, userSignup))
+		assert.True(s.T(), states.ApprovedManually(userSignup))
+	})
+
+	s.Run("missing reason", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(s.T(), testusersignup.NewUserSignup(testusersignup.WithName("johnny")))
+		ctrl := controller.NewAdminSignups(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		rr := act(ctrl, admin.ActionReject, "johnny", `{}`)
+
+		// then
+		assert.Equal(s.T(), http.StatusBadRequest, rr.Code)
+		userSignup := &toolchainv1alpha1.UserSignup{}
+		require.NoError(s.T(), fakeClient.Get(s.T().Context(), client.ObjectKey{Namespace: commontest.HostOperatorNs, Name: "johnny"}, userSignup))
+		assert.False(s.T(), states.Rejected(userSignup))
+	})
+
+	s.Run("not found", func() {
+		// given
+		ctrl := controller.NewAdminSignups(namespaced.NewClient(commontest.NewFakeClient(s.T()), commontest.HostOperatorNs))
+
+		// when
+		rr := act(ctrl, admin.ActionDeactivate, "johnny", `{"reason":"abuse"}`)
+
+		// then
+		test.AssertError(s.T(), rr, http.StatusNotFound, "UserSignup not found", "")
+	})
+}
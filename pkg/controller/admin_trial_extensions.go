@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/codeready-toolchain/registration-service/pkg/admin"
+	"github.com/codeready-toolchain/registration-service/pkg/context"
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminTrialExtensions implements the admin endpoints to review the pending trial extension requests and approve or reject them
+type AdminTrialExtensions struct {
+	extensions *admin.TrialExtensions
+}
+
+// NewAdminTrialExtensions returns a new AdminTrialExtensions instance.
+func NewAdminTrialExtensions(nsClient namespaced.Client) *AdminTrialExtensions {
+	return &AdminTrialExtensions{
+		extensions: admin.NewTrialExtensions(nsClient),
+	}
+}
+
+// ListHandler returns the pending trial extension requests
+func (a *AdminTrialExtensions) ListHandler(ctx *gin.Context) {
+	extensions, err := a.extensions.ListPending(ctx)
+	if err != nil {
+		log.Error(ctx, err, "error listing trial extension requests")
+		crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, "error listing trial extension requests")
+		return
+	}
+	ctx.JSON(http.StatusOK, extensions)
+}
+
+// DecisionHandler returns the handler approving or rejecting the pending trial extension request of the UserSignup
+// with the name given in the path
+func (a *AdminTrialExtensions) DecisionHandler(action admin.Action) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		name := ctx.Param("name")
+		by := ctx.GetString(context.UsernameKey)
+
+		extension, err := a.extensions.Decide(ctx, name, action, by)
+		if err != nil {
+			switch {
+			case errors.Is(err, admin.ErrNotFound):
+				crterrors.AbortWithError(ctx, http.StatusNotFound, err, "")
+			case errors.Is(err, admin.ErrNoPendingTrialExtension):
+				crterrors.AbortWithError(ctx, http.StatusConflict, err, "")
+			default:
+				log.Errorf(ctx, err, "error applying action '%s' on the trial extension request of UserSignup '%s'", string(action), name)
+				crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, "error updating UserSignup resource")
+			}
+			return
+		}
+		log.WithValues(map[string]interface{}{
+			"action": string(action),
+		}).Infof(ctx, "trial extension request of UserSignup '%s' decided by admin '%s'", name, by)
+		ctx.JSON(http.StatusOK, extension)
+	}
+}
@@ -0,0 +1,120 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/admin"
+	rcontext "github.com/codeready-toolchain/registration-service/pkg/context"
+	"github.com/codeready-toolchain/registration-service/pkg/controller"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/signup"
+	"github.com/codeready-toolchain/registration-service/test"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test/masteruserrecord"
+	testusersignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func (s *TestAdminSignupsSuite) TestTrialExtensionsHandlers() {
+	newController := func() (*commontest.FakeClient, *controller.AdminTrialExtensions) {
+		pending := testusersignup.NewUserSignup(testusersignup.WithName("johnny"), testusersignup.WithCompliantUsername("johnny"))
+		signup.RequestTrialExtension(pending, "finishing my project", time.Now())
+		userTier := masteruserrecord.DefaultUserTier()
+		fakeClient := commontest.NewFakeClient(s.T(), pending, testusersignup.NewUserSignup(testusersignup.WithName("jane")),
+			masteruserrecord.NewMasterUserRecord(s.T(), "johnny", masteruserrecord.ProvisionedMur(&metav1.Time{Time: time.Now()})), &userTier)
+		return fakeClient, controller.NewAdminTrialExtensions(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+	}
+	decide := func(ctrl *controller.AdminTrialExtensions, action admin.Action, name string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/admin/trial-extensions/"+name+"/"+string(action), nil)
+		require.NoError(s.T(), err)
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = req
+		ctx.Params = gin.Params{{Key: "name", Value: name}}
+		ctx.Set(rcontext.UsernameKey, "admin")
+		ctrl.DecisionHandler(action)(ctx)
+		return rr
+	}
+
+	s.Run("list", func() {
+		// given
+		_, ctrl := newController()
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/admin/trial-extensions", nil)
+		require.NoError(s.T(), err)
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = req
+
+		// when
+		ctrl.ListHandler(ctx)
+
+		// then
+		require.Equal(s.T(), http.StatusOK, rr.Code)
+		extensions := []admin.TrialExtension{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), &extensions))
+		require.Len(s.T(), extensions, 1)
+		assert.Equal(s.T(), "johnny", extensions[0].Name)
+		assert.Equal(s.T(), "finishing my project", extensions[0].Justification)
+	})
+
+	s.Run("approve", func() {
+		// given
+		fakeClient, ctrl := newController()
+
+		// when
+		rr := decide(ctrl, admin.ActionApprove, "johnny")
+
+		// then
+		require.Equal(s.T(), http.StatusOK, rr.Code)
+		extension := &admin.TrialExtension{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), extension))
+		assert.Equal(s.T(), signup.TrialExtensionApproved, extension.State)
+		userSignup := &toolchainv1alpha1.UserSignup{}
+		require.NoError(s.T(), fakeClient.Get(s.T().Context(), client.ObjectKey{Namespace: commontest.HostOperatorNs, Name: "johnny"}, userSignup))
+		assert.Equal(s.T(), "admin", userSignup.Annotations[signup.TrialExtensionDecidedByAnnotationKey])
+	})
+
+	s.Run("reject", func() {
+		// given
+		_, ctrl := newController()
+
+		// when
+		rr := decide(ctrl, admin.ActionReject, "johnny")
+
+		// then
+		require.Equal(s.T(), http.StatusOK, rr.Code)
+		extension := &admin.TrialExtension{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), extension))
+		assert.Equal(s.T(), signup.TrialExtensionRejected, extension.State)
+	})
+
+	s.Run("no pending request", func() {
+		// given
+		_, ctrl := newController()
+
+		// when
+		rr := decide(ctrl, admin.ActionApprove, "jane")
+
+		// then
+		test.AssertError(s.T(), rr, http.StatusConflict, "no pending trial extension request", "")
+	})
+
+	s.Run("unknown signup", func() {
+		// given
+		_, ctrl := newController()
+
+		// when
+		rr := decide(ctrl, admin.ActionApprove, "unknown")
+
+		// then
+		test.AssertError(s.T(), rr, http.StatusNotFound, "UserSignup not found", "")
+	})
+}
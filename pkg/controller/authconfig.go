@@ -7,7 +7,8 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-type configResponse struct {
+// AuthConfigResponse is the configuration of the authentication client of the UI
+type AuthConfigResponse struct {
 	AuthClientLibraryURL string `json:"auth-client-library-url"`
 	// this holds the raw config. Note: this is intentionally a string
 	// not json as this field may also hold non-json configs!
@@ -30,7 +31,7 @@ func NewAuthConfig() *AuthConfig {
 // GetHandler returns raw auth config content for UI.
 func (ac *AuthConfig) GetHandler(ctx *gin.Context) {
 	cfg := configuration.GetRegistrationServiceConfig()
-	configRespData := configResponse{
+	configRespData := AuthConfigResponse{
 		AuthClientLibraryURL: cfg.Auth().AuthClientLibraryURL(),
 		AuthClientConfigRaw:  cfg.Auth().AuthClientConfigRaw(),
 		SignupURL:            cfg.RegistrationServiceURL(),
@@ -59,7 +59,7 @@ func (s *TestAuthConfigSuite) TestAuthClientConfigHandler() {
 
 		// Check the response body is what we expect.
 		// get config values from endpoint response
-		var dataEnvelope *configResponse
+		var dataEnvelope *AuthConfigResponse
 		err = json.Unmarshal(rr.Body.Bytes(), &dataEnvelope)
 		require.NoError(s.T(), err)
 
@@ -2,7 +2,9 @@ package controller
 
 import (
 	"errors"
+	"io"
 	"net/http"
+	"path"
 
 	customCtx "github.com/codeready-toolchain/registration-service/pkg/context"
 	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
@@ -16,10 +18,18 @@ import (
 // the user in case an error occurs.
 var ErrNamespaceReset = errors.New("namespace reset error")
 
+// ErrNamespaceUsage is the static error message returned to the user when the
+// usage of their namespaces cannot be retrieved.
+var ErrNamespaceUsage = errors.New("namespace usage error")
+
 // NamespacesController holds the required controllers to be able to manage user namespaces.
 type NamespacesController interface {
 	// ResetNamespaces deletes the user's namespaces so that the appropriate controllers can recreate them.
 	ResetNamespaces(*gin.Context)
+	// ResetNamespacesStatus returns the progress of a namespaces reset job.
+	ResetNamespacesStatus(*gin.Context)
+	// Usage returns the consumption of the resources of the user's namespaces.
+	Usage(*gin.Context)
 }
 
 type namespacesCtrl struct {
@@ -33,8 +43,39 @@ func NewNamespacesController(namespacesManager namespaces.Manager) NamespacesCon
 	}
 }
 
+// ResetNamespacesRequest is the optional payload of a request to reset the user's namespaces
+type ResetNamespacesRequest struct {
+	// Namespaces are the names of the namespaces to reset. All the namespaces of the user are reset if empty.
+	Namespaces []string `json:"namespaces"`
+	// DryRun only reports what would be deleted, without deleting anything
+	DryRun bool `json:"dryRun"`
+}
+
+// ResetNamespacesResponse is the response to a request to reset the user's namespaces
+type ResetNamespacesResponse struct {
+	// ID identifies the job which tracks the recreation of the namespaces, empty if there is nothing to track
+	ID string `json:"id,omitempty"`
+	// DryRun is true if nothing was deleted
+	DryRun bool `json:"dryRun"`
+	// Namespaces are the results of the reset of each namespace
+	Namespaces namespaces.NamespaceResults `json:"namespaces"`
+}
+
 func (ctrl *namespacesCtrl) ResetNamespaces(ctx *gin.Context) {
-	err := ctrl.namespacesManager.ResetNamespaces(ctx)
+	req := ResetNamespacesRequest{}
+	// the payload is optional: all the namespaces are reset without one
+	if ctx.Request.Body != nil && ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			log.Error(ctx, err, "error validating namespaces reset request")
+			crterrors.AbortWithError(ctx, http.StatusBadRequest, err, "error reading request body")
+			return
+		}
+	}
+
+	reset, err := ctrl.namespacesManager.ResetNamespaces(ctx, namespaces.ResetOptions{
+		Namespaces: req.Namespaces,
+		DryRun:     req.DryRun,
+	})
 	if err != nil {
 		log.Errorf(ctx, err, `unable to reset the namespaces for user "%s"`, ctx.GetString(customCtx.UsernameKey))
 
@@ -42,14 +83,67 @@ func (ctrl *namespacesCtrl) ResetNamespaces(ctx *gin.Context) {
 			crterrors.AbortWithError(ctx, http.StatusNotFound, ErrNamespaceReset, "The user is either not found or deactivated. Please contact the Developer Sandbox team at devsandbox@redhat.com for assistance")
 		} else if errors.As(err, &namespaces.ErrUserHasNoProvisionedNamespaces{}) {
 			crterrors.AbortWithError(ctx, http.StatusBadRequest, ErrNamespaceReset, "No namespaces provisioned, unable to perform reset. Please try again in a while and if the issue persists, please contact the Developer Sandbox team at devsandbox@redhat.com for assistance")
+		} else if errors.Is(err, namespaces.ErrNamespaceNotProvisioned) {
+			crterrors.AbortWithError(ctx, http.StatusBadRequest, ErrNamespaceReset, err.Error())
 		} else {
 			crterrors.AbortWithError(ctx, http.StatusInternalServerError, ErrNamespaceReset, "Unable to reset your namespaces. Please try again in a while and if the issue persists, please contact the Developer Sandbox team at devsandbox@redhat.com for assistance")
 		}
 		return
 	}
 
-	log.Infof(ctx, `namespaces reset initiated for user "%s"`, ctx.GetString(customCtx.UsernameKey))
+	response := ResetNamespacesResponse{ID: reset.JobID, DryRun: req.DryRun, Namespaces: reset.Namespaces}
+	if reset.JobID != "" {
+		ctx.Header("Location", path.Join(ctx.Request.URL.Path, reset.JobID)) // in the same version of the API as the request
+	}
+	switch {
+	case req.DryRun:
+		ctx.JSON(http.StatusOK, response)
+	case reset.Namespaces.Failed():
+		// the namespaces which could be deleted are recreated anyway, the response tells which ones failed:
+		// server errors are kept for the error envelope, so that clients can tell both apart
+		log.Infof(ctx, `namespaces reset partially failed for user "%s"`, ctx.GetString(customCtx.UsernameKey))
+		ctx.JSON(http.StatusMultiStatus, response)
+	default:
+		log.Infof(ctx, `namespaces reset initiated for user "%s"`, ctx.GetString(customCtx.UsernameKey))
+		ctx.JSON(http.StatusAccepted, response)
+	}
+}
 
-	ctx.Status(http.StatusAccepted)
-	ctx.Writer.WriteHeaderNow()
+// ResetNamespacesStatus returns the progress of the namespaces reset job whose ID is given in the path,
+// so that the user knows when the namespaces can be used again.
+func (ctrl *namespacesCtrl) ResetNamespacesStatus(ctx *gin.Context) {
+	job, err := ctrl.namespacesManager.ResetJob(ctx, ctx.Param("id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, namespaces.ErrResetJobNotFound):
+			crterrors.AbortWithError(ctx, http.StatusNotFound, err, "")
+		case errors.Is(err, namespaces.ErrUserSignUpNotFoundOrDeactivated):
+			crterrors.AbortWithError(ctx, http.StatusNotFound, ErrNamespaceReset, "The user is either not found or deactivated. Please contact the Developer Sandbox team at devsandbox@redhat.com for assistance")
+		default:
+			log.Errorf(ctx, err, `unable to get the namespaces reset job for user "%s"`, ctx.GetString(customCtx.UsernameKey))
+			crterrors.AbortWithError(ctx, http.StatusInternalServerError, ErrNamespaceReset, "Unable to get the status of the reset of your namespaces. Please try again in a while")
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, job)
+}
+
+// Usage returns the used and hard values of the resource quotas of the user's namespaces, along with their pods and
+// persistent volume claims, so that the user knows how close they are to their limits.
+func (ctrl *namespacesCtrl) Usage(ctx *gin.Context) {
+	usage, err := ctrl.namespacesManager.Usage(ctx)
+	if err != nil {
+		log.Errorf(ctx, err, `unable to get the usage of the namespaces for user "%s"`, ctx.GetString(customCtx.UsernameKey))
+
+		switch {
+		case errors.Is(err, namespaces.ErrUserSignUpNotFoundOrDeactivated):
+			crterrors.AbortWithError(ctx, http.StatusNotFound, ErrNamespaceUsage, "The user is either not found or deactivated. Please contact the Developer Sandbox team at devsandbox@redhat.com for assistance")
+		case errors.As(err, &namespaces.ErrUserHasNoProvisionedNamespaces{}):
+			crterrors.AbortWithError(ctx, http.StatusNotFound, ErrNamespaceUsage, "No namespaces provisioned. Please try again in a while and if the issue persists, please contact the Developer Sandbox team at devsandbox@redhat.com for assistance")
+		default:
+			crterrors.AbortWithError(ctx, http.StatusInternalServerError, ErrNamespaceUsage, "Unable to get the usage of your namespaces. Please try again in a while")
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, usage)
 }
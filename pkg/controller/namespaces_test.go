@@ -1,14 +1,18 @@
 package controller
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/codeready-toolchain/registration-service/pkg/namespaces"
 	"github.com/codeready-toolchain/registration-service/test"
 	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
@@ -25,11 +29,30 @@ func TestRunNamespacesSuite(t *testing.T) {
 // us to return custom return values from it, to effectively unit test the
 // handler in isolation.
 type mockNamespacesManager struct {
-	ResetNamespacesReturnValue error
+	ResetNamespacesReturnValue   error
+	ResetNamespacesReturnResults namespaces.NamespaceResults
+	ResetNamespacesReturnJobID   string
+	ResetNamespacesOptions       namespaces.ResetOptions
+	ResetJobReturnValue          *namespaces.ResetJob
+	ResetJobReturnError          error
+	UsageReturnValue             []namespaces.NamespaceUsage
+	UsageReturnError             error
 }
 
-func (mnm *mockNamespacesManager) ResetNamespaces(_ *gin.Context) error {
-	return mnm.ResetNamespacesReturnValue
+func (mnm *mockNamespacesManager) ResetNamespaces(_ *gin.Context, opts namespaces.ResetOptions) (*namespaces.Reset, error) {
+	mnm.ResetNamespacesOptions = opts
+	if mnm.ResetNamespacesReturnValue != nil {
+		return nil, mnm.ResetNamespacesReturnValue
+	}
+	return &namespaces.Reset{JobID: mnm.ResetNamespacesReturnJobID, Namespaces: mnm.ResetNamespacesReturnResults}, nil
+}
+
+func (mnm *mockNamespacesManager) ResetJob(_ *gin.Context, _ string) (*namespaces.ResetJob, error) {
+	return mnm.ResetJobReturnValue, mnm.ResetJobReturnError
+}
+
+func (mnm *mockNamespacesManager) Usage(_ *gin.Context) ([]namespaces.NamespaceUsage, error) {
+	return mnm.UsageReturnValue, mnm.UsageReturnError
 }
 
 // TestResetNamespacesHandler tests that the handler returns a proper
@@ -159,4 +182,214 @@ func (ns *TestNamespacesSuite) TestResetNamespacesHandler() {
 		// returned.
 		test.AssertError(ns.T(), rr, http.StatusInternalServerError, ErrNamespaceReset.Error(), "Unable to reset your namespaces. Please try again in a while and if the issue persists, please contact the Developer Sandbox team at devsandbox@redhat.com for assistance")
 	})
+
+	ns.Run(`handler resets the selected namespaces`, func() {
+		// given
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/reset-namespaces", bytes.NewBufferString(`{"namespaces":["ted-dev"]}`))
+		require.NoError(ns.T(), err)
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = req
+
+		mnm := mockNamespacesManager{
+			ResetNamespacesReturnResults: namespaces.NamespaceResults{{Name: "ted-dev", Status: namespaces.NamespaceDeleted}},
+			ResetNamespacesReturnJobID:   "0123456789abcdef",
+		}
+		ctrl := NewNamespacesController(&mnm)
+
+		// when
+		ctrl.ResetNamespaces(ctx)
+
+		// then
+		require.Equal(ns.T(), http.StatusAccepted, rr.Code)
+		assert.Equal(ns.T(), namespaces.ResetOptions{Namespaces: []string{"ted-dev"}}, mnm.ResetNamespacesOptions)
+		assert.Equal(ns.T(), "/api/v1/reset-namespaces/0123456789abcdef", rr.Header().Get("Location"))
+		assert.JSONEq(ns.T(), `{"id":"0123456789abcdef","dryRun":false,"namespaces":[{"name":"ted-dev","status":"deleted"}]}`, rr.Body.String())
+	})
+
+	ns.Run(`handler returns the resources of the namespaces in a dry run`, func() {
+		// given
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/reset-namespaces", bytes.NewBufferString(`{"dryRun":true}`))
+		require.NoError(ns.T(), err)
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = req
+
+		mnm := mockNamespacesManager{
+			ResetNamespacesReturnResults: namespaces.NamespaceResults{{Name: "ted-dev", Status: namespaces.NamespaceToBeDeleted, Resources: map[string]int{"pods": 2}}},
+		}
+		ctrl := NewNamespacesController(&mnm)
+
+		// when
+		ctrl.ResetNamespaces(ctx)
+
+		// then
+		require.Equal(ns.T(), http.StatusOK, rr.Code)
+		assert.True(ns.T(), mnm.ResetNamespacesOptions.DryRun)
+		assert.JSONEq(ns.T(), `{"dryRun":true,"namespaces":[{"name":"ted-dev","status":"toBeDeleted","resources":{"pods":2}}]}`, rr.Body.String())
+	})
+
+	ns.Run(`handler returns the results when some namespaces could not be deleted`, func() {
+		// given
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/reset-namespaces", nil)
+		require.NoError(ns.T(), err)
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = req
+
+		mnm := mockNamespacesManager{
+			ResetNamespacesReturnResults: namespaces.NamespaceResults{
+				{Name: "ted-dev", Status: namespaces.NamespaceDeleted},
+				{Name: "ted-stage", Status: namespaces.NamespaceFailed, Error: "unable to delete the namespace"},
+			},
+		}
+		ctrl := NewNamespacesController(&mnm)
+
+		// when
+		ctrl.ResetNamespaces(ctx)
+
+		// then
+		require.Equal(ns.T(), http.StatusMultiStatus, rr.Code)
+		assert.JSONEq(ns.T(), `{"dryRun":false,"namespaces":[{"name":"ted-dev","status":"deleted"},{"name":"ted-stage","status":"failed","error":"unable to delete the namespace"}]}`, rr.Body.String())
+	})
+
+	ns.Run(`handler returns a "Bad Request" error when a namespace is not provisioned for the user`, func() {
+		// given
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/reset-namespaces", bytes.NewBufferString(`{"namespaces":["other-dev"]}`))
+		require.NoError(ns.T(), err)
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = req
+
+		mnm := mockNamespacesManager{
+			ResetNamespacesReturnValue: fmt.Errorf(`%w: "other-dev"`, namespaces.ErrNamespaceNotProvisioned),
+		}
+		ctrl := NewNamespacesController(&mnm)
+
+		// when
+		ctrl.ResetNamespaces(ctx)
+
+		// then
+		test.AssertError(ns.T(), rr, http.StatusBadRequest, ErrNamespaceReset.Error(), `the namespace is not provisioned for the user: "other-dev"`)
+	})
+
+	ns.Run(`handler returns a "Bad Request" error when the request is invalid`, func() {
+		// given
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/reset-namespaces", bytes.NewBufferString(`{"namespaces":"ted-dev"}`))
+		require.NoError(ns.T(), err)
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = req
+		ctrl := NewNamespacesController(&mockNamespacesManager{})
+
+		// when
+		ctrl.ResetNamespaces(ctx)
+
+		// then
+		require.Equal(ns.T(), http.StatusBadRequest, rr.Code)
+	})
+}
+
+// TestResetNamespacesStatusHandler tests that the handler returns the
+// progress of the reset job, or a structured error if it cannot be found.
+func (ns *TestNamespacesSuite) TestResetNamespacesStatusHandler() {
+	call := func(mnm *mockNamespacesManager) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/reset-namespaces/0123456789abcdef", nil)
+		require.NoError(ns.T(), err)
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = req
+		ctx.Params = gin.Params{{Key: "id", Value: "0123456789abcdef"}}
+		NewNamespacesController(mnm).ResetNamespacesStatus(ctx)
+		return rr
+	}
+
+	ns.Run("handler returns the progress of the job", func() {
+		// given
+		started := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+		mnm := &mockNamespacesManager{
+			ResetJobReturnValue: &namespaces.ResetJob{
+				ID:      "0123456789abcdef",
+				Phase:   namespaces.ResetPhaseTerminating,
+				Started: started,
+				Namespaces: []namespaces.NamespacePhase{
+					{Name: "ted-dev", Phase: namespaces.ResetPhaseTerminating},
+					{Name: "ted-stage", Phase: namespaces.ResetPhaseReady},
+				},
+			},
+		}
+
+		// when
+		rr := call(mnm)
+
+		// then
+		require.Equal(ns.T(), http.StatusOK, rr.Code)
+		assert.JSONEq(ns.T(), `{"id":"0123456789abcdef","phase":"terminating","started":"2024-05-01T10:00:00Z","namespaces":[{"name":"ted-dev","phase":"terminating"},{"name":"ted-stage","phase":"ready"}]}`, rr.Body.String())
+	})
+
+	ns.Run(`handler returns a "Not Found" error when the job does not exist`, func() {
+		// when
+		rr := call(&mockNamespacesManager{ResetJobReturnError: namespaces.ErrResetJobNotFound})
+
+		// then
+		test.AssertError(ns.T(), rr, http.StatusNotFound, namespaces.ErrResetJobNotFound.Error(), "")
+	})
+
+	ns.Run(`handler returns an internal server error when a generic error occurs`, func() {
+		// when
+		rr := call(&mockNamespacesManager{ResetJobReturnError: errors.New("test error")})
+
+		// then
+		test.AssertError(ns.T(), rr, http.StatusInternalServerError, ErrNamespaceReset.Error(), "Unable to get the status of the reset of your namespaces. Please try again in a while")
+	})
+}
+
+// TestUsageHandler tests that the handler returns the usage of the user's
+// namespaces, or a structured error when it cannot be retrieved.
+func (ns *TestNamespacesSuite) TestUsageHandler() {
+	call := func(mnm *mockNamespacesManager) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/namespaces/usage", nil)
+		require.NoError(ns.T(), err)
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = req
+		NewNamespacesController(mnm).Usage(ctx)
+		return rr
+	}
+
+	ns.Run("handler returns the usage of the namespaces", func() {
+		// given
+		mnm := &mockNamespacesManager{
+			UsageReturnValue: []namespaces.NamespaceUsage{{
+				Name:                   "ted-dev",
+				Quota:                  map[string]namespaces.ResourceUsage{"limits.memory": {Used: "1Gi", Hard: "7Gi"}},
+				Pods:                   2,
+				PersistentVolumeClaims: 1,
+				Storage:                "5Gi",
+			}},
+		}
+
+		// when
+		rr := call(mnm)
+
+		// then
+		require.Equal(ns.T(), http.StatusOK, rr.Code)
+		assert.JSONEq(ns.T(), `[{"name":"ted-dev","quota":{"limits.memory":{"used":"1Gi","hard":"7Gi"}},"pods":2,"persistentVolumeClaims":1,"storage":"5Gi"}]`, rr.Body.String())
+	})
+
+	ns.Run(`handler returns a "Not Found" error when the user signup cannot be found or is deactivated`, func() {
+		// when
+		rr := call(&mockNamespacesManager{UsageReturnError: namespaces.ErrUserSignUpNotFoundOrDeactivated})
+
+		// then
+		test.AssertError(ns.T(), rr, http.StatusNotFound, ErrNamespaceUsage.Error(), "The user is either not found or deactivated. Please contact the Developer Sandbox team at devsandbox@redhat.com for assistance")
+	})
+
+	ns.Run(`handler returns an internal server error when a generic error occurs`, func() {
+		// when
+		rr := call(&mockNamespacesManager{UsageReturnError: errors.New("test error")})
+
+		// then
+		test.AssertError(ns.T(), rr, http.StatusInternalServerError, ErrNamespaceUsage.Error(), "Unable to get the usage of your namespaces. Please try again in a while")
+	})
 }
@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/codeready-toolchain/registration-service/pkg/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPI implements the endpoint which serves the OpenAPI document of the REST API
+type OpenAPI struct {
+	document *openapi.Document
+}
+
+// NewOpenAPI returns a new OpenAPI instance.
+func NewOpenAPI(document *openapi.Document) *OpenAPI {
+	return &OpenAPI{
+		document: document,
+	}
+}
+
+// GetHandler returns the OpenAPI document as JSON
+func (o *OpenAPI) GetHandler(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, o.document.OpenAPI())
+}
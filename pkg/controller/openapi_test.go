@@ -0,0 +1,60 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codeready-toolchain/registration-service/pkg/controller"
+	"github.com/codeready-toolchain/registration-service/pkg/openapi"
+	"github.com/codeready-toolchain/registration-service/test"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type TestOpenAPISuite struct {
+	test.UnitTestSuite
+}
+
+func TestRunOpenAPISuite(t *testing.T) {
+	suite.Run(t, &TestOpenAPISuite{test.UnitTestSuite{}})
+}
+
+func (s *TestOpenAPISuite) TestGetHandler() {
+	// given
+	document := openapi.NewDocument("Registration Service", "v1", openapi.Operation{
+		Method:    http.MethodPut,
+		Path:      "/api/v1/signup/verification",
+		Secured:   true,
+		Request:   controller.Phone{},
+		Responses: map[int]interface{}{http.StatusNoContent: nil},
+	})
+	req, err := http.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil)
+	require.NoError(s.T(), err)
+	rr := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rr)
+	ctx.Request = req
+
+	// when
+	controller.NewOpenAPI(document).GetHandler(ctx)
+
+	// then
+	require.Equal(s.T(), http.StatusOK, rr.Code)
+	data := struct {
+		OpenAPI    string                                `json:"openapi"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Required []string `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}{}
+	require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), &data))
+	assert.Equal(s.T(), "3.0.3", data.OpenAPI)
+	assert.Contains(s.T(), data.Paths["/api/v1/signup/verification"], "put")
+	assert.Equal(s.T(), []string{"country_code", "phone_number"}, data.Components.Schemas["controller.Phone"].Required)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/codeready-toolchain/registration-service/pkg/context"
 	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
 	"github.com/codeready-toolchain/registration-service/pkg/log"
+	"github.com/codeready-toolchain/registration-service/pkg/signup"
 
 	"github.com/gin-gonic/gin"
 	"github.com/nyaruka/phonenumbers"
@@ -21,11 +22,28 @@ type Signup struct {
 	app application.Application
 }
 
+// TrialExtensionRequest is the payload of a trial extension request
+type TrialExtensionRequest struct {
+	// Justification explains why the user needs more time, for the administrators reviewing the request
+	Justification string `json:"justification" binding:"required"`
+}
+
+// Phone is the payload of a request to start the phone verification
 type Phone struct {
 	CountryCode string `form:"country_code" json:"country_code" binding:"required"`
 	PhoneNumber string `form:"phone_number" json:"phone_number" binding:"required"`
 }
 
+// PhoneCodeRequest is the payload of a request to verify the code sent to the phone number of the user
+type PhoneCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// ActivationCodeRequest is the payload of a request to verify an activation code
+type ActivationCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
 // NewSignup returns a new Signup instance.
 func NewSignup(app application.Application) *Signup {
 	return &Signup{
@@ -64,7 +82,7 @@ func (s *Signup) InitVerificationHandler(ctx *gin.Context) {
 
 	// Read the Body content
 	var phone Phone
-	if err := ctx.BindJSON(&phone); err != nil {
+	if err := ctx.ShouldBindJSON(&phone); err != nil {
 		log.Errorf(ctx, err, "request body does not contain required fields phone_number and country_code")
 		crterrors.AbortWithError(ctx, http.StatusBadRequest, err, "error reading request body")
 		return
@@ -118,25 +136,41 @@ func (s *Signup) GetHandler(ctx *gin.Context) {
 			return
 		}
 		crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, "error getting UserSignup resource")
+		return
 	}
 	if signupResource == nil {
 		log.Infof(ctx, "UserSignup resource for username '%s' resource not found", username)
-		ctx.AbortWithStatus(http.StatusNotFound)
+		crterrors.AbortWithError(ctx, http.StatusNotFound, errors.New("signup not found"), "")
 	} else {
 		ctx.JSON(http.StatusOK, signupResource)
 	}
 }
 
-// VerifyPhoneCodeHandler validates the phone verification code passed in by the user
+// VerifyPhoneCodeHandler validates the phone verification code passed in by the user in the path
 func (s *Signup) VerifyPhoneCodeHandler(ctx *gin.Context) {
 	log.Info(ctx, "Verifying phone code")
 	code := ctx.Param("code")
 	if code == "" {
 		log.Error(ctx, nil, "no phone code provided in the request")
-		ctx.AbortWithStatus(http.StatusBadRequest)
+		crterrors.AbortWithError(ctx, http.StatusBadRequest, errors.New("no phone code provided in the request"), "")
 		return
 	}
+	s.verifyPhoneCode(ctx, code)
+}
 
+// PostPhoneCodeHandler validates the phone verification code passed in by the user in the request body
+func (s *Signup) PostPhoneCodeHandler(ctx *gin.Context) {
+	log.Info(ctx, "Verifying phone code")
+	req := PhoneCodeRequest{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error(ctx, err, "no phone code provided in the request")
+		crterrors.AbortWithError(ctx, http.StatusBadRequest, err, "error reading request body")
+		return
+	}
+	s.verifyPhoneCode(ctx, req.Code)
+}
+
+func (s *Signup) verifyPhoneCode(ctx *gin.Context, code string) {
 	username := ctx.GetString(context.UsernameKey)
 
 	err := s.app.VerificationService().VerifyPhoneCode(ctx, username, code)
@@ -154,24 +188,36 @@ func (s *Signup) VerifyPhoneCodeHandler(ctx *gin.Context) {
 	log.Info(ctx, "Verified phone code")
 }
 
-// VerifyActivationCodeHandler validates the activation code passed in by the user as a form value
-func (s *Signup) VerifyActivationCodeHandler(ctx *gin.Context) {
-	body := map[string]interface{}{}
-	if err := ctx.BindJSON(&body); err != nil {
-		log.Error(ctx, nil, "no activation code provided in the request")
-		ctx.AbortWithStatus(http.StatusBadRequest)
+// CheckActivationCodeHandler returns the status of the event of the activation code given in the path,
+// without activating the code: the UserSignup of the caller is left untouched and no verification attempt is counted.
+func (s *Signup) CheckActivationCodeHandler(ctx *gin.Context) {
+	code := ctx.Param("code")
+	activationCode, err := s.app.VerificationService().CheckActivationCode(ctx, code)
+	if err != nil {
+		log.Error(ctx, err, "error checking activation code")
+		e := &crterrors.Error{}
+		if errors.As(err, &e) {
+			crterrors.AbortWithError(ctx, int(e.Code), err, e.Details)
+			return
+		}
+		crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, "unexpected error while checking activation code")
 		return
 	}
-	code, ok := body["code"].(string)
-	if !ok {
-		log.Error(ctx, nil, "no activation code provided in the request")
-		ctx.AbortWithStatus(http.StatusBadRequest)
+	ctx.JSON(http.StatusOK, activationCode)
+}
+
+// VerifyActivationCodeHandler validates the activation code passed in by the user in the request body
+func (s *Signup) VerifyActivationCodeHandler(ctx *gin.Context) {
+	req := ActivationCodeRequest{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error(ctx, err, "no activation code provided in the request")
+		crterrors.AbortWithError(ctx, http.StatusBadRequest, err, "error reading request body")
 		return
 	}
 
 	username := ctx.GetString(context.UsernameKey)
 
-	err := s.app.VerificationService().VerifyActivationCode(ctx, username, code)
+	err := s.app.VerificationService().VerifyActivationCode(ctx, username, req.Code)
 	if err != nil {
 		log.Error(ctx, err, "error validating activation code")
 		e := &crterrors.Error{}
@@ -185,3 +231,33 @@ func (s *Signup) VerifyActivationCodeHandler(ctx *gin.Context) {
 	}
 	ctx.Status(http.StatusOK)
 }
+
+// RequestTrialExtensionHandler records a request to extend the trial of the user. The response contains the state of
+// the request: 200 OK if the request was approved automatically, 202 Accepted if it is pending a review by an administrator.
+func (s *Signup) RequestTrialExtensionHandler(ctx *gin.Context) {
+	req := TrialExtensionRequest{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error(ctx, err, "error validating trial extension request")
+		crterrors.AbortWithError(ctx, http.StatusBadRequest, err, "error reading request body")
+		return
+	}
+	username := ctx.GetString(context.UsernameKey)
+
+	extension, err := s.app.SignupService().RequestTrialExtension(ctx, username, req.Justification)
+	if err != nil {
+		e := &crterrors.Error{}
+		if errors.As(err, &e) {
+			crterrors.AbortWithError(ctx, e.Code, err, e.Details)
+			return
+		}
+		log.Error(ctx, err, "error requesting trial extension")
+		crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, "error requesting trial extension")
+		return
+	}
+	log.Infof(ctx, "trial extension requested by user '%s': %s", username, extension.State)
+	if extension.State == signup.TrialExtensionPending {
+		ctx.JSON(http.StatusAccepted, extension)
+		return
+	}
+	ctx.JSON(http.StatusOK, extension)
+}
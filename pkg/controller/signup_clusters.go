@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"net/http"
+
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/signup"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SignupClusters implements the endpoint which lists the member clusters and regions which the users can choose
+// when they sign up.
+type SignupClusters struct {
+	namespaced.Client
+}
+
+// NewSignupClusters returns a new SignupClusters instance.
+func NewSignupClusters(nsClient namespaced.Client) *SignupClusters {
+	return &SignupClusters{
+		Client: nsClient,
+	}
+}
+
+// GetHandler returns the member clusters, along with their region and capacity
+func (s *SignupClusters) GetHandler(ctx *gin.Context) {
+	options, err := signup.ListClusterOptions(ctx, s.Client)
+	if err != nil {
+		log.Error(ctx, err, "error listing the member clusters")
+		crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, "error listing the member clusters")
+		return
+	}
+	ctx.JSON(http.StatusOK, options)
+}
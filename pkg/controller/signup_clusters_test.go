@@ -0,0 +1,75 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/controller"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/signup"
+	"github.com/codeready-toolchain/registration-service/test"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type TestSignupClustersSuite struct {
+	test.UnitTestSuite
+}
+
+func TestRunSignupClustersSuite(t *testing.T) {
+	suite.Run(t, &TestSignupClustersSuite{test.UnitTestSuite{}})
+}
+
+func (s *TestSignupClustersSuite) TestGetHandler() {
+	get := func(objs ...client.Object) *httptest.ResponseRecorder {
+		ctrl := controller.NewSignupClusters(namespaced.NewClient(commontest.NewFakeClient(s.T(), objs...), commontest.HostOperatorNs))
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/signup/clusters", nil)
+		require.NoError(s.T(), err)
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = req
+		ctrl.GetHandler(ctx)
+		return rr
+	}
+
+	s.Run("success", func() {
+		// given
+		status := &toolchainv1alpha1.ToolchainStatus{
+			ObjectMeta: metav1.ObjectMeta{Name: "toolchain-status", Namespace: commontest.HostOperatorNs},
+			Status: toolchainv1alpha1.ToolchainStatusStatus{
+				Members: []toolchainv1alpha1.Member{{ClusterName: "member-1"}},
+			},
+		}
+		toolchainCluster := &toolchainv1alpha1.ToolchainCluster{ObjectMeta: metav1.ObjectMeta{
+			Name:      "member-1",
+			Namespace: commontest.HostOperatorNs,
+			Labels:    map[string]string{signup.RegionLabelKey: "us"},
+		}}
+
+		// when
+		rr := get(status, toolchainCluster)
+
+		// then
+		require.Equal(s.T(), http.StatusOK, rr.Code)
+		options := []signup.ClusterOption{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), &options))
+		assert.Equal(s.T(), []signup.ClusterOption{{Name: "member-1", Region: "us", Available: false}}, options)
+	})
+
+	s.Run("error", func() {
+		// when
+		rr := get()
+
+		// then
+		require.Equal(s.T(), http.StatusInternalServerError, rr.Code)
+		assert.Contains(s.T(), rr.Body.String(), "error listing the member clusters")
+	})
+}
@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/codeready-toolchain/registration-service/pkg/application"
+	"github.com/codeready-toolchain/registration-service/pkg/context"
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaces"
+
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// DeleteNamespacesKey is the query key for specifying whether the namespaces of the user should be deleted
+// right away when the user deactivates their signup
+const DeleteNamespacesKey = "delete-namespaces"
+
+// SignupDeactivation implements the endpoint which allows the users to deactivate their own signup.
+type SignupDeactivation struct {
+	app               application.Application
+	namespacesManager namespaces.Manager
+}
+
+// NewSignupDeactivation returns a new SignupDeactivation instance.
+func NewSignupDeactivation(app application.Application, namespacesManager namespaces.Manager) *SignupDeactivation {
+	return &SignupDeactivation{
+		app:               app,
+		namespacesManager: namespacesManager,
+	}
+}
+
+// DeleteHandler deactivates the UserSignup of the user. If requested, the namespaces of the user are deleted right away,
+// instead of waiting for the deactivation to be processed.
+// Deactivating a signup which is already deactivated has no effect.
+func (s *SignupDeactivation) DeleteHandler(ctx *gin.Context) {
+	username := ctx.GetString(context.UsernameKey)
+	deleteNamespaces, err := strconv.ParseBool(ctx.DefaultQuery(DeleteNamespacesKey, "false"))
+	if err != nil {
+		crterrors.AbortWithError(ctx, http.StatusBadRequest, err, "invalid '"+DeleteNamespacesKey+"' query parameter")
+		return
+	}
+
+	if deleteNamespaces {
+		// the namespaces are deleted first, as the UserSignup is not available anymore once deactivated
+		// the namespaces are not recreated once the signup is deactivated, so there is nothing to track
+		reset, err := s.namespacesManager.ResetNamespaces(ctx, namespaces.ResetOptions{Untracked: true})
+		if err == nil && reset.Namespaces.Failed() {
+			err = errors.New("some namespaces could not be deleted")
+		}
+		if err != nil && !errors.Is(err, namespaces.ErrUserSignUpNotFoundOrDeactivated) && !errors.As(err, &namespaces.ErrUserHasNoProvisionedNamespaces{}) {
+			log.Errorf(ctx, err, `unable to delete the namespaces of user "%s"`, username)
+			crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, "error deleting the namespaces")
+			return
+		}
+	}
+
+	deactivated, err := s.app.SignupService().DeactivateSignup(ctx, username)
+	if err != nil {
+		log.Error(ctx, err, "error deactivating UserSignup resource")
+		e := &apierrors.StatusError{}
+		if errors.As(err, &e) {
+			crterrors.AbortWithError(ctx, int(e.Status().Code), err, "error deactivating UserSignup resource")
+			return
+		}
+		crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, "error deactivating UserSignup resource")
+		return
+	}
+
+	if deactivated {
+		log.WithValues(map[string]interface{}{
+			"user_id":            ctx.GetString(context.UserIDKey),
+			"subject":            ctx.GetString(context.SubKey),
+			"namespaces_deleted": deleteNamespaces,
+		}).Infof(ctx, "UserSignup deactivated by user '%s'", username)
+	} else {
+		log.Infof(ctx, "UserSignup of user '%s' is already deactivated", username)
+	}
+	ctx.Status(http.StatusAccepted)
+	ctx.Writer.WriteHeaderNow()
+}
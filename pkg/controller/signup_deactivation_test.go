@@ -0,0 +1,188 @@
+package controller_test
+
+import (
+	gocontext "context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/context"
+	"github.com/codeready-toolchain/registration-service/pkg/controller"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaces"
+	"github.com/codeready-toolchain/registration-service/test"
+	testutil "github.com/codeready-toolchain/registration-service/test/util"
+	"github.com/codeready-toolchain/toolchain-common/pkg/states"
+	testusersignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type TestSignupDeactivationSuite struct {
+	test.UnitTestSuite
+}
+
+func TestRunSignupDeactivationSuite(t *testing.T) {
+	suite.Run(t, &TestSignupDeactivationSuite{test.UnitTestSuite{}})
+}
+
+// namespacesManagerStub records the calls to the namespaces manager and returns the configured error
+type namespacesManagerStub struct {
+	calls int
+	err   error
+}
+
+func (m *namespacesManagerStub) ResetNamespaces(_ *gin.Context, _ namespaces.ResetOptions) (*namespaces.Reset, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &namespaces.Reset{}, nil
+}
+
+func (m *namespacesManagerStub) ResetJob(_ *gin.Context, _ string) (*namespaces.ResetJob, error) {
+	return nil, namespaces.ErrResetJobNotFound
+}
+
+func (m *namespacesManagerStub) Usage(_ *gin.Context) ([]namespaces.NamespaceUsage, error) {
+	return nil, nil
+}
+
+func (s *TestSignupDeactivationSuite) TestDeleteHandler() {
+	newUserSignup := func() *toolchainv1alpha1.UserSignup {
+		return testusersignup.NewUserSignup(
+			testusersignup.WithEncodedName("ted@kubesaw"),
+			testusersignup.SignupComplete(""),
+			testusersignup.ApprovedAutomaticallyAgo(time.Second),
+			testusersignup.WithCompliantUsername("ted"),
+			testusersignup.WithHomeSpace("ted"),
+		)
+	}
+
+	deleteSignup := func(ctrl *controller.SignupDeactivation, query string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodDelete, "/api/v1/signup"+query, nil)
+		require.NoError(s.T(), err)
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = req
+		ctx.Set(context.UsernameKey, "ted@kubesaw")
+		ctrl.DeleteHandler(ctx)
+		return rr
+	}
+
+	assertDeactivated := func(cl client.Client, userSignup *toolchainv1alpha1.UserSignup) {
+		actual := &toolchainv1alpha1.UserSignup{}
+		require.NoError(s.T(), cl.Get(gocontext.TODO(), client.ObjectKeyFromObject(userSignup), actual))
+		assert.True(s.T(), states.Deactivated(actual))
+	}
+
+	s.Run("signup deactivated", func() {
+		// given
+		userSignup := newUserSignup()
+		fakeClient, application := testutil.PrepareInClusterApp(s.T(), userSignup)
+		namespacesManager := &namespacesManagerStub{}
+		ctrl := controller.NewSignupDeactivation(application, namespacesManager)
+
+		// when
+		rr := deleteSignup(ctrl, "")
+
+		// then
+		assert.Equal(s.T(), http.StatusAccepted, rr.Code)
+		assertDeactivated(fakeClient, userSignup)
+		assert.Equal(s.T(), 0, namespacesManager.calls)
+
+		s.Run("deactivating again is accepted", func() {
+			// when
+			rr := deleteSignup(ctrl, "")
+
+			// then
+			assert.Equal(s.T(), http.StatusAccepted, rr.Code)
+			assertDeactivated(fakeClient, userSignup)
+		})
+	})
+
+	s.Run("signup deactivated and namespaces deleted", func() {
+		for name, err := range map[string]error{
+			"namespaces deleted":       nil,
+			"no provisioned namespace": namespaces.NewErrUserHasNoProvisionedNamespaces("member-1", "ted"),
+			"signup not provisioned":   namespaces.ErrUserSignUpNotFoundOrDeactivated,
+		} {
+			s.Run(name, func() {
+				// given
+				userSignup := newUserSignup()
+				fakeClient, application := testutil.PrepareInClusterApp(s.T(), userSignup)
+				namespacesManager := &namespacesManagerStub{err: err}
+				ctrl := controller.NewSignupDeactivation(application, namespacesManager)
+
+				// when
+				rr := deleteSignup(ctrl, "?delete-namespaces=true")
+
+				// then
+				assert.Equal(s.T(), http.StatusAccepted, rr.Code)
+				assertDeactivated(fakeClient, userSignup)
+				assert.Equal(s.T(), 1, namespacesManager.calls)
+			})
+		}
+	})
+
+	s.Run("signup not deactivated when the namespaces cannot be deleted", func() {
+		// given
+		userSignup := newUserSignup()
+		fakeClient, application := testutil.PrepareInClusterApp(s.T(), userSignup)
+		ctrl := controller.NewSignupDeactivation(application, &namespacesManagerStub{err: errors.New("oopsie woopsie")})
+
+		// when
+		rr := deleteSignup(ctrl, "?delete-namespaces=true")
+
+		// then
+		test.AssertError(s.T(), rr, http.StatusInternalServerError, "oopsie woopsie", "error deleting the namespaces")
+		actual := &toolchainv1alpha1.UserSignup{}
+		require.NoError(s.T(), fakeClient.Get(gocontext.TODO(), client.ObjectKeyFromObject(userSignup), actual))
+		assert.False(s.T(), states.Deactivated(actual))
+	})
+
+	s.Run("invalid query parameter", func() {
+		// given
+		_, application := testutil.PrepareInClusterApp(s.T(), newUserSignup())
+		ctrl := controller.NewSignupDeactivation(application, &namespacesManagerStub{})
+
+		// when
+		rr := deleteSignup(ctrl, "?delete-namespaces=maybe")
+
+		// then
+		test.AssertError(s.T(), rr, http.StatusBadRequest, `strconv.ParseBool: parsing "maybe": invalid syntax`, "invalid 'delete-namespaces' query parameter")
+	})
+
+	s.Run("signup not found", func() {
+		// given
+		_, application := testutil.PrepareInClusterApp(s.T())
+		ctrl := controller.NewSignupDeactivation(application, &namespacesManagerStub{})
+
+		// when
+		rr := deleteSignup(ctrl, "")
+
+		// then
+		assert.Equal(s.T(), http.StatusNotFound, rr.Code)
+	})
+
+	s.Run("update fails", func() {
+		// given
+		fakeClient, application := testutil.PrepareInClusterApp(s.T(), newUserSignup())
+		fakeClient.MockUpdate = func(_ gocontext.Context, _ client.Object, _ ...client.UpdateOption) error {
+			return errors.New("oopsie woopsie")
+		}
+		ctrl := controller.NewSignupDeactivation(application, &namespacesManagerStub{})
+
+		// when
+		rr := deleteSignup(ctrl, "")
+
+		// then
+		test.AssertError(s.T(), rr, http.StatusInternalServerError, "oopsie woopsie", "error deactivating UserSignup resource")
+	})
+}
@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/codeready-toolchain/registration-service/pkg/application"
+	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	"github.com/codeready-toolchain/registration-service/pkg/context"
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+	"github.com/codeready-toolchain/registration-service/pkg/signup/events"
+	signupcommon "github.com/codeready-toolchain/toolchain-common/pkg/usersignup"
+
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// SignupEvent is the type of the events containing a snapshot of the Signup resource
+	SignupEvent = "signup"
+	// DeactivatedEvent is the type of the event sent before closing the stream, when the signup is deactivated
+	DeactivatedEvent = "deactivated"
+	// ErrorEvent is the type of the event sent before closing the stream, when the Signup resource could not be retrieved
+	ErrorEvent = "error"
+)
+
+// SignupEvents implements the endpoint which streams the status of the signup of the user as Server-Sent Events,
+// so that the clients don't need to poll the signup endpoint.
+type SignupEvents struct {
+	app         application.Application
+	broadcaster *events.Broadcaster
+}
+
+// NewSignupEvents returns a new SignupEvents instance.
+func NewSignupEvents(app application.Application, broadcaster *events.Broadcaster) *SignupEvents {
+	return &SignupEvents{
+		app:         app,
+		broadcaster: broadcaster,
+	}
+}
+
+// GetHandler streams the Signup resource of the user: a `signup` event is sent with the current Signup resource, and then
+// every time it changes after an update of the UserSignup, MasterUserRecord or Space of the user. Comments are sent periodically
+// as heartbeats. The stream is closed after a `deactivated` event when the signup is deactivated, or after an `error` event
+// when the Signup resource could not be retrieved.
+func (s *SignupEvents) GetHandler(ctx *gin.Context) {
+	username := ctx.GetString(context.UsernameKey)
+	// subscribe before retrieving the Signup resource, so that no change is missed
+	changes, unsubscribe := s.broadcaster.Subscribe(signupcommon.EncodeUserIdentifier(username))
+	defer unsubscribe()
+
+	signupResource, err := s.app.SignupService().GetSignup(ctx, username, true)
+	if err != nil {
+		log.Error(ctx, err, "error getting UserSignup resource")
+		e := &apierrors.StatusError{}
+		if errors.As(err, &e) {
+			crterrors.AbortWithError(ctx, int(e.Status().Code), err, "error getting UserSignup resource")
+			return
+		}
+		crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, "error getting UserSignup resource")
+		return
+	}
+	if signupResource == nil {
+		log.Infof(ctx, "UserSignup resource for username '%s' resource not found", username)
+		crterrors.AbortWithError(ctx, http.StatusNotFound, errors.New("signup not found"), "")
+		return
+	}
+
+	// the stream is expected to outlive the write timeout of the server
+	_ = http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{})
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no") // disable the buffering in the router
+	ctx.Status(http.StatusOK)
+
+	last, err := json.Marshal(signupResource)
+	if err != nil {
+		log.Error(ctx, err, "error marshalling Signup resource")
+		return
+	}
+	writeEvent(ctx, SignupEvent, last)
+
+	heartbeat := time.NewTicker(configuration.GetRegistrationServiceConfig().SignupEvents().HeartbeatInterval())
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			_, _ = fmt.Fprint(ctx.Writer, ": heartbeat\n\n")
+			ctx.Writer.Flush()
+		case <-changes:
+			signupResource, err := s.app.SignupService().GetSignup(ctx, username, true)
+			if err != nil {
+				log.Error(ctx, err, "error getting UserSignup resource")
+				writeErrorEvent(ctx, err)
+				return
+			}
+			if signupResource == nil {
+				log.Infof(ctx, "UserSignup resource for username '%s' is deactivated, closing the stream", username)
+				writeEvent(ctx, DeactivatedEvent, []byte("{}"))
+				return
+			}
+			snapshot, err := json.Marshal(signupResource)
+			if err != nil {
+				log.Error(ctx, err, "error marshalling Signup resource")
+				return
+			}
+			if bytes.Equal(snapshot, last) {
+				// the change does not affect the Signup resource
+				continue
+			}
+			last = snapshot
+			writeEvent(ctx, SignupEvent, snapshot)
+		}
+	}
+}
+
+func writeEvent(ctx *gin.Context, event string, data []byte) {
+	_, _ = fmt.Fprintf(ctx.Writer, "event: %s\ndata: %s\n\n", event, data)
+	ctx.Writer.Flush()
+}
+
+func writeErrorEvent(ctx *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	e := &apierrors.StatusError{}
+	if errors.As(err, &e) {
+		code = int(e.Status().Code)
+	}
+	data, _ := json.Marshal(&crterrors.Error{
+		Status:  http.StatusText(code),
+		Code:    code,
+		Message: err.Error(),
+		Details: "error getting UserSignup resource",
+	})
+	writeEvent(ctx, ErrorEvent, data)
+}
@@ -0,0 +1,211 @@
+package controller_test
+
+import (
+	"bufio"
+	gocontext "context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/application"
+	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	"github.com/codeready-toolchain/registration-service/pkg/context"
+	"github.com/codeready-toolchain/registration-service/pkg/controller"
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+	"github.com/codeready-toolchain/registration-service/pkg/signup"
+	"github.com/codeready-toolchain/registration-service/pkg/signup/events"
+	"github.com/codeready-toolchain/registration-service/test"
+	testutil "github.com/codeready-toolchain/registration-service/test/util"
+	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
+	testusersignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+	"github.com/codeready-toolchain/toolchain-common/pkg/usersignup"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type TestSignupEventsSuite struct {
+	test.UnitTestSuite
+}
+
+func TestRunSignupEventsSuite(t *testing.T) {
+	suite.Run(t, &TestSignupEventsSuite{test.UnitTestSuite{}})
+}
+
+func (s *TestSignupEventsSuite) TestGetHandler() {
+	newUserSignup := func() *toolchainv1alpha1.UserSignup {
+		return testusersignup.NewUserSignup(
+			testusersignup.WithEncodedName("ted@kubesaw"),
+			testusersignup.SignupIncomplete("Provisioning", ""),
+			testusersignup.ApprovedAutomaticallyAgo(time.Second),
+			testusersignup.WithCompliantUsername("ted"),
+			testusersignup.WithHomeSpace("ted"),
+		)
+	}
+
+	// starts a server streaming the signup events of the given user
+	startServer := func(app application.Application, broadcaster *events.Broadcaster, username string) *httptest.Server {
+		router := gin.New()
+		router.GET("/api/v1/signup/events", func(ctx *gin.Context) {
+			ctx.Set(context.UsernameKey, username)
+		}, controller.NewSignupEvents(app, broadcaster).GetHandler)
+		return httptest.NewServer(router)
+	}
+
+	// returns the type and the data of the next message of the stream. The type of the heartbeats is ":".
+	nextMessage := func(reader *bufio.Reader) (string, string) {
+		var event, data string
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(s.T(), err)
+			line = strings.TrimSuffix(line, "\n")
+			switch {
+			case line == "":
+				return event, data
+			case strings.HasPrefix(line, ":"):
+				event = ":"
+			case strings.HasPrefix(line, "event: "):
+				event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}
+
+	openStream := func(srv *httptest.Server) (*http.Response, *bufio.Reader) {
+		resp, err := (&http.Client{Timeout: 5 * time.Second}).Get(srv.URL + "/api/v1/signup/events")
+		require.NoError(s.T(), err)
+		return resp, bufio.NewReader(resp.Body)
+	}
+
+	updateCompleteCondition := func(cl client.Client, status corev1.ConditionStatus, reason string) {
+		userSignup := &toolchainv1alpha1.UserSignup{}
+		require.NoError(s.T(), cl.Get(gocontext.TODO(), client.ObjectKey{Namespace: configuration.Namespace(), Name: usersignup.EncodeUserIdentifier("ted@kubesaw")}, userSignup))
+		userSignup.Status.Conditions, _ = condition.AddOrUpdateStatusConditions(userSignup.Status.Conditions, toolchainv1alpha1.Condition{
+			Type:   toolchainv1alpha1.UserSignupComplete,
+			Status: status,
+			Reason: reason,
+		})
+		require.NoError(s.T(), cl.Status().Update(gocontext.TODO(), userSignup))
+	}
+
+	s.Run("initial status and changes", func() {
+		// given
+		fakeClient, application := testutil.PrepareInClusterApp(s.T(), newUserSignup())
+		broadcaster := events.NewBroadcaster()
+		srv := startServer(application, broadcaster, "ted@kubesaw")
+		defer srv.Close()
+
+		// when
+		resp, reader := openStream(srv)
+		defer resp.Body.Close()
+
+		// then
+		require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+		assert.Equal(s.T(), "text/event-stream", resp.Header.Get("Content-Type"))
+		assert.Equal(s.T(), "no-cache", resp.Header.Get("Cache-Control"))
+		event, data := nextMessage(reader)
+		assert.Equal(s.T(), controller.SignupEvent, event)
+		signupResource := &signup.Signup{}
+		require.NoError(s.T(), json.Unmarshal([]byte(data), signupResource))
+		assert.Equal(s.T(), "ted", signupResource.CompliantUsername)
+		assert.Equal(s.T(), "Provisioning", signupResource.Status.Reason)
+
+		s.Run("change is pushed", func() {
+			// when
+			updateCompleteCondition(fakeClient, corev1.ConditionFalse, "PendingApproval")
+			broadcaster.Notify(usersignup.EncodeUserIdentifier("ted@kubesaw"))
+
+			// then
+			event, data := nextMessage(reader)
+			assert.Equal(s.T(), controller.SignupEvent, event)
+			signupResource := &signup.Signup{}
+			require.NoError(s.T(), json.Unmarshal([]byte(data), signupResource))
+			assert.Equal(s.T(), "PendingApproval", signupResource.Status.Reason)
+		})
+
+		s.Run("stream is closed on deactivation", func() {
+			// given
+			// a notification without any change of the Signup resource is not pushed
+			broadcaster.Notify(usersignup.EncodeUserIdentifier("ted@kubesaw"))
+			time.Sleep(100 * time.Millisecond)
+
+			// when
+			updateCompleteCondition(fakeClient, corev1.ConditionTrue, toolchainv1alpha1.UserSignupUserDeactivatedReason)
+			broadcaster.Notify(usersignup.EncodeUserIdentifier("ted@kubesaw"))
+
+			// then
+			event, data := nextMessage(reader)
+			assert.Equal(s.T(), controller.DeactivatedEvent, event)
+			assert.Equal(s.T(), "{}", data)
+			_, err := reader.ReadString('\n')
+			require.Error(s.T(), err) // end of stream
+		})
+	})
+
+	s.Run("heartbeats", func() {
+		// given
+		test.SetSettings(s.T(), "signupEvents: {heartbeatInterval: 10ms}")
+		_, application := testutil.PrepareInClusterApp(s.T(), newUserSignup())
+		srv := startServer(application, events.NewBroadcaster(), "ted@kubesaw")
+		defer srv.Close()
+
+		// when
+		resp, reader := openStream(srv)
+		defer resp.Body.Close()
+
+		// then
+		event, _ := nextMessage(reader)
+		assert.Equal(s.T(), controller.SignupEvent, event)
+		event, _ = nextMessage(reader)
+		assert.Equal(s.T(), ":", event)
+		event, _ = nextMessage(reader)
+		assert.Equal(s.T(), ":", event)
+	})
+
+	s.Run("error event when the user is banned", func() {
+		// given
+		fakeClient, application := testutil.PrepareInClusterApp(s.T(), newUserSignup())
+		broadcaster := events.NewBroadcaster()
+		srv := startServer(application, broadcaster, "ted@kubesaw")
+		defer srv.Close()
+		resp, reader := openStream(srv)
+		defer resp.Body.Close()
+		event, _ := nextMessage(reader)
+		require.Equal(s.T(), controller.SignupEvent, event)
+
+		// when
+		updateCompleteCondition(fakeClient, corev1.ConditionTrue, toolchainv1alpha1.UserSignupUserBannedReason)
+		broadcaster.Notify(usersignup.EncodeUserIdentifier("ted@kubesaw"))
+
+		// then
+		event, data := nextMessage(reader)
+		assert.Equal(s.T(), controller.ErrorEvent, event)
+		e := &crterrors.Error{}
+		require.NoError(s.T(), json.Unmarshal([]byte(data), e))
+		assert.Equal(s.T(), http.StatusForbidden, e.Code)
+		assert.Equal(s.T(), "error getting UserSignup resource", e.Details)
+	})
+
+	s.Run("signup not found", func() {
+		// given
+		_, application := testutil.PrepareInClusterApp(s.T())
+		srv := startServer(application, events.NewBroadcaster(), "ted@kubesaw")
+		defer srv.Close()
+
+		// when
+		resp, _ := openStream(srv)
+		defer resp.Body.Close()
+
+		// then
+		assert.Equal(s.T(), http.StatusNotFound, resp.StatusCode)
+	})
+}
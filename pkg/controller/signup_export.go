@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/codeready-toolchain/registration-service/pkg/context"
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/signup"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ExportFormatKey is the query key for specifying the format of the exported data
+	ExportFormatKey = "format"
+	// ExportFormatJSON returns the exported data as a JSON document (default)
+	ExportFormatJSON = "json"
+	// ExportFormatZip returns the exported data as a zip archive to download
+	ExportFormatZip = "zip"
+
+	exportFileName = "signup-export"
+)
+
+// SignupExport implements the endpoint which allows the users to export the personal data held about them.
+type SignupExport struct {
+	namespaced.Client
+}
+
+// NewSignupExport returns a new SignupExport instance.
+func NewSignupExport(nsClient namespaced.Client) *SignupExport {
+	return &SignupExport{
+		Client: nsClient,
+	}
+}
+
+// GetHandler returns the personal data of the user, either as a JSON document or as a zip archive containing the same
+// JSON document, depending on the `format` query parameter.
+func (s *SignupExport) GetHandler(ctx *gin.Context) {
+	format := ctx.DefaultQuery(ExportFormatKey, ExportFormatJSON)
+	if format != ExportFormatJSON && format != ExportFormatZip {
+		crterrors.AbortWithError(ctx, http.StatusBadRequest, fmt.Errorf("unsupported format '%s'", format),
+			"invalid '"+ExportFormatKey+"' query parameter")
+		return
+	}
+
+	username := ctx.GetString(context.UsernameKey)
+	export, err := signup.ExportPersonalData(ctx, s.Client, username)
+	if err != nil {
+		log.Error(ctx, err, "error exporting the personal data")
+		e := &crterrors.Error{}
+		if errors.As(err, &e) {
+			crterrors.AbortWithError(ctx, e.Code, errors.New(e.Message), e.Details)
+			return
+		}
+		crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, "error exporting the personal data")
+		return
+	}
+	if export == nil {
+		log.Infof(ctx, "UserSignup resource for username '%s' resource not found", username)
+		crterrors.AbortWithError(ctx, http.StatusNotFound, errors.New("signup not found"), "")
+		return
+	}
+	log.WithValues(map[string]interface{}{
+		"user_id": ctx.GetString(context.UserIDKey),
+		"subject": ctx.GetString(context.SubKey),
+		"format":  format,
+	}).Infof(ctx, "personal data exported by user '%s'", username)
+
+	if format == ExportFormatJSON {
+		ctx.JSON(http.StatusOK, export)
+		return
+	}
+	archive, err := zipExport(export)
+	if err != nil {
+		log.Error(ctx, err, "error archiving the personal data")
+		crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, "error archiving the personal data")
+		return
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, exportFileName))
+	ctx.Data(http.StatusOK, "application/zip", archive)
+}
+
+// zipExport returns a zip archive containing the exported data as a JSON document
+func zipExport(export *signup.Export) ([]byte, error) {
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	f, err := w.CreateHeader(&zip.FileHeader{
+		Name:     exportFileName + ".json",
+		Method:   zip.Deflate,
+		Modified: export.ExportedAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
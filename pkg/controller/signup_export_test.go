@@ -0,0 +1,141 @@
+package controller_test
+
+import (
+	"archive/zip"
+	"bytes"
+	gocontext "context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/context"
+	"github.com/codeready-toolchain/registration-service/pkg/controller"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/signup"
+	"github.com/codeready-toolchain/registration-service/test"
+	"github.com/codeready-toolchain/registration-service/test/fake"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	testusersignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type TestSignupExportSuite struct {
+	test.UnitTestSuite
+}
+
+func TestRunSignupExportSuite(t *testing.T) {
+	suite.Run(t, &TestSignupExportSuite{test.UnitTestSuite{}})
+}
+
+func (s *TestSignupExportSuite) TestGetHandler() {
+	newFakeClient := func() *commontest.FakeClient {
+		return commontest.NewFakeClient(s.T(),
+			testusersignup.NewUserSignup(
+				testusersignup.WithEncodedName("ted@kubesaw"),
+				testusersignup.WithCompliantUsername("ted"),
+				testusersignup.WithAnnotation(toolchainv1alpha1.UserSignupVerificationCodeAnnotationKey, "123456"),
+				testusersignup.WithAnnotation(toolchainv1alpha1.UserSignupCaptchaScoreAnnotationKey, "0.9"),
+			),
+			fake.NewMasterUserRecord("ted"),
+			fake.NewSpaceBinding("ted-1", "ted", "ted", "admin"),
+		)
+	}
+
+	getExport := func(cl client.Client, query string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/signup/export"+query, nil)
+		require.NoError(s.T(), err)
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = req
+		ctx.Set(context.UsernameKey, "ted@kubesaw")
+		controller.NewSignupExport(namespaced.NewClient(cl, commontest.HostOperatorNs)).GetHandler(ctx)
+		return rr
+	}
+
+	assertExport := func(data []byte) {
+		export := &signup.Export{}
+		require.NoError(s.T(), json.Unmarshal(data, export))
+		assert.Equal(s.T(), "ted", export.UserSignup.CompliantUsername)
+		assert.NotContains(s.T(), export.UserSignup.Annotations, toolchainv1alpha1.UserSignupVerificationCodeAnnotationKey)
+		assert.Equal(s.T(), "0.9", export.UserSignup.Annotations[toolchainv1alpha1.UserSignupCaptchaScoreAnnotationKey])
+		require.NotNil(s.T(), export.MasterUserRecord)
+		assert.Equal(s.T(), "ted", export.MasterUserRecord.Name)
+		assert.Equal(s.T(), []signup.ExportedSpaceBinding{{Name: "ted-1", Space: "ted", SpaceRole: "admin"}}, export.SpaceBindings)
+	}
+
+	s.Run("export as JSON", func() {
+		for name, query := range map[string]string{
+			"default":  "",
+			"explicit": "?format=json",
+		} {
+			s.Run(name, func() {
+				// when
+				rr := getExport(newFakeClient(), query)
+
+				// then
+				require.Equal(s.T(), http.StatusOK, rr.Code)
+				assert.Contains(s.T(), rr.Header().Get("Content-Type"), "application/json")
+				assertExport(rr.Body.Bytes())
+			})
+		}
+	})
+
+	s.Run("export as zip archive", func() {
+		// when
+		rr := getExport(newFakeClient(), "?format=zip")
+
+		// then
+		require.Equal(s.T(), http.StatusOK, rr.Code)
+		assert.Equal(s.T(), "application/zip", rr.Header().Get("Content-Type"))
+		assert.Equal(s.T(), `attachment; filename="signup-export.zip"`, rr.Header().Get("Content-Disposition"))
+		archive, err := zip.NewReader(bytes.NewReader(rr.Body.Bytes()), int64(rr.Body.Len()))
+		require.NoError(s.T(), err)
+		require.Len(s.T(), archive.File, 1)
+		assert.Equal(s.T(), "signup-export.json", archive.File[0].Name)
+		f, err := archive.File[0].Open()
+		require.NoError(s.T(), err)
+		defer f.Close()
+		data, err := io.ReadAll(f)
+		require.NoError(s.T(), err)
+		assertExport(data)
+	})
+
+	s.Run("unsupported format", func() {
+		// when
+		rr := getExport(newFakeClient(), "?format=xml")
+
+		// then
+		test.AssertError(s.T(), rr, http.StatusBadRequest, "unsupported format 'xml'", "invalid 'format' query parameter")
+	})
+
+	s.Run("signup not found", func() {
+		// when
+		rr := getExport(commontest.NewFakeClient(s.T()), "")
+
+		// then
+		test.AssertError(s.T(), rr, http.StatusNotFound, "signup not found", "")
+	})
+
+	s.Run("error retrieving the signup", func() {
+		// given
+		fakeClient := newFakeClient()
+		fakeClient.MockGet = func(_ gocontext.Context, _ client.ObjectKey, _ client.Object, _ ...client.GetOption) error {
+			return errors.New("oopsie woopsie")
+		}
+
+		// when
+		rr := getExport(fakeClient, "")
+
+		// then
+		test.AssertError(s.T(), rr, http.StatusInternalServerError, "oopsie woopsie", "error retrieving UserSignup resource")
+	})
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/codeready-toolchain/registration-service/pkg/configuration"
 	"github.com/codeready-toolchain/registration-service/pkg/context"
 	"github.com/codeready-toolchain/registration-service/pkg/controller"
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
 	"github.com/codeready-toolchain/registration-service/pkg/signup"
 	"github.com/codeready-toolchain/registration-service/pkg/verification/service"
 	"github.com/codeready-toolchain/registration-service/test"
@@ -25,6 +26,7 @@ import (
 	"github.com/codeready-toolchain/toolchain-common/pkg/states"
 	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
 	testconfig "github.com/codeready-toolchain/toolchain-common/pkg/test/config"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test/masteruserrecord"
 	testsocialevent "github.com/codeready-toolchain/toolchain-common/pkg/test/socialevent"
 	testusersignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
 	"github.com/codeready-toolchain/toolchain-common/pkg/usersignup"
@@ -37,6 +39,7 @@ import (
 	"github.com/stretchr/testify/suite"
 	"gopkg.in/h2non/gock.v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type TestSignupSuite struct {
@@ -197,8 +200,8 @@ func (s *TestSignupSuite) TestSignupGetHandler() {
 		// when
 		handler(ctx)
 
-		// Check the status code is what we expect.
-		assert.Equal(s.T(), http.StatusNotFound, rr.Code, "handler returned wrong status code")
+		// then
+		test.AssertError(s.T(), rr, http.StatusNotFound, "signup not found", "")
 	})
 
 	s.Run("signups service error", func() {
@@ -589,6 +592,90 @@ func (s *TestSignupSuite) TestVerifyPhoneCodeHandler() {
 	})
 }
 
+func (s *TestSignupSuite) TestPostPhoneCodeHandler() {
+	newUserSignup := func(expiry time.Time) *crtapi.UserSignup {
+		return testusersignup.NewUserSignup(
+			testusersignup.WithEncodedName("johnny@kubesaw"),
+			testusersignup.VerificationRequiredAgo(time.Second),
+			testusersignup.WithAnnotation(crtapi.UserVerificationAttemptsAnnotationKey, "0"),
+			testusersignup.WithAnnotation(crtapi.UserSignupVerificationCodeAnnotationKey, "999888"),
+			testusersignup.WithAnnotation(crtapi.UserVerificationExpiryAnnotationKey, expiry.Format(service.TimestampLayout)))
+	}
+	postPhoneCode := func(ctrl *controller.Signup, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		req, err := http.NewRequest(http.MethodPost, "/api/v2/signup/verification/phone-code", bytes.NewBufferString(body))
+		require.NoError(s.T(), err)
+		ctx.Request = req
+		ctx.Set(context.UsernameKey, "johnny@kubesaw")
+		ctx.Set(context.ProblemDetailsKey, true)
+		ctrl.PostPhoneCodeHandler(ctx)
+		return rr
+	}
+	assertProblem := func(rr *httptest.ResponseRecorder, expectedStatus int, expectedErrorCode crterrors.ErrorCode) *crterrors.Problem {
+		require.Equal(s.T(), expectedStatus, rr.Code)
+		assert.Equal(s.T(), crterrors.ProblemMediaType, rr.Header().Get("Content-Type"))
+		problem := &crterrors.Problem{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), problem))
+		assert.Equal(s.T(), expectedStatus, problem.Status)
+		assert.Equal(s.T(), expectedErrorCode, problem.ErrorCode)
+		assert.Equal(s.T(), "/api/v2/signup/verification/phone-code", problem.Instance)
+		return problem
+	}
+
+	s.Run("verification successful", func() {
+		// given
+		userSignup := newUserSignup(time.Now().Add(10 * time.Second))
+		fakeClient, application := testutil.PrepareInClusterApp(s.T(), userSignup)
+
+		// when
+		rr := postPhoneCode(controller.NewSignup(application), `{"code":"999888"}`)
+
+		// then
+		require.Equal(s.T(), http.StatusOK, rr.Code)
+		updated := &crtapi.UserSignup{}
+		require.NoError(s.T(), fakeClient.Get(gocontext.TODO(), client.ObjectKeyFromObject(userSignup), updated))
+		assert.False(s.T(), states.VerificationRequired(updated))
+	})
+
+	s.Run("invalid code", func() {
+		// given
+		_, application := testutil.PrepareInClusterApp(s.T(), newUserSignup(time.Now().Add(10*time.Second)))
+
+		// when
+		rr := postPhoneCode(controller.NewSignup(application), `{"code":"111111"}`)
+
+		// then
+		problem := assertProblem(rr, http.StatusForbidden, crterrors.VerificationCodeInvalid)
+		require.NotNil(s.T(), problem.AttemptsRemaining)
+		assert.Equal(s.T(), configuration.GetRegistrationServiceConfig().Verification().AttemptsAllowed()-1, *problem.AttemptsRemaining)
+	})
+
+	s.Run("expired code", func() {
+		// given
+		_, application := testutil.PrepareInClusterApp(s.T(), newUserSignup(time.Now().Add(-10*time.Second)))
+
+		// when
+		rr := postPhoneCode(controller.NewSignup(application), `{"code":"999888"}`)
+
+		// then
+		problem := assertProblem(rr, http.StatusForbidden, crterrors.VerificationCodeExpired)
+		assert.Equal(s.T(), "expired: verification code expired", problem.Detail)
+		assert.Nil(s.T(), problem.AttemptsRemaining)
+	})
+
+	s.Run("no code provided", func() {
+		// given
+		_, application := testutil.PrepareInClusterApp(s.T(), newUserSignup(time.Now().Add(10*time.Second)))
+
+		// when
+		rr := postPhoneCode(controller.NewSignup(application), `{}`)
+
+		// then
+		assertProblem(rr, http.StatusBadRequest, crterrors.RequestInvalid)
+	})
+}
+
 func initPhoneVerification(t *testing.T, handler gin.HandlerFunc, params gin.Param, data []byte, username, httpMethod, url string) *httptest.ResponseRecorder {
 	// We create a ResponseRecorder (which satisfies http.ResponseWriter) to record the response.
 	rr := httptest.NewRecorder()
@@ -787,6 +874,61 @@ func (s *TestSignupSuite) TestVerifyActivationCodeHandler() {
 	})
 }
 
+func (s *TestSignupSuite) TestCheckActivationCodeHandler() {
+	check := func(handler gin.HandlerFunc, username, code string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/activation-codes/"+code, nil)
+		require.NoError(s.T(), err)
+		ctx.Request = req
+		ctx.Params = gin.Params{{Key: "code", Value: code}}
+		ctx.Set(context.UsernameKey, username)
+		handler(ctx)
+		return rr
+	}
+
+	s.Run("running event", func() {
+		// given
+		userSignup := testusersignup.NewUserSignup(testusersignup.VerificationRequiredAgo(time.Second)) // just signed up
+		event := testsocialevent.NewSocialEvent(commontest.HostOperatorNs, "event", testsocialevent.WithTargetCluster("member-1"))
+		fakeClient, application := testutil.PrepareInClusterApp(s.T(), userSignup, event)
+		ctrl := controller.NewSignup(application)
+
+		// when
+		rr := check(ctrl.CheckActivationCodeHandler, userSignup.Name, event.Name)
+
+		// then
+		require.Equal(s.T(), http.StatusOK, rr.Code)
+		activationCode := &signup.ActivationCode{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), activationCode))
+		assert.True(s.T(), activationCode.Exists)
+		assert.Equal(s.T(), signup.ActivationCodeRunning, activationCode.Status)
+		assert.Equal(s.T(), "member-1", activationCode.TargetCluster)
+		// the UserSignup is left untouched
+		updatedUserSignup := &crtapi.UserSignup{}
+		require.NoError(s.T(), fakeClient.Get(gocontext.TODO(), client.ObjectKeyFromObject(userSignup), updatedUserSignup))
+		assert.True(s.T(), states.VerificationRequired(updatedUserSignup))
+		assert.Empty(s.T(), updatedUserSignup.Labels[crtapi.SocialEventUserSignupLabelKey])
+	})
+
+	s.Run("unknown code does not count as a verification attempt", func() {
+		// given
+		userSignup := testusersignup.NewUserSignup(testusersignup.VerificationRequiredAgo(time.Second)) // just signed up
+		fakeClient, application := testutil.PrepareInClusterApp(s.T(), userSignup)
+		ctrl := controller.NewSignup(application)
+
+		// when
+		rr := check(ctrl.CheckActivationCodeHandler, userSignup.Name, "invalid")
+
+		// then
+		require.Equal(s.T(), http.StatusOK, rr.Code)
+		assert.JSONEq(s.T(), `{"code":"invalid","exists":false,"verificationRequired":false}`, rr.Body.String())
+		updatedUserSignup := &crtapi.UserSignup{}
+		require.NoError(s.T(), fakeClient.Get(gocontext.TODO(), client.ObjectKeyFromObject(userSignup), updatedUserSignup))
+		assert.Empty(s.T(), updatedUserSignup.Annotations[crtapi.UserVerificationAttemptsAnnotationKey])
+	})
+}
+
 func initActivationCodeVerification(t *testing.T, handler gin.HandlerFunc, username, code string) *httptest.ResponseRecorder {
 	// We create a ResponseRecorder (which satisfies http.ResponseWriter) to record the response.
 	rr := httptest.NewRecorder()
@@ -799,3 +941,106 @@ func initActivationCodeVerification(t *testing.T, handler gin.HandlerFunc, usern
 	handler(ctx)
 	return rr
 }
+
+func (s *TestSignupSuite) TestRequestTrialExtensionHandler() {
+	newUserSignup := func() *crtapi.UserSignup {
+		return testusersignup.NewUserSignup(
+			testusersignup.WithEncodedName("ted@kubesaw"),
+			testusersignup.SignupComplete(""),
+			testusersignup.ApprovedAutomaticallyAgo(time.Second),
+			testusersignup.WithCompliantUsername("ted"))
+	}
+	request := func(ctrl *controller.Signup, username, payload string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/signup/extension", bytes.NewBufferString(payload))
+		require.NoError(s.T(), err)
+		req.Header.Set("Content-Type", "application/json")
+		ctx.Request = req
+		ctx.Set(context.UsernameKey, username)
+		ctrl.RequestTrialExtensionHandler(ctx)
+		return rr
+	}
+
+	s.Run("request pending a review", func() {
+		// given
+		userSignup := newUserSignup()
+		fakeClient, application := testutil.PrepareInClusterApp(s.T(), userSignup)
+		ctrl := controller.NewSignup(application)
+
+		// when
+		rr := request(ctrl, "ted@kubesaw", `{"justification":"finishing my project"}`)
+
+		// then
+		require.Equal(s.T(), http.StatusAccepted, rr.Code)
+		extension := &signup.TrialExtension{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), extension))
+		assert.Equal(s.T(), signup.TrialExtensionPending, extension.State)
+		assert.Equal(s.T(), "finishing my project", extension.Justification)
+		updated := &crtapi.UserSignup{}
+		require.NoError(s.T(), fakeClient.Get(gocontext.TODO(), client.ObjectKeyFromObject(userSignup), updated))
+		assert.Equal(s.T(), signup.TrialExtensionPending, updated.Labels[signup.TrialExtensionLabelKey])
+
+		s.Run("a second request is rejected while the first one is pending", func() {
+			// when
+			rr := request(ctrl, "ted@kubesaw", `{"justification":"please"}`)
+
+			// then
+			test.AssertError(s.T(), rr, http.StatusConflict, "trial extension already requested: the previous request is still pending", "the previous request is still pending")
+		})
+	})
+
+	s.Run("request approved automatically", func() {
+		// given
+		test.SetSettings(s.T(), "trialExtensions: {autoApprovedEmailDomains: [redhat.com]}")
+		userTier := masteruserrecord.DefaultUserTier()
+		_, application := testutil.PrepareInClusterApp(s.T(), newUserSignup(),
+			masteruserrecord.NewMasterUserRecord(s.T(), "ted", masteruserrecord.ProvisionedMur(&metav1.Time{Time: time.Now()})), &userTier)
+
+		// when
+		rr := request(controller.NewSignup(application), "ted@kubesaw", `{"justification":"finishing my project"}`)
+
+		// then
+		require.Equal(s.T(), http.StatusOK, rr.Code)
+		extension := &signup.TrialExtension{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), extension))
+		assert.Equal(s.T(), signup.TrialExtensionApproved, extension.State)
+		assert.NotEmpty(s.T(), extension.EndDate)
+	})
+
+	s.Run("missing justification", func() {
+		// given
+		_, application := testutil.PrepareInClusterApp(s.T(), newUserSignup())
+
+		// when
+		rr := request(controller.NewSignup(application), "ted@kubesaw", `{}`)
+
+		// then
+		assert.Equal(s.T(), http.StatusBadRequest, rr.Code)
+	})
+
+	s.Run("signup not found", func() {
+		// given
+		_, application := testutil.PrepareInClusterApp(s.T())
+
+		// when
+		rr := request(controller.NewSignup(application), "ted@kubesaw", `{"justification":"finishing my project"}`)
+
+		// then
+		assert.Equal(s.T(), http.StatusNotFound, rr.Code)
+	})
+
+	s.Run("unexpected error", func() {
+		// given
+		fakeClient, application := testutil.PrepareInClusterApp(s.T(), newUserSignup())
+		fakeClient.MockUpdate = func(_ gocontext.Context, _ client.Object, _ ...client.UpdateOption) error {
+			return errors.New("oopsie woopsie")
+		}
+
+		// when
+		rr := request(controller.NewSignup(application), "ted@kubesaw", `{"justification":"finishing my project"}`)
+
+		// then
+		test.AssertError(s.T(), rr, http.StatusInternalServerError, "oopsie woopsie", "error requesting trial extension")
+	})
+}
@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/codeready-toolchain/registration-service/pkg/context"
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/socialevents"
+	"github.com/gin-gonic/gin"
+)
+
+// PersonalCodesRequest is the payload of a request to generate personal codes
+type PersonalCodesRequest struct {
+	// Count is the number of personal codes to generate
+	Count int `json:"count" binding:"required"`
+}
+
+// RevokedPersonalCodes is the response to a request to revoke personal codes
+type RevokedPersonalCodes struct {
+	// Revoked is the number of revoked personal codes
+	Revoked int `json:"revoked"`
+}
+
+// SocialEvents implements the endpoints of the event organizers to manage their SocialEvents
+type SocialEvents struct {
+	events *socialevents.SocialEvents
+}
+
+// NewSocialEvents returns a new SocialEvents instance.
+func NewSocialEvents(nsClient namespaced.Client) *SocialEvents {
+	return &SocialEvents{
+		events: socialevents.NewSocialEvents(nsClient),
+	}
+}
+
+// PostHandler creates a new event with a generated activation code, owned by the calling organizer
+func (s *SocialEvents) PostHandler(ctx *gin.Context) {
+	settings := socialevents.Settings{}
+	if err := ctx.ShouldBindJSON(&settings); err != nil {
+		log.Error(ctx, err, "error validating event request")
+		crterrors.AbortWithError(ctx, http.StatusBadRequest, err, "error reading request body")
+		return
+	}
+	organizer := ctx.GetString(context.UsernameKey)
+	event, err := s.events.Create(ctx, settings, organizer, ctx.GetBool(context.AdminKey))
+	if err != nil {
+		s.abortWithError(ctx, err, "error creating SocialEvent resource")
+		return
+	}
+	log.Infof(ctx, "SocialEvent '%s' created by organizer '%s'", event.Code, organizer)
+	ctx.JSON(http.StatusCreated, event)
+}
+
+// ListHandler returns the events of the calling organizer, or all the events if the caller is an administrator
+func (s *SocialEvents) ListHandler(ctx *gin.Context) {
+	events, err := s.events.List(ctx, scope(ctx))
+	if err != nil {
+		s.abortWithError(ctx, err, "error listing SocialEvent resources")
+		return
+	}
+	ctx.JSON(http.StatusOK, events)
+}
+
+// GetHandler returns the event with the activation code given in the path
+func (s *SocialEvents) GetHandler(ctx *gin.Context) {
+	event, err := s.events.Get(ctx, ctx.Param("code"), scope(ctx))
+	if err != nil {
+		s.abortWithError(ctx, err, "error retrieving SocialEvent resource")
+		return
+	}
+	ctx.JSON(http.StatusOK, event)
+}
+
+// PatchHandler updates the settings of the event with the activation code given in the path.
+// Only the settings present in the request body are changed.
+func (s *SocialEvents) PatchHandler(ctx *gin.Context) {
+	settings := socialevents.Settings{}
+	if err := ctx.ShouldBindJSON(&settings); err != nil {
+		log.Error(ctx, err, "error validating event request")
+		crterrors.AbortWithError(ctx, http.StatusBadRequest, err, "error reading request body")
+		return
+	}
+	event, err := s.events.Update(ctx, ctx.Param("code"), settings, scope(ctx))
+	if err != nil {
+		s.abortWithError(ctx, err, "error updating SocialEvent resource")
+		return
+	}
+	log.Infof(ctx, "SocialEvent '%s' updated by '%s'", event.Code, ctx.GetString(context.UsernameKey))
+	ctx.JSON(http.StatusOK, event)
+}
+
+// CloseHandler ends the event with the activation code given in the path, so that the code can no longer be activated
+func (s *SocialEvents) CloseHandler(ctx *gin.Context) {
+	event, err := s.events.Close(ctx, ctx.Param("code"), scope(ctx))
+	if err != nil {
+		s.abortWithError(ctx, err, "error updating SocialEvent resource")
+		return
+	}
+	log.Infof(ctx, "SocialEvent '%s' closed by '%s'", event.Code, ctx.GetString(context.UsernameKey))
+	ctx.JSON(http.StatusOK, event)
+}
+
+// AttendeesHandler returns the users who joined through the event with the activation code given in the path
+func (s *SocialEvents) AttendeesHandler(ctx *gin.Context) {
+	attendees, err := s.events.Attendees(ctx, ctx.Param("code"), scope(ctx))
+	if err != nil {
+		s.abortWithError(ctx, err, "error listing UserSignup resources")
+		return
+	}
+	ctx.JSON(http.StatusOK, attendees)
+}
+
+// PostPersonalCodesHandler generates single-use personal codes for the event with the activation code given in the path.
+// The codes are only returned in this response, since only their hashes are stored.
+func (s *SocialEvents) PostPersonalCodesHandler(ctx *gin.Context) {
+	req := PersonalCodesRequest{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error(ctx, err, "error validating personal codes request")
+		crterrors.AbortWithError(ctx, http.StatusBadRequest, err, "error reading request body")
+		return
+	}
+	code := ctx.Param("code")
+	codes, err := s.events.GeneratePersonalCodes(ctx, code, req.Count, scope(ctx))
+	if err != nil {
+		s.abortWithError(ctx, err, "error updating SocialEvent resource")
+		return
+	}
+	log.Infof(ctx, "%s personal codes generated for SocialEvent '%s' by '%s'", strconv.Itoa(len(codes)), code, ctx.GetString(context.UsernameKey))
+	ctx.JSON(http.StatusCreated, codes)
+}
+
+// PersonalCodesUsageHandler lists the usage of the personal codes of the event with the activation code given in the
+// path: their IDs along with the UserSignups which used them. The codes themselves are only returned when they are
+// generated.
+func (s *SocialEvents) PersonalCodesUsageHandler(ctx *gin.Context) {
+	codes, err := s.events.PersonalCodesUsage(ctx, ctx.Param("code"), scope(ctx))
+	if err != nil {
+		s.abortWithError(ctx, err, "error retrieving SocialEvent resource")
+		return
+	}
+	ctx.JSON(http.StatusOK, codes)
+}
+
+// DeletePersonalCodesHandler revokes the unused personal code whose ID is given in the path, or all the unused
+// personal codes of the event if there is no ID in the path
+func (s *SocialEvents) DeletePersonalCodesHandler(ctx *gin.Context) {
+	code := ctx.Param("code")
+	var ids []string
+	if id := ctx.Param("id"); id != "" {
+		ids = []string{id}
+	}
+	revoked, err := s.events.RevokePersonalCodes(ctx, code, ids, scope(ctx))
+	if err != nil {
+		s.abortWithError(ctx, err, "error updating SocialEvent resource")
+		return
+	}
+	log.Infof(ctx, "%s personal codes of SocialEvent '%s' revoked by '%s'", strconv.Itoa(revoked), code, ctx.GetString(context.UsernameKey))
+	ctx.JSON(http.StatusOK, RevokedPersonalCodes{Revoked: revoked})
+}
+
+func (s *SocialEvents) abortWithError(ctx *gin.Context, err error, details string) {
+	switch {
+	case errors.Is(err, socialevents.ErrNotFound), errors.Is(err, socialevents.ErrPersonalCodeNotFound):
+		crterrors.AbortWithError(ctx, http.StatusNotFound, err, "")
+	case errors.Is(err, socialevents.ErrPersonalCodeUsed):
+		crterrors.AbortWithError(ctx, http.StatusConflict, err, "")
+	case errors.Is(err, socialevents.ErrInvalidEvent):
+		crterrors.AbortWithError(ctx, http.StatusBadRequest, err, "")
+	case errors.Is(err, socialevents.ErrForbiddenSetting):
+		crterrors.AbortWithError(ctx, http.StatusForbidden, err, "")
+	default:
+		log.Error(ctx, err, details)
+		crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, details)
+	}
+}
+
+// scope returns the organizer whose events can be accessed by the caller: the caller themselves,
+// or any organizer if the caller is an administrator
+func scope(ctx *gin.Context) string {
+	if ctx.GetBool(context.AdminKey) {
+		return ""
+	}
+	return ctx.GetString(context.UsernameKey)
+}
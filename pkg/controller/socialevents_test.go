@@ -0,0 +1,267 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	rcontext "github.com/codeready-toolchain/registration-service/pkg/context"
+	"github.com/codeready-toolchain/registration-service/pkg/controller"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/socialevents"
+	"github.com/codeready-toolchain/registration-service/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/hash"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type TestSocialEventsSuite struct {
+	test.UnitTestSuite
+}
+
+func TestRunSocialEventsSuite(t *testing.T) {
+	suite.Run(t, &TestSocialEventsSuite{test.UnitTestSuite{}})
+}
+
+func (s *TestSocialEventsSuite) TestHandlers() {
+	// given
+	now := time.Now()
+	fakeClient := commontest.NewFakeClient(s.T(), &toolchainv1alpha1.SocialEvent{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "abcdef",
+			Namespace:   commontest.HostOperatorNs,
+			Labels:      map[string]string{socialevents.OrganizerHashLabelKey: hash.EncodeString("jane")},
+			Annotations: map[string]string{socialevents.OrganizerAnnotationKey: "jane"},
+		},
+		Spec: toolchainv1alpha1.SocialEventSpec{
+			StartTime:    metav1.NewTime(now.Add(-time.Hour)),
+			EndTime:      metav1.NewTime(now.Add(time.Hour)),
+			MaxAttendees: 10,
+			UserTier:     "deactivate30",
+			SpaceTier:    "base1ns",
+		},
+	})
+	ctrl := controller.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+	call := func(handler gin.HandlerFunc, method, code, body, username string, admin bool) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, "/api/v1/social-events/"+code, bytes.NewBufferString(body))
+		require.NoError(s.T(), err)
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = req
+		ctx.Params = gin.Params{{Key: "code", Value: code}}
+		ctx.Set(rcontext.UsernameKey, username)
+		ctx.Set(rcontext.AdminKey, admin)
+		handler(ctx)
+		return rr
+	}
+
+	s.Run("create", func() {
+		// when
+		rr := call(ctrl.PostHandler, http.MethodPost, "", `{"endTime":"`+now.Add(time.Hour).Format(time.RFC3339)+`","maxAttendees":25}`, "johnny", false)
+
+		// then
+		require.Equal(s.T(), http.StatusCreated, rr.Code)
+		event := &socialevents.Event{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), event))
+		assert.Equal(s.T(), "johnny", event.Organizer)
+		assert.Equal(s.T(), 25, event.MaxAttendees)
+
+		s.Run("list own events", func() {
+			// when
+			rr := call(ctrl.ListHandler, http.MethodGet, "", "", "johnny", false)
+
+			// then
+			require.Equal(s.T(), http.StatusOK, rr.Code)
+			events := []socialevents.Event{}
+			require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), &events))
+			require.Len(s.T(), events, 1)
+			assert.Equal(s.T(), event.Code, events[0].Code)
+		})
+
+		s.Run("admin lists all events", func() {
+			// when
+			rr := call(ctrl.ListHandler, http.MethodGet, "", "", "admin", true)
+
+			// then
+			require.Equal(s.T(), http.StatusOK, rr.Code)
+			events := []socialevents.Event{}
+			require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), &events))
+			assert.Len(s.T(), events, 2)
+		})
+	})
+
+	s.Run("create invalid event", func() {
+		// when
+		rr := call(ctrl.PostHandler, http.MethodPost, "", `{"maxAttendees":25}`, "johnny", false)
+
+		// then
+		test.AssertError(s.T(), rr, http.StatusBadRequest, "invalid event: the end time is required", "")
+	})
+
+	s.Run("organizers cannot set the tiers", func() {
+		// when
+		rr := call(ctrl.PostHandler, http.MethodPost, "", `{"endTime":"`+now.Add(time.Hour).Format(time.RFC3339)+`","maxAttendees":25,"userTier":"deactivate80"}`, "johnny", false)
+
+		// then
+		test.AssertError(s.T(), rr, http.StatusForbidden, socialevents.ErrForbiddenSetting.Error(), "")
+	})
+
+	s.Run("get", func() {
+		// when
+		rr := call(ctrl.GetHandler, http.MethodGet, "abcdef", "", "jane", false)
+
+		// then
+		require.Equal(s.T(), http.StatusOK, rr.Code)
+	})
+
+	s.Run("get event of another organizer", func() {
+		// when
+		rr := call(ctrl.GetHandler, http.MethodGet, "abcdef", "", "johnny", false)
+
+		// then
+		test.AssertError(s.T(), rr, http.StatusNotFound, "SocialEvent not found", "")
+	})
+
+	s.Run("update", func() {
+		// when
+		rr := call(ctrl.PatchHandler, http.MethodPatch, "abcdef", `{"description":"Workshop"}`, "jane", false)
+
+		// then
+		require.Equal(s.T(), http.StatusOK, rr.Code)
+		event := &socialevents.Event{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), event))
+		assert.Equal(s.T(), "Workshop", event.Description)
+		assert.Equal(s.T(), 10, event.MaxAttendees)
+	})
+
+	s.Run("attendees", func() {
+		// when
+		rr := call(ctrl.AttendeesHandler, http.MethodGet, "abcdef", "", "admin", true)
+
+		// then
+		require.Equal(s.T(), http.StatusOK, rr.Code)
+		assert.JSONEq(s.T(), `[]`, rr.Body.String())
+	})
+
+	s.Run("close", func() {
+		// when
+		rr := call(ctrl.CloseHandler, http.MethodPost, "abcdef", "", "jane", false)
+
+		// then
+		require.Equal(s.T(), http.StatusOK, rr.Code)
+		event := &socialevents.Event{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), event))
+		assert.True(s.T(), event.Closed)
+	})
+}
+
+func (s *TestSocialEventsSuite) TestPersonalCodesHandlers() {
+	// given
+	now := time.Now()
+	fakeClient := commontest.NewFakeClient(s.T(), &toolchainv1alpha1.SocialEvent{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "abcdef",
+			Namespace:   commontest.HostOperatorNs,
+			Labels:      map[string]string{socialevents.OrganizerHashLabelKey: hash.EncodeString("jane")},
+			Annotations: map[string]string{socialevents.OrganizerAnnotationKey: "jane"},
+		},
+		Spec: toolchainv1alpha1.SocialEventSpec{
+			StartTime:    metav1.NewTime(now.Add(-time.Hour)),
+			EndTime:      metav1.NewTime(now.Add(time.Hour)),
+			MaxAttendees: 10,
+			UserTier:     "deactivate30",
+			SpaceTier:    "base1ns",
+		},
+	})
+	ctrl := controller.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+	call := func(handler gin.HandlerFunc, method, id, body, username string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, "/api/v1/social-events/abcdef/personal-codes/"+id, bytes.NewBufferString(body))
+		require.NoError(s.T(), err)
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = req
+		ctx.Params = gin.Params{{Key: "code", Value: "abcdef"}, {Key: "id", Value: id}}
+		ctx.Set(rcontext.UsernameKey, username)
+		ctx.Set(rcontext.AdminKey, false)
+		handler(ctx)
+		return rr
+	}
+
+	s.Run("generate", func() {
+		// when
+		rr := call(ctrl.PostPersonalCodesHandler, http.MethodPost, "", `{"count":3}`, "jane")
+
+		// then
+		require.Equal(s.T(), http.StatusCreated, rr.Code)
+		generated := []socialevents.PersonalCode{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), &generated))
+		require.Len(s.T(), generated, 3)
+		assert.NotEmpty(s.T(), generated[0].Code)
+
+		s.Run("list", func() {
+			// when
+			rr := call(ctrl.PersonalCodesUsageHandler, http.MethodGet, "", "", "jane")
+
+			// then
+			require.Equal(s.T(), http.StatusOK, rr.Code)
+			codes := []socialevents.PersonalCode{}
+			require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), &codes))
+			require.Len(s.T(), codes, 3)
+			assert.Empty(s.T(), codes[0].Code)
+		})
+
+		s.Run("revoke a single code", func() {
+			// when
+			rr := call(ctrl.DeletePersonalCodesHandler, http.MethodDelete, generated[0].ID, "", "jane")
+
+			// then
+			require.Equal(s.T(), http.StatusOK, rr.Code)
+			assert.JSONEq(s.T(), `{"revoked":1}`, rr.Body.String())
+		})
+
+		s.Run("revoke an unknown code", func() {
+			// when
+			rr := call(ctrl.DeletePersonalCodesHandler, http.MethodDelete, generated[0].ID, "", "jane")
+
+			// then
+			require.Equal(s.T(), http.StatusNotFound, rr.Code)
+		})
+
+		s.Run("revoke all the unused codes", func() {
+			// when
+			rr := call(ctrl.DeletePersonalCodesHandler, http.MethodDelete, "", "", "jane")
+
+			// then
+			require.Equal(s.T(), http.StatusOK, rr.Code)
+			assert.JSONEq(s.T(), `{"revoked":2}`, rr.Body.String())
+		})
+	})
+
+	s.Run("generate without count", func() {
+		// when
+		rr := call(ctrl.PostPersonalCodesHandler, http.MethodPost, "", `{}`, "jane")
+
+		// then
+		require.Equal(s.T(), http.StatusBadRequest, rr.Code)
+	})
+
+	s.Run("generate for an event of another organizer", func() {
+		// when
+		rr := call(ctrl.PostPersonalCodesHandler, http.MethodPost, "", `{"count":3}`, "johnny")
+
+		// then
+		test.AssertError(s.T(), rr, http.StatusNotFound, "SocialEvent not found", "")
+	})
+}
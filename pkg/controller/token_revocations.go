@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	"github.com/codeready-toolchain/registration-service/pkg/context"
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/revocation"
+	"github.com/gin-gonic/gin"
+)
+
+// TokenRevocationRequest is the payload of a token revocation request.
+// Exactly one of TokenID or Subject must be provided.
+type TokenRevocationRequest struct {
+	// TokenID is the `jti` claim of the token to revoke
+	TokenID string `json:"jti"`
+	// ExpiresAt is the expiry time (the `exp` claim) of the token to revoke, after which the revocation is deleted.
+	// Defaults to now plus the configured TTL of the revoked tokens.
+	ExpiresAt *time.Time `json:"expiresAt"`
+	// Subject is the `sub` claim of the tokens to revoke
+	Subject string `json:"sub"`
+	// IssuedBefore is the time before which all the tokens of the Subject are revoked. Defaults to now.
+	IssuedBefore *time.Time `json:"issuedBefore"`
+	// Reason is the reason of the revocation
+	Reason string `json:"reason"`
+}
+
+// TokenRevocations implements the admin endpoints to manage the token revocations
+type TokenRevocations struct {
+	revoker *revocation.Revoker
+}
+
+// NewTokenRevocations returns a new TokenRevocations instance.
+func NewTokenRevocations(nsClient namespaced.Client) *TokenRevocations {
+	return &TokenRevocations{
+		revoker: revocation.NewRevoker(nsClient),
+	}
+}
+
+// PostHandler revokes a single token or all the tokens of a subject issued before a given time
+func (t *TokenRevocations) PostHandler(ctx *gin.Context) {
+	req := TokenRevocationRequest{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error(ctx, err, "error validating token revocation request")
+		crterrors.AbortWithError(ctx, http.StatusBadRequest, err, "error reading request body")
+		return
+	}
+	if (req.TokenID == "") == (req.Subject == "") {
+		crterrors.AbortWithError(ctx, http.StatusBadRequest, errors.New("invalid token revocation request"), "exactly one of 'jti' or 'sub' must be provided")
+		return
+	}
+	if req.Subject != "" && req.ExpiresAt != nil {
+		crterrors.AbortWithError(ctx, http.StatusBadRequest, errors.New("invalid token revocation request"), "'expiresAt' only applies to the revocation of a 'jti'")
+		return
+	}
+	revokedBy := ctx.GetString(context.UsernameKey)
+
+	var r *revocation.Revocation
+	var err error
+	if req.TokenID != "" {
+		expiresAt := time.Now().Add(configuration.GetRegistrationServiceConfig().Auth().RevokedTokenTTL())
+		if req.ExpiresAt != nil {
+			expiresAt = *req.ExpiresAt
+		}
+		r, err = t.revoker.RevokeToken(ctx.Request.Context(), req.TokenID, expiresAt, req.Reason, revokedBy)
+	} else {
+		issuedBefore := time.Now()
+		if req.IssuedBefore != nil {
+			issuedBefore = *req.IssuedBefore
+		}
+		r, err = t.revoker.RevokeSubject(ctx.Request.Context(), req.Subject, issuedBefore, req.Reason, revokedBy)
+	}
+	if err != nil {
+		log.Error(ctx, err, "error revoking token")
+		crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, "error revoking token")
+		return
+	}
+	log.Infof(ctx, "token revocation '%s' created by '%s' (jti: '%s', sub: '%s', reason: '%s')", r.Name, revokedBy, r.TokenID, r.Subject, r.Reason)
+	ctx.JSON(http.StatusCreated, r)
+}
+
+// ListHandler returns all the token revocations
+func (t *TokenRevocations) ListHandler(ctx *gin.Context) {
+	revocations, err := t.revoker.List(ctx.Request.Context())
+	if err != nil {
+		log.Error(ctx, err, "error listing token revocations")
+		crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, "error listing token revocations")
+		return
+	}
+	ctx.JSON(http.StatusOK, revocations)
+}
+
+// DeleteHandler deletes the token revocation with the name given in the path
+func (t *TokenRevocations) DeleteHandler(ctx *gin.Context) {
+	name := ctx.Param("name")
+	if err := t.revoker.Delete(ctx.Request.Context(), name); err != nil {
+		if errors.Is(err, revocation.ErrNotFound) {
+			crterrors.AbortWithError(ctx, http.StatusNotFound, err, "")
+			return
+		}
+		log.Error(ctx, err, "error deleting token revocation")
+		crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, "error deleting token revocation")
+		return
+	}
+	log.Infof(ctx, "token revocation '%s' deleted by '%s'", name, ctx.GetString(context.UsernameKey))
+	ctx.Status(http.StatusNoContent)
+	ctx.Writer.WriteHeaderNow()
+}
@@ -0,0 +1,177 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	rcontext "github.com/codeready-toolchain/registration-service/pkg/context"
+	"github.com/codeready-toolchain/registration-service/pkg/controller"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/revocation"
+	"github.com/codeready-toolchain/registration-service/test"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type TestTokenRevocationsSuite struct {
+	test.UnitTestSuite
+}
+
+func TestRunTokenRevocationsSuite(t *testing.T) {
+	suite.Run(t, &TestTokenRevocationsSuite{test.UnitTestSuite{}})
+}
+
+func (s *TestTokenRevocationsSuite) TestPostHandler() {
+	fakeClient := commontest.NewFakeClient(s.T())
+	ctrl := controller.NewTokenRevocations(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+	handler := gin.HandlerFunc(ctrl.PostHandler)
+
+	s.Run("revoke by jti", func() {
+		// when
+		rr := s.serve(handler, http.MethodPost, `{"jti":"jti-1","reason":"leaked"}`)
+
+		// then
+		require.Equal(s.T(), http.StatusCreated, rr.Code)
+		r := &revocation.Revocation{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), r))
+		assert.Equal(s.T(), "jti-1", r.TokenID)
+		assert.Equal(s.T(), "leaked", r.Reason)
+		assert.Equal(s.T(), "admin", r.RevokedBy)
+		require.NotNil(s.T(), r.ExpiresAt)
+		assert.WithinDuration(s.T(), time.Now().Add(24*time.Hour), *r.ExpiresAt, 5*time.Second)
+	})
+
+	s.Run("revoke by jti with explicit expiry", func() {
+		// when
+		rr := s.serve(handler, http.MethodPost, `{"jti":"jti-2","expiresAt":"2124-01-02T03:04:05Z"}`)
+
+		// then
+		require.Equal(s.T(), http.StatusCreated, rr.Code)
+		r := &revocation.Revocation{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), r))
+		require.NotNil(s.T(), r.ExpiresAt)
+		assert.Equal(s.T(), time.Date(2124, 1, 2, 3, 4, 5, 0, time.UTC), r.ExpiresAt.UTC())
+	})
+
+	s.Run("revoke by sub with explicit time", func() {
+		// when
+		rr := s.serve(handler, http.MethodPost, `{"sub":"sub-1","issuedBefore":"2024-01-02T03:04:05Z"}`)
+
+		// then
+		require.Equal(s.T(), http.StatusCreated, rr.Code)
+		r := &revocation.Revocation{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), r))
+		assert.Equal(s.T(), "sub-1", r.Subject)
+		require.NotNil(s.T(), r.IssuedBefore)
+		assert.Equal(s.T(), time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), r.IssuedBefore.UTC())
+	})
+
+	s.Run("revoke by sub defaults to now", func() {
+		// when
+		rr := s.serve(handler, http.MethodPost, `{"sub":"sub-2"}`)
+
+		// then
+		require.Equal(s.T(), http.StatusCreated, rr.Code)
+		r := &revocation.Revocation{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), r))
+		require.NotNil(s.T(), r.IssuedBefore)
+		assert.WithinDuration(s.T(), time.Now(), *r.IssuedBefore, 5*time.Second)
+	})
+
+	s.Run("neither jti nor sub", func() {
+		rr := s.serve(handler, http.MethodPost, `{"reason":"leaked"}`)
+		test.AssertError(s.T(), rr, http.StatusBadRequest, "invalid token revocation request", "exactly one of 'jti' or 'sub' must be provided")
+	})
+
+	s.Run("both jti and sub", func() {
+		rr := s.serve(handler, http.MethodPost, `{"jti":"jti-1","sub":"sub-1"}`)
+		test.AssertError(s.T(), rr, http.StatusBadRequest, "invalid token revocation request", "exactly one of 'jti' or 'sub' must be provided")
+	})
+
+	s.Run("expiry with sub", func() {
+		rr := s.serve(handler, http.MethodPost, `{"sub":"sub-1","expiresAt":"2124-01-02T03:04:05Z"}`)
+		test.AssertError(s.T(), rr, http.StatusBadRequest, "invalid token revocation request", "'expiresAt' only applies to the revocation of a 'jti'")
+	})
+
+	s.Run("invalid body", func() {
+		rr := s.serve(handler, http.MethodPost, `{`)
+		assert.Equal(s.T(), http.StatusBadRequest, rr.Code)
+	})
+
+	s.Run("client error", func() {
+		// given
+		fakeClient.MockCreate = func(_ context.Context, _ client.Object, _ ...client.CreateOption) error {
+			return errors.New("mock error")
+		}
+		defer func() { fakeClient.MockCreate = nil }()
+
+		// when
+		rr := s.serve(handler, http.MethodPost, `{"jti":"jti-3"}`)
+
+		// then
+		test.AssertError(s.T(), rr, http.StatusInternalServerError, "mock error", "error revoking token")
+	})
+}
+
+func (s *TestTokenRevocationsSuite) TestListAndDeleteHandlers() {
+	// given
+	fakeClient := commontest.NewFakeClient(s.T())
+	nsClient := namespaced.NewClient(fakeClient, commontest.HostOperatorNs)
+	r, err := revocation.NewRevoker(nsClient).RevokeToken(context.TODO(), "jti-1", time.Now().Add(time.Hour), "leaked", "admin")
+	require.NoError(s.T(), err)
+	ctrl := controller.NewTokenRevocations(nsClient)
+
+	s.Run("list", func() {
+		// when
+		rr := s.serve(ctrl.ListHandler, http.MethodGet, "")
+
+		// then
+		require.Equal(s.T(), http.StatusOK, rr.Code)
+		var revocations []revocation.Revocation
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), &revocations))
+		require.Len(s.T(), revocations, 1)
+		assert.Equal(s.T(), "jti-1", revocations[0].TokenID)
+	})
+
+	s.Run("delete", func() {
+		// when
+		rr := s.serve(ctrl.DeleteHandler, http.MethodDelete, "", gin.Param{Key: "name", Value: r.Name})
+
+		// then
+		require.Equal(s.T(), http.StatusNoContent, rr.Code)
+		revocations, err := revocation.NewRevoker(nsClient).List(context.TODO())
+		require.NoError(s.T(), err)
+		assert.Empty(s.T(), revocations)
+	})
+
+	s.Run("delete not found", func() {
+		// when
+		rr := s.serve(ctrl.DeleteHandler, http.MethodDelete, "", gin.Param{Key: "name", Value: r.Name})
+
+		// then
+		test.AssertError(s.T(), rr, http.StatusNotFound, "token revocation not found", "")
+	})
+}
+
+func (s *TestTokenRevocationsSuite) serve(handler gin.HandlerFunc, method, body string, params ...gin.Param) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(method, "/api/v1/admin/token-revocations", bytes.NewBufferString(body))
+	require.NoError(s.T(), err)
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rr)
+	ctx.Request = req
+	ctx.Params = params
+	ctx.Set(rcontext.UsernameKey, "admin")
+	handler(ctx)
+	return rr
+}
@@ -15,6 +15,18 @@ type UIConfigResponse struct {
 	WorkatoWebHookURL string `json:"workatoWebHookURL"`
 
 	DisabledIntegrations []string `json:"disabledIntegrations"`
+
+	// The tiers which the users can choose when they sign up
+	SelectableTiers []UITier `json:"selectableTiers"`
+}
+
+// UITier is a tier which the users can choose when they sign up. The eligibility rules are not exposed, as the names
+// of the SocialEvents are their activation codes.
+type UITier struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName,omitempty"`
+	// Restricted is true if only some users are eligible to the tier
+	Restricted bool `json:"restricted"`
 }
 
 // UIConfig implements the ui config endpoint, which is invoked to
@@ -34,6 +46,14 @@ func (uic *UIConfig) GetHandler(ctx *gin.Context) {
 		UICanaryDeploymentWeight: cfg.UICanaryDeploymentWeight(),
 		WorkatoWebHookURL:        cfg.WorkatoWebHookURL(),
 		DisabledIntegrations:     cfg.DisabledIntegrations(),
+		SelectableTiers:          []UITier{},
+	}
+	for _, tier := range cfg.Tiers().Selectable() {
+		configRespData.SelectableTiers = append(configRespData.SelectableTiers, UITier{
+			Name:        tier.Name,
+			DisplayName: tier.DisplayName,
+			Restricted:  len(tier.EmailDomains) > 0 || len(tier.SocialEvents) > 0,
+		})
 	}
 	ctx.JSON(http.StatusOK, configRespData)
 }
@@ -72,6 +72,10 @@ func (s *TestUIConfigSuite) TestUIConfigHandler() {
 		s.Run("disabledIntegrations defaults to empty array", func() {
 			assert.Equal(s.T(), []string{}, data.DisabledIntegrations, "disabledIntegrations should be an empty array when not configured")
 		})
+
+		s.Run("selectableTiers defaults to empty array", func() {
+			assert.Equal(s.T(), []UITier{}, data.SelectableTiers, "selectableTiers should be an empty array when not configured")
+		})
 	})
 }
 
@@ -103,3 +107,32 @@ func (s *TestUIConfigSuite) TestUIConfigHandlerWithDisabledIntegrations() {
 
 	assert.Equal(s.T(), integrations, data.DisabledIntegrations, "disabledIntegrations should match configured values")
 }
+
+func (s *TestUIConfigSuite) TestUIConfigHandlerWithSelectableTiers() {
+	req, err := http.NewRequest(http.MethodGet, "/api/v1/uiconfig", nil)
+	require.NoError(s.T(), err)
+	test.SetSettings(s.T(), `
+tiers:
+  selectable:
+  - name: deactivate30
+    displayName: 30 days
+  - name: ai
+    displayName: AI workloads
+    socialEvents: [summit]`)
+
+	rr := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rr)
+	ctx.Request = req
+
+	NewUIConfig().GetHandler(ctx)
+
+	require.Equal(s.T(), http.StatusOK, rr.Code)
+	var data *UIConfigResponse
+	require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), &data))
+	assert.Equal(s.T(), []UITier{
+		{Name: "deactivate30", DisplayName: "30 days"},
+		{Name: "ai", DisplayName: "AI workloads", Restricted: true},
+	}, data.SelectableTiers)
+	// the names of the SocialEvents are activation codes, which must not be disclosed
+	assert.NotContains(s.T(), rr.Body.String(), "summit")
+}
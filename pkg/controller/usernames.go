@@ -1,15 +1,23 @@
 package controller
 
 import (
+	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/configuration"
 	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+	"github.com/codeready-toolchain/registration-service/pkg/indexes"
 	"github.com/codeready-toolchain/registration-service/pkg/log"
 	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/signup"
 	"github.com/codeready-toolchain/registration-service/pkg/username"
+	"github.com/codeready-toolchain/toolchain-common/pkg/hash"
 	"github.com/gin-gonic/gin"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // Usernames implements the usernames endpoint, which is invoked for checking if a given username/email exists.
@@ -25,22 +33,26 @@ func NewUsernames(nsClient namespaced.Client) *Usernames {
 }
 
 // GetHandler returns the list of usernames found, if any.
+// The query string is either the name of a MasterUserRecord or the email address of the users.
 func (s *Usernames) GetHandler(ctx *gin.Context) {
 	queryString := ctx.Param("username")
 	if queryString == "" {
 		log.Info(ctx, "empty username provided")
-		ctx.AbortWithStatus(http.StatusNotFound)
+		crterrors.AbortWithError(ctx, http.StatusNotFound, fmt.Errorf("username not found"), "")
+		return
 	}
 
-	// TODO check if the queryString is an email
-	// in that case we have to fetch the UserSignup resources with the provided email and the MasterUserRecords associated with those.
+	if strings.Contains(queryString, "@") {
+		s.searchByEmail(ctx, queryString)
+		return
+	}
 
 	murResource := &toolchainv1alpha1.MasterUserRecord{}
 	err := s.Get(ctx.Request.Context(), s.NamespacedName(queryString), murResource)
 	// handle not found error
 	if errors.IsNotFound(err) {
 		log.Infof(ctx, "MasterUserRecord resource for: %s not found", queryString)
-		ctx.AbortWithStatus(http.StatusNotFound)
+		crterrors.AbortWithError(ctx, http.StatusNotFound, fmt.Errorf("username not found"), "")
 		return
 	}
 	// ...otherwise is a server error
@@ -50,10 +62,69 @@ func (s *Usernames) GetHandler(ctx *gin.Context) {
 		return
 	}
 
-	// TODO
-	// once we implement search by email the response might contain multiple usernames
-	// for now there can be only one username with a given name.
 	ctx.JSON(http.StatusOK, username.Response{
 		{Username: murResource.GetName()},
 	})
 }
+
+// AvailabilityHandler checks if the username given in the `name` query parameter is available before signing up,
+// and suggests alternative usernames if it is already taken.
+func (s *Usernames) AvailabilityHandler(ctx *gin.Context) {
+	name := strings.TrimSpace(ctx.Query("name"))
+	if name == "" {
+		crterrors.AbortWithError(ctx, http.StatusBadRequest, fmt.Errorf("missing username"), "the 'name' query parameter is required")
+		return
+	}
+	availability, err := signup.CheckUsernameAvailability(ctx, s.Client, name, configuration.GetRegistrationServiceConfig().Usernames().Suggestions())
+	if err != nil {
+		log.Error(ctx, err, "error checking the availability of the username")
+		crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, "error checking the availability of the username")
+		return
+	}
+	ctx.JSON(http.StatusOK, availability)
+}
+
+// searchByEmail returns the usernames of the MasterUserRecords of the users with the given email address.
+// There can be several of them, since the same email address may be used by several accounts of the SSO.
+func (s *Usernames) searchByEmail(ctx *gin.Context, email string) {
+	// retrieve the UserSignups, using the field index of the informer cache
+	userSignups := &toolchainv1alpha1.UserSignupList{}
+	if err := s.List(ctx.Request.Context(), userSignups, client.InNamespace(s.Namespace),
+		client.MatchingFields{indexes.UserSignupEmailHash: hash.EncodeString(email)}); err != nil {
+		log.Error(ctx, err, "error listing UserSignup resources")
+		crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, "error listing UserSignup resources")
+		return
+	}
+
+	usernames := []string{}
+	for _, userSignup := range userSignups.Items {
+		murName := userSignup.Status.CompliantUsername
+		if murName == "" {
+			// the user is not provisioned (yet)
+			continue
+		}
+		murResource := &toolchainv1alpha1.MasterUserRecord{}
+		if err := s.Get(ctx.Request.Context(), s.NamespacedName(murName), murResource); err != nil {
+			if errors.IsNotFound(err) {
+				// the user was deactivated
+				continue
+			}
+			log.Error(ctx, err, "error getting MasterUserRecord resource")
+			crterrors.AbortWithError(ctx, http.StatusInternalServerError, err, "error getting MasterUserRecord resource")
+			return
+		}
+		usernames = append(usernames, murResource.GetName())
+	}
+	if len(usernames) == 0 {
+		log.Info(ctx, "no MasterUserRecord resource found for the given email address")
+		crterrors.AbortWithError(ctx, http.StatusNotFound, fmt.Errorf("username not found"), "")
+		return
+	}
+
+	sort.Strings(usernames)
+	response := username.Response{}
+	for _, name := range usernames {
+		response = append(response, username.Username{Username: name})
+	}
+	ctx.JSON(http.StatusOK, response)
+}
@@ -8,12 +8,17 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	rcontext "github.com/codeready-toolchain/registration-service/pkg/context"
 	"github.com/codeready-toolchain/registration-service/pkg/controller"
 	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
 	"github.com/codeready-toolchain/registration-service/pkg/username"
 	"github.com/codeready-toolchain/registration-service/test"
 	"github.com/codeready-toolchain/registration-service/test/fake"
+	testutil "github.com/codeready-toolchain/registration-service/test/util"
+	"github.com/codeready-toolchain/toolchain-common/pkg/hash"
 	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	testusersignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -78,8 +83,8 @@ func (s *TestUsernamesSuite) TestUsernamesGetHandler() {
 
 			handler(ctx)
 
-			// Check the status code is what we expect.
-			assert.Equal(s.T(), http.StatusNotFound, rr.Code, "handler returned wrong status code")
+			// then
+			test.AssertError(s.T(), rr, http.StatusNotFound, "username not found", "")
 		})
 
 		s.Run("empty query string provided", func() {
@@ -91,8 +96,8 @@ func (s *TestUsernamesSuite) TestUsernamesGetHandler() {
 
 			handler(ctx)
 
-			// Check the status code is what we expect.
-			assert.Equal(s.T(), http.StatusNotFound, rr.Code, "handler returned wrong status code")
+			// then
+			test.AssertError(s.T(), rr, http.StatusNotFound, "username not found", "")
 		})
 
 	})
@@ -122,3 +127,150 @@ func (s *TestUsernamesSuite) TestUsernamesGetHandler() {
 		})
 	})
 }
+
+func (s *TestUsernamesSuite) TestUsernamesGetHandlerByEmail() {
+	newUserSignup := func(name, email, compliantUsername string) *toolchainv1alpha1.UserSignup {
+		return testusersignup.NewUserSignup(
+			testusersignup.WithName(name),
+			testusersignup.WithLabel(toolchainv1alpha1.UserSignupUserEmailHashLabelKey, hash.EncodeString(email)),
+			testusersignup.WithCompliantUsername(compliantUsername),
+		)
+	}
+	fakeClient := testutil.NewFakeClient(s.T(),
+		newUserSignup("johnny", "johnny@kubesaw.io", "johnny"),
+		newUserSignup("johnny-2", "johnny@kubesaw.io", "johnny-2"),
+		newUserSignup("johnny-3", "johnny@kubesaw.io", ""),            // not provisioned
+		newUserSignup("johnny-4", "johnny@kubesaw.io", "deactivated"), // no MUR anymore
+		newUserSignup("jane", "jane@kubesaw.io", "jane"),
+		fake.NewMasterUserRecord("johnny"),
+		fake.NewMasterUserRecord("johnny-2"),
+		fake.NewMasterUserRecord("jane"),
+	)
+
+	getUsernames := func(ctrl *controller.Usernames, caller, query string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/usernames/"+query, nil)
+		require.NoError(s.T(), err)
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = req
+		ctx.Set(rcontext.UsernameKey, caller)
+		ctx.AddParam("username", query)
+		ctrl.GetHandler(ctx)
+		return rr
+	}
+
+	s.Run("usernames found", func() {
+		// given
+		ctrl := controller.NewUsernames(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		rr := getUsernames(ctrl, "ted", "johnny@kubesaw.io")
+
+		// then
+		require.Equal(s.T(), http.StatusOK, rr.Code)
+		data := username.Response{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), &data))
+		assert.Equal(s.T(), username.Response{{Username: "johnny"}, {Username: "johnny-2"}}, data)
+	})
+
+	s.Run("usernames not found", func() {
+		// given
+		ctrl := controller.NewUsernames(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		rr := getUsernames(ctrl, "ted", "unknown@kubesaw.io")
+
+		// then
+		test.AssertError(s.T(), rr, http.StatusNotFound, "username not found", "")
+	})
+
+	s.Run("error listing the UserSignups", func() {
+		// given
+		fakeClient := testutil.NewFakeClient(s.T())
+		fakeClient.MockList = func(_ context.Context, _ client.ObjectList, _ ...client.ListOption) error {
+			return fmt.Errorf("mock error")
+		}
+		ctrl := controller.NewUsernames(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		rr := getUsernames(ctrl, "ted", "johnny@kubesaw.io")
+
+		// then
+		test.AssertError(s.T(), rr, http.StatusInternalServerError, "mock error", "error listing UserSignup resources")
+	})
+}
+
+func (s *TestUsernamesSuite) TestAvailabilityHandler() {
+	fakeClient := commontest.NewFakeClient(s.T(), fake.NewMasterUserRecord("johnny"))
+
+	checkAvailability := func(ctrl *controller.Usernames, query string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/usernames/availability"+query, nil)
+		require.NoError(s.T(), err)
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = req
+		ctx.Set(rcontext.UsernameKey, "ted")
+		ctrl.AvailabilityHandler(ctx)
+		return rr
+	}
+
+	s.Run("available", func() {
+		// given
+		ctrl := controller.NewUsernames(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		rr := checkAvailability(ctrl, "?name=jane")
+
+		// then
+		require.Equal(s.T(), http.StatusOK, rr.Code)
+		availability := &username.Availability{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), availability))
+		assert.Equal(s.T(), &username.Availability{Name: "jane", CompliantUsername: "jane", Available: true}, availability)
+	})
+
+	s.Run("taken", func() {
+		// given
+		test.SetSettings(s.T(), "usernames: {suggestions: 2}")
+		ctrl := controller.NewUsernames(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		rr := checkAvailability(ctrl, "?name=johnny")
+
+		// then
+		require.Equal(s.T(), http.StatusOK, rr.Code)
+		availability := &username.Availability{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), availability))
+		assert.Equal(s.T(), &username.Availability{
+			Name:              "johnny",
+			CompliantUsername: "johnny",
+			Reason:            username.ReasonTaken,
+			Suggestions:       []string{"johnny-2", "johnny-3"},
+		}, availability)
+	})
+
+	s.Run("missing name", func() {
+		// given
+		ctrl := controller.NewUsernames(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		rr := checkAvailability(ctrl, "")
+
+		// then
+		test.AssertError(s.T(), rr, http.StatusBadRequest, "missing username", "the 'name' query parameter is required")
+	})
+
+	s.Run("error", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(s.T())
+		fakeClient.MockGet = func(_ context.Context, _ client.ObjectKey, _ client.Object, _ ...client.GetOption) error {
+			return fmt.Errorf("mock error")
+		}
+		ctrl := controller.NewUsernames(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		rr := checkAvailability(ctrl, "?name=jane")
+
+		// then
+		test.AssertError(s.T(), rr, http.StatusInternalServerError, "mock error", "error checking the availability of the username")
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/codeready-toolchain/registration-service/pkg/context"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -14,8 +16,13 @@ type Error struct {
 	Details string `json:"details"`
 }
 
-// AbortWithError stops the chain, writes the status code and the given error
+// AbortWithError stops the chain, writes the status code and the given error.
+// The error is written as problem details if they are enabled for the request (ie, in the v2 API).
 func AbortWithError(ctx *gin.Context, code int, err error, details string) {
+	if ctx.GetBool(context.ProblemDetailsKey) {
+		AbortWithProblem(ctx, code, err)
+		return
+	}
 	ctx.AbortWithStatusJSON(code, &Error{
 		Status:  http.StatusText(code),
 		Code:    code,
@@ -58,6 +65,15 @@ func NewTooManyRequestsError(message, details string) *Error {
 	}
 }
 
+func NewConflictError(message, details string) *Error {
+	return &Error{
+		Status:  http.StatusText(http.StatusConflict),
+		Code:    http.StatusConflict,
+		Message: message,
+		Details: details,
+	}
+}
+
 func NewInternalError(err error, details string) *Error {
 	return &Error{
 		Status:  http.StatusText(http.StatusInternalServerError),
@@ -0,0 +1,194 @@
+package errors
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemMediaType is the media type of the RFC 7807 problem details
+const ProblemMediaType = "application/problem+json"
+
+// problemTypePrefix is the prefix of the URI identifying the type of a problem, followed by its error code
+const problemTypePrefix = "urn:registration-service:error:"
+
+// ErrorCode is a stable, machine-readable identifier of an error, returned in the problem details of the v2 API
+type ErrorCode string
+
+const (
+	// RequestInvalid is the code of the errors caused by an invalid request, with no more specific code
+	RequestInvalid ErrorCode = "request.invalid"
+	// RequestUnauthenticated is the code of the errors caused by a missing or invalid bearer token
+	RequestUnauthenticated ErrorCode = "request.unauthenticated"
+	// RequestForbidden is the code of the errors caused by a forbidden request, with no more specific code
+	RequestForbidden ErrorCode = "request.forbidden"
+	// RequestRateLimited is the code of the errors caused by too many requests of the caller
+	RequestRateLimited ErrorCode = "request.rate_limited"
+	// ResourceNotFound is the code of the errors caused by a missing resource
+	ResourceNotFound ErrorCode = "resource.not_found"
+	// ResourceConflict is the code of the errors caused by a conflict with the state of a resource
+	ResourceConflict ErrorCode = "resource.conflict"
+	// InternalError is the code of the unexpected errors
+	InternalError ErrorCode = "internal.error"
+
+	// SignupBanned is the code of the errors caused by a banned user
+	SignupBanned ErrorCode = "signup.banned"
+	// SignupRejected is the code of the errors caused by a user whose signup was rejected
+	SignupRejected ErrorCode = "signup.rejected"
+
+	// VerificationNotRequired is the code of the errors caused by a user who does not need to be verified
+	VerificationNotRequired ErrorCode = "verification.not_required"
+	// VerificationRejected is the code of the errors caused by a user who cannot be verified automatically
+	VerificationRejected ErrorCode = "verification.rejected"
+	// VerificationPhoneInUse is the code of the errors caused by a phone number already used by another user
+	VerificationPhoneInUse ErrorCode = "verification.phone_in_use"
+	// VerificationDailyLimit is the code of the errors caused by a user who requested too many verification codes
+	// within 24 hours
+	VerificationDailyLimit ErrorCode = "verification.daily_limit"
+	// VerificationCodeInvalid is the code of the errors caused by a wrong verification code
+	VerificationCodeInvalid ErrorCode = "verification.code_invalid"
+	// VerificationCodeExpired is the code of the errors caused by an expired verification code
+	VerificationCodeExpired ErrorCode = "verification.code_expired"
+	// VerificationTooManyAttempts is the code of the errors caused by a user who made too many attempts
+	// with the same verification code
+	VerificationTooManyAttempts ErrorCode = "verification.too_many_attempts"
+
+	// ActivationCodeInvalid is the code of the errors caused by an unknown or unusable activation code
+	ActivationCodeInvalid ErrorCode = "activation_code.invalid"
+	// ActivationCodeRequired is the code of the errors caused by an event which requires a personal code
+	ActivationCodeRequired ErrorCode = "activation_code.personal_code_required"
+	// ActivationCodeUsed is the code of the errors caused by a personal code which was already used
+	ActivationCodeUsed ErrorCode = "activation_code.used"
+	// ActivationCodeEventFull is the code of the errors caused by an event which has no more capacity
+	ActivationCodeEventFull ErrorCode = "activation_code.event_full"
+	// ActivationCodeNotYetValid is the code of the errors caused by an event which has not started yet
+	ActivationCodeNotYetValid ErrorCode = "activation_code.not_yet_valid"
+	// ActivationCodeExpired is the code of the errors caused by an event which has ended
+	ActivationCodeExpired ErrorCode = "activation_code.expired"
+
+	// TrialExtensionNotAllowed is the code of the errors caused by a trial which cannot be extended
+	TrialExtensionNotAllowed ErrorCode = "trial_extension.not_allowed"
+	// TrialExtensionPending is the code of the errors caused by a trial extension request which is still pending
+	TrialExtensionPending ErrorCode = "trial_extension.pending"
+
+	// IdempotencyKeyReused is the code of the errors caused by an idempotency key reused for another request
+	IdempotencyKeyReused ErrorCode = "idempotency.key_reused"
+)
+
+// Problem is the payload of the error responses of the v2 API, as specified by RFC 7807
+type Problem struct {
+	// Type is a URI identifying the type of the problem, derived from its error code
+	Type string `json:"type"`
+	// Title is the status text of the response
+	Title string `json:"title"`
+	// Status is the status code of the response
+	Status int `json:"status"`
+	// Detail is the human-readable explanation of the problem
+	Detail string `json:"detail,omitempty"`
+	// Instance is the path of the request which caused the problem
+	Instance string `json:"instance,omitempty"`
+	// ErrorCode is the stable, machine-readable identifier of the problem
+	ErrorCode ErrorCode `json:"errorCode"`
+	// RetryAfter is the number of seconds after which the request may succeed, if any
+	RetryAfter *int64 `json:"retryAfter,omitempty"`
+	// AttemptsRemaining is the number of attempts remaining before the caller is blocked, if any
+	AttemptsRemaining *int `json:"attemptsRemaining,omitempty"`
+}
+
+// problemError is an error annotated with the structured fields of its problem details.
+// It does not alter the message of the error, so that the v1 responses are unchanged.
+type problemError struct {
+	err               error
+	errorCode         ErrorCode
+	retryAfter        *time.Duration
+	attemptsRemaining *int
+}
+
+func (e *problemError) Error() string {
+	return e.err.Error()
+}
+
+func (e *problemError) Unwrap() error {
+	return e.err
+}
+
+// WithErrorCode returns the given error, identified by the given code in the problem details
+func WithErrorCode(err error, code ErrorCode) error {
+	return &problemError{err: err, errorCode: code}
+}
+
+// WithRetryAfter returns the given error, with the delay after which the request may succeed in the problem details
+func WithRetryAfter(err error, retryAfter time.Duration) error {
+	return &problemError{err: err, retryAfter: &retryAfter}
+}
+
+// WithAttemptsRemaining returns the given error, with the number of remaining attempts in the problem details
+func WithAttemptsRemaining(err error, attemptsRemaining int) error {
+	return &problemError{err: err, attemptsRemaining: &attemptsRemaining}
+}
+
+// NewProblem returns the problem details of the given error, for a response with the given status code.
+// The error code defaults to a generic one, derived from the status code.
+func NewProblem(code int, err error) *Problem {
+	p := &Problem{
+		Title:  http.StatusText(code),
+		Status: code,
+		Detail: err.Error(),
+	}
+	// the outermost annotations take precedence
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		pe, ok := e.(*problemError)
+		if !ok {
+			continue
+		}
+		if p.ErrorCode == "" {
+			p.ErrorCode = pe.errorCode
+		}
+		if p.RetryAfter == nil && pe.retryAfter != nil {
+			seconds := int64(math.Ceil(pe.retryAfter.Seconds()))
+			p.RetryAfter = &seconds
+		}
+		if p.AttemptsRemaining == nil && pe.attemptsRemaining != nil {
+			attemptsRemaining := *pe.attemptsRemaining
+			p.AttemptsRemaining = &attemptsRemaining
+		}
+	}
+	if p.ErrorCode == "" {
+		p.ErrorCode = defaultErrorCode(code)
+	}
+	p.Type = problemTypePrefix + string(p.ErrorCode)
+	return p
+}
+
+// AbortWithProblem stops the chain, writes the status code and the problem details of the given error
+func AbortWithProblem(ctx *gin.Context, code int, err error) {
+	p := NewProblem(code, err)
+	if ctx.Request != nil {
+		p.Instance = ctx.Request.URL.Path
+	}
+	// gin keeps the content type if it is already set
+	ctx.Header("Content-Type", ProblemMediaType)
+	ctx.AbortWithStatusJSON(code, p)
+}
+
+func defaultErrorCode(code int) ErrorCode {
+	switch {
+	case code == http.StatusUnauthorized:
+		return RequestUnauthenticated
+	case code == http.StatusForbidden:
+		return RequestForbidden
+	case code == http.StatusNotFound:
+		return ResourceNotFound
+	case code == http.StatusConflict:
+		return ResourceConflict
+	case code == http.StatusTooManyRequests:
+		return RequestRateLimited
+	case code >= http.StatusInternalServerError:
+		return InternalError
+	default:
+		return RequestInvalid
+	}
+}
@@ -0,0 +1,114 @@
+package errors_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/codeready-toolchain/registration-service/pkg/context"
+	errs "github.com/codeready-toolchain/registration-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *TestErrorsSuite) TestProblems() {
+	s.Run("generic error code derived from the status code", func() {
+		for code, expected := range map[int]errs.ErrorCode{
+			http.StatusBadRequest:          errs.RequestInvalid,
+			http.StatusUnauthorized:        errs.RequestUnauthenticated,
+			http.StatusForbidden:           errs.RequestForbidden,
+			http.StatusNotFound:            errs.ResourceNotFound,
+			http.StatusConflict:            errs.ResourceConflict,
+			http.StatusTooManyRequests:     errs.RequestRateLimited,
+			http.StatusInternalServerError: errs.InternalError,
+			http.StatusServiceUnavailable:  errs.InternalError,
+		} {
+			// when
+			p := errs.NewProblem(code, errors.New("oops"))
+
+			// then
+			assert.Equal(s.T(), expected, p.ErrorCode)
+			assert.Equal(s.T(), "urn:registration-service:error:"+string(expected), p.Type)
+			assert.Equal(s.T(), http.StatusText(code), p.Title)
+			assert.Equal(s.T(), code, p.Status)
+			assert.Equal(s.T(), "oops", p.Detail)
+			assert.Nil(s.T(), p.RetryAfter)
+			assert.Nil(s.T(), p.AttemptsRemaining)
+		}
+	})
+
+	s.Run("annotated error", func() {
+		// given
+		err := errs.WithErrorCode(errs.NewForbiddenError("invalid code", "the provided code is invalid"), errs.VerificationCodeInvalid)
+		err = errs.WithAttemptsRemaining(err, 2)
+		err = errs.WithRetryAfter(fmt.Errorf("wrapped: %w", err), 1500*time.Millisecond)
+
+		// when
+		p := errs.NewProblem(http.StatusForbidden, err)
+
+		// then
+		assert.Equal(s.T(), errs.VerificationCodeInvalid, p.ErrorCode)
+		assert.Equal(s.T(), "urn:registration-service:error:verification.code_invalid", p.Type)
+		assert.Equal(s.T(), "wrapped: invalid code: the provided code is invalid", p.Detail)
+		require.NotNil(s.T(), p.AttemptsRemaining)
+		assert.Equal(s.T(), 2, *p.AttemptsRemaining)
+		require.NotNil(s.T(), p.RetryAfter)
+		assert.Equal(s.T(), int64(2), *p.RetryAfter) // rounded up
+	})
+
+	s.Run("annotations keep the message and the type of the error", func() {
+		// given
+		err := errs.WithErrorCode(errs.NewForbiddenError("invalid code", "the provided code is invalid"), errs.VerificationCodeInvalid)
+
+		// then
+		assert.Equal(s.T(), "invalid code: the provided code is invalid", err.Error())
+		e := &errs.Error{}
+		require.ErrorAs(s.T(), err, &e)
+		assert.Equal(s.T(), http.StatusForbidden, e.Code)
+	})
+
+	s.Run("abort with problem", func() {
+		// given
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/api/v2/signup", nil)
+		ctx.Set(context.ProblemDetailsKey, true)
+
+		// when
+		errs.AbortWithError(ctx, http.StatusForbidden, errs.WithErrorCode(errors.New("banned"), errs.SignupBanned), "error getting UserSignup resource")
+
+		// then
+		assert.True(s.T(), ctx.IsAborted())
+		assert.Equal(s.T(), http.StatusForbidden, rr.Code)
+		assert.Equal(s.T(), errs.ProblemMediaType, rr.Header().Get("Content-Type"))
+		p := map[string]interface{}{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), &p))
+		assert.Equal(s.T(), map[string]interface{}{
+			"type":      "urn:registration-service:error:signup.banned",
+			"title":     "Forbidden",
+			"status":    float64(http.StatusForbidden),
+			"detail":    "banned",
+			"instance":  "/api/v2/signup",
+			"errorCode": "signup.banned",
+		}, p)
+	})
+
+	s.Run("annotations are not returned without problem details", func() {
+		// given
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+
+		// when
+		errs.AbortWithError(ctx, http.StatusForbidden, errs.WithErrorCode(errors.New("banned"), errs.SignupBanned), "error getting UserSignup resource")
+
+		// then
+		assert.Equal(s.T(), http.StatusForbidden, rr.Code)
+		assert.Equal(s.T(), "application/json; charset=utf-8", rr.Header().Get("Content-Type"))
+		assert.JSONEq(s.T(), `{"status":"Forbidden","code":403,"message":"banned","details":"error getting UserSignup resource"}`, rr.Body.String())
+	})
+}
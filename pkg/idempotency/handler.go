@@ -0,0 +1,171 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/codeready-toolchain/registration-service/pkg/context"
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// Header is the header of the requests holding the idempotency key chosen by the client
+	Header = "Idempotency-Key"
+	// ReplayedHeader is the header set to `true` in the responses which replay a recorded outcome
+	ReplayedHeader = "Idempotent-Replayed"
+	// maxKeyLength is the maximum length of the idempotency keys
+	maxKeyLength = 255
+)
+
+// Handler returns a handler which replays the recorded outcome of the requests repeated by the same user with the
+// same idempotency key, instead of passing them to the next handlers. A request with the same key as a request still
+// in progress waits for its outcome. The requests without key are left untouched, as well as the responses with a
+// status code telling the client that it may retry (ie, 429 Too Many Requests and the server errors). A request with
+// a new key is rejected with 429 Too Many Requests when the user or all the users already have too many recorded keys.
+// The handler requires the context to contain the username, so it needs to be executed after the JWTMiddleware.
+func (s *Store) Handler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(Header)
+		if key == "" || s.ttl <= 0 {
+			return
+		}
+		if len(key) > maxKeyLength {
+			crterrors.AbortWithError(ctx, http.StatusBadRequest, errors.New("invalid idempotency key"),
+				"the "+Header+" header must not be longer than 255 characters")
+			return
+		}
+		fingerprint, err := fingerprintOf(ctx)
+		if err != nil {
+			log.Error(ctx, err, "error reading request body")
+			crterrors.AbortWithError(ctx, http.StatusBadRequest, err, "error reading request body")
+			return
+		}
+		username := ctx.GetString(context.UsernameKey)
+
+		for {
+			e, first, err := s.acquire(username, key)
+			if err != nil {
+				log.Error(ctx, err, "cannot record the idempotency key")
+				crterrors.AbortWithError(ctx, http.StatusTooManyRequests, err,
+					"too many requests with an "+Header+" header, please retry later")
+				return
+			}
+			if first {
+				s.run(ctx, username, key, fingerprint, e)
+				return
+			}
+			select {
+			case <-e.done:
+			case <-ctx.Request.Context().Done():
+				ctx.Abort()
+				return
+			}
+			if e.outcome != nil {
+				replay(ctx, e.outcome, fingerprint)
+				return
+			}
+			// the first request had no outcome to replay, so the request may run again
+		}
+	}
+}
+
+// run passes the request to the next handlers, unless an outcome is found in the persistence, and records the outcome
+func (s *Store) run(ctx *gin.Context, username, key, fingerprint string, e *entry) {
+	var outcome *Outcome
+	defer func() {
+		// the waiting requests are released even if the next handlers panic
+		s.complete(username, key, e, outcome)
+	}()
+
+	if s.persistence != nil {
+		persisted, err := s.persistence.Load(ctx, username, key)
+		if err != nil {
+			log.Error(ctx, err, "error loading the outcome of the idempotency key")
+		} else if persisted != nil && s.now().Before(persisted.ExpiresAt) {
+			outcome = persisted
+			replay(ctx, outcome, fingerprint)
+			return
+		}
+	}
+
+	recorder := &responseRecorder{ResponseWriter: ctx.Writer}
+	ctx.Writer = recorder
+	ctx.Next()
+
+	status := recorder.Status()
+	if status == http.StatusTooManyRequests || status >= http.StatusInternalServerError {
+		return
+	}
+	outcome = &Outcome{
+		Fingerprint: fingerprint,
+		Status:      status,
+		ContentType: recorder.Header().Get("Content-Type"),
+		Body:        recorder.body.String(),
+		ExpiresAt:   s.now().Add(s.ttl),
+	}
+	if s.persistence != nil {
+		if err := s.persistence.Save(ctx, username, key, *outcome); err != nil {
+			// the outcome is still replayed by this instance of the service
+			log.Error(ctx, err, "error saving the outcome of the idempotency key")
+		}
+	}
+}
+
+// replay writes the given outcome as the response of the request, unless the request differs from the one which
+// produced the outcome
+func replay(ctx *gin.Context, outcome *Outcome, fingerprint string) {
+	if outcome.Fingerprint != fingerprint {
+		crterrors.AbortWithError(ctx, http.StatusUnprocessableEntity,
+			crterrors.WithErrorCode(errors.New("idempotency key reused"), crterrors.IdempotencyKeyReused),
+			"the "+Header+" header was already used for another request")
+		return
+	}
+	log.Infof(ctx, "replaying the outcome of the idempotency key")
+	ctx.Header(ReplayedHeader, "true")
+	if outcome.Body == "" {
+		ctx.Status(outcome.Status)
+		ctx.Writer.WriteHeaderNow()
+		ctx.Abort()
+		return
+	}
+	ctx.Data(outcome.Status, outcome.ContentType, []byte(outcome.Body))
+	ctx.Abort()
+}
+
+// fingerprintOf returns the fingerprint of the request, made of its method, route and body.
+// The body is restored, so that it can be read again by the next handlers.
+func fingerprintOf(ctx *gin.Context) (string, error) {
+	var body []byte
+	if ctx.Request.Body != nil {
+		var err error
+		if body, err = io.ReadAll(ctx.Request.Body); err != nil {
+			return "", err
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	hash := sha256.Sum256(body)
+	return ctx.Request.Method + " " + ctx.FullPath() + " " + hex.EncodeToString(hash[:]), nil
+}
+
+// responseRecorder keeps a copy of the body written in the response
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
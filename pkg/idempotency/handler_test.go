@@ -0,0 +1,323 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	rcontext "github.com/codeready-toolchain/registration-service/pkg/context"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePersistence keeps the outcomes in a map
+type fakePersistence struct {
+	mu       sync.Mutex
+	outcomes map[string]Outcome
+}
+
+func (p *fakePersistence) Load(_ context.Context, username, key string) (*Outcome, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if outcome, found := p.outcomes[username+"/"+key]; found {
+		return &outcome, nil
+	}
+	return nil, nil
+}
+
+func (p *fakePersistence) Save(_ context.Context, username, key string, outcome Outcome) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.outcomes[username+"/"+key] = outcome
+	return nil
+}
+
+func TestHandler(t *testing.T) {
+	log.Init("idempotency-testing")
+	gin.SetMode(gin.TestMode)
+
+	// returns a store whose clock is controlled by the test
+	newStore := func(ttl time.Duration, persistence Persistence) (*Store, *time.Time) {
+		now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		s := NewStore(ttl, persistence)
+		s.now = func() time.Time { return now }
+		return s, &now
+	}
+
+	// returns a router whose handler responds with the given status, and counts its calls
+	newRouter := func(s *Store, status int) (*gin.Engine, *int) {
+		calls := 0
+		router := gin.New()
+		router.Use(func(ctx *gin.Context) {
+			ctx.Set(rcontext.UsernameKey, ctx.GetHeader("X-Username"))
+		})
+		router.POST("/api/v1/signup", s.Handler(), func(ctx *gin.Context) {
+			calls++
+			ctx.JSON(status, gin.H{"call": calls})
+		})
+		return router, &calls
+	}
+
+	send := func(router *gin.Engine, username, key, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/signup", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		req.Header.Set("X-Username", username)
+		if key != "" {
+			req.Header.Set(Header, key)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("requests without key are not replayed", func(t *testing.T) {
+		// given
+		s, _ := newStore(time.Hour, nil)
+		router, calls := newRouter(s, http.StatusAccepted)
+
+		// when
+		send(router, "johnny", "", "")
+		rr := send(router, "johnny", "", "")
+
+		// then
+		assert.Equal(t, 2, *calls)
+		assert.JSONEq(t, `{"call":2}`, rr.Body.String())
+		assert.Empty(t, rr.Header().Get(ReplayedHeader))
+	})
+
+	t.Run("request with the same key is replayed", func(t *testing.T) {
+		// given
+		s, _ := newStore(time.Hour, nil)
+		router, calls := newRouter(s, http.StatusAccepted)
+		first := send(router, "johnny", "key-1", `{"foo":"bar"}`)
+
+		// when
+		rr := send(router, "johnny", "key-1", `{"foo":"bar"}`)
+
+		// then
+		assert.Equal(t, 1, *calls)
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		assert.Equal(t, first.Body.String(), rr.Body.String())
+		assert.Equal(t, first.Header().Get("Content-Type"), rr.Header().Get("Content-Type"))
+		assert.Equal(t, "true", rr.Header().Get(ReplayedHeader))
+	})
+
+	t.Run("requests with other keys or from other users are not replayed", func(t *testing.T) {
+		// given
+		s, _ := newStore(time.Hour, nil)
+		router, calls := newRouter(s, http.StatusAccepted)
+		send(router, "johnny", "key-1", "")
+
+		// when
+		send(router, "johnny", "key-2", "")
+		send(router, "jane", "key-1", "")
+
+		// then
+		assert.Equal(t, 3, *calls)
+	})
+
+	t.Run("key reused for another request", func(t *testing.T) {
+		// given
+		s, _ := newStore(time.Hour, nil)
+		router, calls := newRouter(s, http.StatusAccepted)
+		send(router, "johnny", "key-1", `{"foo":"bar"}`)
+
+		// when
+		rr := send(router, "johnny", "key-1", `{"foo":"baz"}`)
+
+		// then
+		assert.Equal(t, 1, *calls)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Contains(t, rr.Body.String(), "idempotency key reused")
+	})
+
+	t.Run("outcome is not replayed after the TTL", func(t *testing.T) {
+		// given
+		s, now := newStore(time.Hour, nil)
+		router, calls := newRouter(s, http.StatusAccepted)
+		send(router, "johnny", "key-1", "")
+		*now = now.Add(time.Hour)
+
+		// when
+		rr := send(router, "johnny", "key-1", "")
+
+		// then
+		assert.Equal(t, 2, *calls)
+		assert.Empty(t, rr.Header().Get(ReplayedHeader))
+	})
+
+	t.Run("retryable outcomes are not replayed", func(t *testing.T) {
+		for _, status := range []int{http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable} {
+			t.Run(http.StatusText(status), func(t *testing.T) {
+				// given
+				s, _ := newStore(time.Hour, nil)
+				router, calls := newRouter(s, status)
+				send(router, "johnny", "key-1", "")
+
+				// when
+				rr := send(router, "johnny", "key-1", "")
+
+				// then
+				assert.Equal(t, 2, *calls)
+				assert.Equal(t, status, rr.Code)
+				assert.Empty(t, rr.Header().Get(ReplayedHeader))
+			})
+		}
+	})
+
+	t.Run("client errors are replayed", func(t *testing.T) {
+		// given
+		s, _ := newStore(time.Hour, nil)
+		router, calls := newRouter(s, http.StatusForbidden)
+		send(router, "johnny", "key-1", "")
+
+		// when
+		rr := send(router, "johnny", "key-1", "")
+
+		// then
+		assert.Equal(t, 1, *calls)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Equal(t, "true", rr.Header().Get(ReplayedHeader))
+	})
+
+	t.Run("key too long", func(t *testing.T) {
+		// given
+		s, _ := newStore(time.Hour, nil)
+		router, calls := newRouter(s, http.StatusAccepted)
+
+		// when
+		rr := send(router, "johnny", strings.Repeat("k", 256), "")
+
+		// then
+		assert.Equal(t, 0, *calls)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("too many keys per user", func(t *testing.T) {
+		// given
+		s, now := newStore(time.Hour, nil)
+		router, calls := newRouter(s, http.StatusAccepted)
+		for i := 0; i < maxEntriesPerUser; i++ {
+			send(router, "johnny", fmt.Sprintf("key-%d", i), "")
+		}
+
+		// when
+		rr := send(router, "johnny", "key-new", "")
+
+		// then
+		assert.Equal(t, maxEntriesPerUser, *calls)
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+
+		t.Run("other users are not limited", func(t *testing.T) {
+			// when
+			rr := send(router, "jane", "key-new", "")
+
+			// then
+			assert.Equal(t, http.StatusAccepted, rr.Code)
+		})
+
+		t.Run("recorded keys are replayed", func(t *testing.T) {
+			// when
+			rr := send(router, "johnny", "key-0", "")
+
+			// then
+			assert.Equal(t, http.StatusAccepted, rr.Code)
+			assert.Equal(t, "true", rr.Header().Get(ReplayedHeader))
+		})
+
+		t.Run("new keys are recorded once the previous ones expired", func(t *testing.T) {
+			// given
+			*now = now.Add(time.Hour)
+
+			// when
+			rr := send(router, "johnny", "key-new", "")
+
+			// then
+			assert.Equal(t, http.StatusAccepted, rr.Code)
+			assert.Len(t, s.entries, 1)
+			assert.Equal(t, map[string]int{"johnny": 1}, s.userEntries)
+		})
+	})
+
+	t.Run("disabled with a TTL of 0", func(t *testing.T) {
+		// given
+		s, _ := newStore(0, nil)
+		router, calls := newRouter(s, http.StatusAccepted)
+
+		// when
+		send(router, "johnny", "key-1", "")
+		send(router, "johnny", "key-1", "")
+
+		// then
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("concurrent request waits for the outcome of the first one", func(t *testing.T) {
+		// given
+		s, _ := newStore(time.Hour, nil)
+		started := make(chan struct{})
+		release := make(chan struct{})
+		calls := 0
+		router := gin.New()
+		router.POST("/api/v1/signup", s.Handler(), func(ctx *gin.Context) {
+			calls++
+			close(started)
+			<-release
+			ctx.Status(http.StatusAccepted)
+		})
+		responses := make([]*httptest.ResponseRecorder, 2)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[0] = send(router, "", "key-1", "")
+		}()
+		<-started
+
+		// when
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[1] = send(router, "", "key-1", "")
+		}()
+		time.Sleep(50 * time.Millisecond) // let the second request wait for the first one
+		close(release)
+		wg.Wait()
+
+		// then
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, http.StatusAccepted, responses[0].Code)
+		assert.Equal(t, http.StatusAccepted, responses[1].Code)
+		assert.Equal(t, "true", responses[1].Header().Get(ReplayedHeader))
+	})
+
+	t.Run("persisted outcome is replayed after a restart", func(t *testing.T) {
+		// given
+		persistence := &fakePersistence{outcomes: map[string]Outcome{}}
+		s, _ := newStore(time.Hour, persistence)
+		router, calls := newRouter(s, http.StatusAccepted)
+		first := send(router, "johnny", "key-1", "")
+		require.Contains(t, persistence.outcomes, "johnny/key-1")
+		restarted, _ := newStore(time.Hour, persistence)
+		restartedRouter, restartedCalls := newRouter(restarted, http.StatusAccepted)
+
+		// when
+		rr := send(restartedRouter, "johnny", "key-1", "")
+
+		// then
+		assert.Equal(t, 1, *calls)
+		assert.Equal(t, 0, *restartedCalls)
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		assert.Equal(t, first.Body.String(), rr.Body.String())
+		assert.Equal(t, "true", rr.Header().Get(ReplayedHeader))
+	})
+}
@@ -0,0 +1,115 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	signupcommon "github.com/codeready-toolchain/toolchain-common/pkg/usersignup"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+)
+
+// OutcomesAnnotationKey is the annotation of the UserSignup holding the recorded outcomes of the requests of the user,
+// by idempotency key, in JSON format
+const OutcomesAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "idempotency-outcomes"
+
+// maxPersistedOutcomes is the maximum number of outcomes kept in the annotation of a UserSignup, so that the annotation
+// stays well below the size limit of the annotations of an object
+const maxPersistedOutcomes = 20
+
+// SignupPersistence keeps the outcomes in an annotation of the UserSignup of the user. The outcomes of the requests
+// of a user who has no UserSignup (yet) are not persisted.
+type SignupPersistence struct {
+	namespaced.Client
+}
+
+// NewSignupPersistence returns a new SignupPersistence instance
+func NewSignupPersistence(nsClient namespaced.Client) *SignupPersistence {
+	return &SignupPersistence{
+		Client: nsClient,
+	}
+}
+
+// Load returns the outcome recorded in the UserSignup of the user for the given key, or nil if there is none
+func (p *SignupPersistence) Load(ctx context.Context, username, key string) (*Outcome, error) {
+	userSignup := &toolchainv1alpha1.UserSignup{}
+	if err := p.Get(ctx, p.NamespacedName(signupcommon.EncodeUserIdentifier(username)), userSignup); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	outcome, found := outcomesOf(userSignup)[key]
+	if !found {
+		return nil, nil
+	}
+	return &outcome, nil
+}
+
+// Save records the outcome in the UserSignup of the user for the given key, and removes the expired ones as well as
+// the ones expiring first beyond the maximum number of outcomes.
+// The UserSignup is read without the cache, which may not contain it yet when it was created by the request itself.
+func (p *SignupPersistence) Save(ctx context.Context, username, key string, outcome Outcome) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		userSignup := &toolchainv1alpha1.UserSignup{}
+		if err := p.APIReader.Get(ctx, p.NamespacedName(signupcommon.EncodeUserIdentifier(username)), userSignup); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		outcomes := outcomesOf(userSignup)
+		now := time.Now()
+		for k, o := range outcomes {
+			if !now.Before(o.ExpiresAt) {
+				delete(outcomes, k)
+			}
+		}
+		outcomes[key] = outcome
+		limitOutcomes(outcomes, key)
+		value, err := json.Marshal(outcomes)
+		if err != nil {
+			return err
+		}
+		if userSignup.Annotations == nil {
+			userSignup.Annotations = map[string]string{}
+		}
+		userSignup.Annotations[OutcomesAnnotationKey] = string(value)
+		return p.Update(ctx, userSignup)
+	})
+}
+
+// limitOutcomes removes the outcomes expiring first, except the one of the given key, until the given outcomes do not
+// exceed the maximum number of persisted outcomes
+func limitOutcomes(outcomes map[string]Outcome, key string) {
+	if len(outcomes) <= maxPersistedOutcomes {
+		return
+	}
+	keys := make([]string, 0, len(outcomes))
+	for k := range outcomes {
+		if k != key {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return outcomes[keys[i]].ExpiresAt.Before(outcomes[keys[j]].ExpiresAt)
+	})
+	for _, k := range keys[:len(outcomes)-maxPersistedOutcomes] {
+		delete(outcomes, k)
+	}
+}
+
+// outcomesOf returns the outcomes recorded in the given UserSignup. An invalid annotation is ignored.
+func outcomesOf(userSignup *toolchainv1alpha1.UserSignup) map[string]Outcome {
+	outcomes := map[string]Outcome{}
+	if value, found := userSignup.Annotations[OutcomesAnnotationKey]; found {
+		if err := json.Unmarshal([]byte(value), &outcomes); err != nil {
+			return map[string]Outcome{}
+		}
+	}
+	return outcomes
+}
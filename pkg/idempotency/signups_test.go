@@ -0,0 +1,161 @@
+package idempotency_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/idempotency"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	testusersignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestSignupPersistence(t *testing.T) {
+	outcome := idempotency.Outcome{
+		Fingerprint: "POST /api/v1/signup e3b0c442",
+		Status:      http.StatusAccepted,
+		ExpiresAt:   time.Now().Add(time.Hour).Truncate(time.Second),
+	}
+
+	t.Run("save and load", func(t *testing.T) {
+		// given
+		userSignup := testusersignup.NewUserSignup(testusersignup.WithEncodedName("johnny@kubesaw"))
+		fakeClient := commontest.NewFakeClient(t, userSignup)
+		persistence := idempotency.NewSignupPersistence(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		err := persistence.Save(context.TODO(), "johnny@kubesaw", "key-1", outcome)
+
+		// then
+		require.NoError(t, err)
+		loaded, err := persistence.Load(context.TODO(), "johnny@kubesaw", "key-1")
+		require.NoError(t, err)
+		require.NotNil(t, loaded)
+		assert.Equal(t, outcome.Fingerprint, loaded.Fingerprint)
+		assert.Equal(t, outcome.Status, loaded.Status)
+		assert.True(t, outcome.ExpiresAt.Equal(loaded.ExpiresAt))
+
+		missing, err := persistence.Load(context.TODO(), "johnny@kubesaw", "key-2")
+		require.NoError(t, err)
+		assert.Nil(t, missing)
+	})
+
+	t.Run("expired outcomes are removed", func(t *testing.T) {
+		// given
+		expired := outcome
+		expired.ExpiresAt = time.Now().Add(-time.Minute)
+		value, err := json.Marshal(map[string]idempotency.Outcome{"key-0": expired})
+		require.NoError(t, err)
+		userSignup := testusersignup.NewUserSignup(testusersignup.WithEncodedName("johnny@kubesaw"),
+			testusersignup.WithAnnotation(idempotency.OutcomesAnnotationKey, string(value)))
+		fakeClient := commontest.NewFakeClient(t, userSignup)
+		persistence := idempotency.NewSignupPersistence(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		err = persistence.Save(context.TODO(), "johnny@kubesaw", "key-1", outcome)
+
+		// then
+		require.NoError(t, err)
+		updated := &toolchainv1alpha1.UserSignup{}
+		require.NoError(t, fakeClient.Get(context.TODO(), client.ObjectKeyFromObject(userSignup), updated))
+		outcomes := map[string]idempotency.Outcome{}
+		require.NoError(t, json.Unmarshal([]byte(updated.Annotations[idempotency.OutcomesAnnotationKey]), &outcomes))
+		assert.Contains(t, outcomes, "key-1")
+		assert.NotContains(t, outcomes, "key-0")
+	})
+
+	t.Run("number of outcomes is limited", func(t *testing.T) {
+		// given
+		previous := map[string]idempotency.Outcome{}
+		for i := 0; i < 25; i++ {
+			o := outcome
+			o.ExpiresAt = time.Now().Add(time.Duration(i+1) * time.Minute)
+			previous[fmt.Sprintf("key-%02d", i)] = o
+		}
+		value, err := json.Marshal(previous)
+		require.NoError(t, err)
+		userSignup := testusersignup.NewUserSignup(testusersignup.WithEncodedName("johnny@kubesaw"),
+			testusersignup.WithAnnotation(idempotency.OutcomesAnnotationKey, string(value)))
+		fakeClient := commontest.NewFakeClient(t, userSignup)
+		persistence := idempotency.NewSignupPersistence(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		err = persistence.Save(context.TODO(), "johnny@kubesaw", "key-new", outcome)
+
+		// then
+		require.NoError(t, err)
+		updated := &toolchainv1alpha1.UserSignup{}
+		require.NoError(t, fakeClient.Get(context.TODO(), client.ObjectKeyFromObject(userSignup), updated))
+		outcomes := map[string]idempotency.Outcome{}
+		require.NoError(t, json.Unmarshal([]byte(updated.Annotations[idempotency.OutcomesAnnotationKey]), &outcomes))
+		assert.Len(t, outcomes, 20)
+		assert.Contains(t, outcomes, "key-new")
+		// the outcomes expiring first are removed
+		for i := 0; i < 6; i++ {
+			assert.NotContains(t, outcomes, fmt.Sprintf("key-%02d", i))
+		}
+		assert.Contains(t, outcomes, "key-06")
+		assert.Contains(t, outcomes, "key-24")
+	})
+
+	t.Run("signup just created is read without the cache", func(t *testing.T) {
+		// given
+		userSignup := testusersignup.NewUserSignup(testusersignup.WithEncodedName("johnny@kubesaw"))
+		fakeClient := commontest.NewFakeClient(t, userSignup)
+		// the cache does not contain the UserSignup created by the request yet
+		fakeClient.MockGet = func(_ context.Context, key client.ObjectKey, _ client.Object, _ ...client.GetOption) error {
+			return apierrors.NewNotFound(toolchainv1alpha1.GroupVersion.WithResource("usersignups").GroupResource(), key.Name)
+		}
+		persistence := idempotency.NewSignupPersistence(
+			namespaced.NewClientWithAPIReader(fakeClient, fakeClient.Client, commontest.HostOperatorNs))
+
+		// when
+		err := persistence.Save(context.TODO(), "johnny@kubesaw", "key-1", outcome)
+
+		// then
+		require.NoError(t, err)
+		updated := &toolchainv1alpha1.UserSignup{}
+		require.NoError(t, fakeClient.Client.Get(context.TODO(), client.ObjectKeyFromObject(userSignup), updated))
+		assert.Contains(t, updated.Annotations[idempotency.OutcomesAnnotationKey], "key-1")
+	})
+
+	t.Run("invalid annotation is ignored", func(t *testing.T) {
+		// given
+		userSignup := testusersignup.NewUserSignup(testusersignup.WithEncodedName("johnny@kubesaw"),
+			testusersignup.WithAnnotation(idempotency.OutcomesAnnotationKey, "not json"))
+		fakeClient := commontest.NewFakeClient(t, userSignup)
+		persistence := idempotency.NewSignupPersistence(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		loaded, err := persistence.Load(context.TODO(), "johnny@kubesaw", "key-1")
+
+		// then
+		require.NoError(t, err)
+		assert.Nil(t, loaded)
+	})
+
+	t.Run("no signup", func(t *testing.T) {
+		// given
+		fakeClient := commontest.NewFakeClient(t)
+		persistence := idempotency.NewSignupPersistence(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		err := persistence.Save(context.TODO(), "johnny@kubesaw", "key-1", outcome)
+
+		// then
+		require.NoError(t, err)
+		loaded, err := persistence.Load(context.TODO(), "johnny@kubesaw", "key-1")
+		require.NoError(t, err)
+		assert.Nil(t, loaded)
+	})
+}
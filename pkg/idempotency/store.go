@@ -0,0 +1,142 @@
+// Package idempotency replays the outcome of a request when a client repeats it with the same `Idempotency-Key` header,
+// instead of running it again, so that double-clicks and retries have no side effect.
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// cleanupInterval is the minimum interval between two removals of the expired outcomes
+	cleanupInterval = time.Minute
+	// maxEntriesPerUser is the maximum number of keys of a user recorded by the Store at a given time
+	maxEntriesPerUser = 100
+	// maxEntries is the maximum number of keys recorded by the Store at a given time
+	maxEntries = 100000
+)
+
+// errTooManyKeys is returned when the Store cannot record another key, until some of the recorded ones expire
+var errTooManyKeys = errors.New("too many idempotency keys")
+
+// Outcome is the recorded response of a request
+type Outcome struct {
+	// Fingerprint identifies the request (method, route and body), so that a key cannot be reused for another request
+	Fingerprint string `json:"fingerprint"`
+	// Status is the status code of the response
+	Status int `json:"status"`
+	// ContentType is the media type of the body of the response, if any
+	ContentType string `json:"contentType,omitempty"`
+	// Body is the body of the response, if any
+	Body string `json:"body,omitempty"`
+	// ExpiresAt is the time after which the outcome is no longer replayed
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Persistence keeps the outcomes beyond the memory of the service, so that they survive its restarts and are shared
+// by its replicas
+type Persistence interface {
+	// Load returns the outcome recorded for the given user and key, or nil if there is none
+	Load(ctx context.Context, username, key string) (*Outcome, error)
+	// Save records the outcome for the given user and key
+	Save(ctx context.Context, username, key string, outcome Outcome) error
+}
+
+// Store records the outcomes of the requests by user and key, for a given TTL.
+// A Store with a TTL lower or equal to 0 records nothing.
+type Store struct {
+	mu          sync.Mutex
+	ttl         time.Duration
+	persistence Persistence
+	entries     map[string]*entry
+	userEntries map[string]int
+	lastCleanup time.Time
+	now         func() time.Time
+}
+
+// entry is the state of a request with a given user and key. Its done channel is closed when the first request
+// completed, after its outcome was set (or not, if the outcome is not to be replayed).
+type entry struct {
+	username string
+	done     chan struct{}
+	outcome  *Outcome
+}
+
+// NewStore returns a new Store keeping the outcomes in memory for the given TTL, and in the given persistence if not nil
+func NewStore(ttl time.Duration, persistence Persistence) *Store {
+	return &Store{
+		ttl:         ttl,
+		persistence: persistence,
+		entries:     map[string]*entry{},
+		userEntries: map[string]int{},
+		now:         time.Now,
+	}
+}
+
+// acquire returns the entry of the given user and key, and true if the caller is the first to make the request,
+// and thus must complete the entry. It returns errTooManyKeys if the user or all the users already have the maximum
+// number of recorded keys.
+func (s *Store) acquire(username, key string) (*entry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.removeExpiredEntries(now, false)
+
+	id := username + "\n" + key
+	if e, found := s.entries[id]; found {
+		if e.outcome == nil || now.Before(e.outcome.ExpiresAt) {
+			return e, false, nil
+		}
+		s.delete(id)
+	}
+	if s.userEntries[username] >= maxEntriesPerUser || len(s.entries) >= maxEntries {
+		s.removeExpiredEntries(now, true)
+		if s.userEntries[username] >= maxEntriesPerUser || len(s.entries) >= maxEntries {
+			return nil, false, errTooManyKeys
+		}
+	}
+	e := &entry{username: username, done: make(chan struct{})}
+	s.entries[id] = e
+	s.userEntries[username]++
+	return e, true, nil
+}
+
+// complete records the outcome of the request of the given entry (if not nil), and releases the requests waiting for it
+func (s *Store) complete(username, key string, e *entry, outcome *Outcome) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if outcome == nil {
+		// the next request with the same key runs again
+		s.delete(username + "\n" + key)
+	}
+	e.outcome = outcome
+	close(e.done)
+}
+
+// removeExpiredEntries forgets the expired outcomes, so that the memory used by the Store doesn't grow over time.
+// Unless forced, the outcomes are checked at most once per cleanup interval.
+func (s *Store) removeExpiredEntries(now time.Time, force bool) {
+	if !force && now.Sub(s.lastCleanup) < cleanupInterval {
+		return
+	}
+	s.lastCleanup = now
+	for id, e := range s.entries {
+		if e.outcome != nil && !now.Before(e.outcome.ExpiresAt) {
+			s.delete(id)
+		}
+	}
+}
+
+// delete forgets the entry with the given id, if any
+func (s *Store) delete(id string) {
+	e, found := s.entries[id]
+	if !found {
+		return
+	}
+	delete(s.entries, id)
+	if s.userEntries[e.username]--; s.userEntries[e.username] <= 0 {
+		delete(s.userEntries, e.username)
+	}
+}
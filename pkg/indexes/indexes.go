@@ -0,0 +1,60 @@
+// Package indexes defines the field indexes registered in the informer cache of the host-operator namespace,
+// which allow looking up resources without iterating over all of them.
+package indexes
+
+import (
+	"context"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// BannedUserEmailHash is the name of the index of the BannedUsers by the hash of their email address
+	BannedUserEmailHash = "bannedUserEmailHash"
+	// UserSignupEmailHash is the name of the index of the UserSignups by the hash of their email address
+	UserSignupEmailHash = "userSignupEmailHash"
+)
+
+// Index is a field index of a given type of resource
+type Index struct {
+	Object  client.Object
+	Field   string
+	Extract client.IndexerFunc
+}
+
+// All returns all the field indexes used by the registration service and the proxy
+func All() []Index {
+	return []Index{
+		{
+			Object:  &toolchainv1alpha1.BannedUser{},
+			Field:   BannedUserEmailHash,
+			Extract: labelValue(toolchainv1alpha1.BannedUserEmailHashLabelKey),
+		},
+		{
+			Object:  &toolchainv1alpha1.UserSignup{},
+			Field:   UserSignupEmailHash,
+			Extract: labelValue(toolchainv1alpha1.UserSignupUserEmailHashLabelKey),
+		},
+	}
+}
+
+// Register registers all the field indexes in the given indexer.
+// This must be done before the informers are started.
+func Register(ctx context.Context, indexer client.FieldIndexer) error {
+	for _, idx := range All() {
+		if err := indexer.IndexField(ctx, idx.Object, idx.Field, idx.Extract); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func labelValue(key string) client.IndexerFunc {
+	return func(obj client.Object) []string {
+		if value, found := obj.GetLabels()[key]; found {
+			return []string{value}
+		}
+		return nil
+	}
+}
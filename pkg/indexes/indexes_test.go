@@ -0,0 +1,35 @@
+package indexes_test
+
+import (
+	"context"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/indexes"
+	"github.com/codeready-toolchain/registration-service/test/fake"
+	"github.com/codeready-toolchain/registration-service/test/util"
+	commonconfig "github.com/codeready-toolchain/toolchain-common/pkg/configuration"
+	"github.com/codeready-toolchain/toolchain-common/pkg/hash"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestBannedUserEmailHashIndex(t *testing.T) {
+	// given
+	t.Setenv(commonconfig.WatchNamespaceEnvVar, commontest.HostOperatorNs)
+	cl := util.NewFakeClient(t,
+		fake.NewBannedUser("alice", "alice@redhat.com"),
+		fake.NewBannedUser("bob", "bob@redhat.com"))
+
+	// when
+	bannedUsers := &toolchainv1alpha1.BannedUserList{}
+	err := cl.List(context.TODO(), bannedUsers, client.InNamespace(commontest.HostOperatorNs),
+		client.MatchingFields{indexes.BannedUserEmailHash: hash.EncodeString("alice@redhat.com")})
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, bannedUsers.Items, 1)
+	assert.Equal(t, "alice@redhat.com", bannedUsers.Items[0].Spec.Email)
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/codeready-toolchain/registration-service/pkg/auth"
+	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	"github.com/codeready-toolchain/registration-service/pkg/context"
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin returns a middleware which rejects the requests of users who are neither member of one of the
+// configured admin groups nor holder of one of the configured admin roles.
+// This middleware requires the context to contain the claims of the token,
+// so it needs to be executed after the JWTMiddleware.
+func RequireAdmin() gin.HandlerFunc {
+	return requireClaims("admin", IsAdmin)
+}
+
+// RequireOrganizer returns a middleware which rejects the requests of users who are neither event organizers nor
+// administrators (see IsOrganizer and IsAdmin). Whether the user is an administrator is stored in the context under
+// the context.AdminKey key, so that the handlers can decide if the user has access to all the events or only to their own.
+// This middleware requires the context to contain the claims of the token,
+// so it needs to be executed after the JWTMiddleware.
+func RequireOrganizer() gin.HandlerFunc {
+	return requireClaims("organizer", func(claims *auth.TokenClaims) bool {
+		return IsOrganizer(claims) || IsAdmin(claims)
+	})
+}
+
+func requireClaims(privileges string, allowed func(claims *auth.TokenClaims) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := c.Get(context.JWTClaimsKey)
+		if !ok {
+			crterrors.AbortWithError(c, http.StatusUnauthorized, errors.New("unauthenticated request"), "no claims found in the context")
+			return
+		}
+		tokenClaims, ok := claims.(*auth.TokenClaims)
+		if !ok || !allowed(tokenClaims) {
+			log.Infof(c, "user '%s' is not allowed to access the %s endpoint", c.GetString(context.UsernameKey), privileges)
+			crterrors.AbortWithError(c, http.StatusForbidden, errors.New("forbidden"), privileges+" privileges are required")
+			return
+		}
+		c.Set(context.AdminKey, IsAdmin(tokenClaims))
+		c.Next()
+	}
+}
+
+// IsAdmin returns true if the given claims contain one of the configured admin groups or roles
+func IsAdmin(claims *auth.TokenClaims) bool {
+	cfg := configuration.GetRegistrationServiceConfig().Admin()
+	return hasGroupOrRole(claims, cfg.Groups(), cfg.Roles())
+}
+
+// IsOrganizer returns true if the given claims contain one of the configured event organizer groups or roles
+func IsOrganizer(claims *auth.TokenClaims) bool {
+	cfg := configuration.GetRegistrationServiceConfig().Organizers()
+	return hasGroupOrRole(claims, cfg.Groups(), cfg.Roles())
+}
+
+// hasGroupOrRole returns true if the given claims contain one of the given groups or roles. Only the groups and roles
+// of the main SSO realm are considered, since the additional realms may define groups and roles with the same names.
+func hasGroupOrRole(claims *auth.TokenClaims, groups, roles []string) bool {
+	if claims.SSORealm != "" {
+		return false
+	}
+	for _, group := range groups {
+		if slices.Contains(claims.Groups, group) {
+			return true
+		}
+	}
+	for _, role := range roles {
+		if slices.Contains(claims.RealmAccess.Roles, role) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codeready-toolchain/registration-service/pkg/auth"
+	"github.com/codeready-toolchain/registration-service/pkg/context"
+	"github.com/codeready-toolchain/registration-service/pkg/middleware"
+	"github.com/codeready-toolchain/registration-service/test"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type TestAdminMiddlewareSuite struct {
+	test.UnitTestSuite
+}
+
+func TestRunAdminMiddlewareSuite(t *testing.T) {
+	suite.Run(t, &TestAdminMiddlewareSuite{test.UnitTestSuite{}})
+}
+
+func (s *TestAdminMiddlewareSuite) TestRequireAdmin() {
+	test.SetSettings(s.T(), "admin: {groups: [sandbox-admins], roles: [sandbox-admin]}")
+
+	tests := map[string]struct {
+		claims         interface{}
+		expectedStatus int
+	}{
+		"no claims": {
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"unexpected claims type": {
+			claims:         "foo",
+			expectedStatus: http.StatusForbidden,
+		},
+		"no groups nor roles": {
+			claims:         &auth.TokenClaims{},
+			expectedStatus: http.StatusForbidden,
+		},
+		"other groups and roles": {
+			claims:         &auth.TokenClaims{Groups: []string{"devs"}, RealmAccess: auth.RealmAccess{Roles: []string{"user"}}},
+			expectedStatus: http.StatusForbidden,
+		},
+		"admin group": {
+			claims:         &auth.TokenClaims{Groups: []string{"devs", "sandbox-admins"}},
+			expectedStatus: http.StatusOK,
+		},
+		"admin role": {
+			claims:         &auth.TokenClaims{RealmAccess: auth.RealmAccess{Roles: []string{"sandbox-admin"}}},
+			expectedStatus: http.StatusOK,
+		},
+		"admin group and role of an additional SSO realm": {
+			claims:         &auth.TokenClaims{Groups: []string{"sandbox-admins"}, RealmAccess: auth.RealmAccess{Roles: []string{"sandbox-admin"}}, SSORealm: "employees"},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for name, tc := range tests {
+		s.Run(name, func() {
+			// given
+			rr := httptest.NewRecorder()
+			_, router := gin.CreateTestContext(rr)
+			router.GET("/admin", func(c *gin.Context) {
+				if tc.claims != nil {
+					c.Set(context.JWTClaimsKey, tc.claims)
+				}
+			}, middleware.RequireAdmin(), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+
+			// when
+			router.ServeHTTP(rr, req)
+
+			// then
+			assert.Equal(s.T(), tc.expectedStatus, rr.Code)
+		})
+	}
+}
+
+func (s *TestAdminMiddlewareSuite) TestRequireOrganizer() {
+	test.SetSettings(s.T(), `
+admin:
+  groups: [sandbox-admins]
+organizers:
+  groups: [event-organizers]
+  roles: [sandbox-organizer]`)
+
+	tests := map[string]struct {
+		claims         interface{}
+		expectedStatus int
+		expectedAdmin  bool
+	}{
+		"no claims": {
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"other groups and roles": {
+			claims:         &auth.TokenClaims{Groups: []string{"devs"}, RealmAccess: auth.RealmAccess{Roles: []string{"user"}}},
+			expectedStatus: http.StatusForbidden,
+		},
+		"organizer group": {
+			claims:         &auth.TokenClaims{Groups: []string{"devs", "event-organizers"}},
+			expectedStatus: http.StatusOK,
+		},
+		"organizer role": {
+			claims:         &auth.TokenClaims{RealmAccess: auth.RealmAccess{Roles: []string{"sandbox-organizer"}}},
+			expectedStatus: http.StatusOK,
+		},
+		"admin group": {
+			claims:         &auth.TokenClaims{Groups: []string{"sandbox-admins"}},
+			expectedStatus: http.StatusOK,
+			expectedAdmin:  true,
+		},
+		"organizer group of an additional SSO realm": {
+			claims:         &auth.TokenClaims{Groups: []string{"event-organizers"}, SSORealm: "employees"},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for name, tc := range tests {
+		s.Run(name, func() {
+			// given
+			rr := httptest.NewRecorder()
+			_, router := gin.CreateTestContext(rr)
+			admin := false
+			router.GET("/events", func(c *gin.Context) {
+				if tc.claims != nil {
+					c.Set(context.JWTClaimsKey, tc.claims)
+				}
+			}, middleware.RequireOrganizer(), func(c *gin.Context) {
+				admin = c.GetBool(context.AdminKey)
+				c.Status(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/events", nil)
+
+			// when
+			router.ServeHTTP(rr, req)
+
+			// then
+			assert.Equal(s.T(), tc.expectedStatus, rr.Code)
+			assert.Equal(s.T(), tc.expectedAdmin, admin)
+		})
+	}
+}
@@ -2,12 +2,16 @@ package middleware
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/codeready-toolchain/registration-service/pkg/auth"
 	"github.com/codeready-toolchain/registration-service/pkg/context"
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
 	"github.com/codeready-toolchain/registration-service/pkg/log"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/revocation"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,16 +19,18 @@ import (
 // JWTMiddleware is the JWT token validation middleware
 type JWTMiddleware struct {
 	tokenParser *auth.TokenParser
+	revoker     *revocation.Revoker
 }
 
 // NewAuthMiddleware returns a new middleware for JWT authentication
-func NewAuthMiddleware() (*JWTMiddleware, error) {
+func NewAuthMiddleware(nsClient namespaced.Client) (*JWTMiddleware, error) {
 	tokenParserInstance, err := auth.DefaultTokenParser()
 	if err != nil {
 		return nil, err
 	}
 	return &JWTMiddleware{
 		tokenParser: tokenParserInstance,
+		revoker:     revocation.NewRevoker(nsClient),
 	}, nil
 }
 
@@ -48,7 +54,11 @@ func (m *JWTMiddleware) extractToken(c *gin.Context) (string, error) {
 	return "", errors.New("no token found")
 }
 
-func (m *JWTMiddleware) respondWithError(c *gin.Context, code int, message interface{}) {
+func (m *JWTMiddleware) respondWithError(c *gin.Context, code int, message string) {
+	if c.GetBool(context.ProblemDetailsKey) {
+		crterrors.AbortWithProblem(c, code, errors.New(message))
+		return
+	}
 	c.AbortWithStatusJSON(code, gin.H{"error": message})
 }
 
@@ -67,6 +77,22 @@ func (m *JWTMiddleware) HandlerFunc() gin.HandlerFunc {
 			m.respondWithError(c, http.StatusUnauthorized, err.Error())
 			return
 		}
+		// only the tokens of the main SSO realm are accepted: the additional realms are only supported by the proxy
+		if token.SSORealm != "" {
+			m.respondWithError(c, http.StatusUnauthorized, fmt.Sprintf("tokens issued by the SSO realm '%s' are not accepted", token.SSORealm))
+			return
+		}
+		// then, check that the token was not revoked
+		revoked, err := m.revoker.IsRevoked(c.Request.Context(), token)
+		if err != nil {
+			log.Error(c, err, "unable to check if the token was revoked")
+			m.respondWithError(c, http.StatusInternalServerError, "unable to check if the token was revoked")
+			return
+		}
+		if revoked {
+			m.respondWithError(c, http.StatusUnauthorized, "token has been revoked")
+			return
+		}
 
 		if token.UserID == "" || token.AccountID == "" {
 			rawClaims := ""
@@ -1,6 +1,7 @@
 package middleware_test
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -9,9 +10,11 @@ import (
 	"time"
 
 	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
 	"github.com/codeready-toolchain/registration-service/pkg/middleware"
 	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
 	"github.com/codeready-toolchain/registration-service/pkg/proxy"
+	"github.com/codeready-toolchain/registration-service/pkg/revocation"
 	"github.com/codeready-toolchain/registration-service/pkg/server"
 	"github.com/codeready-toolchain/registration-service/test"
 	"github.com/codeready-toolchain/registration-service/test/util"
@@ -20,6 +23,7 @@ import (
 	authsupport "github.com/codeready-toolchain/toolchain-common/pkg/test/auth"
 	testconfig "github.com/codeready-toolchain/toolchain-common/pkg/test/config"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
@@ -38,7 +42,7 @@ func TestRunAuthMiddlewareSuite(t *testing.T) {
 
 func (s *TestAuthMiddlewareSuite) TestAuthMiddleware() {
 	s.Run("create with DefaultTokenParser failing", func() {
-		authMiddleware, err := middleware.NewAuthMiddleware()
+		authMiddleware, err := middleware.NewAuthMiddleware(namespaced.NewClient(commontest.NewFakeClient(s.T()), commontest.HostOperatorNs))
 		require.Nil(s.T(), authMiddleware)
 		require.Error(s.T(), err)
 		require.Equal(s.T(), "no default TokenParser created, call `InitializeDefaultTokenParser()` first", err.Error())
@@ -72,6 +76,34 @@ func (s *TestAuthMiddlewareSuite) TestAuthMiddlewareService() {
 	tokenInvalidExpiredJWT := tokengenerator.GenerateToken(identity0, kid0, emailClaim0, expClaim)
 	tokenInvalidExpired, err := tokengenerator.SignToken(tokenInvalidExpiredJWT, kid0)
 	require.NoError(s.T(), err)
+	// revoked token - by jti
+	revokedTokenID := uuid.NewString()
+	tokenRevokedByID, err := tokengenerator.GenerateSignedToken(identity0, kid0, emailClaim0, func(token *jwt.Token) {
+		token.Claims.(*authsupport.MyClaims).ID = revokedTokenID
+	})
+	require.NoError(s.T(), err)
+	// revoked token - by sub and iat
+	identity1 := authsupport.Identity{
+		ID:       uuid.New(),
+		Username: uuid.NewString(),
+	}
+	tokenRevokedBySub, err := tokengenerator.GenerateSignedToken(identity1, kid0, emailClaim0, authsupport.WithIATClaim(time.Now().Add(-time.Hour)))
+	require.NoError(s.T(), err)
+	tokenIssuedAfterRevocation, err := tokengenerator.GenerateSignedToken(identity1, kid0, emailClaim0, authsupport.WithIATClaim(time.Now().Add(time.Minute)))
+	require.NoError(s.T(), err)
+
+	// token of an additional SSO realm
+	employeesTokengenerator := authsupport.NewTokenManager()
+	kid1 := uuid.NewString()
+	_, err = employeesTokengenerator.AddPrivateKey(kid1)
+	require.NoError(s.T(), err)
+	employeesKeyServer := employeesTokengenerator.NewKeyServer()
+	defer employeesKeyServer.Close()
+	tokenOfAdditionalRealm, err := employeesTokengenerator.GenerateSignedToken(identity0, kid1, emailClaim0, func(token *jwt.Token) {
+		token.Claims.(*authsupport.MyClaims).Issuer = employeesKeyServer.URL + "/auth/realms/employees"
+	})
+	require.NoError(s.T(), err)
+	test.SetSettings(s.T(), "auth: {additionalSSORealms: {employees: "+employeesKeyServer.URL+"}}")
 
 	// start key service
 	keysEndpointURL := tokengenerator.NewKeyServer().URL
@@ -89,6 +121,11 @@ func (s *TestAuthMiddlewareSuite) TestAuthMiddlewareService() {
 
 	// Setting up the routes.
 	nsClient := namespaced.NewClient(commontest.NewFakeClient(s.T()), commontest.HostOperatorNs)
+	revoker := revocation.NewRevoker(nsClient)
+	_, err = revoker.RevokeToken(context.TODO(), revokedTokenID, time.Now().Add(time.Hour), "leaked", "admin")
+	require.NoError(s.T(), err)
+	_, err = revoker.RevokeSubject(context.TODO(), identity1.ID.String(), time.Now(), "logout", "admin")
+	require.NoError(s.T(), err)
 	err = srv.SetupRoutes(proxy.DefaultPort, prometheus.NewRegistry(), nsClient)
 	require.NoError(s.T(), err)
 
@@ -156,6 +193,10 @@ func (s *TestAuthMiddlewareSuite) TestAuthMiddlewareService() {
 			{"auth_test, invalid header auth, token garbage", "/api/v1/auth_test", http.MethodGet, "Bearer " + tokenInvalidGarbage, http.StatusUnauthorized},
 			{"auth_test, invalid header auth, wrong header format", "/api/v1/auth_test", http.MethodGet, tokenValid, http.StatusUnauthorized},
 			{"auth_test, invalid header auth, bearer but no token", "/api/v1/auth_test", http.MethodGet, "Bearer ", http.StatusUnauthorized},
+			{"auth_test, revoked token", "/api/v1/auth_test", http.MethodGet, "Bearer " + tokenRevokedByID, http.StatusUnauthorized},
+			{"auth_test, token of revoked subject", "/api/v1/auth_test", http.MethodGet, "Bearer " + tokenRevokedBySub, http.StatusUnauthorized},
+			{"auth_test, token of revoked subject issued after the revocation", "/api/v1/auth_test", http.MethodGet, "Bearer " + tokenIssuedAfterRevocation, http.StatusOK},
+			{"auth_test, token of an additional SSO realm", "/api/v1/auth_test", http.MethodGet, "Bearer " + tokenOfAdditionalRealm, http.StatusUnauthorized},
 		}
 		for _, tt := range authtests {
 			s.Run(tt.name, func() {
@@ -182,4 +223,60 @@ func (s *TestAuthMiddlewareSuite) TestAuthMiddlewareService() {
 			})
 		}
 	})
+	s.Run("auth requests in the v2 API", func() {
+		// mock proxy
+		defer gock.Off()
+		gock.New(fmt.Sprintf("http://localhost:%s/proxyhealth", proxy.DefaultPort)).
+			Persist().
+			Reply(http.StatusOK).
+			BodyString("")
+
+		s.Run("unauthorized request returns problem details", func() {
+			// given
+			resp := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, "/api/v2/auth_test", nil)
+			require.NoError(s.T(), err)
+
+			// when
+			srv.Engine().ServeHTTP(resp, req)
+
+			// then
+			require.Equal(s.T(), http.StatusUnauthorized, resp.Code)
+			assert.Equal(s.T(), crterrors.ProblemMediaType, resp.Header().Get("Content-Type"))
+			problem := &crterrors.Problem{}
+			require.NoError(s.T(), json.Unmarshal(resp.Body.Bytes(), problem))
+			assert.Equal(s.T(), crterrors.RequestUnauthenticated, problem.ErrorCode)
+			assert.Equal(s.T(), http.StatusUnauthorized, problem.Status)
+			assert.Equal(s.T(), "no token found", problem.Detail)
+			assert.Equal(s.T(), "/api/v2/auth_test", problem.Instance)
+		})
+
+		s.Run("unauthorized request in the v1 API is unchanged", func() {
+			// given
+			resp := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, "/api/v1/auth_test", nil)
+			require.NoError(s.T(), err)
+
+			// when
+			srv.Engine().ServeHTTP(resp, req)
+
+			// then
+			require.Equal(s.T(), http.StatusUnauthorized, resp.Code)
+			assert.JSONEq(s.T(), `{"error":"no token found"}`, resp.Body.String())
+		})
+
+		s.Run("authorized request", func() {
+			// given
+			resp := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, "/api/v2/auth_test", nil)
+			require.NoError(s.T(), err)
+			req.Header.Set("Authorization", "Bearer "+tokenValid)
+
+			// when
+			srv.Engine().ServeHTTP(resp, req)
+
+			// then
+			assert.Equal(s.T(), http.StatusOK, resp.Code)
+		})
+	})
 }
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"github.com/codeready-toolchain/registration-service/pkg/context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemDetails returns a middleware which makes the handlers return their errors as RFC 7807 problem details
+// (`application/problem+json`) instead of the crterrors.Error payload, as in the v2 API.
+// It needs to be executed before the other middlewares, so that their errors are returned as problem details too.
+func ProblemDetails() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(context.ProblemDetailsKey, true)
+	}
+}
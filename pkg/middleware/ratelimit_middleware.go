@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	"github.com/codeready-toolchain/registration-service/pkg/context"
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+	"github.com/codeready-toolchain/registration-service/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RateLimiter limits the rate of the requests of each caller of the endpoints, with a distinct limit for each endpoint.
+// The endpoints are identified by their method and their route relative to the version of the API
+// (eg, `PUT /signup/verification`), so that a caller shares the same limits on all the versions of the API.
+type RateLimiter struct {
+	scope        string
+	callerOf     func(c *gin.Context) string
+	defaultLimit configuration.RateLimit
+	routes       map[string]configuration.RateLimit
+	rejected     *prometheus.CounterVec
+	mu           sync.Mutex
+	limiters     map[string]*ratelimit.Limiter
+}
+
+// sharedRouteLimits are the routes which share the limit of another route, since they serve the same operation in
+// different versions of the API. The limit of the other route applies.
+var sharedRouteLimits = map[string]string{
+	// replaced by `POST /signup/verification/phone-code` in v2, so that the phone codes cannot be guessed twice as fast
+	"GET /signup/verification/:code": "POST /signup/verification/phone-code",
+}
+
+// NewUserRateLimiter returns a RateLimiter limiting the requests of each user, with the given default limit and limits
+// by route. The rejected requests are counted in the given counter, with the `method`, `path` and `scope` labels.
+// Its handler requires the context to contain the username, so it needs to be executed after the JWTMiddleware.
+func NewUserRateLimiter(defaultLimit configuration.RateLimit, routes map[string]configuration.RateLimit, rejected *prometheus.CounterVec) *RateLimiter {
+	return newRateLimiter("user", func(c *gin.Context) string {
+		return c.GetString(context.UsernameKey)
+	}, defaultLimit, routes, rejected)
+}
+
+// NewClientIPRateLimiter returns a RateLimiter limiting the requests of each client IP address, with the given default
+// limit and limits by route. The rejected requests are counted in the given counter, with the `method`, `path` and
+// `scope` labels.
+// The client IP address is only taken from the `X-Forwarded-For` header when the request comes from one of the trusted
+// proxies of the router (see gin.Engine.SetTrustedProxies), so that the clients cannot choose the key of their limit.
+func NewClientIPRateLimiter(defaultLimit configuration.RateLimit, routes map[string]configuration.RateLimit, rejected *prometheus.CounterVec) *RateLimiter {
+	return newRateLimiter("client_ip", (*gin.Context).ClientIP, defaultLimit, routes, rejected)
+}
+
+func newRateLimiter(scope string, callerOf func(c *gin.Context) string, defaultLimit configuration.RateLimit, routes map[string]configuration.RateLimit, rejected *prometheus.CounterVec) *RateLimiter {
+	return &RateLimiter{
+		scope:        scope,
+		callerOf:     callerOf,
+		defaultLimit: defaultLimit,
+		routes:       routes,
+		rejected:     rejected,
+		limiters:     map[string]*ratelimit.Limiter{},
+	}
+}
+
+// HandlerFunc returns a handler which rejects the requests of the callers who exceeded the limit of the endpoint
+// with a `429 Too Many Requests` response and a `Retry-After` header
+func (l *RateLimiter) HandlerFunc() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := unversionedPath(c)
+		route := c.Request.Method + " " + path
+		if shared, found := sharedRouteLimits[route]; found {
+			route = shared
+		}
+		caller := l.callerOf(c)
+		allowed, retryAfter := l.limiterOf(route).Allow(caller)
+		if allowed {
+			return
+		}
+		log.Infof(c, "too many requests on '%s' by %s '%s'", route, l.scope, caller)
+		l.rejected.With(prometheus.Labels{"method": c.Request.Method, "path": path, "scope": l.scope}).Inc()
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		crterrors.AbortWithError(c, http.StatusTooManyRequests,
+			crterrors.WithRetryAfter(crterrors.NewTooManyRequestsError("too many requests", ""), retryAfter),
+			"please retry later")
+	}
+}
+
+// limiterOf returns the limiter of the given route, which is created on the first request
+func (l *RateLimiter) limiterOf(route string) *ratelimit.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if limiter, found := l.limiters[route]; found {
+		return limiter
+	}
+	limit, found := l.routes[route]
+	if !found {
+		limit = l.defaultLimit
+	}
+	// a burst lower than 1 would reject all the requests
+	limiter := ratelimit.NewLimiter(limit.PerMinute, max(limit.Burst, 1))
+	l.limiters[route] = limiter
+	return limiter
+}
+
+// unversionedPath returns the route of the request, without the `/api/<version>` prefix
+func unversionedPath(c *gin.Context) string {
+	path := c.FullPath()
+	if rest, found := strings.CutPrefix(path, "/api/"); found {
+		if i := strings.Index(rest, "/"); i >= 0 {
+			path = rest[i:]
+		}
+	}
+	return path
+}
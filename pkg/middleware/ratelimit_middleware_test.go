@@ -0,0 +1,216 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	"github.com/codeready-toolchain/registration-service/pkg/context"
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+	"github.com/codeready-toolchain/registration-service/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	log.Init("ratelimit-testing")
+	gin.SetMode(gin.TestMode)
+
+	newCounter := func() *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{Name: "rate_limited_requests_total"}, []string{"method", "path", "scope"})
+	}
+
+	// returns a router serving the v1 and v2 versions of the same routes, with the username taken from a header
+	newRouter := func(limiter *middleware.RateLimiter) *gin.Engine {
+		router := gin.New()
+		for version, middlewares := range map[string][]gin.HandlerFunc{
+			"v1": nil,
+			"v2": {middleware.ProblemDetails()},
+		} {
+			group := router.Group("/api/"+version, middlewares...)
+			group.Use(func(c *gin.Context) {
+				c.Set(context.UsernameKey, c.GetHeader("X-Username"))
+			}, limiter.HandlerFunc())
+			group.GET("/signup", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			group.PUT("/signup/verification", func(c *gin.Context) {
+				c.Status(http.StatusNoContent)
+			})
+			group.GET("/usernames/:username", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			group.GET("/signup/verification/:code", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			group.POST("/signup/verification/phone-code", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+		}
+		return router
+	}
+
+	send := func(router *gin.Engine, method, path, username, clientIP string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Username", username)
+		req.RemoteAddr = clientIP + ":12345"
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("requests of a user are limited", func(t *testing.T) {
+		// given
+		counter := newCounter()
+		limiter := middleware.NewUserRateLimiter(configuration.RateLimit{PerMinute: 1, Burst: 2}, nil, counter)
+		router := newRouter(limiter)
+		for range 2 {
+			require.Equal(t, http.StatusOK, send(router, http.MethodGet, "/api/v1/signup", "johnny", "10.0.0.1").Code)
+		}
+
+		// when
+		rr := send(router, http.MethodGet, "/api/v1/signup", "johnny", "10.0.0.1")
+
+		// then
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		retryAfter, err := strconv.Atoi(rr.Header().Get("Retry-After"))
+		require.NoError(t, err)
+		assert.InDelta(t, 60, retryAfter, 1)
+		assert.JSONEq(t, `{"status":"Too Many Requests","code":429,"message":"too many requests","details":"please retry later"}`, rr.Body.String())
+		assert.InDelta(t, float64(1), testutil.ToFloat64(counter.WithLabelValues(http.MethodGet, "/signup", "user")), 0.01)
+		// the other users are not limited
+		assert.Equal(t, http.StatusOK, send(router, http.MethodGet, "/api/v1/signup", "jane", "10.0.0.1").Code)
+	})
+
+	t.Run("requests of a client IP address are limited", func(t *testing.T) {
+		// given
+		counter := newCounter()
+		limiter := middleware.NewClientIPRateLimiter(configuration.RateLimit{PerMinute: 1, Burst: 1}, nil, counter)
+		router := newRouter(limiter)
+		require.Equal(t, http.StatusOK, send(router, http.MethodGet, "/api/v1/signup", "", "10.0.0.1").Code)
+
+		// when
+		rr := send(router, http.MethodGet, "/api/v1/signup", "", "10.0.0.1")
+
+		// then
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.InDelta(t, float64(1), testutil.ToFloat64(counter.WithLabelValues(http.MethodGet, "/signup", "client_ip")), 0.01)
+		// the other client IP addresses are not limited
+		assert.Equal(t, http.StatusOK, send(router, http.MethodGet, "/api/v1/signup", "", "10.0.0.2").Code)
+	})
+
+	t.Run("forwarded client IP address is only trusted from the trusted proxies", func(t *testing.T) {
+		// given
+		counter := newCounter()
+		limiter := middleware.NewClientIPRateLimiter(configuration.RateLimit{PerMinute: 1, Burst: 1}, nil, counter)
+		router := newRouter(limiter)
+		require.NoError(t, router.SetTrustedProxies([]string{"10.128.0.0/14"}))
+		sendForwarded := func(remoteIP, forwardedFor string) int {
+			req, err := http.NewRequest(http.MethodGet, "/api/v1/signup", nil)
+			require.NoError(t, err)
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+			req.RemoteAddr = remoteIP + ":12345"
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr.Code
+		}
+		require.Equal(t, http.StatusOK, sendForwarded("203.0.113.7", "198.51.100.1"))
+
+		// when
+		spoofed := sendForwarded("203.0.113.7", "198.51.100.2")
+		proxied := []int{sendForwarded("10.128.0.5", "198.51.100.3"), sendForwarded("10.128.0.5", "198.51.100.4")}
+
+		// then
+		// the header of an untrusted caller is ignored, so that it cannot escape its limit
+		assert.Equal(t, http.StatusTooManyRequests, spoofed)
+		// the clients behind a trusted proxy have their own limits
+		assert.Equal(t, []int{http.StatusOK, http.StatusOK}, proxied)
+	})
+
+	t.Run("phone code routes of v1 and v2 share the same limit", func(t *testing.T) {
+		// given
+		counter := newCounter()
+		limiter := middleware.NewUserRateLimiter(configuration.RateLimit{PerMinute: 1, Burst: 10}, map[string]configuration.RateLimit{
+			"POST /signup/verification/phone-code": {PerMinute: 1, Burst: 2},
+		}, counter)
+		router := newRouter(limiter)
+		for range 2 {
+			require.Equal(t, http.StatusOK, send(router, http.MethodGet, "/api/v1/signup/verification/123456", "johnny", "10.0.0.1").Code)
+		}
+
+		// when
+		rr := send(router, http.MethodPost, "/api/v2/signup/verification/phone-code", "johnny", "10.0.0.1")
+
+		// then
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	})
+
+	t.Run("routes have their own limits", func(t *testing.T) {
+		// given
+		counter := newCounter()
+		limiter := middleware.NewUserRateLimiter(configuration.RateLimit{PerMinute: 1, Burst: 1}, map[string]configuration.RateLimit{
+			"PUT /signup/verification": {PerMinute: 1, Burst: 3},
+			"GET /usernames/:username": {}, // not limited
+		}, counter)
+		router := newRouter(limiter)
+		require.Equal(t, http.StatusOK, send(router, http.MethodGet, "/api/v1/signup", "johnny", "10.0.0.1").Code)
+
+		// when
+		var verifications []int
+		for range 4 {
+			verifications = append(verifications, send(router, http.MethodPut, "/api/v1/signup/verification", "johnny", "10.0.0.1").Code)
+		}
+		var lookups []int
+		for _, name := range []string{"jane", "john", "jack"} {
+			lookups = append(lookups, send(router, http.MethodGet, "/api/v1/usernames/"+name, "johnny", "10.0.0.1").Code)
+		}
+
+		// then
+		assert.Equal(t, []int{http.StatusNoContent, http.StatusNoContent, http.StatusNoContent, http.StatusTooManyRequests}, verifications)
+		assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusOK}, lookups)
+		assert.Equal(t, http.StatusTooManyRequests, send(router, http.MethodGet, "/api/v1/signup", "johnny", "10.0.0.1").Code)
+		assert.InDelta(t, float64(1), testutil.ToFloat64(counter.WithLabelValues(http.MethodPut, "/signup/verification", "user")), 0.01)
+	})
+
+	t.Run("versions of the API share the limits", func(t *testing.T) {
+		// given
+		counter := newCounter()
+		limiter := middleware.NewUserRateLimiter(configuration.RateLimit{PerMinute: 1, Burst: 1}, nil, counter)
+		router := newRouter(limiter)
+		require.Equal(t, http.StatusOK, send(router, http.MethodGet, "/api/v1/signup", "johnny", "10.0.0.1").Code)
+
+		// when
+		rr := send(router, http.MethodGet, "/api/v2/signup", "johnny", "10.0.0.1")
+
+		// then
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, crterrors.ProblemMediaType, rr.Header().Get("Content-Type"))
+		p := crterrors.Problem{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &p))
+		assert.Equal(t, crterrors.RequestRateLimited, p.ErrorCode)
+		require.NotNil(t, p.RetryAfter)
+		assert.InDelta(t, int64(60), *p.RetryAfter, 1)
+		assert.NotEmpty(t, rr.Header().Get("Retry-After"))
+	})
+
+	t.Run("burst lower than 1", func(t *testing.T) {
+		// given
+		limiter := middleware.NewUserRateLimiter(configuration.RateLimit{PerMinute: 1, Burst: 0}, nil, newCounter())
+		router := newRouter(limiter)
+
+		// when
+		rr := send(router, http.MethodGet, "/api/v1/signup", "johnny", "10.0.0.1")
+
+		// then
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
@@ -6,11 +6,19 @@ import (
 )
 
 func NewClient(client client.Client, namespace string) Client {
-	return Client{Client: client, Namespace: namespace}
+	return Client{Client: client, APIReader: client, Namespace: namespace}
+}
+
+// NewClientWithAPIReader returns a client whose APIReader is the given reader, which should bypass the cache of the client
+func NewClientWithAPIReader(client client.Client, apiReader client.Reader, namespace string) Client {
+	return Client{Client: client, APIReader: apiReader, Namespace: namespace}
 }
 
 type Client struct {
 	client.Client
+	// APIReader reads the latest version of the objects, for the read-modify-write loops which cannot rely on a cache
+	// that may not have caught up with their own updates yet
+	APIReader client.Reader
 	Namespace string
 }
 
@@ -0,0 +1,213 @@
+package namespaces
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ResetJobLabelKey is the key of the label set on the ConfigMaps holding a namespaces reset job.
+	// Its value is the compliant username of the user whose namespaces are reset.
+	ResetJobLabelKey = toolchainv1alpha1.LabelKeyPrefix + "namespaces-reset"
+
+	resetJobNamePrefix = "namespaces-reset-"
+
+	clusterKey    = "cluster"
+	namespacesKey = "namespaces"
+	startedKey    = "started"
+)
+
+// The phases of a namespaces reset job, in the order in which they happen.
+const (
+	// ResetPhaseDeleting means that the deletion of some namespaces was requested but is not visible yet
+	ResetPhaseDeleting = "deleting"
+	// ResetPhaseTerminating means that some namespaces are being terminated
+	ResetPhaseTerminating = "terminating"
+	// ResetPhaseRecreating means that some namespaces are deleted and wait to be recreated by the NSTemplateSet controller
+	ResetPhaseRecreating = "recreating"
+	// ResetPhaseReady means that all the namespaces were recreated and are ready to be used
+	ResetPhaseReady = "ready"
+)
+
+var resetPhases = []string{ResetPhaseDeleting, ResetPhaseTerminating, ResetPhaseRecreating, ResetPhaseReady}
+
+// ErrResetJobNotFound is returned when the namespaces reset job does not exist, or belongs to another user
+var ErrResetJobNotFound = errors.New("namespaces reset job not found")
+
+// ResetJob is the progress of a namespaces reset
+type ResetJob struct {
+	// ID identifies the job
+	ID string `json:"id"`
+	// Phase is the phase of the least advanced namespace of the job
+	Phase string `json:"phase"`
+	// Started is the time at which the reset was requested
+	Started time.Time `json:"started"`
+	// Namespaces are the phases of the individual namespaces
+	Namespaces []NamespacePhase `json:"namespaces"`
+}
+
+// NamespacePhase is the phase of a namespace during a reset
+type NamespacePhase struct {
+	// Name is the name of the namespace
+	Name string `json:"name"`
+	// Phase is the phase of the namespace
+	Phase string `json:"phase"`
+}
+
+// createResetJob stores a job to track the recreation of the given namespaces of the user. Only the latest job of a
+// user is kept, and the jobs of the other users are deleted once they expire, so that the jobs do not pile up.
+func (mgr *manager) createResetJob(ginCtx *gin.Context, compliantUsername, clusterName string, namespaces []string, started time.Time) (string, error) {
+	ctx := ginCtx.Request.Context()
+	id, err := newResetJobID()
+	if err != nil {
+		return "", err
+	}
+	previous := &v1.ConfigMapList{}
+	if err := mgr.hostNamespaceClient.List(ctx, previous, client.InNamespace(mgr.hostNamespaceClient.Namespace),
+		client.HasLabels{ResetJobLabelKey}); err != nil {
+		return "", fmt.Errorf("unable to list the previous namespaces reset jobs: %w", err)
+	}
+	ttl := configuration.GetRegistrationServiceConfig().Namespaces().ResetJobTTL()
+	for i := range previous.Items {
+		// the jobs with an invalid start time are deleted as well, since they cannot be read anyway
+		previousStarted, err := time.Parse(time.RFC3339, previous.Items[i].Data[startedKey])
+		if previous.Items[i].Labels[ResetJobLabelKey] != compliantUsername && err == nil && started.Sub(previousStarted) <= ttl {
+			continue
+		}
+		if err := mgr.hostNamespaceClient.Delete(ctx, &previous.Items[i]); err != nil && !apierrors.IsNotFound(err) {
+			return "", fmt.Errorf("unable to delete the previous namespaces reset job: %w", err)
+		}
+	}
+	job := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      resetJobNamePrefix + id,
+			Namespace: mgr.hostNamespaceClient.Namespace,
+			Labels: map[string]string{
+				configuration.ConfigMapLabelKey: "namespaces-reset",
+				ResetJobLabelKey:                compliantUsername,
+			},
+		},
+		Data: map[string]string{
+			clusterKey:    clusterName,
+			namespacesKey: strings.Join(namespaces, ","),
+			startedKey:    started.UTC().Format(time.RFC3339),
+		},
+	}
+	if err := mgr.hostNamespaceClient.Create(ctx, job); err != nil {
+		return "", fmt.Errorf("unable to create the namespaces reset job: %w", err)
+	}
+	return id, nil
+}
+
+func (mgr *manager) ResetJob(ginCtx *gin.Context, id string) (*ResetJob, error) {
+	compliantUsername, err := mgr.compliantUsername(ginCtx)
+	if err != nil {
+		return nil, err
+	}
+	ctx := ginCtx.Request.Context()
+
+	cm := &v1.ConfigMap{}
+	key := mgr.hostNamespaceClient.NamespacedName(resetJobNamePrefix + id)
+	err = mgr.hostNamespaceClient.Get(ctx, key, cm)
+	if apierrors.IsNotFound(err) {
+		// the cache may not contain the job yet if it was just created
+		err = mgr.hostNamespaceClient.APIReader.Get(ctx, key, cm)
+	}
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, ErrResetJobNotFound
+		}
+		return nil, fmt.Errorf("unable to get the namespaces reset job: %w", err)
+	}
+	if cm.Labels[ResetJobLabelKey] != compliantUsername {
+		return nil, ErrResetJobNotFound
+	}
+	started, err := time.Parse(time.RFC3339, cm.Data[startedKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid start time of namespaces reset job '%s': %w", id, err)
+	}
+	if time.Since(started) > configuration.GetRegistrationServiceConfig().Namespaces().ResetJobTTL() {
+		// expired, and deleted on the next reset of any user
+		return nil, ErrResetJobNotFound
+	}
+
+	clusterName := cm.Data[clusterKey]
+	memberClusters := mgr.getMemberClustersFunc(func(clstr *cluster.CachedToolchainCluster) bool {
+		return clstr.Name == clusterName
+	})
+	if len(memberClusters) == 0 || memberClusters[0].Client == nil {
+		return nil, fmt.Errorf(`unable to locate the cluster "%s" of the namespaces reset job`, clusterName)
+	}
+	memberCluster := memberClusters[0]
+
+	job := &ResetJob{
+		ID:      id,
+		Phase:   ResetPhaseReady,
+		Started: started,
+	}
+	for _, name := range strings.Split(cm.Data[namespacesKey], ",") {
+		phase, err := namespacePhase(ginCtx, memberCluster, name, started)
+		if err != nil {
+			return nil, err
+		}
+		job.Namespaces = append(job.Namespaces, NamespacePhase{Name: name, Phase: phase})
+		if slices.Index(resetPhases, phase) < slices.Index(resetPhases, job.Phase) {
+			job.Phase = phase
+		}
+	}
+
+	if job.Phase == ResetPhaseReady {
+		// the namespaces exist again, but their content is only provisioned once the NSTemplateSet is ready
+		var nsTemplateSet toolchainv1alpha1.NSTemplateSet
+		err := memberCluster.Client.Get(ctx, types.NamespacedName{Namespace: memberCluster.OperatorNamespace, Name: compliantUsername}, &nsTemplateSet)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf(`unable to get the "NSTemplateSet" resource for the user in cluster "%s": %w`, memberCluster.Name, err)
+		}
+		if err != nil || !condition.IsTrue(nsTemplateSet.Status.Conditions, toolchainv1alpha1.ConditionReady) {
+			job.Phase = ResetPhaseRecreating
+		}
+	}
+	return job, nil
+}
+
+// namespacePhase returns the phase of the given namespace, which was reset at the given time
+func namespacePhase(ginCtx *gin.Context, memberCluster *cluster.CachedToolchainCluster, name string, started time.Time) (string, error) {
+	namespace := &v1.Namespace{}
+	err := memberCluster.Client.Get(ginCtx.Request.Context(), types.NamespacedName{Name: name}, namespace)
+	switch {
+	case apierrors.IsNotFound(err):
+		return ResetPhaseRecreating, nil
+	case err != nil:
+		return "", fmt.Errorf(`unable to get user namespace "%s" in cluster "%s": %w`, name, memberCluster.Name, err)
+	case namespace.DeletionTimestamp != nil:
+		return ResetPhaseTerminating, nil
+	case namespace.CreationTimestamp.Time.Before(started):
+		// the namespace is still the one which existed before the reset
+		return ResetPhaseDeleting, nil
+	}
+	return ResetPhaseReady, nil
+}
+
+func newResetJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
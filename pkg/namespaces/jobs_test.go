@@ -0,0 +1,292 @@
+package namespaces
+
+import (
+	gocontext "context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	"github.com/codeready-toolchain/registration-service/pkg/context"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/signup"
+	"github.com/codeready-toolchain/registration-service/test"
+	"github.com/codeready-toolchain/registration-service/test/fake"
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TestResetJobs tests that the namespaces resets create a job which tracks
+// the recreation of the namespaces.
+func (nms *TestNamespacesManagerSuite) TestResetJobs() {
+	const (
+		hostNamespace   = "toolchain-host-operator"
+		memberNamespace = "toolchain-member-operator"
+		username        = "ted"
+	)
+
+	newContext := func(username string) *gin.Context {
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/reset-namespaces", nil)
+		require.NoError(nms.T(), err)
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = req
+		ctx.Set(context.UsernameKey, username)
+		return ctx
+	}
+	space := &toolchainv1alpha1.Space{
+		ObjectMeta: metav1.ObjectMeta{Name: username, Namespace: hostNamespace},
+		Spec:       toolchainv1alpha1.SpaceSpec{TargetCluster: "member-cluster"},
+	}
+	newNSTemplateSet := func(ready bool) *toolchainv1alpha1.NSTemplateSet {
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		return &toolchainv1alpha1.NSTemplateSet{
+			ObjectMeta: metav1.ObjectMeta{Name: username, Namespace: memberNamespace},
+			Status: toolchainv1alpha1.NSTemplateSetStatus{
+				ProvisionedNamespaces: []toolchainv1alpha1.SpaceNamespace{{Name: "ted-dev"}, {Name: "ted-stage"}},
+				Conditions:            []toolchainv1alpha1.Condition{{Type: toolchainv1alpha1.ConditionReady, Status: status}},
+			},
+		}
+	}
+	newNamespace := func(name string, created time.Time) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(created)}}
+	}
+	// returns a manager whose cache is the given client, read without the cache with the given reader
+	newManagerWithAPIReader := func(fakeClient client.Client, apiReader client.Reader) Manager {
+		memberCluster := &cluster.CachedToolchainCluster{
+			Client: fakeClient,
+			Config: &cluster.Config{Name: "member-cluster", OperatorNamespace: memberNamespace},
+		}
+		signupService := fake.NewSignupService(
+			&signup.Signup{Name: username, Username: username, CompliantUsername: username},
+			&signup.Signup{Name: "jane", Username: "jane", CompliantUsername: "jane"},
+		)
+		return NewNamespacesManager(getMemberClusters([]*cluster.CachedToolchainCluster{memberCluster}),
+			namespaced.NewClientWithAPIReader(fakeClient, apiReader, hostNamespace), signupService)
+	}
+	newManager := func(fakeClient client.Client) Manager {
+		return newManagerWithAPIReader(fakeClient, fakeClient)
+	}
+	getJobs := func(fakeClient client.Client) []corev1.ConfigMap {
+		jobs := &corev1.ConfigMapList{}
+		require.NoError(nms.T(), fakeClient.List(gocontext.TODO(), jobs, client.InNamespace(hostNamespace), client.HasLabels{ResetJobLabelKey}))
+		return jobs.Items
+	}
+	created := time.Now().Add(-24 * time.Hour)
+
+	nms.Run("the reset creates a job", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(nms.T(), space, newNSTemplateSet(true),
+			newNamespace("ted-dev", created), newNamespace("ted-stage", created))
+		manager := newManager(fakeClient)
+
+		// when
+		reset, err := manager.ResetNamespaces(newContext(username), ResetOptions{})
+
+		// then
+		require.NoError(nms.T(), err)
+		require.NotEmpty(nms.T(), reset.JobID)
+		jobs := getJobs(fakeClient)
+		require.Len(nms.T(), jobs, 1)
+		assert.Equal(nms.T(), resetJobNamePrefix+reset.JobID, jobs[0].Name)
+		assert.Equal(nms.T(), username, jobs[0].Labels[ResetJobLabelKey])
+		assert.Equal(nms.T(), "namespaces-reset", jobs[0].Labels[configuration.ConfigMapLabelKey])
+		assert.Equal(nms.T(), "ted-dev,ted-stage", jobs[0].Data[namespacesKey])
+
+		nms.Run("the namespaces are being recreated", func() {
+			// when
+			job, err := manager.ResetJob(newContext(username), reset.JobID)
+
+			// then
+			require.NoError(nms.T(), err)
+			assert.Equal(nms.T(), reset.JobID, job.ID)
+			assert.Equal(nms.T(), ResetPhaseRecreating, job.Phase)
+			assert.Equal(nms.T(), []NamespacePhase{
+				{Name: "ted-dev", Phase: ResetPhaseRecreating},
+				{Name: "ted-stage", Phase: ResetPhaseRecreating},
+			}, job.Namespaces)
+		})
+
+		nms.Run("the job just created is read without the cache", func() {
+			// given
+			cachedClient := commontest.NewFakeClient(nms.T(), space, newNSTemplateSet(true),
+				newNamespace("ted-dev", created), newNamespace("ted-stage", created))
+			// the cache does not contain the job yet
+			cachedClient.MockGet = func(ctx gocontext.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if _, ok := obj.(*corev1.ConfigMap); ok {
+					return apierrors.NewNotFound(corev1.Resource("configmaps"), key.Name)
+				}
+				return cachedClient.Client.Get(ctx, key, obj, opts...)
+			}
+			manager := newManagerWithAPIReader(cachedClient, fakeClient)
+
+			// when
+			job, err := manager.ResetJob(newContext(username), reset.JobID)
+
+			// then
+			require.NoError(nms.T(), err)
+			assert.Equal(nms.T(), reset.JobID, job.ID)
+		})
+
+		nms.Run("the job of another user cannot be read", func() {
+			// when
+			_, err := manager.ResetJob(newContext("jane"), reset.JobID)
+
+			// then
+			require.ErrorIs(nms.T(), err, ErrResetJobNotFound)
+		})
+
+		nms.Run("the next reset replaces the job", func() {
+			// when
+			next, err := manager.ResetNamespaces(newContext(username), ResetOptions{Namespaces: []string{"ted-dev"}})
+
+			// then
+			require.NoError(nms.T(), err)
+			jobs := getJobs(fakeClient)
+			require.Len(nms.T(), jobs, 1)
+			assert.Equal(nms.T(), resetJobNamePrefix+next.JobID, jobs[0].Name)
+			assert.Equal(nms.T(), "ted-dev", jobs[0].Data[namespacesKey])
+			_, err = manager.ResetJob(newContext(username), reset.JobID)
+			require.ErrorIs(nms.T(), err, ErrResetJobNotFound)
+		})
+	})
+
+	nms.Run("the reset deletes the expired jobs of the other users", func() {
+		// given
+		newJob := func(name, username string, started time.Time) *corev1.ConfigMap {
+			return &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: resetJobNamePrefix + name, Namespace: hostNamespace, Labels: map[string]string{ResetJobLabelKey: username}},
+				Data:       map[string]string{clusterKey: "member-cluster", namespacesKey: username + "-dev", startedKey: started.UTC().Format(time.RFC3339)},
+			}
+		}
+		fakeClient := commontest.NewFakeClient(nms.T(), space, newNSTemplateSet(true),
+			newNamespace("ted-dev", created), newNamespace("ted-stage", created),
+			newJob("expired", "jane", time.Now().Add(-25*time.Hour)),
+			newJob("recent", "john", time.Now().Add(-time.Hour)))
+
+		// when
+		reset, err := newManager(fakeClient).ResetNamespaces(newContext(username), ResetOptions{})
+
+		// then
+		require.NoError(nms.T(), err)
+		var names []string
+		for _, job := range getJobs(fakeClient) {
+			names = append(names, job.Name)
+		}
+		assert.ElementsMatch(nms.T(), []string{resetJobNamePrefix + reset.JobID, resetJobNamePrefix + "recent"}, names)
+	})
+
+	nms.Run("no job is created", func() {
+		for name, opts := range map[string]ResetOptions{
+			"in a dry run":           {DryRun: true},
+			"for an untracked reset": {Untracked: true},
+		} {
+			nms.Run(name, func() {
+				// given
+				fakeClient := commontest.NewFakeClient(nms.T(), space, newNSTemplateSet(true),
+					newNamespace("ted-dev", created), newNamespace("ted-stage", created))
+
+				// when
+				reset, err := newManager(fakeClient).ResetNamespaces(newContext(username), opts)
+
+				// then
+				require.NoError(nms.T(), err)
+				assert.Empty(nms.T(), reset.JobID)
+				assert.Empty(nms.T(), getJobs(fakeClient))
+			})
+		}
+	})
+
+	nms.Run("phases", func() {
+		started := time.Now().Add(-time.Minute).Truncate(time.Second)
+		job := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      resetJobNamePrefix + "0123456789abcdef",
+				Namespace: hostNamespace,
+				Labels:    map[string]string{ResetJobLabelKey: username},
+			},
+			Data: map[string]string{
+				clusterKey:    "member-cluster",
+				namespacesKey: "ted-dev,ted-stage",
+				startedKey:    started.Format(time.RFC3339),
+			},
+		}
+		terminating := newNamespace("ted-dev", created)
+		terminating.DeletionTimestamp = &metav1.Time{Time: started}
+		terminating.Finalizers = []string{"kubernetes"}
+
+		for name, tc := range map[string]struct {
+			objects       []client.Object
+			expectedPhase string
+			expected      []NamespacePhase
+		}{
+			"deletion not visible yet": {
+				objects:       []client.Object{newNSTemplateSet(true), newNamespace("ted-dev", created), newNamespace("ted-stage", started.Add(time.Second))},
+				expectedPhase: ResetPhaseDeleting,
+				expected:      []NamespacePhase{{Name: "ted-dev", Phase: ResetPhaseDeleting}, {Name: "ted-stage", Phase: ResetPhaseReady}},
+			},
+			"namespace terminating": {
+				objects:       []client.Object{newNSTemplateSet(false), terminating},
+				expectedPhase: ResetPhaseTerminating,
+				expected:      []NamespacePhase{{Name: "ted-dev", Phase: ResetPhaseTerminating}, {Name: "ted-stage", Phase: ResetPhaseRecreating}},
+			},
+			"namespaces recreated but not provisioned yet": {
+				objects:       []client.Object{newNSTemplateSet(false), newNamespace("ted-dev", started.Add(time.Second)), newNamespace("ted-stage", started.Add(time.Second))},
+				expectedPhase: ResetPhaseRecreating,
+				expected:      []NamespacePhase{{Name: "ted-dev", Phase: ResetPhaseReady}, {Name: "ted-stage", Phase: ResetPhaseReady}},
+			},
+			"namespaces ready": {
+				objects:       []client.Object{newNSTemplateSet(true), newNamespace("ted-dev", started.Add(time.Second)), newNamespace("ted-stage", started.Add(time.Second))},
+				expectedPhase: ResetPhaseReady,
+				expected:      []NamespacePhase{{Name: "ted-dev", Phase: ResetPhaseReady}, {Name: "ted-stage", Phase: ResetPhaseReady}},
+			},
+		} {
+			nms.Run(name, func() {
+				// given
+				fakeClient := commontest.NewFakeClient(nms.T(), append(tc.objects, space, job.DeepCopy())...)
+
+				// when
+				result, err := newManager(fakeClient).ResetJob(newContext(username), "0123456789abcdef")
+
+				// then
+				require.NoError(nms.T(), err)
+				assert.Equal(nms.T(), tc.expectedPhase, result.Phase)
+				assert.Equal(nms.T(), tc.expected, result.Namespaces)
+				assert.True(nms.T(), started.Equal(result.Started))
+			})
+		}
+
+		nms.Run("expired job", func() {
+			// given
+			test.SetSettings(nms.T(), "namespaces: {resetJobTTL: 30s}")
+			fakeClient := commontest.NewFakeClient(nms.T(), space, job, newNSTemplateSet(true))
+
+			// when
+			_, err := newManager(fakeClient).ResetJob(newContext(username), "0123456789abcdef")
+
+			// then
+			require.ErrorIs(nms.T(), err, ErrResetJobNotFound)
+		})
+
+		nms.Run("unknown job", func() {
+			// given
+			fakeClient := commontest.NewFakeClient(nms.T(), space)
+
+			// when
+			_, err := newManager(fakeClient).ResetJob(newContext(username), "0123456789abcdef")
+
+			// then
+			require.ErrorIs(nms.T(), err, ErrResetJobNotFound)
+		})
+	})
+}
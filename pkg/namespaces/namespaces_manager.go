@@ -3,18 +3,24 @@ package namespaces
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	"github.com/codeready-toolchain/registration-service/pkg/application/service"
+	"github.com/codeready-toolchain/registration-service/pkg/configuration"
 	customCtx "github.com/codeready-toolchain/registration-service/pkg/context"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
 	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
 	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
 	"github.com/gin-gonic/gin"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // ErrUserSignUpNotFoundDeactivated is a custom error type used for signaling
@@ -41,17 +47,94 @@ func (e ErrUserHasNoProvisionedNamespaces) Error() string {
 	return fmt.Sprintf(`the associated NSTemplateSet "%s" in the member cluster "%s" does not have any provisioned namespaces`, e.nsTemplateSetName, e.memberClusterName)
 }
 
+// ErrNamespaceNotProvisioned is returned when a namespace to reset is not one of the namespaces provisioned for the user.
+var ErrNamespaceNotProvisioned = errors.New("the namespace is not provisioned for the user")
+
+// The statuses of the namespaces in the results of a reset.
+const (
+	// NamespaceDeleted means that the namespace was deleted, so that it gets recreated
+	NamespaceDeleted = "deleted"
+	// NamespaceAlreadyDeleted means that the namespace did not exist anymore, eg because it was already being recreated
+	NamespaceAlreadyDeleted = "alreadyDeleted"
+	// NamespaceToBeDeleted means that the namespace would be deleted by the reset, in a dry run
+	NamespaceToBeDeleted = "toBeDeleted"
+	// NamespaceFailed means that the namespace could not be deleted, or inspected in a dry run
+	NamespaceFailed = "failed"
+)
+
+// ResetOptions are the options of a namespaces reset.
+type ResetOptions struct {
+	// Namespaces are the names of the namespaces to reset. All the provisioned namespaces of the user are reset if empty.
+	Namespaces []string
+	// DryRun only reports what would be deleted, without deleting anything.
+	DryRun bool
+	// Untracked does not create a job to track the recreation of the namespaces, eg because they will not be recreated.
+	Untracked bool
+}
+
+// Reset is the outcome of a namespaces reset.
+type Reset struct {
+	// JobID identifies the job which tracks the recreation of the namespaces, see Manager.ResetJob.
+	// It is empty if there is nothing to track, eg in a dry run.
+	JobID string
+	// Namespaces are the results of the reset of each namespace
+	Namespaces NamespaceResults
+}
+
+// NamespaceResult is the result of the reset of a single namespace.
+type NamespaceResult struct {
+	// Name is the name of the namespace
+	Name string `json:"name"`
+	// Status is the outcome of the reset of the namespace, eg "deleted" or "failed"
+	Status string `json:"status"`
+	// Resources are the number of resources in the namespace by kind, only set in a dry run
+	Resources map[string]int `json:"resources,omitempty"`
+	// Error explains why the namespace could not be reset, if it failed
+	Error string `json:"error,omitempty"`
+}
+
+// NamespaceResults are the results of a namespaces reset, one per namespace.
+type NamespaceResults []NamespaceResult
+
+// Failed returns true if any of the namespaces could not be reset.
+func (r NamespaceResults) Failed() bool {
+	for _, result := range r {
+		if result.Status == NamespaceFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// countedResources are the kinds of resources which are counted in the namespaces to reset during a dry run, so that
+// the user knows what would be lost.
+var countedResources = map[string]func() client.ObjectList{
+	"pods":                   func() client.ObjectList { return &v1.PodList{} },
+	"services":               func() client.ObjectList { return &v1.ServiceList{} },
+	"configmaps":             func() client.ObjectList { return &v1.ConfigMapList{} },
+	"secrets":                func() client.ObjectList { return &v1.SecretList{} },
+	"persistentvolumeclaims": func() client.ObjectList { return &v1.PersistentVolumeClaimList{} },
+}
+
 // Manager manages the user's namespaces.
 type Manager interface {
 	// ResetNamespaces locates the user's namespaces in their corresponding member clusters and deletes them, so that
-	// the NSTemplate controller can recreate them.
-	ResetNamespaces(ginCtx *gin.Context) error
+	// the NSTemplate controller can recreate them. The options allow to only reset some of the namespaces, or to only
+	// report what would be deleted. The failures to delete the individual namespaces are reported in the results,
+	// the returned error is about the user's resources which could not be retrieved or the invalid options.
+	// The namespaces are recreated asynchronously: the progress can be followed with the returned job.
+	ResetNamespaces(ginCtx *gin.Context, opts ResetOptions) (*Reset, error)
+	// ResetJob returns the progress of the namespaces reset job with the given ID, which must belong to the user.
+	ResetJob(ginCtx *gin.Context, id string) (*ResetJob, error)
+	// Usage returns the consumption of the resources of the user's namespaces. The usage is cached for a short time.
+	Usage(ginCtx *gin.Context) ([]NamespaceUsage, error)
 }
 
 type manager struct {
 	getMemberClustersFunc cluster.GetMemberClustersFunc
 	hostNamespaceClient   namespaced.Client
 	signupService         service.SignupService
+	usageCache            *usageCache
 }
 
 // NewNamespacesManager creates a new instance of the manager which can be used to manage user's namespaces.
@@ -60,33 +143,85 @@ func NewNamespacesManager(getMemberClustersFunc cluster.GetMemberClustersFunc, h
 		getMemberClustersFunc: getMemberClustersFunc,
 		hostNamespaceClient:   hostNamespaceClient,
 		signupService:         signupService,
+		usageCache:            newUsageCache(configuration.GetRegistrationServiceConfig().Namespaces().UsageCacheTTL()),
 	}
 }
 
-func (mgr *manager) ResetNamespaces(ginCtx *gin.Context) error {
-	// Grab the corresponding user signup resource to get the user's compliant
-	// username, since that is the one that is used across the Developer
-	// Sandbox resources.
-	userSignup, err := mgr.signupService.GetSignup(ginCtx, ginCtx.GetString(customCtx.UsernameKey), true)
+// userNamespaces are the namespaces provisioned for the user in a member cluster
+type userNamespaces struct {
+	memberCluster *cluster.CachedToolchainCluster
+	names         []string
+}
+
+func (mgr *manager) ResetNamespaces(ginCtx *gin.Context, opts ResetOptions) (*Reset, error) {
+	compliantUsername, err := mgr.compliantUsername(ginCtx)
 	if err != nil {
-		return fmt.Errorf("unable to obtain the user signup: %w", err)
+		return nil, err
 	}
 
-	// The SignupService might return a "nil" user signup if the user is not
-	// found or is deactivated. The service can also return an empty compliant
-	// username if the user is on "pending approval" state or the signup, for
-	// some reason, was incomplete.
-	if userSignup == nil || strings.TrimSpace(userSignup.CompliantUsername) == "" {
-		return ErrUserSignUpNotFoundOrDeactivated
+	// Determine which namespaces need to be reset. Nothing is deleted before all the requested namespaces are known
+	// to be provisioned for the user.
+	targetCluster, provisioned, err := mgr.provisionedNamespaces(ginCtx, compliantUsername)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Namespaces) > 0 {
+		for i := range provisioned {
+			provisioned[i].names = slices.DeleteFunc(provisioned[i].names, func(name string) bool {
+				return !slices.Contains(opts.Namespaces, name)
+			})
+		}
+	}
+
+	var unknown []string
+	for _, name := range opts.Namespaces {
+		if !slices.ContainsFunc(provisioned, func(ns userNamespaces) bool { return slices.Contains(ns.names, name) }) {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf(`%w: "%s"`, ErrNamespaceNotProvisioned, strings.Join(unknown, `", "`))
 	}
 
-	compliantUsername := userSignup.CompliantUsername
+	started := time.Now().Truncate(time.Second)
+	results := NamespaceResults{}
+	for _, ns := range provisioned {
+		for _, name := range ns.names {
+			if opts.DryRun {
+				results = append(results, mgr.inspectNamespace(ginCtx, ns.memberCluster, name))
+			} else {
+				results = append(results, mgr.deleteNamespace(ginCtx, ns.memberCluster, name))
+			}
+		}
+	}
+
+	reset := &Reset{Namespaces: results}
+	if opts.DryRun || opts.Untracked {
+		return reset, nil
+	}
+	// the namespaces which could not be deleted are not recreated, so they are not tracked
+	var deleted []string
+	for _, result := range results {
+		if result.Status != NamespaceFailed {
+			deleted = append(deleted, result.Name)
+		}
+	}
+	if len(deleted) > 0 {
+		if reset.JobID, err = mgr.createResetJob(ginCtx, compliantUsername, targetCluster, deleted, started); err != nil {
+			return nil, err
+		}
+	}
+	return reset, nil
+}
 
+// provisionedNamespaces locates the namespaces provisioned for the user in their corresponding member clusters.
+// It also returns the name of the cluster targeted by the user's space.
+func (mgr *manager) provisionedNamespaces(ginCtx *gin.Context, compliantUsername string) (string, []userNamespaces, error) {
 	// Fetch the user's space.
 	var userSpace toolchainv1alpha1.Space
-	err = mgr.hostNamespaceClient.Get(ginCtx.Request.Context(), types.NamespacedName{Namespace: mgr.hostNamespaceClient.Namespace, Name: compliantUsername}, &userSpace)
+	err := mgr.hostNamespaceClient.Get(ginCtx.Request.Context(), types.NamespacedName{Namespace: mgr.hostNamespaceClient.Namespace, Name: compliantUsername}, &userSpace)
 	if err != nil {
-		return fmt.Errorf(`unable to get user's space resource: %w`, err)
+		return "", nil, fmt.Errorf(`unable to get user's space resource: %w`, err)
 	}
 
 	// Get the client for the cluster in which the user's NSTemplateSet is located.
@@ -95,39 +230,91 @@ func (mgr *manager) ResetNamespaces(ginCtx *gin.Context) error {
 	})
 
 	if len(memberClusters) == 0 {
-		return fmt.Errorf(`unable to locate the target cluster "%s" for the user`, userSpace.Spec.TargetCluster)
+		return "", nil, fmt.Errorf(`unable to locate the target cluster "%s" for the user`, userSpace.Spec.TargetCluster)
 	}
 
-	// Loop through the member clusters to get the NSTemplateSet of the user and determine which namespaces need
-	// to be reset.
+	// Loop through the member clusters to get the NSTemplateSet of the user, which holds the provisioned namespaces.
+	provisioned := make([]userNamespaces, 0, len(memberClusters))
 	for _, memberCluster := range memberClusters {
 		if memberCluster.Client == nil {
-			return fmt.Errorf(`unable to obtain the client for cluster "%s"`, memberCluster.Name)
+			return "", nil, fmt.Errorf(`unable to obtain the client for cluster "%s"`, memberCluster.Name)
 		}
 
-		// Obtain the user's NSTemplateSet to be able to determine which namespaces we are deleting.
 		var nsTemplateSet toolchainv1alpha1.NSTemplateSet
 		err := memberCluster.Client.Get(ginCtx.Request.Context(), types.NamespacedName{Namespace: memberCluster.OperatorNamespace, Name: compliantUsername}, &nsTemplateSet)
 		if err != nil {
-			return fmt.Errorf(`unable to get the "NSTemplateSet" resource for the user in cluster "%s": %w`, memberCluster.Name, err)
+			return "", nil, fmt.Errorf(`unable to get the "NSTemplateSet" resource for the user in cluster "%s": %w`, memberCluster.Name, err)
 		}
 
 		if len(nsTemplateSet.Status.ProvisionedNamespaces) == 0 {
-			return NewErrUserHasNoProvisionedNamespaces(memberCluster.Name, nsTemplateSet.Name)
+			return "", nil, NewErrUserHasNoProvisionedNamespaces(memberCluster.Name, nsTemplateSet.Name)
 		}
 
-		// Delete the given namespaces from the cluster. We use individual
-		// requests instead of a single "DeleteAllOf" call because even if the
-		// service account has the required permissions, the requests end up
-		// failing with a "the server does not allow this method on the
-		// requested resource" error.
+		names := make([]string, 0, len(nsTemplateSet.Status.ProvisionedNamespaces))
 		for _, namespace := range nsTemplateSet.Status.ProvisionedNamespaces {
-			err := memberCluster.Client.Delete(ginCtx.Request.Context(), &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace.Name}})
-			if err != nil && !apierrors.IsNotFound(err) {
-				return fmt.Errorf(`unable to delete user namespace "%s" in cluster "%s": %w`, namespace.Name, memberCluster.Name, err)
-			}
+			names = append(names, namespace.Name)
 		}
+		provisioned = append(provisioned, userNamespaces{memberCluster: memberCluster, names: names})
+	}
+	return userSpace.Spec.TargetCluster, provisioned, nil
+}
+
+// compliantUsername returns the compliant username of the user, which is the one that is used across the Developer
+// Sandbox resources.
+func (mgr *manager) compliantUsername(ginCtx *gin.Context) (string, error) {
+	// Grab the corresponding user signup resource to get the user's compliant
+	// username.
+	userSignup, err := mgr.signupService.GetSignup(ginCtx, ginCtx.GetString(customCtx.UsernameKey), true)
+	if err != nil {
+		return "", fmt.Errorf("unable to obtain the user signup: %w", err)
+	}
+
+	// The SignupService might return a "nil" user signup if the user is not
+	// found or is deactivated. The service can also return an empty compliant
+	// username if the user is on "pending approval" state or the signup, for
+	// some reason, was incomplete.
+	if userSignup == nil || strings.TrimSpace(userSignup.CompliantUsername) == "" {
+		return "", ErrUserSignUpNotFoundOrDeactivated
 	}
 
-	return nil
+	return userSignup.CompliantUsername, nil
+}
+
+// deleteNamespace deletes the given namespace from the cluster. We use individual
+// requests instead of a single "DeleteAllOf" call because even if the
+// service account has the required permissions, the requests end up
+// failing with a "the server does not allow this method on the
+// requested resource" error.
+func (mgr *manager) deleteNamespace(ginCtx *gin.Context, memberCluster *cluster.CachedToolchainCluster, name string) NamespaceResult {
+	err := memberCluster.Client.Delete(ginCtx.Request.Context(), &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	switch {
+	case apierrors.IsNotFound(err):
+		return NamespaceResult{Name: name, Status: NamespaceAlreadyDeleted}
+	case err != nil:
+		log.Errorf(ginCtx, err, `unable to delete user namespace "%s" in cluster "%s"`, name, memberCluster.Name)
+		return NamespaceResult{Name: name, Status: NamespaceFailed, Error: "unable to delete the namespace"}
+	}
+	return NamespaceResult{Name: name, Status: NamespaceDeleted}
+}
+
+// inspectNamespace reports what the deletion of the given namespace would delete, without deleting anything.
+func (mgr *manager) inspectNamespace(ginCtx *gin.Context, memberCluster *cluster.CachedToolchainCluster, name string) NamespaceResult {
+	err := memberCluster.Client.Get(ginCtx.Request.Context(), types.NamespacedName{Name: name}, &v1.Namespace{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return NamespaceResult{Name: name, Status: NamespaceAlreadyDeleted}
+	case err != nil:
+		log.Errorf(ginCtx, err, `unable to get user namespace "%s" in cluster "%s"`, name, memberCluster.Name)
+		return NamespaceResult{Name: name, Status: NamespaceFailed, Error: "unable to inspect the namespace"}
+	}
+	resources := make(map[string]int, len(countedResources))
+	for kind, newList := range countedResources {
+		list := newList()
+		if err := memberCluster.Client.List(ginCtx.Request.Context(), list, client.InNamespace(name)); err != nil {
+			log.Errorf(ginCtx, err, `unable to list the %s of user namespace "%s" in cluster "%s"`, kind, name, memberCluster.Name)
+			return NamespaceResult{Name: name, Status: NamespaceFailed, Error: "unable to inspect the namespace"}
+		}
+		resources[kind] = meta.LenList(list)
+	}
+	return NamespaceResult{Name: name, Status: NamespaceToBeDeleted, Resources: resources}
 }
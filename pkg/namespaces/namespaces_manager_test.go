@@ -18,7 +18,9 @@ import (
 	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -152,7 +154,7 @@ func (nms *TestNamespacesManagerSuite) TestResetNamespaces() {
 
 		// when
 		// Call the function under test.
-		err := namespacesManager.ResetNamespaces(ctx)
+		_, err := namespacesManager.ResetNamespaces(ctx, ResetOptions{})
 
 		// then
 		// Assert that the returned error is the expected one.
@@ -186,7 +188,7 @@ func (nms *TestNamespacesManagerSuite) TestResetNamespaces() {
 
 			// when
 			// Call the function under test.
-			err := namespacesManager.ResetNamespaces(ctx)
+			_, err := namespacesManager.ResetNamespaces(ctx, ResetOptions{})
 
 			// then
 			// Assert that the returned error is the expected one.
@@ -209,7 +211,7 @@ func (nms *TestNamespacesManagerSuite) TestResetNamespaces() {
 
 		// when
 		// Call the function under test.
-		err := namespacesManager.ResetNamespaces(ctx)
+		_, err := namespacesManager.ResetNamespaces(ctx, ResetOptions{})
 
 		// then
 		// Assert that the returned error is the expected one.
@@ -242,7 +244,7 @@ func (nms *TestNamespacesManagerSuite) TestResetNamespaces() {
 
 		// when
 		// Call the function under test.
-		err := namespacesManager.ResetNamespaces(ctx)
+		_, err := namespacesManager.ResetNamespaces(ctx, ResetOptions{})
 
 		// then
 		// Assert that the returned error is the expected one.
@@ -273,7 +275,7 @@ func (nms *TestNamespacesManagerSuite) TestResetNamespaces() {
 
 		// when
 		// Call the function under test.
-		err := namespacesManager.ResetNamespaces(ctx)
+		_, err := namespacesManager.ResetNamespaces(ctx, ResetOptions{})
 
 		// then
 		// Assert that the returned error is the expected one.
@@ -301,7 +303,7 @@ func (nms *TestNamespacesManagerSuite) TestResetNamespaces() {
 
 		// when
 		// Call the function under test.
-		err := namespacesManager.ResetNamespaces(ctx)
+		_, err := namespacesManager.ResetNamespaces(ctx, ResetOptions{})
 
 		// then
 		// Assert that the returned error is the expected one.
@@ -336,7 +338,7 @@ func (nms *TestNamespacesManagerSuite) TestResetNamespaces() {
 
 		// when
 		// Call the function under test.
-		err := namespacesManager.ResetNamespaces(ctx)
+		_, err := namespacesManager.ResetNamespaces(ctx, ResetOptions{})
 
 		// then
 		// Assert that the returned error is the expected one.
@@ -377,11 +379,13 @@ func (nms *TestNamespacesManagerSuite) TestResetNamespaces() {
 
 		// when
 		// Call the function under test.
-		err := namespacesManager.ResetNamespaces(ctx)
+		reset, err := namespacesManager.ResetNamespaces(ctx, ResetOptions{})
 
 		// then
 		// Assert that no error is returned since "not found" errors are ignored.
 		assert.NoError(nms.T(), err)
+		assert.False(nms.T(), reset.Namespaces.Failed())
+		assert.Equal(nms.T(), NamespaceAlreadyDeleted, reset.Namespaces[0].Status)
 	})
 
 	nms.Run("when unable to delete a user namespace an error is returned", func() {
@@ -414,11 +418,18 @@ func (nms *TestNamespacesManagerSuite) TestResetNamespaces() {
 
 		// when
 		// Call the function under test.
-		err := namespacesManager.ResetNamespaces(ctx)
+		reset, err := namespacesManager.ResetNamespaces(ctx, ResetOptions{})
 
 		// then
-		// Assert that the returned error is the expected one.
-		assert.Equal(nms.T(), fmt.Sprintf(`unable to delete user namespace "%s" in cluster "%s": %s`, mockNSTemplateSet.Status.ProvisionedNamespaces[0].Name, mockMemberClusters[1].Name, ErrKubernetes.Error()), err.Error())
+		// Assert that the failure is reported for each namespace instead of
+		// as a single error.
+		require.NoError(nms.T(), err)
+		assert.True(nms.T(), reset.Namespaces.Failed())
+		require.Len(nms.T(), reset.Namespaces, 3)
+		for _, result := range reset.Namespaces {
+			assert.Equal(nms.T(), NamespaceFailed, result.Status)
+			assert.Equal(nms.T(), "unable to delete the namespace", result.Error)
+		}
 	})
 
 	nms.Run(`the "reset namespaces" feature works as expected and does not return any errors`, func() {
@@ -462,7 +473,7 @@ func (nms *TestNamespacesManagerSuite) TestResetNamespaces() {
 
 		// when
 		// Call the function under test.
-		err := namespacesManager.ResetNamespaces(ctx)
+		_, err := namespacesManager.ResetNamespaces(ctx, ResetOptions{})
 		if err != nil {
 			nms.Fail(`unexpected error while calling the "ResetNamespaces" function. None expected`, err.Error())
 			return
@@ -474,4 +485,119 @@ func (nms *TestNamespacesManagerSuite) TestResetNamespaces() {
 		assert.Equal(nms.T(), 1, getNSTemplateSetCount)
 		assert.Equal(nms.T(), 3, deletionCount)
 	})
+
+	nms.Run("only the selected namespaces are reset", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(nms.T())
+		fakeClient.MockGet = func(_ gocontext.Context, _ client.ObjectKey, obj client.Object, _ ...client.GetOption) error {
+			if space, ok := obj.(*toolchainv1alpha1.Space); ok {
+				*space = *mockSpace
+				return nil
+			} else if nsTemplateSet, ok := obj.(*toolchainv1alpha1.NSTemplateSet); ok {
+				*nsTemplateSet = *mockNSTemplateSet
+				return nil
+			}
+
+			return ErrKubernetes
+		}
+		var deleted []string
+		fakeClient.MockDelete = func(_ gocontext.Context, obj client.Object, _ ...client.DeleteOption) error {
+			deleted = append(deleted, obj.GetName())
+			return nil
+		}
+
+		nsClient := namespaced.NewClient(fakeClient, "namespace")
+		fakeSignupService := fake.NewSignupService(mockSignup...)
+		namespacesManager := NewNamespacesManager(getMemberClusters(createMockMemberClusters(nsClient)), nsClient, fakeSignupService)
+
+		// when
+		reset, err := namespacesManager.ResetNamespaces(ctx, ResetOptions{Namespaces: []string{"namespace-2"}})
+
+		// then
+		require.NoError(nms.T(), err)
+		assert.Equal(nms.T(), NamespaceResults{{Name: "namespace-2", Status: NamespaceDeleted}}, reset.Namespaces)
+		assert.Equal(nms.T(), []string{"namespace-2"}, deleted)
+	})
+
+	nms.Run("namespaces which are not provisioned for the user are rejected", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(nms.T())
+		fakeClient.MockGet = func(_ gocontext.Context, _ client.ObjectKey, obj client.Object, _ ...client.GetOption) error {
+			if space, ok := obj.(*toolchainv1alpha1.Space); ok {
+				*space = *mockSpace
+				return nil
+			} else if nsTemplateSet, ok := obj.(*toolchainv1alpha1.NSTemplateSet); ok {
+				*nsTemplateSet = *mockNSTemplateSet
+				return nil
+			}
+
+			return ErrKubernetes
+		}
+		deletionCount := 0
+		fakeClient.MockDelete = func(_ gocontext.Context, _ client.Object, _ ...client.DeleteOption) error {
+			deletionCount++
+			return nil
+		}
+
+		nsClient := namespaced.NewClient(fakeClient, "namespace")
+		fakeSignupService := fake.NewSignupService(mockSignup...)
+		namespacesManager := NewNamespacesManager(getMemberClusters(createMockMemberClusters(nsClient)), nsClient, fakeSignupService)
+
+		// when
+		_, err := namespacesManager.ResetNamespaces(ctx, ResetOptions{Namespaces: []string{"namespace-1", "other-namespace"}})
+
+		// then
+		require.ErrorIs(nms.T(), err, ErrNamespaceNotProvisioned)
+		assert.EqualError(nms.T(), err, `the namespace is not provisioned for the user: "other-namespace"`)
+		assert.Zero(nms.T(), deletionCount)
+	})
+
+	nms.Run("a dry run reports the resources of the namespaces without deleting them", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(nms.T(),
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "namespace-1"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "namespace-2"}},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "namespace-1"}},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-2", Namespace: "namespace-1"}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret-1", Namespace: "namespace-1"}},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-3", Namespace: "namespace-2"}},
+		)
+		fakeClient.MockGet = func(ctx gocontext.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if space, ok := obj.(*toolchainv1alpha1.Space); ok {
+				*space = *mockSpace
+				return nil
+			} else if nsTemplateSet, ok := obj.(*toolchainv1alpha1.NSTemplateSet); ok {
+				*nsTemplateSet = *mockNSTemplateSet
+				return nil
+			}
+
+			return fakeClient.Client.Get(ctx, key, obj, opts...)
+		}
+		deletionCount := 0
+		fakeClient.MockDelete = func(_ gocontext.Context, _ client.Object, _ ...client.DeleteOption) error {
+			deletionCount++
+			return nil
+		}
+
+		nsClient := namespaced.NewClient(fakeClient, "namespace")
+		fakeSignupService := fake.NewSignupService(mockSignup...)
+		namespacesManager := NewNamespacesManager(getMemberClusters(createMockMemberClusters(nsClient)), nsClient, fakeSignupService)
+
+		// when
+		reset, err := namespacesManager.ResetNamespaces(ctx, ResetOptions{DryRun: true})
+
+		// then
+		require.NoError(nms.T(), err)
+		assert.Zero(nms.T(), deletionCount)
+		require.Len(nms.T(), reset.Namespaces, 3)
+		assert.Equal(nms.T(), NamespaceResult{
+			Name:      "namespace-1",
+			Status:    NamespaceToBeDeleted,
+			Resources: map[string]int{"pods": 2, "services": 0, "configmaps": 0, "secrets": 1, "persistentvolumeclaims": 0},
+		}, reset.Namespaces[0])
+		assert.Equal(nms.T(), NamespaceToBeDeleted, reset.Namespaces[1].Status)
+		assert.Equal(nms.T(), 1, reset.Namespaces[1].Resources["pods"])
+		// the namespace does not exist anymore
+		assert.Equal(nms.T(), NamespaceResult{Name: "namespace-3", Status: NamespaceAlreadyDeleted}, reset.Namespaces[2])
+	})
 }
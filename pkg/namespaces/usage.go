@@ -0,0 +1,164 @@
+package namespaces
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NamespaceUsage is the consumption of the resources of a namespace of the user
+type NamespaceUsage struct {
+	// Name is the name of the namespace
+	Name string `json:"name"`
+	// Quota are the used and hard values of the resources limited by the ResourceQuotas of the namespace,
+	// by resource name (eg `limits.memory`). When several quotas limit the same resource, the lowest limit is reported.
+	Quota map[string]ResourceUsage `json:"quota"`
+	// Pods is the number of pods of the namespace which are not terminated
+	Pods int `json:"pods"`
+	// PersistentVolumeClaims is the number of PVCs of the namespace
+	PersistentVolumeClaims int `json:"persistentVolumeClaims"`
+	// Storage is the total storage capacity of the PVCs of the namespace, or the requested one for unbound PVCs
+	Storage string `json:"storage"`
+}
+
+// ResourceUsage is the used and hard value of a resource limited by a ResourceQuota
+type ResourceUsage struct {
+	// Used is the amount of the resource currently used in the namespace
+	Used string `json:"used"`
+	// Hard is the maximum amount of the resource allowed in the namespace
+	Hard string `json:"hard"`
+}
+
+func (mgr *manager) Usage(ginCtx *gin.Context) ([]NamespaceUsage, error) {
+	compliantUsername, err := mgr.compliantUsername(ginCtx)
+	if err != nil {
+		return nil, err
+	}
+	if usage, found := mgr.usageCache.get(compliantUsername); found {
+		return usage, nil
+	}
+
+	_, provisioned, err := mgr.provisionedNamespaces(ginCtx, compliantUsername)
+	if err != nil {
+		return nil, err
+	}
+	usage := []NamespaceUsage{}
+	for _, ns := range provisioned {
+		for _, name := range ns.names {
+			u, err := namespaceUsage(ginCtx, ns.memberCluster, name)
+			if err != nil {
+				return nil, err
+			}
+			usage = append(usage, u)
+		}
+	}
+	mgr.usageCache.add(compliantUsername, usage)
+	return usage, nil
+}
+
+// namespaceUsage reads the status of the ResourceQuotas of the given namespace, along with its pods and PVCs
+func namespaceUsage(ginCtx *gin.Context, memberCluster *cluster.CachedToolchainCluster, name string) (NamespaceUsage, error) {
+	ctx := ginCtx.Request.Context()
+	usage := NamespaceUsage{
+		Name:  name,
+		Quota: map[string]ResourceUsage{},
+	}
+
+	quotas := &v1.ResourceQuotaList{}
+	if err := memberCluster.Client.List(ctx, quotas, client.InNamespace(name)); err != nil {
+		return usage, fmt.Errorf(`unable to list the resource quotas of user namespace "%s" in cluster "%s": %w`, name, memberCluster.Name, err)
+	}
+	hardLimits := map[v1.ResourceName]resource.Quantity{}
+	for _, quota := range quotas.Items {
+		for resourceName, hard := range quota.Status.Hard {
+			if lowest, found := hardLimits[resourceName]; found && lowest.Cmp(hard) <= 0 {
+				continue
+			}
+			hardLimits[resourceName] = hard
+			used := quota.Status.Used[resourceName]
+			usage.Quota[string(resourceName)] = ResourceUsage{Used: used.String(), Hard: hard.String()}
+		}
+	}
+
+	pods := &v1.PodList{}
+	if err := memberCluster.Client.List(ctx, pods, client.InNamespace(name)); err != nil {
+		return usage, fmt.Errorf(`unable to list the pods of user namespace "%s" in cluster "%s": %w`, name, memberCluster.Name, err)
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed {
+			usage.Pods++
+		}
+	}
+
+	pvcs := &v1.PersistentVolumeClaimList{}
+	if err := memberCluster.Client.List(ctx, pvcs, client.InNamespace(name)); err != nil {
+		return usage, fmt.Errorf(`unable to list the persistent volume claims of user namespace "%s" in cluster "%s": %w`, name, memberCluster.Name, err)
+	}
+	storage := resource.Quantity{}
+	for _, pvc := range pvcs.Items {
+		if capacity, found := pvc.Status.Capacity[v1.ResourceStorage]; found {
+			storage.Add(capacity)
+		} else if request, found := pvc.Spec.Resources.Requests[v1.ResourceStorage]; found {
+			storage.Add(request)
+		}
+	}
+	usage.PersistentVolumeClaims = len(pvcs.Items)
+	usage.Storage = storage.String()
+	return usage, nil
+}
+
+// usageCache keeps the usage of the namespaces of the users for a short time, keyed by compliant username
+type usageCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]usageCacheEntry
+}
+
+type usageCacheEntry struct {
+	usage     []NamespaceUsage
+	expiresAt time.Time
+}
+
+// newUsageCache returns a new cache keeping the entries for the given TTL.
+// A cache with a TTL lower or equal to 0 never caches anything.
+func newUsageCache(ttl time.Duration) *usageCache {
+	return &usageCache{
+		ttl:     ttl,
+		entries: map[string]usageCacheEntry{},
+	}
+}
+
+func (c *usageCache) get(username string) ([]NamespaceUsage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, found := c.entries[username]
+	if !found || !time.Now().Before(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.usage, true
+}
+
+func (c *usageCache) add(username string, usage []NamespaceUsage) {
+	if c.ttl <= 0 {
+		return
+	}
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// evict the expired entries, so that the cache only holds the users who recently looked at their usage
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	c.entries[username] = usageCacheEntry{
+		usage:     usage,
+		expiresAt: now.Add(c.ttl),
+	}
+}
@@ -0,0 +1,179 @@
+package namespaces
+
+import (
+	gocontext "context"
+	"net/http"
+	"net/http/httptest"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/context"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/signup"
+	"github.com/codeready-toolchain/registration-service/test"
+	"github.com/codeready-toolchain/registration-service/test/fake"
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TestUsage tests that the usage of the user's namespaces is read from the
+// member cluster and cached for a short time.
+func (nms *TestNamespacesManagerSuite) TestUsage() {
+	const (
+		hostNamespace   = "toolchain-host-operator"
+		memberNamespace = "toolchain-member-operator"
+	)
+
+	req, err := http.NewRequest(http.MethodGet, "/api/v1/namespaces/usage", nil)
+	require.NoError(nms.T(), err)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = req
+	ctx.Set(context.UsernameKey, "ted")
+
+	newObjects := func() []client.Object {
+		return []client.Object{
+			&toolchainv1alpha1.Space{
+				ObjectMeta: metav1.ObjectMeta{Name: "ted", Namespace: hostNamespace},
+				Spec:       toolchainv1alpha1.SpaceSpec{TargetCluster: "member-cluster"},
+			},
+			&toolchainv1alpha1.NSTemplateSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "ted", Namespace: memberNamespace},
+				Status: toolchainv1alpha1.NSTemplateSetStatus{
+					ProvisionedNamespaces: []toolchainv1alpha1.SpaceNamespace{{Name: "ted-dev"}, {Name: "ted-stage"}},
+				},
+			},
+			&corev1.ResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "ted-dev"},
+				Status: corev1.ResourceQuotaStatus{
+					Hard: corev1.ResourceList{
+						corev1.ResourceLimitsMemory: resource.MustParse("7Gi"),
+						corev1.ResourcePods:         resource.MustParse("50"),
+					},
+					Used: corev1.ResourceList{
+						corev1.ResourceLimitsMemory: resource.MustParse("1536Mi"),
+						corev1.ResourcePods:         resource.MustParse("2"),
+					},
+				},
+			},
+			&corev1.ResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "deployments", Namespace: "ted-dev"},
+				Status: corev1.ResourceQuotaStatus{
+					Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("10")},
+					Used: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("2")},
+				},
+			},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: "ted-dev"}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "ted-dev"}, Status: corev1.PodStatus{Phase: corev1.PodPending}},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "completed", Namespace: "ted-dev"}, Status: corev1.PodStatus{Phase: corev1.PodSucceeded}},
+			&corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "bound", Namespace: "ted-dev"},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					Resources: corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}},
+				},
+				Status: corev1.PersistentVolumeClaimStatus{Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("2Gi")}},
+			},
+			&corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "ted-dev"},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					Resources: corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}},
+				},
+			},
+		}
+	}
+	newManager := func(fakeClient client.Client) Manager {
+		memberCluster := &cluster.CachedToolchainCluster{
+			Client: fakeClient,
+			Config: &cluster.Config{Name: "member-cluster", OperatorNamespace: memberNamespace},
+		}
+		return NewNamespacesManager(getMemberClusters([]*cluster.CachedToolchainCluster{memberCluster}),
+			namespaced.NewClient(fakeClient, hostNamespace),
+			fake.NewSignupService(&signup.Signup{Name: "ted", Username: "ted", CompliantUsername: "ted"}))
+	}
+	addPod := func(fakeClient client.Client) {
+		require.NoError(nms.T(), fakeClient.Create(gocontext.TODO(), &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "ted-dev"},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}))
+	}
+
+	nms.Run("usage of the namespaces", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(nms.T(), newObjects()...)
+		manager := newManager(fakeClient)
+
+		// when
+		usage, err := manager.Usage(ctx)
+
+		// then
+		require.NoError(nms.T(), err)
+		assert.Equal(nms.T(), []NamespaceUsage{
+			{
+				Name: "ted-dev",
+				Quota: map[string]ResourceUsage{
+					"limits.memory": {Used: "1536Mi", Hard: "7Gi"},
+					// the lowest limit of the two quotas
+					"pods": {Used: "2", Hard: "10"},
+				},
+				Pods:                   2,
+				PersistentVolumeClaims: 2,
+				Storage:                "3Gi",
+			},
+			{
+				Name:    "ted-stage",
+				Quota:   map[string]ResourceUsage{},
+				Storage: "0",
+			},
+		}, usage)
+
+		nms.Run("the usage is cached", func() {
+			// given
+			addPod(fakeClient)
+
+			// when
+			usage, err := manager.Usage(ctx)
+
+			// then
+			require.NoError(nms.T(), err)
+			assert.Equal(nms.T(), 2, usage[0].Pods)
+		})
+	})
+
+	nms.Run("the usage is not cached when the cache is disabled", func() {
+		// given
+		test.SetSettings(nms.T(), "namespaces: {usageCacheTTL: 0s}")
+		fakeClient := commontest.NewFakeClient(nms.T(), newObjects()...)
+		manager := newManager(fakeClient)
+		_, err := manager.Usage(ctx)
+		require.NoError(nms.T(), err)
+		addPod(fakeClient)
+
+		// when
+		usage, err := manager.Usage(ctx)
+
+		// then
+		require.NoError(nms.T(), err)
+		assert.Equal(nms.T(), 3, usage[0].Pods)
+	})
+
+	nms.Run("user without provisioned namespaces", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(nms.T(), &toolchainv1alpha1.Space{
+			ObjectMeta: metav1.ObjectMeta{Name: "ted", Namespace: hostNamespace},
+			Spec:       toolchainv1alpha1.SpaceSpec{TargetCluster: "member-cluster"},
+		}, &toolchainv1alpha1.NSTemplateSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "ted", Namespace: memberNamespace},
+		})
+
+		// when
+		_, err := newManager(fakeClient).Usage(ctx)
+
+		// then
+		require.ErrorAs(nms.T(), err, &ErrUserHasNoProvisionedNamespaces{})
+	})
+}
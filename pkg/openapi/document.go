@@ -0,0 +1,208 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+
+	"k8s.io/kube-openapi/pkg/spec3"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+)
+
+const (
+	jsonMediaType      = "application/json"
+	bearerAuthScheme   = "bearerAuth"
+	openAPIVersion     = "3.0.3"
+	componentSchemaRef = "#/components/schemas/"
+)
+
+// Operation describes an endpoint of the REST API
+type Operation struct {
+	// Method is the HTTP method of the endpoint
+	Method string
+	// Path is the path of the endpoint, in the syntax of the gin router (eg, `/api/v1/usernames/:username`)
+	Path string
+	// Summary is a short description of what the endpoint does
+	Summary string
+	// Secured is true if the endpoint requires a bearer token
+	Secured bool
+	// Query are the names of the optional query parameters
+	Query []string
+	// Headers are the names of the optional request headers
+	Headers []string
+	// Request is a value of the type of the JSON body of the requests, if any. The bodies of the incoming requests
+	// are validated against its schema.
+	Request interface{}
+	// OptionalRequest is true if the requests may have no body at all
+	OptionalRequest bool
+	// Responses are values of the types of the JSON bodies of the responses, by status code. A nil value means that
+	// the response has no body, and a Content value that the response body is not a JSON document.
+	Responses map[int]interface{}
+	// Errors are the status codes of the error responses, whose body is a crterrors.Error.
+	// A 400 Bad Request is implied if the endpoint has a request body, and a 401 Unauthorized if it is secured.
+	Errors []int
+	// Problems is true if the body of all the error responses is a crterrors.Problem (`application/problem+json`)
+	Problems bool
+}
+
+// Content is the media type of a response whose body is not a JSON document (eg, `text/event-stream`)
+type Content string
+
+// Document is the OpenAPI 3 document of the REST API, generated from the types of the payloads of the operations
+type Document struct {
+	openAPI *spec3.OpenAPI
+	// requests are the request bodies of the operations, by method and path
+	requests map[string]requestBody
+}
+
+// NewDocument returns the OpenAPI document of the given operations
+func NewDocument(title, version string, operations ...Operation) *Document {
+	components := map[string]*spec.Schema{}
+	errorSchema := schemaRef(reflect.TypeOf(crterrors.Error{}), components)
+	unauthorizedSchema := spec.Schema{}
+	unauthorizedSchema.Typed("object", "").SetProperty("error", *spec.StringProperty())
+	var problemSchema *spec.Schema
+	d := &Document{
+		openAPI: &spec3.OpenAPI{
+			Version: openAPIVersion,
+			Info:    &spec.Info{InfoProps: spec.InfoProps{Title: title, Version: version}},
+			Paths:   &spec3.Paths{Paths: map[string]*spec3.Path{}},
+			Components: &spec3.Components{
+				Schemas: components,
+				SecuritySchemes: spec3.SecuritySchemes{
+					bearerAuthScheme: &spec3.SecurityScheme{SecuritySchemeProps: spec3.SecuritySchemeProps{
+						Type:         "http",
+						Scheme:       "bearer",
+						BearerFormat: "JWT",
+					}},
+				},
+			},
+		},
+		requests: map[string]requestBody{},
+	}
+
+	for _, op := range operations {
+		operation := &spec3.Operation{OperationProps: spec3.OperationProps{
+			Summary:   op.Summary,
+			Responses: &spec3.Responses{ResponsesProps: spec3.ResponsesProps{StatusCodeResponses: map[int]*spec3.Response{}}},
+		}}
+		for _, segment := range strings.Split(op.Path, "/") {
+			if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+				operation.Parameters = append(operation.Parameters, &spec3.Parameter{ParameterProps: spec3.ParameterProps{
+					Name:     segment[1:],
+					In:       "path",
+					Required: true,
+					Schema:   spec.StringProperty(),
+				}})
+			}
+		}
+		for _, name := range op.Query {
+			operation.Parameters = append(operation.Parameters, &spec3.Parameter{ParameterProps: spec3.ParameterProps{
+				Name:   name,
+				In:     "query",
+				Schema: spec.StringProperty(),
+			}})
+		}
+		for _, name := range op.Headers {
+			operation.Parameters = append(operation.Parameters, &spec3.Parameter{ParameterProps: spec3.ParameterProps{
+				Name:   name,
+				In:     "header",
+				Schema: spec.StringProperty(),
+			}})
+		}
+
+		responses := operation.Responses.StatusCodeResponses
+		errorResponse := func(code int) *spec3.Response {
+			if op.Problems {
+				if problemSchema == nil {
+					problemSchema = ptr(schemaRef(reflect.TypeOf(crterrors.Problem{}), components))
+				}
+				return newResponse(code, problemSchema, crterrors.ProblemMediaType)
+			}
+			if code == http.StatusUnauthorized {
+				// returned by the JWT middleware
+				return newResponse(code, &unauthorizedSchema, jsonMediaType)
+			}
+			return newResponse(code, &errorSchema, jsonMediaType)
+		}
+		if op.Request != nil {
+			t := reflect.TypeOf(op.Request)
+			schema := schemaRef(t, components)
+			operation.RequestBody = &spec3.RequestBody{RequestBodyProps: spec3.RequestBodyProps{
+				Content:  map[string]*spec3.MediaType{jsonMediaType: {MediaTypeProps: spec3.MediaTypeProps{Schema: &schema}}},
+				Required: !op.OptionalRequest,
+			}}
+			d.requests[operationKey(op.Method, op.Path)] = requestBody{schema: ptr(schemaOf(t)), required: !op.OptionalRequest}
+			responses[http.StatusBadRequest] = errorResponse(http.StatusBadRequest)
+		}
+		if op.Secured {
+			operation.SecurityRequirement = []map[string][]string{{bearerAuthScheme: {}}}
+			responses[http.StatusUnauthorized] = errorResponse(http.StatusUnauthorized)
+		}
+		for _, code := range op.Errors {
+			responses[code] = errorResponse(code)
+		}
+		for code, body := range op.Responses {
+			switch body := body.(type) {
+			case nil:
+				responses[code] = newResponse(code, nil, "")
+			case Content:
+				responses[code] = newResponse(code, nil, string(body))
+			default:
+				schema := schemaRef(reflect.TypeOf(body), components)
+				responses[code] = newResponse(code, &schema, jsonMediaType)
+			}
+		}
+
+		path := pathOf(op.Path)
+		item, exists := d.openAPI.Paths.Paths[path]
+		if !exists {
+			item = &spec3.Path{}
+			d.openAPI.Paths.Paths[path] = item
+		}
+		switch op.Method {
+		case http.MethodGet:
+			item.Get = operation
+		case http.MethodPost:
+			item.Post = operation
+		case http.MethodPut:
+			item.Put = operation
+		case http.MethodPatch:
+			item.Patch = operation
+		case http.MethodDelete:
+			item.Delete = operation
+		}
+	}
+	return d
+}
+
+// OpenAPI returns the OpenAPI document, to be marshalled as JSON
+func (d *Document) OpenAPI() *spec3.OpenAPI {
+	return d.openAPI
+}
+
+func newResponse(code int, schema *spec.Schema, mediaType string) *spec3.Response {
+	response := &spec3.Response{ResponseProps: spec3.ResponseProps{Description: http.StatusText(code)}}
+	if mediaType != "" {
+		response.Content = map[string]*spec3.MediaType{mediaType: {MediaTypeProps: spec3.MediaTypeProps{Schema: schema}}}
+	}
+	return response
+}
+
+// pathOf converts the given path from the syntax of the gin router to the syntax of OpenAPI
+// (eg, `/api/v1/usernames/:username` to `/api/v1/usernames/{username}`)
+func pathOf(ginPath string) string {
+	segments := strings.Split(ginPath, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func operationKey(method, ginPath string) string {
+	return method + " " + ginPath
+}
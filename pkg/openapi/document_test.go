@@ -0,0 +1,173 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type itemRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type item struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+}
+
+func newTestDocument() *Document {
+	return NewDocument("Test", "v1",
+		Operation{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/items",
+			Summary:   "Lists the items",
+			Query:     []string{"filter"},
+			Responses: map[int]interface{}{http.StatusOK: []item{}},
+		},
+		Operation{
+			Method:    http.MethodPost,
+			Path:      "/api/v1/items",
+			Summary:   "Creates an item",
+			Secured:   true,
+			Request:   itemRequest{},
+			Responses: map[int]interface{}{http.StatusCreated: item{}},
+			Errors:    []int{http.StatusConflict},
+		},
+		Operation{
+			Method:    http.MethodDelete,
+			Path:      "/api/v1/items/:name",
+			Summary:   "Deletes an item",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusNoContent: nil},
+		},
+		Operation{
+			Method:          http.MethodPut,
+			Path:            "/api/v1/items/:name/refresh",
+			Summary:         "Refreshes an item",
+			Secured:         true,
+			Request:         itemRequest{},
+			OptionalRequest: true,
+			Responses:       map[int]interface{}{http.StatusOK: Content("text/plain")},
+		},
+	)
+}
+
+func TestNewDocument(t *testing.T) {
+	// when
+	data, err := json.Marshal(newTestDocument().OpenAPI())
+
+	// then
+	require.NoError(t, err)
+	document := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(data, &document))
+	assert.Equal(t, "3.0.3", document["openapi"])
+	assert.Equal(t, map[string]interface{}{"title": "Test", "version": "v1"}, document["info"])
+
+	paths := document["paths"].(map[string]interface{})
+	require.Len(t, paths, 3)
+
+	t.Run("list", func(t *testing.T) {
+		list := paths["/api/v1/items"].(map[string]interface{})["get"].(map[string]interface{})
+		assert.Equal(t, "Lists the items", list["summary"])
+		assert.Nil(t, list["security"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"name": "filter", "in": "query", "schema": map[string]interface{}{"type": "string"}},
+		}, list["parameters"])
+		responses := list["responses"].(map[string]interface{})
+		assert.Len(t, responses, 1)
+		assert.Equal(t, map[string]interface{}{
+			"description": "OK",
+			"content": map[string]interface{}{"application/json": map[string]interface{}{"schema": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"$ref": "#/components/schemas/openapi.item"},
+			}}},
+		}, responses["200"])
+	})
+
+	t.Run("create", func(t *testing.T) {
+		create := paths["/api/v1/items"].(map[string]interface{})["post"].(map[string]interface{})
+		assert.Equal(t, []interface{}{map[string]interface{}{"bearerAuth": []interface{}{}}}, create["security"])
+		assert.Equal(t, map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{"application/json": map[string]interface{}{"schema": map[string]interface{}{
+				"$ref": "#/components/schemas/openapi.itemRequest",
+			}}},
+		}, create["requestBody"])
+		responses := create["responses"].(map[string]interface{})
+		assert.ElementsMatch(t, []string{"201", "400", "401", "409"}, keysOf(responses))
+		errorContent := map[string]interface{}{"application/json": map[string]interface{}{"schema": map[string]interface{}{
+			"$ref": "#/components/schemas/errors.Error",
+		}}}
+		assert.Equal(t, errorContent, responses["400"].(map[string]interface{})["content"])
+		assert.Equal(t, errorContent, responses["409"].(map[string]interface{})["content"])
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		del := paths["/api/v1/items/{name}"].(map[string]interface{})["delete"].(map[string]interface{})
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"name": "name", "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"}},
+		}, del["parameters"])
+		assert.Equal(t, map[string]interface{}{"description": "No Content"}, del["responses"].(map[string]interface{})["204"])
+	})
+
+	t.Run("refresh", func(t *testing.T) {
+		refresh := paths["/api/v1/items/{name}/refresh"].(map[string]interface{})["put"].(map[string]interface{})
+		assert.NotContains(t, refresh["requestBody"], "required")
+		assert.Equal(t, map[string]interface{}{
+			"description": "OK",
+			"content":     map[string]interface{}{"text/plain": map[string]interface{}{}},
+		}, refresh["responses"].(map[string]interface{})["200"])
+	})
+
+	t.Run("components", func(t *testing.T) {
+		components := document["components"].(map[string]interface{})
+		assert.ElementsMatch(t, []string{"errors.Error", "openapi.item", "openapi.itemRequest"},
+			keysOf(components["schemas"].(map[string]interface{})))
+		assert.Equal(t, map[string]interface{}{
+			"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+		}, components["securitySchemes"])
+	})
+}
+
+func TestNewDocumentWithProblems(t *testing.T) {
+	// given
+	document := NewDocument("Test", "v2", Operation{
+		Method:    http.MethodPost,
+		Path:      "/api/v2/items",
+		Secured:   true,
+		Request:   itemRequest{},
+		Responses: map[int]interface{}{http.StatusCreated: item{}},
+		Errors:    []int{http.StatusConflict},
+		Problems:  true,
+	})
+
+	// when
+	data, err := json.Marshal(document.OpenAPI())
+
+	// then
+	require.NoError(t, err)
+	doc := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(data, &doc))
+	create := doc["paths"].(map[string]interface{})["/api/v2/items"].(map[string]interface{})["post"].(map[string]interface{})
+	responses := create["responses"].(map[string]interface{})
+	assert.ElementsMatch(t, []string{"201", "400", "401", "409"}, keysOf(responses))
+	problemContent := map[string]interface{}{"application/problem+json": map[string]interface{}{"schema": map[string]interface{}{
+		"$ref": "#/components/schemas/errors.Problem",
+	}}}
+	for _, code := range []string{"400", "401", "409"} {
+		assert.Equal(t, problemContent, responses[code].(map[string]interface{})["content"], code)
+	}
+	schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	assert.Contains(t, schemas, "errors.Problem")
+}
+
+func keysOf(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
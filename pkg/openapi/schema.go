@@ -0,0 +1,158 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	metav1TimeType    = reflect.TypeOf(metav1.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaName returns the name of the schema of the given named type in the components of the document, qualified by
+// the name of its package to avoid the collisions (eg, `signup.Signup`)
+func schemaName(t reflect.Type) string {
+	return fmt.Sprintf("%s.%s", path.Base(t.PkgPath()), t.Name())
+}
+
+// schemaRef returns the schema of the given type, as a reference to the components of the document if the type is a
+// named struct, in which case the (inlined) schema of the struct is added to the given components
+func schemaRef(t reflect.Type, components map[string]*spec.Schema) spec.Schema {
+	switch {
+	case t.Kind() == reflect.Pointer:
+		return schemaRef(t.Elem(), components)
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() != reflect.Uint8:
+		return *spec.ArrayProperty(ptr(schemaRef(t.Elem(), components)))
+	case t.Kind() == reflect.Struct && t.Name() != "" && !isScalar(t):
+		name := schemaName(t)
+		if _, exists := components[name]; !exists {
+			s := schemaOf(t)
+			components[name] = &s
+		}
+		return *spec.RefSchema("#/components/schemas/" + name)
+	}
+	return schemaOf(t)
+}
+
+// schemaOf returns the schema of the values of the given type once marshalled as JSON. Nested structs are inlined, so
+// that the schema can be used for the validation without resolving any reference.
+// The properties of the structs are named after their `json` tag, and the properties with a `binding:"required"` tag
+// are required, as for the binding of the requests by gin.
+func schemaOf(t reflect.Type) spec.Schema {
+	return schemaOfType(t, map[reflect.Type]bool{})
+}
+
+func schemaOfType(t reflect.Type, visiting map[reflect.Type]bool) spec.Schema {
+	if t.Kind() == reflect.Pointer {
+		return schemaOfType(t.Elem(), visiting)
+	}
+	switch {
+	case t == timeType || t == metav1TimeType:
+		return *spec.DateTimeProperty()
+	case implements(t, jsonMarshalerType):
+		// the JSON representation cannot be inferred from the type
+		return spec.Schema{}
+	case implements(t, textMarshalerType):
+		return *spec.StringProperty()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return *spec.BoolProperty()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return *spec.Int32Property()
+	case reflect.Int64, reflect.Uint64:
+		return *spec.Int64Property()
+	case reflect.Float32:
+		return *spec.Float32Property()
+	case reflect.Float64:
+		return *spec.Float64Property()
+	case reflect.String:
+		return *spec.StringProperty()
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoded in base64 by the JSON encoder
+			s := spec.StringProperty()
+			s.Format = "byte"
+			return *s
+		}
+		return *spec.ArrayProperty(ptr(schemaOfType(t.Elem(), visiting)))
+	case reflect.Map:
+		return *spec.MapProperty(ptr(schemaOfType(t.Elem(), visiting)))
+	case reflect.Struct:
+		if visiting[t] {
+			// recursive type
+			return *spec.MapProperty(nil)
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		s := spec.Schema{}
+		s.Typed("object", "")
+		addFields(&s, t, visiting)
+		return s
+	}
+	// interfaces, which may hold anything
+	return spec.Schema{}
+}
+
+// addFields adds the exported fields of the given struct type to the properties of the given schema, including the
+// fields of the embedded structs
+func addFields(s *spec.Schema, t reflect.Type, visiting map[reflect.Type]bool) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				addFields(s, embedded, visiting)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		property := schemaOfType(field.Type, visiting)
+		switch field.Type.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+			property.Nullable = true
+		}
+		s.SetProperty(name, property)
+		if binding := field.Tag.Get("binding"); strings.Contains(binding, "required") {
+			s.AddRequired(name)
+		}
+	}
+}
+
+// isScalar returns true if the values of the given struct type are not marshalled as JSON objects
+func isScalar(t reflect.Type) bool {
+	return t == timeType || t == metav1TimeType || implements(t, jsonMarshalerType) || implements(t, textMarshalerType)
+}
+
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+func ptr(s spec.Schema) *spec.Schema {
+	return &s
+}
@@ -0,0 +1,140 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+)
+
+type embedded struct {
+	ID string `json:"id"`
+}
+
+type nested struct {
+	Value int64 `json:"value"`
+}
+
+type sample struct {
+	embedded
+	Name        string            `json:"name" binding:"required"`
+	Count       int               `json:"count,omitempty"`
+	Ratio       float64           `json:"ratio"`
+	Enabled     *bool             `json:"enabled"`
+	Tags        []string          `json:"tags"`
+	Labels      map[string]string `json:"labels"`
+	Nested      nested            `json:"nested"`
+	Created     time.Time         `json:"created"`
+	Updated     metav1.Time       `json:"updated"`
+	Data        []byte            `json:"data"`
+	Any         interface{}       `json:"any"`
+	NoTag       string
+	Ignored     string  `json:"-"`
+	unexported  string  //nolint:unused
+	Recursive   *sample `json:"recursive"`
+	Description string  `json:"description"`
+}
+
+func TestSchemaOf(t *testing.T) {
+	// when
+	s := schemaOf(reflect.TypeOf(sample{}))
+
+	// then
+	assert.Equal(t, spec.StringOrArray{"object"}, s.Type)
+	assert.Equal(t, []string{"name"}, s.Required)
+	assert.ElementsMatch(t, []string{"id", "name", "count", "ratio", "enabled", "tags", "labels", "nested", "created",
+		"updated", "data", "any", "NoTag", "recursive", "description"}, keys(s.Properties))
+
+	for name, expected := range map[string]struct {
+		typ      string
+		format   string
+		nullable bool
+	}{
+		"id":      {typ: "string"},
+		"name":    {typ: "string"},
+		"count":   {typ: "integer", format: "int32"},
+		"ratio":   {typ: "number", format: "double"},
+		"enabled": {typ: "boolean", nullable: true},
+		"tags":    {typ: "array", nullable: true},
+		"labels":  {typ: "object", nullable: true},
+		"nested":  {typ: "object"},
+		"created": {typ: "string", format: "date-time"},
+		"updated": {typ: "string", format: "date-time"},
+		"data":    {typ: "string", format: "byte", nullable: true},
+		"NoTag":   {typ: "string"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			property := s.Properties[name]
+			assert.Equal(t, spec.StringOrArray{expected.typ}, property.Type)
+			assert.Equal(t, expected.format, property.Format)
+			assert.Equal(t, expected.nullable, property.Nullable)
+		})
+	}
+
+	t.Run("nested struct is inlined", func(t *testing.T) {
+		value := s.Properties["nested"].Properties["value"]
+		assert.Equal(t, spec.StringOrArray{"integer"}, value.Type)
+		assert.Equal(t, "int64", value.Format)
+	})
+
+	t.Run("interface accepts anything", func(t *testing.T) {
+		assert.Empty(t, s.Properties["any"].Type)
+	})
+
+	t.Run("recursive type is not expanded", func(t *testing.T) {
+		assert.Equal(t, spec.StringOrArray{"object"}, s.Properties["recursive"].Type)
+		assert.Empty(t, s.Properties["recursive"].Properties)
+	})
+}
+
+func TestSchemaRef(t *testing.T) {
+	t.Run("named struct", func(t *testing.T) {
+		// given
+		components := map[string]*spec.Schema{}
+
+		// when
+		s := schemaRef(reflect.TypeOf(&nested{}), components)
+
+		// then
+		assert.Equal(t, "#/components/schemas/openapi.nested", s.Ref.String())
+		require.Contains(t, components, "openapi.nested")
+		assert.Contains(t, components["openapi.nested"].Properties, "value")
+	})
+
+	t.Run("slice of named structs", func(t *testing.T) {
+		// given
+		components := map[string]*spec.Schema{}
+
+		// when
+		s := schemaRef(reflect.TypeOf([]nested{}), components)
+
+		// then
+		assert.Equal(t, spec.StringOrArray{"array"}, s.Type)
+		assert.Equal(t, "#/components/schemas/openapi.nested", s.Items.Schema.Ref.String())
+		assert.Contains(t, components, "openapi.nested")
+	})
+
+	t.Run("time is not a component", func(t *testing.T) {
+		// given
+		components := map[string]*spec.Schema{}
+
+		// when
+		s := schemaRef(reflect.TypeOf(time.Time{}), components)
+
+		// then
+		assert.Equal(t, "date-time", s.Format)
+		assert.Empty(t, components)
+	})
+}
+
+func keys(properties map[string]spec.Schema) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,63 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+	"k8s.io/kube-openapi/pkg/validation/strfmt"
+	"k8s.io/kube-openapi/pkg/validation/validate"
+)
+
+type requestBody struct {
+	schema   *spec.Schema
+	required bool
+}
+
+// validate validates the given request body against the schema of the operation
+func (b requestBody) validate(data []byte) error {
+	if len(bytes.TrimSpace(data)) == 0 {
+		if b.required {
+			return errors.New("request body is required")
+		}
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	return validate.AgainstSchema(b.schema, value, strfmt.Default)
+}
+
+// ValidateRequest returns a middleware which validates the body of the incoming requests against the schema of the
+// matching operation of the document, and aborts with a 400 Bad Request if the body is invalid.
+// The requests of the routes which are not in the document, or which have no request body, are left untouched.
+func (d *Document) ValidateRequest() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		body, found := d.requests[operationKey(ctx.Request.Method, ctx.FullPath())]
+		if !found {
+			return
+		}
+		var data []byte
+		if ctx.Request.Body != nil {
+			var err error
+			if data, err = io.ReadAll(ctx.Request.Body); err != nil {
+				crterrors.AbortWithError(ctx, http.StatusBadRequest, err, "error reading request body")
+				return
+			}
+		}
+		// the handler reads the body again
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(data))
+		if err := body.validate(data); err != nil {
+			log.Error(ctx, err, "invalid request body")
+			crterrors.AbortWithError(ctx, http.StatusBadRequest, err, "invalid request body")
+		}
+	}
+}
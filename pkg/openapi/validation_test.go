@@ -0,0 +1,99 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateRequest(t *testing.T) {
+	// given
+	log.Init("openapi-testing")
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(newTestDocument().ValidateRequest())
+	var received []byte
+	handler := func(ctx *gin.Context) {
+		received, _ = io.ReadAll(ctx.Request.Body)
+		ctx.Status(http.StatusOK)
+	}
+	router.POST("/api/v1/items", handler)
+	router.PUT("/api/v1/items/:name/refresh", handler)
+	router.POST("/api/v1/others", handler)
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		received = nil
+		req, err := http.NewRequest(method, path, bytes.NewBufferString(body))
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for name, tc := range map[string]struct {
+		method string
+		path   string
+		body   string
+	}{
+		"valid body":                     {method: http.MethodPost, path: "/api/v1/items", body: `{"name":"foo"}`},
+		"unknown properties are ignored": {method: http.MethodPost, path: "/api/v1/items", body: `{"name":"foo","other":1}`},
+		"optional body":                  {method: http.MethodPut, path: "/api/v1/items/foo/refresh", body: ``},
+		"route not in the document":      {method: http.MethodPost, path: "/api/v1/others", body: `not json`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// when
+			rr := send(tc.method, tc.path, tc.body)
+
+			// then
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, tc.body, string(received)) // the handler can still read the body
+		})
+	}
+
+	for name, tc := range map[string]struct {
+		body            string
+		expectedMessage string
+	}{
+		"missing required property": {
+			body:            `{}`,
+			expectedMessage: "name in body is required",
+		},
+		"invalid type": {
+			body:            `{"name":1}`,
+			expectedMessage: "name in body must be of type string",
+		},
+		"malformed JSON": {
+			body:            `{"name":`,
+			expectedMessage: "unexpected end of JSON input",
+		},
+		"missing body": {
+			body:            ``,
+			expectedMessage: "request body is required",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// when
+			rr := send(http.MethodPost, "/api/v1/items", tc.body)
+
+			// then
+			require.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Nil(t, received) // the handler was not called
+			e := &crterrors.Error{}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), e))
+			assert.Equal(t, "Bad Request", e.Status)
+			assert.Equal(t, http.StatusBadRequest, e.Code)
+			assert.Contains(t, e.Message, tc.expectedMessage)
+			assert.Equal(t, "invalid request body", e.Details)
+		})
+	}
+}
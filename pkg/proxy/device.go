@@ -0,0 +1,157 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// deviceStatusEndpoint is the status page of the device authorization flow, which shows the user code
+	deviceStatusEndpoint = "/device"
+)
+
+func openidDeviceAuthEndpoint(realm string) string {
+	return openidAuthEndpoint(realm) + "/device"
+}
+
+func ssoDeviceVerificationTarget(ssoBaseURL, realm string) string {
+	return fmt.Sprintf("%s/auth/realms/%s/device", ssoBaseURL, realm)
+}
+
+var deviceStatusTemplate = template.Must(template.New("device").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Developer Sandbox - Device Login</title>
+  <style>
+    body { font-family: sans-serif; margin: 4em auto; max-width: 40em; text-align: center; }
+    .code { font-family: monospace; font-size: 2.5em; letter-spacing: 0.1em; margin: 1em 0; }
+  </style>
+</head>
+<body>
+  <h1>Device Login</h1>
+{{- if .UserCode }}
+  <p>Make sure that the following code matches the one displayed by your command-line tool:</p>
+  <p class="code">{{ .UserCode }}</p>
+{{- else }}
+  <p>Enter the code displayed by your command-line tool on the next page.</p>
+{{- end }}
+  <p><a href="{{ .VerificationURL }}">Continue to log in</a></p>
+</body>
+</html>
+`))
+
+// deviceAuthorization handles the device authorization requests and forwards them to SSO.
+// The verification URIs returned by SSO are replaced with the status page of the proxy.
+func (p *Proxy) deviceAuthorization(ctx echo.Context) error {
+	realm, ssoBaseURL, err := ssoRealm(ctx.Param(ssoRealmParam))
+	if err != nil {
+		return err
+	}
+	targetURL, err := url.Parse(ssoBaseURL)
+	if err != nil {
+		return err
+	}
+	targetURL.Path = ctx.Request().URL.Path
+	targetURL.RawQuery = ctx.Request().URL.RawQuery
+
+	statusURL := proxyBaseURL(ctx.Request()) + deviceStatusEndpoint
+	query := url.Values{}
+	if realm != configuration.GetRegistrationServiceConfig().Auth().SSORealm() {
+		// the status page needs to know the realm to link to its verification page
+		query.Set(ssoRealmParam, realm)
+	}
+	return p.handleSSORequest(targetURL, modifyJSONResponse(func(authz map[string]interface{}) {
+		authz["verification_uri"] = withQuery(statusURL, query)
+		if userCode, ok := authz["user_code"].(string); ok {
+			completeQuery := maps.Clone(query)
+			completeQuery.Set("user_code", userCode)
+			authz["verification_uri_complete"] = withQuery(statusURL, completeQuery)
+		}
+	}))(ctx)
+}
+
+// deviceStatus renders the status page of the device authorization flow
+func (p *Proxy) deviceStatus(ctx echo.Context) error {
+	realm, ssoBaseURL, err := ssoRealm(ctx.QueryParam(ssoRealmParam))
+	if err != nil {
+		return err
+	}
+	userCode := ctx.QueryParam("user_code")
+	verificationURL := ssoDeviceVerificationTarget(ssoBaseURL, realm)
+	if userCode != "" {
+		verificationURL += "?user_code=" + url.QueryEscape(userCode)
+	}
+	buf := &bytes.Buffer{}
+	if err := deviceStatusTemplate.Execute(buf, struct {
+		UserCode        string
+		VerificationURL string
+	}{
+		UserCode:        userCode,
+		VerificationURL: verificationURL,
+	}); err != nil {
+		return err
+	}
+	return ctx.HTMLBlob(http.StatusOK, buf.Bytes())
+}
+
+// modifyJSONResponse returns a function which applies the given modification to successful responses containing a JSON object.
+// Other responses are returned unchanged.
+func modifyJSONResponse(modify func(map[string]interface{})) func(*http.Response) error {
+	return func(resp *http.Response) error {
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Encoding") != "" {
+			return nil
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+
+		content := map[string]interface{}{}
+		if err := json.Unmarshal(body, &content); err != nil {
+			// not a JSON object, return the response as is
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			return nil
+		}
+		modify(content)
+		if body, err = json.Marshal(content); err != nil {
+			return err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		resp.ContentLength = int64(len(body))
+		resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		return nil
+	}
+}
+
+func withQuery(u string, query url.Values) string {
+	if len(query) == 0 {
+		return u
+	}
+	return u + "?" + query.Encode()
+}
+
+// proxyBaseURL returns the base URL of the proxy as seen by the client, ie, the configured public URL of the proxy.
+// Without public URL, the host and scheme of the request are used. The `X-Forwarded-*` headers are not trusted,
+// since they could be set by the client to make the proxy advertise the URLs of another server.
+func proxyBaseURL(req *http.Request) string {
+	if publicURL := configuration.GetRegistrationServiceConfig().Proxy().PublicURL(); publicURL != "" {
+		return publicURL
+	}
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + req.Host
+}
@@ -8,6 +8,10 @@ const (
 	MetricLabelRejected  = "Rejected"
 	MetricsLabelVerbGet  = "Get"
 	MetricsLabelVerbList = "List"
+
+	MetricsLabelCacheToken = "token"
+	MetricsLabelCacheHit   = "hit"
+	MetricsLabelCacheMiss  = "miss"
 )
 
 type ProxyMetrics struct {
@@ -15,7 +19,9 @@ type ProxyMetrics struct {
 	RegServProxyAPIHistogramVec *prometheus.HistogramVec
 	// RegServWorkspaceHistogramVec measures the response time for either response or error from proxy when there is no routing
 	RegServWorkspaceHistogramVec *prometheus.HistogramVec
-	Reg                          *prometheus.Registry
+	// RegServProxyCacheCounterVec counts the hits and misses of the caches used by the proxy
+	RegServProxyCacheCounterVec *prometheus.CounterVec
+	Reg                         *prometheus.Registry
 }
 
 const metricsPrefix = "sandbox_"
@@ -23,11 +29,14 @@ const metricsPrefix = "sandbox_"
 func NewProxyMetrics(reg *prometheus.Registry) *ProxyMetrics {
 	regServProxyAPIHistogramVec := newHistogramVec("proxy_api_http_request_time", "time taken by proxy to route to a target cluster", "status_code", "route_to")
 	regServWorkspaceHistogramVec := newHistogramVec("proxy_workspace_http_request_time", "time for response of a request to proxy ", "status_code", "kube_verb")
+	regServProxyCacheCounterVec := newCounterVec("proxy_cache_lookups_total", "number of lookups in the caches of the proxy", "cache", "result")
 	reg.MustRegister(regServProxyAPIHistogramVec)
 	reg.MustRegister(regServWorkspaceHistogramVec)
+	reg.MustRegister(regServProxyCacheCounterVec)
 	return &ProxyMetrics{
 		RegServWorkspaceHistogramVec: regServWorkspaceHistogramVec,
 		RegServProxyAPIHistogramVec:  regServProxyAPIHistogramVec,
+		RegServProxyCacheCounterVec:  regServProxyCacheCounterVec,
 		Reg:                          reg,
 	}
 }
@@ -40,3 +49,10 @@ func newHistogramVec(name, help string, labels ...string) *prometheus.HistogramV
 	}, labels)
 	return v
 }
+
+func newCounterVec(name, help string, labels ...string) *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: metricsPrefix + name,
+		Help: help,
+	}, labels)
+}
@@ -103,6 +103,27 @@ var expectedResponse = `
 		sandbox_test_histogram_vec_count{kube_verb="list",status_code="500"} 2
 		`
 
+func TestCounterVec(t *testing.T) {
+	// given
+	reg := prometheus.NewRegistry()
+	m := newCounterVec("test_counter_vec", "test counter description", "cache", "result")
+	reg.MustRegister(m)
+
+	// when
+	m.WithLabelValues(MetricsLabelCacheToken, MetricsLabelCacheHit).Inc()
+	m.WithLabelValues(MetricsLabelCacheToken, MetricsLabelCacheHit).Inc()
+	m.WithLabelValues(MetricsLabelCacheToken, MetricsLabelCacheMiss).Inc()
+
+	// then
+	assert.InDelta(t, float64(2), promtestutil.ToFloat64(m.WithLabelValues(MetricsLabelCacheToken, MetricsLabelCacheHit)), 0.01)
+	assert.InDelta(t, float64(1), promtestutil.ToFloat64(m.WithLabelValues(MetricsLabelCacheToken, MetricsLabelCacheMiss)), 0.01)
+	g, err := reg.Gather()
+	require.NoError(t, err)
+	require.Len(t, g, 1)
+	assert.Equal(t, "sandbox_test_counter_vec", g[0].GetName())
+	assert.Equal(t, "test counter description", g[0].GetHelp())
+}
+
 func compareLabelPairValues(t *testing.T, expected []clientmodel.LabelPair, labelPairs []*clientmodel.LabelPair) {
 	for i := range labelPairs {
 		require.Equal(t, expected[i].GetName(), labelPairs[i].GetName())
@@ -23,11 +23,13 @@ import (
 	"github.com/codeready-toolchain/registration-service/pkg/configuration"
 	"github.com/codeready-toolchain/registration-service/pkg/context"
 	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+	"github.com/codeready-toolchain/registration-service/pkg/indexes"
 	"github.com/codeready-toolchain/registration-service/pkg/log"
 	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
 	"github.com/codeready-toolchain/registration-service/pkg/proxy/access"
 	"github.com/codeready-toolchain/registration-service/pkg/proxy/handlers"
 	"github.com/codeready-toolchain/registration-service/pkg/proxy/metrics"
+	"github.com/codeready-toolchain/registration-service/pkg/revocation"
 	"github.com/codeready-toolchain/registration-service/pkg/signup"
 	commoncluster "github.com/codeready-toolchain/toolchain-common/pkg/cluster"
 	"github.com/codeready-toolchain/toolchain-common/pkg/hash"
@@ -47,26 +49,40 @@ const (
 
 	proxyHealthEndpoint          = "/proxyhealth"
 	authEndpoint                 = "/auth/"
+	ssoRealmParam                = "realm"
 	wellKnownOauthConfigEndpoint = "/.well-known/oauth-authorization-server"
 	pluginsEndpoint              = "/plugins/"
 )
 
-func ssoWellKnownTarget() string {
-	return fmt.Sprintf("%s/auth/realms/%s/.well-known/openid-configuration", configuration.GetRegistrationServiceConfig().Auth().SSOBaseURL(), configuration.GetRegistrationServiceConfig().Auth().SSORealm())
+func ssoWellKnownTarget(ssoBaseURL, realm string) string {
+	return fmt.Sprintf("%s/auth/realms/%s/.well-known/openid-configuration", ssoBaseURL, realm)
 }
 
-func openidAuthEndpoint() string {
-	return fmt.Sprintf("/auth/realms/%s/protocol/openid-connect/auth", configuration.GetRegistrationServiceConfig().Auth().SSORealm())
+func openidAuthEndpoint(realm string) string {
+	return fmt.Sprintf("/auth/realms/%s/protocol/openid-connect/auth", realm)
 }
 
-func authorizationEndpointTarget() string {
-	return fmt.Sprintf("%s%s", configuration.GetRegistrationServiceConfig().Auth().SSOBaseURL(), openidAuthEndpoint())
+// ssoRealm returns the name and the base URL of the given SSO realm, or of the default realm if the given one is empty.
+// Requests to realms which are not configured are rejected rather than forwarded to SSO.
+func ssoRealm(realm string) (string, string, error) {
+	cfg := configuration.GetRegistrationServiceConfig().Auth()
+	if realm == "" {
+		realm = cfg.SSORealm()
+	}
+	ssoBaseURL, found := cfg.SSORealms()[realm]
+	if !found {
+		return "", "", crterrors.NewNotFoundError(fmt.Errorf("unknown SSO realm '%s'", realm), "unable to forward the request to SSO")
+	}
+	return realm, ssoBaseURL, nil
 }
 
 type Proxy struct {
 	namespaced.Client
 	signupService  service.SignupService
 	tokenParser    *auth.TokenParser
+	claimsCache    *auth.ClaimsCache
+	revoker        *revocation.Revoker
+	wellKnownCache *wellKnownCache
 	spaceLister    *handlers.SpaceLister
 	metrics        *metrics.ProxyMetrics
 	getMembersFunc commoncluster.GetMemberClustersFunc
@@ -80,10 +96,14 @@ func NewProxy(nsClient namespaced.Client, app application.Application, proxyMetr
 
 	// init handlers
 	spaceLister := handlers.NewSpaceLister(nsClient, app, proxyMetrics)
+	cfg := configuration.GetRegistrationServiceConfig().Proxy()
 	return &Proxy{
 		Client:         nsClient,
 		signupService:  app.SignupService(),
 		tokenParser:    tokenParser,
+		claimsCache:    auth.NewClaimsCache(cfg.TokenCacheSize(), cfg.TokenCacheTTL()),
+		revoker:        revocation.NewRevoker(nsClient),
+		wellKnownCache: newWellKnownCache(),
 		spaceLister:    spaceLister,
 		metrics:        proxyMetrics,
 		getMembersFunc: getMembersFunc,
@@ -112,6 +132,7 @@ func (p *Proxy) StartProxy(port string) *http.Server {
 			}
 		},
 		p.ensureUserIsNotBanned(),
+		p.ensureTokenIsNotRevoked(),
 		p.addPublicViewerContext(),
 	)
 
@@ -142,16 +163,32 @@ func (p *Proxy) StartProxy(port string) *http.Server {
 	// Here is the expected flow for the "oc login -w" command:
 	// 1. "oc login -w --server=<proxy_url>"
 	// 2. oc calls <proxy_url>/.well-known/oauth-authorization-server (wellKnownOauthConfigEndpoint endpoint)
-	// 3. proxy forwards it to <sso_url>/auth/realms/<sso_realm>/.well-known/openid-configuration
+	// 3. proxy retrieves <sso_url>/auth/realms/<sso_realm>/.well-known/openid-configuration and returns it with the endpoints rewritten to point at the proxy
 	// 4. oc starts an OAuth flow by opening a browser for <proxy_url>/auth/realms/<realm>/protocol/openid-connect/auth
 	// 5. proxy redirects (the request is not proxied but redirected via 403 See Others response!) the request
 	//    to <sso_url>/auth/realms/<realm>/protocol/openid-connect/auth
 	//    Note: oc uses this hardcoded public (no secret) oauth client name: "openshift-cli-client" which has to exist in SSO to make this flow work.
 	// 6. user provides the login credentials in the sso login page
 	// 7. all following oc requests (<proxy_url>/auth/*) go to the proxy and forwarded to SSO as is. This is used to obtain the generated token by oc.
-	router.Any(wellKnownOauthConfigEndpoint, p.oauthConfiguration)     // <- this is the step 2 in the flow above
-	router.Any(fmt.Sprintf("%s*", openidAuthEndpoint()), p.openidAuth) // <- this is the step 5 in the flow above
-	router.Any(fmt.Sprintf("%s*", authEndpoint), p.auth)               // <- this is the step 7.
+	// Several SSO realms can be configured (see `AuthConfig.SSORealms()`): the requests to /auth/realms/<realm>/* are forwarded
+	// to the base URL of their realm, and the requests to realms which are not configured are rejected.
+	router.Any(wellKnownOauthConfigEndpoint, p.oauthConfiguration)                 // <- this is the step 2 in the flow above
+	router.Any(ssoWellKnownTarget("", ":"+ssoRealmParam), p.oauthConfiguration)    // <- same as above, for a given realm
+	router.Any(openidDeviceAuthEndpoint(":"+ssoRealmParam), p.deviceAuthorization) // <- this is the step 2 of the device flow below
+	router.Any(openidAuthEndpoint(":"+ssoRealmParam)+"*", p.openidAuth)            // <- this is the step 5 in the flow above
+	router.Any("/auth/realms/:"+ssoRealmParam, p.auth)                             // <- this is the step 7 in the flow above and the step 5 of the device flow below.
+	router.Any("/auth/realms/:"+ssoRealmParam+"/*", p.auth)                        // <- same as above
+	router.Any(fmt.Sprintf("%s*", authEndpoint), p.auth)                           // <- same as above, for the resources which don't belong to a realm
+	// Device authorization grant (RFC 8628). Used by CLI login from environments without a local browser (remote shells, Cloud IDE terminals).
+	// Here is the expected flow:
+	// 1. the client reads the `device_authorization_endpoint` from <proxy_url>/.well-known/oauth-authorization-server
+	// 2. the client calls <proxy_url>/auth/realms/<realm>/protocol/openid-connect/auth/device, which is forwarded to SSO.
+	//    The verification URIs of the response are rewritten to point at the status page of the proxy.
+	// 3. the user opens <proxy_url>/device?user_code=<code> (deviceStatusEndpoint) in any browser, which shows the user code
+	//    and links to the SSO verification page
+	// 4. user provides the login credentials in the sso login page and confirms the user code
+	// 5. meanwhile, the client polls <proxy_url>/auth/realms/<realm>/protocol/openid-connect/token, which is forwarded to SSO as is
+	router.GET(deviceStatusEndpoint, p.deviceStatus)
 	// The main proxy route
 	router.Any("/*", p.handleRequestAndRedirect)
 
@@ -185,34 +222,34 @@ func (p *Proxy) StartProxy(port string) *http.Server {
 // unsecured returns true if the request does not require authentication
 func unsecured(ctx echo.Context) bool {
 	uri := ctx.Request().URL.RequestURI()
-	return uri == proxyHealthEndpoint || uri == wellKnownOauthConfigEndpoint || strings.HasPrefix(uri, authEndpoint)
+	return uri == proxyHealthEndpoint || uri == wellKnownOauthConfigEndpoint || strings.HasPrefix(uri, authEndpoint) ||
+		ctx.Request().URL.Path == deviceStatusEndpoint
 }
 
 // auth handles requests to SSO. Used by web login.
 func (p *Proxy) auth(ctx echo.Context) error {
 	req := ctx.Request()
-	targetURL, err := url.Parse(configuration.GetRegistrationServiceConfig().Auth().SSOBaseURL())
+	_, ssoBaseURL, err := ssoRealm(ctx.Param(ssoRealmParam))
+	if err != nil {
+		return err
+	}
+	targetURL, err := url.Parse(ssoBaseURL)
 	if err != nil {
 		return err
 	}
 	targetURL.Path = req.URL.Path
 	targetURL.RawQuery = req.URL.RawQuery
 
-	return p.handleSSORequest(targetURL)(ctx)
+	return p.handleSSORequest(targetURL, nil)(ctx)
 }
 
-// oauthConfiguration handles requests to oauth configuration and proxies them to the corresponding SSO endpoint. Used by web login.
-func (p *Proxy) oauthConfiguration(ctx echo.Context) error {
-	targetURL, err := url.Parse(ssoWellKnownTarget())
+// openidAuth handles requests to the openID Connect authentication endpoint. Used by web login.
+func (p *Proxy) openidAuth(ctx echo.Context) error {
+	_, ssoBaseURL, err := ssoRealm(ctx.Param(ssoRealmParam))
 	if err != nil {
 		return err
 	}
-	return p.handleSSORequest(targetURL)(ctx)
-}
-
-// openidAuth handles requests to the openID Connect authentication endpoint. Used by web login.
-func (p *Proxy) openidAuth(ctx echo.Context) error {
-	targetURL, err := url.Parse(authorizationEndpointTarget())
+	targetURL, err := url.Parse(ssoBaseURL)
 	if err != nil {
 		return err
 	}
@@ -231,7 +268,8 @@ func (p *Proxy) redirectTo(ctx echo.Context, to string) error {
 }
 
 // handleSSORequest handles requests to the cluster authentication server and proxy them to SSO instead. Used by web login.
-func (p *Proxy) handleSSORequest(targetURL *url.URL) echo.HandlerFunc {
+// If not nil, the modifyResponse function is called with the response of SSO before it is returned to the client.
+func (p *Proxy) handleSSORequest(targetURL *url.URL, modifyResponse func(*http.Response) error) echo.HandlerFunc {
 	return func(ctx echo.Context) error {
 		req := ctx.Request()
 		director := func(req *http.Request) {
@@ -241,13 +279,18 @@ func (p *Proxy) handleSSORequest(targetURL *url.URL) echo.HandlerFunc {
 			req.URL.Path = targetURL.Path
 			req.URL.RawQuery = targetURL.RawQuery
 			req.Host = targetURL.Host
+			if modifyResponse != nil {
+				// make sure the response is not compressed, so that it can be modified
+				req.Header.Del("Accept-Encoding")
+			}
 			log.InfoEchof(ctx, "forwarding %s to %s", origin, req.URL.String())
 		}
 		transport := getTransport(req.Header)
 		reverseProxy := &httputil.ReverseProxy{
-			Director:      director,
-			Transport:     transport,
-			FlushInterval: -1,
+			Director:       director,
+			Transport:      transport,
+			FlushInterval:  -1,
+			ModifyResponse: modifyResponse,
 		}
 
 		// Note that ServeHttp is non-blocking and uses a go routine under the hood
@@ -559,6 +602,7 @@ func (p *Proxy) addUserContext() echo.MiddlewareFunc {
 			ctx.Set(context.SubKey, token.Subject)
 			ctx.Set(context.UsernameKey, token.PreferredUsername)
 			ctx.Set(context.EmailKey, token.Email)
+			ctx.Set(context.JWTClaimsKey, token)
 
 			return next(ctx)
 		}
@@ -592,11 +636,11 @@ func (p *Proxy) ensureUserIsNotBanned() echo.MiddlewareFunc {
 				return crterrors.NewUnauthorizedError("unauthenticated request", "invalid email in token")
 			}
 
-			// retrieve banned users
+			// retrieve banned users, using the field index of the informer cache
 			hashedEmail := hash.EncodeString(email)
 			bannedUsers := &toolchainv1alpha1.BannedUserList{}
 			if err := p.List(ctx.Request().Context(), bannedUsers, client.InNamespace(p.Namespace),
-				client.MatchingLabels{toolchainv1alpha1.BannedUserEmailHashLabelKey: hashedEmail}); err != nil {
+				client.MatchingFields{indexes.BannedUserEmailHash: hashedEmail}); err != nil {
 				ctx.Logger().Errorf("error retrieving the list of banned users with email address %s: %v", email, err)
 				return crterrors.NewInternalError(errs.New("user access could not be verified"), "could not define user access")
 			}
@@ -612,6 +656,35 @@ func (p *Proxy) ensureUserIsNotBanned() echo.MiddlewareFunc {
 	}
 }
 
+// ensureTokenIsNotRevoked rejects the request if the token was revoked.
+// This Middleware requires the context to contain the claims of the token,
+// so it needs to be executed after the `addUserContext` Middleware.
+func (p *Proxy) ensureTokenIsNotRevoked() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if unsecured(ctx) { // skip only for unsecured endpoints
+				return next(ctx)
+			}
+
+			claims, ok := ctx.Get(context.JWTClaimsKey).(*auth.TokenClaims)
+			if !ok {
+				return crterrors.NewUnauthorizedError("unauthenticated request", "no claims found in the context")
+			}
+			revoked, err := p.revoker.IsRevoked(ctx.Request().Context(), claims)
+			if err != nil {
+				ctx.Logger().Errorf("error checking if the token of user %s was revoked: %v", claims.PreferredUsername, err)
+				return crterrors.NewInternalError(errs.New("user access could not be verified"), "could not check token revocation")
+			}
+			if revoked {
+				return crterrors.NewUnauthorizedError("invalid bearer token", "token has been revoked")
+			}
+
+			// token is not revoked
+			return next(ctx)
+		}
+	}
+}
+
 func (p *Proxy) stripInvalidHeaders() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
@@ -654,10 +727,18 @@ func (p *Proxy) extractUserToken(req *http.Request) (*auth.TokenClaims, error) {
 		}
 	}
 
+	// skip the parsing and the validation of the token if it was already validated recently
+	if token, found := p.claimsCache.Get(userToken); found {
+		p.metrics.RegServProxyCacheCounterVec.WithLabelValues(metrics.MetricsLabelCacheToken, metrics.MetricsLabelCacheHit).Inc()
+		return token, nil
+	}
+	p.metrics.RegServProxyCacheCounterVec.WithLabelValues(metrics.MetricsLabelCacheToken, metrics.MetricsLabelCacheMiss).Inc()
+
 	token, err := p.tokenParser.FromString(userToken)
 	if err != nil {
 		return nil, crterrors.NewUnauthorizedError("unable to extract claims from token", err.Error())
 	}
+	p.claimsCache.Add(userToken, token)
 	return token, nil
 }
 
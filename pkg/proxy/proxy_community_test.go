@@ -10,7 +10,7 @@ import (
 	"github.com/codeready-toolchain/registration-service/pkg/proxy/handlers"
 	"github.com/codeready-toolchain/registration-service/pkg/signup"
 	"github.com/codeready-toolchain/registration-service/test/fake"
-	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/codeready-toolchain/registration-service/test/util"
 	authsupport "github.com/codeready-toolchain/toolchain-common/pkg/test/auth"
 	"go.uber.org/atomic"
 
@@ -128,7 +128,7 @@ func (s *TestProxySuite) checkProxyCommunityOK(proxy *Proxy, port string) {
 		)
 
 		// init fakeClient
-		cli := commontest.NewFakeClient(s.T(),
+		cli := util.NewFakeClient(s.T(),
 			fake.NewSpace("smith-community", "member-2", "smith"),
 			fake.NewSpace("alice-private", "member-2", "alice"),
 			fake.NewSpaceBinding("smith-community-smith", "smith", "smith-community", "admin"),
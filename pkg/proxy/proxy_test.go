@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -13,6 +14,7 @@ import (
 	"net/url"
 	"reflect"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -21,13 +23,16 @@ import (
 	"github.com/codeready-toolchain/registration-service/pkg/proxy/handlers"
 	"github.com/codeready-toolchain/registration-service/pkg/proxy/metrics"
 	proxytest "github.com/codeready-toolchain/registration-service/pkg/proxy/test"
+	"github.com/codeready-toolchain/registration-service/pkg/revocation"
 	"github.com/codeready-toolchain/registration-service/pkg/signup"
 	"github.com/codeready-toolchain/registration-service/test"
 	"github.com/codeready-toolchain/registration-service/test/fake"
 	"github.com/codeready-toolchain/registration-service/test/util"
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 	"k8s.io/client-go/kubernetes/scheme"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
@@ -70,6 +75,8 @@ var (
 	}
 
 	bannedUserListErrorEmailValue = "banneduser-list-error"
+
+	revokedTokenID = "revoked-token-id"
 )
 
 func (s *TestProxySuite) TestProxy() {
@@ -95,12 +102,14 @@ func (s *TestProxySuite) TestProxy() {
 				for _, opt := range opts {
 					opt.ApplyToList(listOptions)
 				}
-				if strings.Contains(listOptions.LabelSelector.String(), hash.EncodeString(bannedUserListErrorEmailValue)) {
+				if listOptions.FieldSelector != nil && strings.Contains(listOptions.FieldSelector.String(), hash.EncodeString(bannedUserListErrorEmailValue)) {
 					return fmt.Errorf("list banned user error")
 				}
 				return fakeClient.Client.List(ctx, list, opts...)
 			}
 			nsClient := namespaced.NewClient(fakeClient, commontest.HostOperatorNs)
+			_, err := revocation.NewRevoker(nsClient).RevokeToken(context.TODO(), revokedTokenID, time.Now().Add(time.Hour), "leaked", "admin")
+			require.NoError(s.T(), err)
 
 			proxyMetrics := metrics.NewProxyMetrics(prometheus.NewRegistry())
 			proxy, err := NewProxy(nsClient, app, proxyMetrics, proxytest.NewGetMembersFunc(commontest.NewFakeClient(s.T())))
@@ -122,6 +131,9 @@ func (s *TestProxySuite) TestProxy() {
 			s.checkPlainHTTPErrors(proxy)
 			s.checkWebsocketsError()
 			s.checkWebLogin()
+			s.checkDeviceLogin()
+			s.checkWellKnownRewrite(proxy)
+			s.checkSSORealms()
 			s.checkProxyOK(proxy)
 		})
 	}
@@ -322,6 +334,32 @@ func (s *TestProxySuite) checkPlainHTTPErrors(proxy *Proxy) {
 			s.assertResponseBody(resp, "user access is forbidden: user access is forbidden")
 		})
 
+		s.Run("cached token of banned user is still forbidden", func() {
+			// given
+			token := s.token("alice", authsupport.WithSubClaim("alice"), authsupport.WithEmailClaim(bannedUser.Spec.Email))
+			hits := promtestutil.ToFloat64(proxy.metrics.RegServProxyCacheCounterVec.WithLabelValues(metrics.MetricsLabelCacheToken, metrics.MetricsLabelCacheHit))
+			misses := promtestutil.ToFloat64(proxy.metrics.RegServProxyCacheCounterVec.WithLabelValues(metrics.MetricsLabelCacheToken, metrics.MetricsLabelCacheMiss))
+
+			for i := 0; i < 2; i++ {
+				req, err := http.NewRequest("GET", "http://localhost:8081/api/mycoolworkspace/pods", nil)
+				require.NoError(s.T(), err)
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+				// when
+				resp, err := http.DefaultClient.Do(req)
+
+				// then
+				require.NoError(s.T(), err)
+				require.NotNil(s.T(), resp)
+				assert.Equal(s.T(), http.StatusForbidden, resp.StatusCode)
+				s.assertResponseBody(resp, "user access is forbidden: user access is forbidden")
+				resp.Body.Close()
+			}
+			// first request is a miss, second one is a hit
+			assert.InDelta(s.T(), misses+1, promtestutil.ToFloat64(proxy.metrics.RegServProxyCacheCounterVec.WithLabelValues(metrics.MetricsLabelCacheToken, metrics.MetricsLabelCacheMiss)), 0.01)
+			assert.InDelta(s.T(), hits+1, promtestutil.ToFloat64(proxy.metrics.RegServProxyCacheCounterVec.WithLabelValues(metrics.MetricsLabelCacheToken, metrics.MetricsLabelCacheHit)), 0.01)
+		})
+
 		s.Run("internal error if error occurred while defining if the user is banned", func() {
 			// given
 			req, err := http.NewRequest("GET", "http://localhost:8081/api/mycoolworkspace/pods", nil)
@@ -338,6 +376,25 @@ func (s *TestProxySuite) checkPlainHTTPErrors(proxy *Proxy) {
 			assert.Equal(s.T(), http.StatusInternalServerError, resp.StatusCode)
 			s.assertResponseBody(resp, "user access could not be verified: could not define user access")
 		})
+
+		s.Run("unauthorized if token is revoked", func() {
+			// given
+			req, err := http.NewRequest("GET", "http://localhost:8081/api/mycoolworkspace/pods", nil)
+			require.NoError(s.T(), err)
+			require.NotNil(s.T(), req)
+			token := s.token("bob", func(token *jwt.Token) {
+				token.Claims.(*authsupport.MyClaims).ID = revokedTokenID
+			})
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+			resp, err := http.DefaultClient.Do(req)
+
+			// then
+			require.NoError(s.T(), err)
+			require.NotNil(s.T(), resp)
+			defer resp.Body.Close()
+			assert.Equal(s.T(), http.StatusUnauthorized, resp.StatusCode)
+			s.assertResponseBody(resp, "invalid bearer token: token has been revoked")
+		})
 	})
 }
 
@@ -488,6 +545,405 @@ func (s *TestProxySuite) checkWebLogin() {
 	})
 }
 
+func (s *TestProxySuite) checkDeviceLogin() {
+	s.Run("device login", func() {
+		// use a mock sso server
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch p := r.URL.Path; p {
+			case "/auth/realms/sandbox-dev/.well-known/openid-configuration":
+				w.WriteHeader(http.StatusOK)
+				_, err := w.Write([]byte(`{"issuer":"https://sso/auth/realms/sandbox-dev","token_endpoint":"https://sso/auth/realms/sandbox-dev/protocol/openid-connect/token"}`))
+				assert.NoError(s.T(), err)
+			case "/auth/realms/sandbox-dev/protocol/openid-connect/auth/device":
+				assert.Equal(s.T(), http.MethodPost, r.Method)
+				assert.NoError(s.T(), r.ParseForm())
+				if r.Form.Get("client_id") != "openshift-cli-client" {
+					w.WriteHeader(http.StatusUnauthorized)
+					_, err := w.Write([]byte(`{"error":"invalid_client"}`))
+					assert.NoError(s.T(), err)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				_, err := w.Write([]byte(`{"device_code":"mydevicecode","user_code":"ABCD-EFGH","verification_uri":"https://sso/auth/realms/sandbox-dev/device","verification_uri_complete":"https://sso/auth/realms/sandbox-dev/device?user_code=ABCD-EFGH","expires_in":600,"interval":5}`))
+				assert.NoError(s.T(), err)
+			case "/auth/realms/sandbox-dev/protocol/openid-connect/token":
+				assert.NoError(s.T(), r.ParseForm())
+				assert.Equal(s.T(), "urn:ietf:params:oauth:grant-type:device_code", r.Form.Get("grant_type"))
+				assert.Equal(s.T(), "mydevicecode", r.Form.Get("device_code"))
+				w.WriteHeader(http.StatusBadRequest)
+				_, err := w.Write([]byte(`{"error":"authorization_pending"}`))
+				assert.NoError(s.T(), err)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer testServer.Close()
+
+		ssoBaseURL := s.DefaultConfig().Auth().SSOBaseURL()
+		defer s.SetConfig(testconfig.RegistrationService().Auth().SSOBaseURL(ssoBaseURL))
+		s.SetConfig(testconfig.RegistrationService().Auth().SSOBaseURL(testServer.URL))
+
+		s.Run("well-known configuration advertises the device authorization endpoint", func() {
+			// given
+			test.SetSettings(s.T(), "proxy: {publicURL: https://api-proxy.example.com/}")
+
+			// when
+			resp, err := http.Get("http://localhost:8081/.well-known/oauth-authorization-server")
+
+			// then
+			require.NoError(s.T(), err)
+			defer resp.Body.Close()
+			require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+			config := map[string]interface{}{}
+			require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&config))
+			assert.Equal(s.T(), "https://api-proxy.example.com/auth/realms/sandbox-dev/protocol/openid-connect/auth/device", config["device_authorization_endpoint"])
+			assert.Equal(s.T(), "https://sso/auth/realms/sandbox-dev", config["issuer"])
+		})
+
+		s.Run("device authorization request is forwarded and verification URIs point at the proxy", func() {
+			// when
+			resp, err := http.PostForm("http://localhost:8081/auth/realms/sandbox-dev/protocol/openid-connect/auth/device",
+				url.Values{"client_id": {"openshift-cli-client"}})
+
+			// then
+			require.NoError(s.T(), err)
+			defer resp.Body.Close()
+			require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+			authz := map[string]interface{}{}
+			require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&authz))
+			assert.Equal(s.T(), "mydevicecode", authz["device_code"])
+			assert.Equal(s.T(), "ABCD-EFGH", authz["user_code"])
+			assert.Equal(s.T(), "http://localhost:8081/device", authz["verification_uri"])
+			assert.Equal(s.T(), "http://localhost:8081/device?user_code=ABCD-EFGH", authz["verification_uri_complete"])
+			assert.InDelta(s.T(), float64(5), authz["interval"], 0.01)
+		})
+
+		s.Run("verification URIs point at the public URL of the proxy", func() {
+			// given
+			test.SetSettings(s.T(), "proxy: {publicURL: https://api-proxy.example.com}")
+
+			// when
+			resp, err := http.PostForm("http://localhost:8081/auth/realms/sandbox-dev/protocol/openid-connect/auth/device",
+				url.Values{"client_id": {"openshift-cli-client"}})
+
+			// then
+			require.NoError(s.T(), err)
+			defer resp.Body.Close()
+			require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+			authz := map[string]interface{}{}
+			require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&authz))
+			assert.Equal(s.T(), "https://api-proxy.example.com/device", authz["verification_uri"])
+		})
+
+		s.Run("forwarded headers are ignored", func() {
+			// given
+			req, err := http.NewRequest(http.MethodPost, "http://localhost:8081/auth/realms/sandbox-dev/protocol/openid-connect/auth/device",
+				strings.NewReader(url.Values{"client_id": {"openshift-cli-client"}}.Encode()))
+			require.NoError(s.T(), err)
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.Header.Set("X-Forwarded-Proto", "https")
+			req.Header.Set("X-Forwarded-Host", "evil.example.com")
+
+			// when
+			resp, err := http.DefaultClient.Do(req)
+
+			// then
+			require.NoError(s.T(), err)
+			defer resp.Body.Close()
+			require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+			authz := map[string]interface{}{}
+			require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&authz))
+			assert.Equal(s.T(), "http://localhost:8081/device", authz["verification_uri"])
+		})
+
+		s.Run("device authorization error is returned as is", func() {
+			// when
+			resp, err := http.PostForm("http://localhost:8081/auth/realms/sandbox-dev/protocol/openid-connect/auth/device",
+				url.Values{"client_id": {"unknown"}})
+
+			// then
+			require.NoError(s.T(), err)
+			defer resp.Body.Close()
+			assert.Equal(s.T(), http.StatusUnauthorized, resp.StatusCode)
+			s.assertResponseBody(resp, `{"error":"invalid_client"}`)
+		})
+
+		s.Run("token polling is forwarded", func() {
+			// when
+			resp, err := http.PostForm("http://localhost:8081/auth/realms/sandbox-dev/protocol/openid-connect/token",
+				url.Values{"grant_type": {"urn:ietf:params:oauth:grant-type:device_code"}, "device_code": {"mydevicecode"}, "client_id": {"openshift-cli-client"}})
+
+			// then
+			require.NoError(s.T(), err)
+			defer resp.Body.Close()
+			assert.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+			s.assertResponseBody(resp, `{"error":"authorization_pending"}`)
+		})
+
+		s.Run("status page shows the user code", func() {
+			// when
+			resp, err := http.Get("http://localhost:8081/device?user_code=ABCD-EFGH")
+
+			// then
+			require.NoError(s.T(), err)
+			defer resp.Body.Close()
+			require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+			assert.Contains(s.T(), resp.Header.Get("Content-Type"), "text/html")
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(s.T(), err)
+			assert.Contains(s.T(), string(body), `<p class="code">ABCD-EFGH</p>`)
+			assert.Contains(s.T(), string(body), `href="`+testServer.URL+`/auth/realms/sandbox-dev/device?user_code=ABCD-EFGH"`)
+		})
+
+		s.Run("status page escapes the user code", func() {
+			// when
+			resp, err := http.Get("http://localhost:8081/device?user_code=" + url.QueryEscape("<script>"))
+
+			// then
+			require.NoError(s.T(), err)
+			defer resp.Body.Close()
+			require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(s.T(), err)
+			assert.NotContains(s.T(), string(body), "<script>")
+			assert.Contains(s.T(), string(body), "&lt;script&gt;")
+		})
+
+		s.Run("status page without user code", func() {
+			// when
+			resp, err := http.Get("http://localhost:8081/device")
+
+			// then
+			require.NoError(s.T(), err)
+			defer resp.Body.Close()
+			require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(s.T(), err)
+			assert.Contains(s.T(), string(body), `href="`+testServer.URL+`/auth/realms/sandbox-dev/device"`)
+		})
+	})
+}
+
+func (s *TestProxySuite) checkWellKnownRewrite(proxy *Proxy) {
+	s.Run("well-known rewrite", func() {
+		// use a mock sso server
+		var calls atomic.Int32
+		var testServer *httptest.Server
+		testServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/auth/realms/sandbox-dev/.well-known/openid-configuration" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			calls.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			_, err := fmt.Fprintf(w, `{
+				"issuer": "%[1]s/auth/realms/sandbox-dev",
+				"authorization_endpoint": "%[1]s/auth/realms/sandbox-dev/protocol/openid-connect/auth",
+				"token_endpoint": "%[1]s/auth/realms/sandbox-dev/protocol/openid-connect/token",
+				"jwks_uri": "%[1]s/auth/realms/sandbox-dev/protocol/openid-connect/certs",
+				"userinfo_endpoint": "https://elsewhere.example.com/userinfo",
+				"grant_types_supported": ["authorization_code", "urn:ietf:params:oauth:grant-type:device_code"]
+			}`, testServer.URL)
+			assert.NoError(s.T(), err)
+		}))
+		defer testServer.Close()
+
+		ssoBaseURL := s.DefaultConfig().Auth().SSOBaseURL()
+		defer s.SetConfig(testconfig.RegistrationService().Auth().SSOBaseURL(ssoBaseURL))
+		s.SetConfig(testconfig.RegistrationService().Auth().SSOBaseURL(testServer.URL))
+
+		test.SetSettings(s.T(), "proxy: {publicURL: https://api-proxy.example.com}")
+
+		getConfig := func() map[string]interface{} {
+			resp, err := http.Get("http://localhost:8081/.well-known/oauth-authorization-server")
+			require.NoError(s.T(), err)
+			defer resp.Body.Close()
+			require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+			config := map[string]interface{}{}
+			require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&config))
+			return config
+		}
+
+		s.Run("endpoints pointing at SSO are rewritten", func() {
+			// when
+			config := getConfig()
+
+			// then
+			assert.Equal(s.T(), "https://api-proxy.example.com/auth/realms/sandbox-dev/protocol/openid-connect/auth", config["authorization_endpoint"])
+			assert.Equal(s.T(), "https://api-proxy.example.com/auth/realms/sandbox-dev/protocol/openid-connect/token", config["token_endpoint"])
+			assert.Equal(s.T(), "https://api-proxy.example.com/auth/realms/sandbox-dev/protocol/openid-connect/certs", config["jwks_uri"])
+			assert.Equal(s.T(), "https://api-proxy.example.com/auth/realms/sandbox-dev/protocol/openid-connect/auth/device", config["device_authorization_endpoint"])
+			// not in the allowlist
+			assert.Equal(s.T(), testServer.URL+"/auth/realms/sandbox-dev", config["issuer"])
+			// not pointing at SSO
+			assert.Equal(s.T(), "https://elsewhere.example.com/userinfo", config["userinfo_endpoint"])
+			// other values are kept
+			assert.Equal(s.T(), []interface{}{"authorization_code", "urn:ietf:params:oauth:grant-type:device_code"}, config["grant_types_supported"])
+		})
+
+		s.Run("configurable allowlist", func() {
+			// given
+			test.SetSettings(s.T(), "proxy: {publicURL: https://api-proxy.example.com, wellKnownRewriteEndpoints: [token_endpoint]}")
+
+			// when
+			config := getConfig()
+
+			// then
+			assert.Equal(s.T(), testServer.URL+"/auth/realms/sandbox-dev/protocol/openid-connect/auth", config["authorization_endpoint"])
+			assert.Equal(s.T(), "https://api-proxy.example.com/auth/realms/sandbox-dev/protocol/openid-connect/token", config["token_endpoint"])
+		})
+
+		s.Run("document is cached", func() {
+			// given
+			proxy.wellKnownCache = newWellKnownCache()
+			calls.Store(0)
+
+			// when
+			getConfig()
+			getConfig()
+
+			// then
+			assert.Equal(s.T(), int32(1), calls.Load())
+
+			s.Run("until it expires", func() {
+				// given
+				test.SetSettings(s.T(), "proxy: {publicURL: https://api-proxy.example.com, wellKnownCacheTTL: 10ms}")
+				proxy.wellKnownCache = newWellKnownCache()
+				calls.Store(0)
+
+				// when
+				getConfig()
+				time.Sleep(20 * time.Millisecond)
+				getConfig()
+
+				// then
+				assert.Equal(s.T(), int32(2), calls.Load())
+			})
+		})
+	})
+}
+
+func (s *TestProxySuite) checkSSORealms() {
+	s.Run("several SSO realms", func() {
+		// use a mock sso server for an additional realm
+		var testServer *httptest.Server
+		testServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch p := r.URL.Path; p {
+			case "/auth/realms/employees/.well-known/openid-configuration":
+				w.WriteHeader(http.StatusOK)
+				_, err := fmt.Fprintf(w, `{"issuer":"%[1]s/auth/realms/employees","token_endpoint":"%[1]s/auth/realms/employees/protocol/openid-connect/token"}`, testServer.URL)
+				assert.NoError(s.T(), err)
+			case "/auth/realms/employees/protocol/openid-connect/auth/device":
+				w.WriteHeader(http.StatusOK)
+				_, err := w.Write([]byte(`{"device_code":"mydevicecode","user_code":"ABCD-EFGH","verification_uri":"https://sso/auth/realms/employees/device","expires_in":600,"interval":5}`))
+				assert.NoError(s.T(), err)
+			case "/auth/realms/employees/protocol/openid-connect/token":
+				w.WriteHeader(http.StatusOK)
+				_, err := w.Write([]byte(`{"access_token":"mytoken"}`))
+				assert.NoError(s.T(), err)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer testServer.Close()
+		test.SetSettings(s.T(), "auth: {additionalSSORealms: {employees: "+testServer.URL+"}}")
+
+		client := &http.Client{
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+		do := func(method, url string) (int, http.Header, string) {
+			req, err := http.NewRequest(method, url, nil)
+			require.NoError(s.T(), err)
+			resp, err := client.Do(req)
+			require.NoError(s.T(), err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(s.T(), err)
+			return resp.StatusCode, resp.Header, string(body)
+		}
+
+		s.Run("authentication is redirected to the SSO of the realm", func() {
+			// when
+			status, header, _ := do(http.MethodGet, "http://localhost:8081/auth/realms/employees/protocol/openid-connect/auth?state=mystate")
+
+			// then
+			assert.Equal(s.T(), http.StatusSeeOther, status)
+			assert.Equal(s.T(), testServer.URL+"/auth/realms/employees/protocol/openid-connect/auth?state=mystate", header.Get("Location"))
+		})
+
+		s.Run("requests are forwarded to the SSO of the realm", func() {
+			// when
+			status, _, body := do(http.MethodPost, "http://localhost:8081/auth/realms/employees/protocol/openid-connect/token")
+
+			// then
+			assert.Equal(s.T(), http.StatusOK, status)
+			assert.JSONEq(s.T(), `{"access_token":"mytoken"}`, body)
+		})
+
+		s.Run("well-known configuration of the realm is rewritten", func() {
+			// when
+			status, _, body := do(http.MethodGet, "http://localhost:8081/auth/realms/employees/.well-known/openid-configuration")
+
+			// then
+			assert.Equal(s.T(), http.StatusOK, status)
+			config := map[string]interface{}{}
+			require.NoError(s.T(), json.Unmarshal([]byte(body), &config))
+			assert.Equal(s.T(), testServer.URL+"/auth/realms/employees", config["issuer"])
+			assert.Equal(s.T(), "http://localhost:8081/auth/realms/employees/protocol/openid-connect/token", config["token_endpoint"])
+			assert.Equal(s.T(), "http://localhost:8081/auth/realms/employees/protocol/openid-connect/auth/device", config["device_authorization_endpoint"])
+		})
+
+		s.Run("device login with the realm", func() {
+			// when
+			status, _, body := do(http.MethodPost, "http://localhost:8081/auth/realms/employees/protocol/openid-connect/auth/device")
+
+			// then
+			assert.Equal(s.T(), http.StatusOK, status)
+			authz := map[string]interface{}{}
+			require.NoError(s.T(), json.Unmarshal([]byte(body), &authz))
+			assert.Equal(s.T(), "http://localhost:8081/device?realm=employees", authz["verification_uri"])
+			assert.Equal(s.T(), "http://localhost:8081/device?realm=employees&user_code=ABCD-EFGH", authz["verification_uri_complete"])
+
+			s.Run("status page links to the SSO of the realm", func() {
+				// when
+				status, _, body := do(http.MethodGet, authz["verification_uri_complete"].(string))
+
+				// then
+				assert.Equal(s.T(), http.StatusOK, status)
+				assert.Contains(s.T(), body, `href="`+testServer.URL+`/auth/realms/employees/device?user_code=ABCD-EFGH"`)
+			})
+		})
+
+		s.Run("unknown realm is rejected", func() {
+			for _, req := range []struct {
+				method string
+				url    string
+			}{
+				{http.MethodGet, "http://localhost:8081/auth/realms/unknown/protocol/openid-connect/auth?state=mystate"},
+				{http.MethodPost, "http://localhost:8081/auth/realms/unknown/protocol/openid-connect/token"},
+				{http.MethodPost, "http://localhost:8081/auth/realms/unknown/protocol/openid-connect/auth/device"},
+				{http.MethodGet, "http://localhost:8081/auth/realms/unknown/.well-known/openid-configuration"},
+				{http.MethodGet, "http://localhost:8081/auth/realms/unknown"},
+				{http.MethodGet, "http://localhost:8081/device?realm=unknown"},
+			} {
+				s.Run(req.method+" "+req.url, func() {
+					// when
+					status, _, body := do(req.method, req.url)
+
+					// then
+					assert.Equal(s.T(), http.StatusNotFound, status)
+					assert.Equal(s.T(), "unknown SSO realm 'unknown': unable to forward the request to SSO", body)
+				})
+			}
+		})
+	})
+}
+
 func (s *TestProxySuite) checkProxyOK(proxy *Proxy) {
 	s.Run("successfully proxy", func() {
 		username := "smith2"
@@ -848,7 +1304,7 @@ func (s *TestProxySuite) checkProxyOK(proxy *Proxy) {
 										Status: toolchainv1alpha1.ProxyPluginStatus{},
 									}
 									require.NoError(s.T(), routev1.Install(scheme.Scheme))
-									fakeClient := commontest.NewFakeClient(s.T(),
+									fakeClient := util.NewFakeClient(s.T(),
 										fake.NewSpace("mycoolworkspace", "member-2", "smith2"),
 										fake.NewSpaceBinding("mycoolworkspace-smith2", "smith2", "mycoolworkspace", "admin"),
 										proxyPlugin,
@@ -0,0 +1,109 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+	"github.com/labstack/echo/v4"
+)
+
+// wellKnownCache keeps the SSO discovery documents for a short time, so that SSO is not called for every login
+type wellKnownCache struct {
+	mu      sync.Mutex
+	entries map[string]wellKnownCacheEntry
+}
+
+type wellKnownCacheEntry struct {
+	config    map[string]interface{}
+	expiresAt time.Time
+}
+
+func newWellKnownCache() *wellKnownCache {
+	return &wellKnownCache{
+		entries: map[string]wellKnownCacheEntry{},
+	}
+}
+
+func (c *wellKnownCache) get(url string) (map[string]interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, found := c.entries[url]
+	if !found || !time.Now().Before(entry.expiresAt) {
+		delete(c.entries, url)
+		return nil, false
+	}
+	return entry.config, true
+}
+
+func (c *wellKnownCache) add(url string, config map[string]interface{}, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[url] = wellKnownCacheEntry{
+		config:    config,
+		expiresAt: time.Now().Add(ttl),
+	}
+}
+
+// oauthConfiguration handles requests to oauth configuration. Used by web login and device login.
+// The configuration is retrieved from the corresponding SSO endpoint (or from the cache), and the URLs of the
+// configured endpoints are rewritten to point at the proxy, so that clients only need to reach the proxy.
+// The device authorization endpoint of the proxy is always advertised.
+func (p *Proxy) oauthConfiguration(ctx echo.Context) error {
+	realm, ssoBaseURL, err := ssoRealm(ctx.Param(ssoRealmParam))
+	if err != nil {
+		return err
+	}
+	target := ssoWellKnownTarget(ssoBaseURL, realm)
+	config, found := p.wellKnownCache.get(target)
+	if !found {
+		resp, err := (&http.Client{
+			Transport: getTransport(ctx.Request().Header),
+			Timeout:   10 * time.Second,
+		}).Get(target)
+		if err != nil {
+			return crterrors.NewInternalError(err, "unable to retrieve the SSO configuration")
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return crterrors.NewInternalError(err, "unable to read the SSO configuration")
+		}
+		if resp.StatusCode != http.StatusOK || json.Unmarshal(body, &config) != nil || config == nil {
+			// not a valid configuration, return the response as is
+			log.InfoEchof(ctx, "returning the SSO configuration as is (status: %s)", strconv.Itoa(resp.StatusCode))
+			return ctx.Blob(resp.StatusCode, resp.Header.Get("Content-Type"), body)
+		}
+		p.wellKnownCache.add(target, config, configuration.GetRegistrationServiceConfig().Proxy().WellKnownCacheTTL())
+	}
+	return ctx.JSON(http.StatusOK, rewriteWellKnown(config, ssoBaseURL, realm, proxyBaseURL(ctx.Request())))
+}
+
+// rewriteWellKnown returns a copy of the given SSO discovery document, in which the URLs of the configured endpoints
+// are rewritten to point at the proxy. Only the URLs pointing at the SSO base URL of the realm are rewritten, as they are
+// the only ones that the proxy is able to forward.
+func rewriteWellKnown(config map[string]interface{}, ssoBaseURL, realm, proxyURL string) map[string]interface{} {
+	ssoBaseURL = strings.TrimSuffix(ssoBaseURL, "/")
+	rewritten := maps.Clone(config)
+	for _, key := range configuration.GetRegistrationServiceConfig().Proxy().WellKnownRewriteEndpoints() {
+		endpoint, ok := rewritten[key].(string)
+		if !ok || !strings.HasPrefix(endpoint, ssoBaseURL+"/") {
+			continue
+		}
+		rewritten[key] = fmt.Sprintf("%s%s", proxyURL, strings.TrimPrefix(endpoint, ssoBaseURL))
+	}
+	rewritten["device_authorization_endpoint"] = proxyURL + openidDeviceAuthEndpoint(realm)
+	return rewritten
+}
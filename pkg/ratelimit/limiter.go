@@ -0,0 +1,72 @@
+// Package ratelimit limits the rate of the requests of each caller of an endpoint, independently of the other callers.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// cleanupInterval is the minimum interval between two removals of the idle callers
+const cleanupInterval = time.Minute
+
+// Limiter allows a given number of requests per minute for each caller, with bursts of up to a given number of requests.
+// A Limiter with a rate of 0 allows all the requests.
+type Limiter struct {
+	mu          sync.Mutex
+	limit       rate.Limit
+	burst       int
+	callers     map[string]*rate.Limiter
+	lastCleanup time.Time
+	now         func() time.Time
+}
+
+// NewLimiter returns a new Limiter allowing the given number of requests per minute and per caller
+func NewLimiter(perMinute, burst int) *Limiter {
+	return &Limiter{
+		limit:   rate.Limit(float64(perMinute) / time.Minute.Seconds()),
+		burst:   burst,
+		callers: map[string]*rate.Limiter{},
+		now:     time.Now,
+	}
+}
+
+// Allow returns true if the given caller is allowed to make a request now.
+// Otherwise, it returns false along with the duration after which the caller is allowed to make a new request.
+func (l *Limiter) Allow(caller string) (bool, time.Duration) {
+	if l.limit <= 0 {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.removeIdleCallers(now)
+
+	limiter, found := l.callers[caller]
+	if !found {
+		limiter = rate.NewLimiter(l.limit, l.burst)
+		l.callers[caller] = limiter
+	}
+	reservation := limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		// the request is rejected, so it must not consume the tokens of the next ones
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// removeIdleCallers forgets the callers which have not made any request long enough to be allowed a full burst again,
+// so that the memory used by the Limiter doesn't grow with the number of callers over time
+func (l *Limiter) removeIdleCallers(now time.Time) {
+	if now.Sub(l.lastCleanup) < cleanupInterval {
+		return
+	}
+	l.lastCleanup = now
+	for caller, limiter := range l.callers {
+		if limiter.TokensAt(now) >= float64(l.burst) {
+			delete(l.callers, caller)
+		}
+	}
+}
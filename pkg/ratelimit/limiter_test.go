@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	// returns a limiter whose clock is controlled by the test
+	newLimiter := func(perMinute, burst int) (*Limiter, *time.Time) {
+		now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		l := NewLimiter(perMinute, burst)
+		l.now = func() time.Time { return now }
+		return l, &now
+	}
+
+	t.Run("requests within the burst are allowed", func(t *testing.T) {
+		// given
+		l, _ := newLimiter(6, 3)
+
+		// when
+		for i := 0; i < 3; i++ {
+			allowed, _ := l.Allow("johnny")
+
+			// then
+			assert.True(t, allowed)
+		}
+	})
+
+	t.Run("requests beyond the burst are rejected until a token is available", func(t *testing.T) {
+		// given
+		l, now := newLimiter(6, 3)
+		for i := 0; i < 3; i++ {
+			allowed, _ := l.Allow("johnny")
+			require.True(t, allowed)
+		}
+
+		// when
+		allowed, retryAfter := l.Allow("johnny")
+
+		// then
+		assert.False(t, allowed)
+		assert.Equal(t, 10*time.Second, retryAfter)
+
+		t.Run("rejected requests don't delay the next ones", func(t *testing.T) {
+			// when
+			allowed, retryAfter := l.Allow("johnny")
+
+			// then
+			assert.False(t, allowed)
+			assert.Equal(t, 10*time.Second, retryAfter)
+		})
+
+		t.Run("allowed again after the delay", func(t *testing.T) {
+			// given
+			*now = now.Add(10 * time.Second)
+
+			// when
+			allowed, _ := l.Allow("johnny")
+
+			// then
+			assert.True(t, allowed)
+		})
+	})
+
+	t.Run("callers are limited independently", func(t *testing.T) {
+		// given
+		l, _ := newLimiter(6, 1)
+		allowed, _ := l.Allow("johnny")
+		require.True(t, allowed)
+
+		// when
+		allowed, _ = l.Allow("jane")
+
+		// then
+		assert.True(t, allowed)
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		// given
+		l, _ := newLimiter(0, 1)
+
+		// when
+		for i := 0; i < 100; i++ {
+			allowed, _ := l.Allow("johnny")
+
+			// then
+			assert.True(t, allowed)
+		}
+	})
+
+	t.Run("idle callers are removed", func(t *testing.T) {
+		// given
+		l, now := newLimiter(60, 2)
+		l.Allow("johnny")
+		l.Allow("jane")
+		*now = now.Add(cleanupInterval)
+		l.Allow("jane")
+		l.Allow("jane")
+		require.Len(t, l.callers, 1) // johnny was idle long enough to recover a full burst
+		require.Contains(t, l.callers, "jane")
+
+		// when
+		*now = now.Add(cleanupInterval)
+		l.Allow("johnny")
+
+		// then
+		assert.Len(t, l.callers, 1) // jane was idle long enough to recover a full burst
+		assert.Contains(t, l.callers, "johnny")
+	})
+}
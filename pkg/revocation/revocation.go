@@ -0,0 +1,256 @@
+// Package revocation provides the means to invalidate access tokens before they expire.
+//
+// A revocation is stored as a ConfigMap in the host-operator namespace, so that it can be served by the
+// informer cache when the tokens are checked. A revocation either targets a single token (by its `jti` claim)
+// or all the tokens of a given subject (by its `sub` claim) which were issued before a given point in time.
+// The revocations of single tokens are deleted on the next revocation once the tokens expired, so that they do not
+// pile up.
+package revocation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/auth"
+	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/toolchain-common/pkg/hash"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// LabelKey is the key of the label set on all the ConfigMaps holding a token revocation
+	LabelKey = toolchainv1alpha1.LabelKeyPrefix + "token-revocation"
+
+	tokenIDNamePrefix = "revoked-token-"
+	subjectNamePrefix = "revoked-subject-"
+
+	tokenIDKey      = "jti"
+	subjectKey      = "sub"
+	issuedBeforeKey = "issuedBefore"
+	expiresAtKey    = "expiresAt"
+	reasonKey       = "reason"
+	revokedByKey    = "revokedBy"
+)
+
+// ErrNotFound is returned when the revocation to delete does not exist
+var ErrNotFound = errors.New("token revocation not found")
+
+// Revocation represents a token revocation
+type Revocation struct {
+	// Name is the name of the resource holding the revocation
+	Name string `json:"name"`
+	// TokenID is the `jti` claim of the revoked token
+	TokenID string `json:"jti,omitempty"`
+	// ExpiresAt is the expiry time of the revoked token, after which the revocation is deleted
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// Subject is the `sub` claim of the revoked tokens
+	Subject string `json:"sub,omitempty"`
+	// IssuedBefore is the time before which all the tokens of the Subject are revoked
+	IssuedBefore *time.Time `json:"issuedBefore,omitempty"`
+	// Reason is the reason of the revocation
+	Reason string `json:"reason,omitempty"`
+	// RevokedBy is the username of the administrator who revoked the token(s)
+	RevokedBy string `json:"revokedBy,omitempty"`
+	// Created is the creation time of the revocation
+	Created time.Time `json:"created"`
+}
+
+// Revoker creates, lists and deletes the token revocations, and checks tokens against them
+type Revoker struct {
+	namespaced.Client
+}
+
+// NewRevoker returns a new Revoker instance
+func NewRevoker(nsClient namespaced.Client) *Revoker {
+	return &Revoker{
+		Client: nsClient,
+	}
+}
+
+// IsRevoked returns true if the token with the given claims was revoked, either explicitly by its ID
+// or because all the tokens of its subject issued before a given time were revoked.
+// Tokens without an `iat` claim are considered revoked as soon as their subject has a revocation.
+func (r *Revoker) IsRevoked(ctx context.Context, claims *auth.TokenClaims) (bool, error) {
+	if claims.ID != "" {
+		if _, err := r.get(ctx, tokenIDName(claims.ID)); err == nil {
+			return true, nil
+		} else if !apierrors.IsNotFound(err) {
+			return false, err
+		}
+	}
+	cm, err := r.get(ctx, subjectName(claims.Subject))
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	issuedBefore, err := time.Parse(time.RFC3339, cm.Data[issuedBeforeKey])
+	if err != nil {
+		return false, fmt.Errorf("invalid token revocation '%s': %w", cm.Name, err)
+	}
+	return claims.IssuedAt == nil || !claims.IssuedAt.After(issuedBefore), nil
+}
+
+// RevokeToken revokes the token with the given ID until the given expiry time of the token.
+// Revoking the same token twice is a no-op.
+func (r *Revoker) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time, reason, revokedBy string) (*Revocation, error) {
+	if err := r.deleteExpiredTokens(ctx, time.Now()); err != nil {
+		return nil, err
+	}
+	cm := r.newConfigMap(tokenIDName(tokenID), reason, revokedBy)
+	cm.Data[tokenIDKey] = tokenID
+	cm.Data[expiresAtKey] = expiresAt.UTC().Format(time.RFC3339)
+	if err := r.Create(ctx, cm); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return nil, err
+		}
+		if cm, err = r.get(ctx, cm.Name); err != nil {
+			return nil, err
+		}
+	}
+	return toRevocation(cm), nil
+}
+
+// RevokeSubject revokes all the tokens of the given subject issued before (or at) the given time.
+// If the subject already has a revocation, then its time and reason are updated.
+func (r *Revoker) RevokeSubject(ctx context.Context, subject string, issuedBefore time.Time, reason, revokedBy string) (*Revocation, error) {
+	if err := r.deleteExpiredTokens(ctx, time.Now()); err != nil {
+		return nil, err
+	}
+	cm := r.newConfigMap(subjectName(subject), reason, revokedBy)
+	cm.Data[subjectKey] = subject
+	cm.Data[issuedBeforeKey] = issuedBefore.UTC().Format(time.RFC3339)
+	if err := r.Create(ctx, cm); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return nil, err
+		}
+		existing, err := r.get(ctx, cm.Name)
+		if err != nil {
+			return nil, err
+		}
+		existing.Data = cm.Data
+		if err := r.Update(ctx, existing); err != nil {
+			return nil, err
+		}
+		cm = existing
+	}
+	return toRevocation(cm), nil
+}
+
+// List returns all the token revocations
+func (r *Revoker) List(ctx context.Context) ([]Revocation, error) {
+	cms := &corev1.ConfigMapList{}
+	if err := r.Client.List(ctx, cms, client.InNamespace(r.Namespace), client.HasLabels{LabelKey}); err != nil {
+		return nil, err
+	}
+	revocations := make([]Revocation, 0, len(cms.Items))
+	for i := range cms.Items {
+		revocations = append(revocations, *toRevocation(&cms.Items[i]))
+	}
+	return revocations, nil
+}
+
+// Delete deletes the token revocation with the given name. Returns ErrNotFound if there is no such revocation.
+func (r *Revoker) Delete(ctx context.Context, name string) error {
+	cm, err := r.get(ctx, name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if err := r.Client.Delete(ctx, cm); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// deleteExpiredTokens deletes the revocations of the single tokens which expired, since these tokens are rejected anyway
+func (r *Revoker) deleteExpiredTokens(ctx context.Context, now time.Time) error {
+	cms := &corev1.ConfigMapList{}
+	if err := r.Client.List(ctx, cms, client.InNamespace(r.Namespace), client.HasLabels{LabelKey}); err != nil {
+		return fmt.Errorf("unable to list the token revocations: %w", err)
+	}
+	for i := range cms.Items {
+		if cms.Items[i].Data[tokenIDKey] == "" || expiresAt(&cms.Items[i]).After(now) {
+			continue
+		}
+		if err := r.Client.Delete(ctx, &cms.Items[i]); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("unable to delete the expired token revocation '%s': %w", cms.Items[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// get returns the ConfigMap with the given name, as long as it holds a token revocation
+func (r *Revoker) get(ctx context.Context, name string) (*corev1.ConfigMap, error) {
+	cm := &corev1.ConfigMap{}
+	if err := r.Get(ctx, r.NamespacedName(name), cm); err != nil {
+		return nil, err
+	}
+	if _, ok := cm.Labels[LabelKey]; !ok {
+		return nil, apierrors.NewNotFound(corev1.Resource("configmaps"), name)
+	}
+	return cm, nil
+}
+
+func (r *Revoker) newConfigMap(name, reason, revokedBy string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: r.Namespace,
+			Labels: map[string]string{
+				configuration.ConfigMapLabelKey: "token-revocation",
+				LabelKey:                        "true",
+			},
+		},
+		Data: map[string]string{
+			reasonKey:    reason,
+			revokedByKey: revokedBy,
+		},
+	}
+}
+
+func toRevocation(cm *corev1.ConfigMap) *Revocation {
+	revocation := &Revocation{
+		Name:      cm.Name,
+		TokenID:   cm.Data[tokenIDKey],
+		Subject:   cm.Data[subjectKey],
+		Reason:    cm.Data[reasonKey],
+		RevokedBy: cm.Data[revokedByKey],
+		Created:   cm.CreationTimestamp.Time,
+	}
+	if issuedBefore, err := time.Parse(time.RFC3339, cm.Data[issuedBeforeKey]); err == nil {
+		revocation.IssuedBefore = &issuedBefore
+	}
+	if revocation.TokenID != "" {
+		expiresAt := expiresAt(cm)
+		revocation.ExpiresAt = &expiresAt
+	}
+	return revocation
+}
+
+// expiresAt returns the expiry time of the token revoked by the given ConfigMap. The revocations which do not record
+// it expire after the configured TTL.
+func expiresAt(cm *corev1.ConfigMap) time.Time {
+	if expiresAt, err := time.Parse(time.RFC3339, cm.Data[expiresAtKey]); err == nil {
+		return expiresAt
+	}
+	return cm.CreationTimestamp.Add(configuration.GetRegistrationServiceConfig().Auth().RevokedTokenTTL())
+}
+
+func tokenIDName(tokenID string) string {
+	return tokenIDNamePrefix + hash.EncodeString(tokenID)
+}
+
+func subjectName(subject string) string {
+	return subjectNamePrefix + hash.EncodeString(subject)
+}
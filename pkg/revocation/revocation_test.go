@@ -0,0 +1,250 @@
+package revocation_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codeready-toolchain/registration-service/pkg/auth"
+	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/revocation"
+	"github.com/codeready-toolchain/registration-service/test"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type TestRevocationSuite struct {
+	test.UnitTestSuite
+}
+
+func TestRunRevocationSuite(t *testing.T) {
+	suite.Run(t, &TestRevocationSuite{test.UnitTestSuite{}})
+}
+
+func (s *TestRevocationSuite) TestRevokeToken() {
+	// given
+	fakeClient := commontest.NewFakeClient(s.T())
+	revoker := revocation.NewRevoker(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+	claims := newClaims("jti-1", "sub-1", time.Now())
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	revoked, err := revoker.IsRevoked(context.TODO(), claims)
+	require.NoError(s.T(), err)
+	require.False(s.T(), revoked)
+
+	// when
+	r, err := revoker.RevokeToken(context.TODO(), "jti-1", expiresAt, "leaked", "admin")
+
+	// then
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "jti-1", r.TokenID)
+	assert.Equal(s.T(), "leaked", r.Reason)
+	assert.Equal(s.T(), "admin", r.RevokedBy)
+	assert.Nil(s.T(), r.IssuedBefore)
+	require.NotNil(s.T(), r.ExpiresAt)
+	assert.True(s.T(), expiresAt.Equal(*r.ExpiresAt))
+	cm := &corev1.ConfigMap{}
+	require.NoError(s.T(), fakeClient.Get(context.TODO(), types.NamespacedName{Namespace: commontest.HostOperatorNs, Name: r.Name}, cm))
+	assert.Equal(s.T(), "token-revocation", cm.Labels[configuration.ConfigMapLabelKey])
+
+	s.Run("token is revoked", func() {
+		revoked, err := revoker.IsRevoked(context.TODO(), claims)
+		require.NoError(s.T(), err)
+		assert.True(s.T(), revoked)
+	})
+
+	s.Run("other token of the same subject is not revoked", func() {
+		revoked, err := revoker.IsRevoked(context.TODO(), newClaims("jti-2", "sub-1", time.Now()))
+		require.NoError(s.T(), err)
+		assert.False(s.T(), revoked)
+	})
+
+	s.Run("revoking twice is a no-op", func() {
+		again, err := revoker.RevokeToken(context.TODO(), "jti-1", time.Now().Add(time.Hour), "other reason", "other-admin")
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), r.Name, again.Name)
+		assert.Equal(s.T(), "leaked", again.Reason)
+	})
+}
+
+func (s *TestRevocationSuite) TestRevokeSubject() {
+	// given
+	fakeClient := commontest.NewFakeClient(s.T())
+	revoker := revocation.NewRevoker(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+	now := time.Now().Truncate(time.Second)
+
+	// when
+	r, err := revoker.RevokeSubject(context.TODO(), "sub-1", now, "logout", "admin")
+
+	// then
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "sub-1", r.Subject)
+	require.NotNil(s.T(), r.IssuedBefore)
+	assert.True(s.T(), now.Equal(*r.IssuedBefore))
+
+	s.Run("token issued before is revoked", func() {
+		revoked, err := revoker.IsRevoked(context.TODO(), newClaims("jti-1", "sub-1", now.Add(-time.Minute)))
+		require.NoError(s.T(), err)
+		assert.True(s.T(), revoked)
+	})
+
+	s.Run("token issued at the same time is revoked", func() {
+		revoked, err := revoker.IsRevoked(context.TODO(), newClaims("jti-1", "sub-1", now))
+		require.NoError(s.T(), err)
+		assert.True(s.T(), revoked)
+	})
+
+	s.Run("token without iat is revoked", func() {
+		claims := newClaims("jti-1", "sub-1", now)
+		claims.IssuedAt = nil
+		revoked, err := revoker.IsRevoked(context.TODO(), claims)
+		require.NoError(s.T(), err)
+		assert.True(s.T(), revoked)
+	})
+
+	s.Run("token issued after is not revoked", func() {
+		revoked, err := revoker.IsRevoked(context.TODO(), newClaims("jti-1", "sub-1", now.Add(time.Minute)))
+		require.NoError(s.T(), err)
+		assert.False(s.T(), revoked)
+	})
+
+	s.Run("token of another subject is not revoked", func() {
+		revoked, err := revoker.IsRevoked(context.TODO(), newClaims("jti-1", "sub-2", now.Add(-time.Minute)))
+		require.NoError(s.T(), err)
+		assert.False(s.T(), revoked)
+	})
+
+	s.Run("revoking again updates the time", func() {
+		later := now.Add(time.Hour)
+		again, err := revoker.RevokeSubject(context.TODO(), "sub-1", later, "logout again", "admin")
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), r.Name, again.Name)
+		assert.Equal(s.T(), "logout again", again.Reason)
+
+		revoked, err := revoker.IsRevoked(context.TODO(), newClaims("jti-1", "sub-1", now.Add(time.Minute)))
+		require.NoError(s.T(), err)
+		assert.True(s.T(), revoked)
+	})
+}
+
+func (s *TestRevocationSuite) TestListAndDelete() {
+	// given
+	unrelated := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "revoked-token-unrelated", Namespace: commontest.HostOperatorNs},
+	}
+	fakeClient := commontest.NewFakeClient(s.T(), unrelated)
+	revoker := revocation.NewRevoker(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+	byToken, err := revoker.RevokeToken(context.TODO(), "jti-1", time.Now().Add(time.Hour), "", "admin")
+	require.NoError(s.T(), err)
+	_, err = revoker.RevokeSubject(context.TODO(), "sub-1", time.Now(), "", "admin")
+	require.NoError(s.T(), err)
+
+	s.Run("list", func() {
+		revocations, err := revoker.List(context.TODO())
+		require.NoError(s.T(), err)
+		assert.Len(s.T(), revocations, 2)
+	})
+
+	s.Run("delete", func() {
+		err := revoker.Delete(context.TODO(), byToken.Name)
+		require.NoError(s.T(), err)
+
+		revocations, err := revoker.List(context.TODO())
+		require.NoError(s.T(), err)
+		require.Len(s.T(), revocations, 1)
+		assert.Equal(s.T(), "sub-1", revocations[0].Subject)
+	})
+
+	s.Run("delete unknown", func() {
+		err := revoker.Delete(context.TODO(), byToken.Name)
+		require.ErrorIs(s.T(), err, revocation.ErrNotFound)
+	})
+
+	s.Run("delete ConfigMap which is not a revocation", func() {
+		err := revoker.Delete(context.TODO(), unrelated.Name)
+		require.ErrorIs(s.T(), err, revocation.ErrNotFound)
+	})
+}
+
+func (s *TestRevocationSuite) TestDeleteExpiredTokens() {
+	// given
+	withoutExpiry := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "revoked-token-without-expiry",
+			Namespace:         commontest.HostOperatorNs,
+			Labels:            map[string]string{revocation.LabelKey: "true"},
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-25 * time.Hour)),
+		},
+		Data: map[string]string{"jti": "jti-0"},
+	}
+	fakeClient := commontest.NewFakeClient(s.T(), withoutExpiry)
+	revoker := revocation.NewRevoker(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+	expired, err := revoker.RevokeToken(context.TODO(), "jti-1", time.Now().Add(-time.Minute), "", "admin")
+	require.NoError(s.T(), err)
+	valid, err := revoker.RevokeToken(context.TODO(), "jti-2", time.Now().Add(time.Hour), "", "admin")
+	require.NoError(s.T(), err)
+	bySubject, err := revoker.RevokeSubject(context.TODO(), "sub-1", time.Now().Add(-48*time.Hour), "", "admin")
+	require.NoError(s.T(), err)
+
+	// when
+	next, err := revoker.RevokeToken(context.TODO(), "jti-3", time.Now().Add(time.Hour), "", "admin")
+
+	// then
+	require.NoError(s.T(), err)
+	revocations, err := revoker.List(context.TODO())
+	require.NoError(s.T(), err)
+	names := make([]string, 0, len(revocations))
+	for _, r := range revocations {
+		names = append(names, r.Name)
+	}
+	assert.ElementsMatch(s.T(), []string{valid.Name, bySubject.Name, next.Name}, names)
+	assert.NotContains(s.T(), names, expired.Name)
+
+	s.Run("revocations are kept when they cannot be listed", func() {
+		// given
+		fakeClient.MockList = func(_ context.Context, _ client.ObjectList, _ ...client.ListOption) error {
+			return errors.New("mock error")
+		}
+		defer func() { fakeClient.MockList = nil }()
+
+		// when
+		_, err := revoker.RevokeSubject(context.TODO(), "sub-2", time.Now(), "", "admin")
+
+		// then
+		require.EqualError(s.T(), err, "unable to list the token revocations: mock error")
+	})
+}
+
+func (s *TestRevocationSuite) TestIsRevokedError() {
+	// given
+	fakeClient := commontest.NewFakeClient(s.T())
+	fakeClient.MockGet = func(_ context.Context, _ client.ObjectKey, _ client.Object, _ ...client.GetOption) error {
+		return errors.New("mock error")
+	}
+	revoker := revocation.NewRevoker(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+	// when
+	_, err := revoker.IsRevoked(context.TODO(), newClaims("jti-1", "sub-1", time.Now()))
+
+	// then
+	require.EqualError(s.T(), err, "mock error")
+}
+
+func newClaims(jti, sub string, iat time.Time) *auth.TokenClaims {
+	return &auth.TokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:       jti,
+			Subject:  sub,
+			IssuedAt: jwt.NewNumericDate(iat),
+		},
+	}
+}
@@ -0,0 +1,445 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/codeready-toolchain/registration-service/pkg/admin"
+	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	"github.com/codeready-toolchain/registration-service/pkg/controller"
+	"github.com/codeready-toolchain/registration-service/pkg/idempotency"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaces"
+	"github.com/codeready-toolchain/registration-service/pkg/openapi"
+	"github.com/codeready-toolchain/registration-service/pkg/revocation"
+	"github.com/codeready-toolchain/registration-service/pkg/signup"
+	"github.com/codeready-toolchain/registration-service/pkg/signup/service"
+	"github.com/codeready-toolchain/registration-service/pkg/socialevents"
+	"github.com/codeready-toolchain/registration-service/pkg/username"
+)
+
+var (
+	// the errors of the endpoints restricted to the administrators
+	adminErrors = []int{http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError}
+	// the errors of the endpoints restricted to the event organizers
+	organizerErrors = []int{http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError}
+)
+
+// apiDocument returns the OpenAPI document of the REST API.
+// Every route registered in SetupRoutes must be described here, and the other way around: the server tests fail
+// if the routes and the document drift apart.
+func apiDocument() *openapi.Document {
+	operations := []openapi.Operation{
+		// unsecured routes
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/health",
+			Summary:   "Returns the health of the service",
+			Responses: map[int]interface{}{http.StatusOK: controller.HealthStatus{}, http.StatusServiceUnavailable: controller.HealthStatus{}},
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/authconfig",
+			Summary:   "Returns the configuration of the authentication client of the UI",
+			Responses: map[int]interface{}{http.StatusOK: controller.AuthConfigResponse{}},
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/segment-write-key",
+			Summary:   "Returns the Segment write key of DevSpaces",
+			Responses: map[int]interface{}{http.StatusOK: openapi.Content("text/plain")},
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/analytics/segment-write-key",
+			Summary:   "Returns the Segment write key of the sandbox",
+			Responses: map[int]interface{}{http.StatusOK: openapi.Content("text/plain")},
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/openapi.json",
+			Summary:   "Returns this document",
+			Responses: map[int]interface{}{http.StatusOK: openapi.Content("application/json")},
+		},
+
+		// secured routes
+		{
+			Method:          http.MethodPost,
+			Path:            "/api/v1/reset-namespaces",
+			Summary:         "Resets all or some of the namespaces of the user",
+			Secured:         true,
+			Request:         controller.ResetNamespacesRequest{},
+			OptionalRequest: true,
+			Responses: map[int]interface{}{
+				http.StatusOK:          controller.ResetNamespacesResponse{},
+				http.StatusAccepted:    controller.ResetNamespacesResponse{},
+				http.StatusMultiStatus: controller.ResetNamespacesResponse{},
+			},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/reset-namespaces/:id",
+			Summary:   "Returns the progress of a reset of the namespaces of the user",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusOK: namespaces.ResetJob{}},
+			Errors:    []int{http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/namespaces/usage",
+			Summary:   "Returns the usage of the resource quotas of the namespaces of the user",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusOK: []namespaces.NamespaceUsage{}},
+			Errors:    []int{http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			Method:    http.MethodPost,
+			Path:      "/api/v1/signup",
+			Summary:   "Signs the user up, or reactivates their deactivated signup",
+			Secured:   true,
+			Query:     []string{service.NoSpaceKey, service.TierKey, service.RegionKey, service.ClusterKey},
+			Headers:   []string{idempotency.Header},
+			Responses: map[int]interface{}{http.StatusAccepted: nil},
+			Errors:    []int{http.StatusBadRequest, http.StatusForbidden, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		},
+		{
+			Method:    http.MethodPut,
+			Path:      "/api/v1/signup/verification",
+			Summary:   "Sends a verification code to the phone number of the user",
+			Secured:   true,
+			Headers:   []string{idempotency.Header},
+			Request:   controller.Phone{},
+			Responses: map[int]interface{}{http.StatusNoContent: nil},
+			Errors:    []int{http.StatusForbidden, http.StatusUnprocessableEntity, http.StatusTooManyRequests, http.StatusInternalServerError},
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/signup",
+			Summary:   "Returns the signup of the user",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusOK: signup.Signup{}, http.StatusNotFound: nil},
+			Errors:    []int{http.StatusInternalServerError},
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/signup/events",
+			Summary:   "Streams the signup of the user as Server-Sent Events, each time it changes",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusOK: openapi.Content("text/event-stream"), http.StatusNotFound: nil},
+			Errors:    []int{http.StatusInternalServerError},
+		},
+		{
+			Method:    http.MethodDelete,
+			Path:      "/api/v1/signup",
+			Summary:   "Deactivates the signup of the user, and optionally deletes their namespaces right away",
+			Secured:   true,
+			Query:     []string{controller.DeleteNamespacesKey},
+			Responses: map[int]interface{}{http.StatusAccepted: nil},
+			Errors:    []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/api/v1/signup/extension",
+			Summary: "Requests an extension of the trial of the user",
+			Secured: true,
+			Request: controller.TrialExtensionRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:       signup.TrialExtension{},
+				http.StatusAccepted: signup.TrialExtension{},
+			},
+			Errors: []int{http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/signup/clusters",
+			Summary:   "Returns the member clusters and regions which the user can choose when they sign up",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusOK: []signup.ClusterOption{}},
+			Errors:    []int{http.StatusInternalServerError},
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/signup/export",
+			Summary:   "Exports the personal data of the user, as JSON or as a zip archive depending on the format",
+			Secured:   true,
+			Query:     []string{controller.ExportFormatKey},
+			Responses: map[int]interface{}{http.StatusOK: signup.Export{}, http.StatusNotFound: nil},
+			Errors:    []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/signup/verification/:code",
+			Summary:   "Verifies the code sent to the phone number of the user",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusOK: nil},
+			Errors:    []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusTooManyRequests, http.StatusInternalServerError},
+		},
+		{
+			Method:    http.MethodPost,
+			Path:      "/api/v1/signup/verification/activation-code",
+			Summary:   "Verifies the user with the activation code of a social event",
+			Secured:   true,
+			Request:   controller.ActivationCodeRequest{},
+			Responses: map[int]interface{}{http.StatusOK: nil},
+			Errors:    []int{http.StatusForbidden, http.StatusNotFound, http.StatusTooManyRequests, http.StatusInternalServerError},
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/activation-codes/:code",
+			Summary:   "Checks an activation code, without activating it",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusOK: signup.ActivationCode{}},
+			Errors:    []int{http.StatusNotFound, http.StatusTooManyRequests, http.StatusInternalServerError},
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/usernames/availability",
+			Summary:   "Checks whether a username is available, and suggests alternatives if not",
+			Secured:   true,
+			Query:     []string{"name"},
+			Responses: map[int]interface{}{http.StatusOK: username.Availability{}},
+			Errors:    []int{http.StatusBadRequest, http.StatusTooManyRequests, http.StatusInternalServerError},
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/usernames/:username",
+			Summary:   "Looks a user up by username or email address",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusOK: username.Response{}, http.StatusNotFound: nil},
+			Errors:    []int{http.StatusTooManyRequests, http.StatusInternalServerError},
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/uiconfig",
+			Summary:   "Returns the configuration of the UI",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusOK: controller.UIConfigResponse{}},
+		},
+
+		// admin routes
+		{
+			Method:    http.MethodPost,
+			Path:      "/api/v1/admin/token-revocations",
+			Summary:   "Revokes a token, or all the tokens of a subject issued before a given time",
+			Secured:   true,
+			Request:   controller.TokenRevocationRequest{},
+			Responses: map[int]interface{}{http.StatusCreated: revocation.Revocation{}},
+			Errors:    adminErrors,
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/admin/token-revocations",
+			Summary:   "Lists the token revocations",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusOK: []revocation.Revocation{}},
+			Errors:    adminErrors,
+		},
+		{
+			Method:    http.MethodDelete,
+			Path:      "/api/v1/admin/token-revocations/:name",
+			Summary:   "Deletes a token revocation",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusNoContent: nil},
+			Errors:    adminErrors,
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/admin/signups",
+			Summary:   "Lists the signups in a given state",
+			Secured:   true,
+			Query:     []string{controller.StateKey},
+			Responses: map[int]interface{}{http.StatusOK: []admin.Signup{}},
+			Errors:    append([]int{http.StatusBadRequest}, adminErrors...),
+		},
+		adminSignupAction(admin.ActionApprove),
+		adminSignupAction(admin.ActionReject),
+		adminSignupAction(admin.ActionDeactivate),
+		{
+			Method:  http.MethodPost,
+			Path:    "/api/v1/admin/banned-users",
+			Summary: "Bans a user by username, email address or phone number",
+			Secured: true,
+			Request: controller.BanRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:      admin.BanResult{},
+				http.StatusCreated: admin.BanResult{},
+			},
+			Errors: adminErrors,
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/admin/banned-users",
+			Summary:   "Lists the bans",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusOK: []admin.Ban{}},
+			Errors:    adminErrors,
+		},
+		{
+			Method:    http.MethodDelete,
+			Path:      "/api/v1/admin/banned-users/:name",
+			Summary:   "Unbans a user",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusNoContent: nil},
+			Errors:    adminErrors,
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/admin/trial-extensions",
+			Summary:   "Lists the pending trial extension requests",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusOK: []admin.TrialExtension{}},
+			Errors:    adminErrors,
+		},
+		adminTrialExtensionDecision(admin.ActionApprove),
+		adminTrialExtensionDecision(admin.ActionReject),
+
+		// event organizer routes
+		{
+			Method:    http.MethodPost,
+			Path:      "/api/v1/social-events",
+			Summary:   "Creates a social event",
+			Secured:   true,
+			Request:   socialevents.Settings{},
+			Responses: map[int]interface{}{http.StatusCreated: socialevents.Event{}},
+			Errors:    organizerErrors,
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/social-events",
+			Summary:   "Lists the social events of the organizer",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusOK: []socialevents.Event{}},
+			Errors:    organizerErrors,
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/social-events/:code",
+			Summary:   "Returns a social event",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusOK: socialevents.Event{}},
+			Errors:    organizerErrors,
+		},
+		{
+			Method:    http.MethodPatch,
+			Path:      "/api/v1/social-events/:code",
+			Summary:   "Updates the settings of a social event",
+			Secured:   true,
+			Request:   socialevents.Settings{},
+			Responses: map[int]interface{}{http.StatusOK: socialevents.Event{}},
+			Errors:    organizerErrors,
+		},
+		{
+			Method:    http.MethodPost,
+			Path:      "/api/v1/social-events/:code/close",
+			Summary:   "Closes a social event, so that its code can no longer be activated",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusOK: socialevents.Event{}},
+			Errors:    organizerErrors,
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/social-events/:code/attendees",
+			Summary:   "Lists the attendees of a social event",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusOK: []socialevents.Attendee{}},
+			Errors:    organizerErrors,
+		},
+		{
+			Method:    http.MethodPost,
+			Path:      "/api/v1/social-events/:code/personal-codes",
+			Summary:   "Generates personal codes for a social event. The response is the only export of the codes, which are not stored",
+			Secured:   true,
+			Request:   controller.PersonalCodesRequest{},
+			Responses: map[int]interface{}{http.StatusCreated: []socialevents.PersonalCode{}},
+			Errors:    organizerErrors,
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/social-events/:code/personal-codes",
+			Summary:   "Lists the usage of the personal codes of a social event: their IDs and the signups which used them, without the codes",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusOK: []socialevents.PersonalCode{}},
+			Errors:    organizerErrors,
+		},
+		{
+			Method:    http.MethodDelete,
+			Path:      "/api/v1/social-events/:code/personal-codes",
+			Summary:   "Revokes all the unused personal codes of a social event",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusOK: controller.RevokedPersonalCodes{}},
+			Errors:    organizerErrors,
+		},
+		{
+			Method:    http.MethodDelete,
+			Path:      "/api/v1/social-events/:code/personal-codes/:id",
+			Summary:   "Revokes an unused personal code of a social event",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusOK: controller.RevokedPersonalCodes{}},
+			Errors:    organizerErrors,
+		},
+	}
+
+	if configuration.IsTestingMode() {
+		operations = append(operations, openapi.Operation{
+			Method:    http.MethodGet,
+			Path:      "/api/v1/auth_test",
+			Summary:   "Returns the health of the service, for testing the authentication",
+			Secured:   true,
+			Responses: map[int]interface{}{http.StatusOK: controller.HealthStatus{}, http.StatusServiceUnavailable: controller.HealthStatus{}},
+		})
+	}
+	// all the routes may be rate limited, by client IP address (behind trusted proxies) or by user
+	for i, op := range operations {
+		if !slices.Contains(op.Errors, http.StatusTooManyRequests) {
+			operations[i].Errors = append(slices.Clone(op.Errors), http.StatusTooManyRequests)
+		}
+	}
+	return openapi.NewDocument("Registration Service", "v2", append(operations, v2Operations(operations)...)...)
+}
+
+// v2Operations returns the operations of the v2 API, given those of the v1 API: the v2 API serves the same routes,
+// except for the verification of the phone code, and returns the errors as problem details.
+func v2Operations(v1 []openapi.Operation) []openapi.Operation {
+	operations := make([]openapi.Operation, 0, len(v1))
+	for _, op := range v1 {
+		if op.Method == http.MethodGet && op.Path == "/api/v1/signup/verification/:code" {
+			op = openapi.Operation{
+				Method:    http.MethodPost,
+				Path:      "/api/v1/signup/verification/phone-code",
+				Summary:   op.Summary,
+				Secured:   true,
+				Request:   controller.PhoneCodeRequest{},
+				Responses: op.Responses,
+				Errors:    op.Errors,
+			}
+		}
+		op.Path = "/api/v2" + strings.TrimPrefix(op.Path, "/api/v1")
+		op.Problems = true
+		operations = append(operations, op)
+	}
+	return operations
+}
+
+func adminSignupAction(action admin.Action) openapi.Operation {
+	return openapi.Operation{
+		Method:    http.MethodPost,
+		Path:      "/api/v1/admin/signups/:name/" + string(action),
+		Summary:   "Applies the '" + string(action) + "' action on a signup",
+		Secured:   true,
+		Request:   controller.AdminSignupActionRequest{},
+		Responses: map[int]interface{}{http.StatusOK: admin.Signup{}},
+		Errors:    adminErrors,
+	}
+}
+
+func adminTrialExtensionDecision(action admin.Action) openapi.Operation {
+	return openapi.Operation{
+		Method:    http.MethodPost,
+		Path:      "/api/v1/admin/trial-extensions/:name/" + string(action),
+		Summary:   "Applies the '" + string(action) + "' decision on a pending trial extension request",
+		Secured:   true,
+		Responses: map[int]interface{}{http.StatusOK: admin.TrialExtension{}},
+		Errors:    append([]int{http.StatusConflict}, adminErrors...),
+	}
+}
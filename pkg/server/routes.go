@@ -3,11 +3,13 @@ package server
 import (
 	"time"
 
+	"github.com/codeready-toolchain/registration-service/pkg/admin"
 	"github.com/codeready-toolchain/registration-service/pkg/assets"
 	"github.com/codeready-toolchain/registration-service/pkg/auth"
 	"github.com/codeready-toolchain/registration-service/pkg/configuration"
 	rcontext "github.com/codeready-toolchain/registration-service/pkg/context"
 	"github.com/codeready-toolchain/registration-service/pkg/controller"
+	"github.com/codeready-toolchain/registration-service/pkg/idempotency"
 	"github.com/codeready-toolchain/registration-service/pkg/middleware"
 	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
 	"github.com/codeready-toolchain/registration-service/pkg/namespaces"
@@ -19,6 +21,9 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// signupEventsPaths are the paths of the streams of signup events, in all the versions of the API
+var signupEventsPaths = []string{"/api/v1/signup/events", "/api/v2/signup/events"}
+
 // SetupRoutes registers handlers for various URL paths.
 // proxyPort is the API Proxy Server port to be used to setup a route for the health checker for the proxy.
 func (srv *RegistrationServer) SetupRoutes(proxyPort string, reg *prometheus.Registry, nsClient namespaced.Client) error {
@@ -51,34 +56,63 @@ func (srv *RegistrationServer) SetupRoutes(proxyPort string, reg *prometheus.Reg
 		[]string{"code", "method", "path"},
 	)
 
+	rateLimitedCounter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sandbox_rate_limited_requests_total",
+			Help: "A counter for requests rejected by the rate limits.",
+		},
+		[]string{"method", "path", "scope"},
+	)
+
 	// Register all of the metrics in the standard registry.
-	reg.MustRegister(counter, histVec, inFlightGauge)
+	reg.MustRegister(counter, histVec, inFlightGauge, rateLimitedCounter)
 
 	srv.routesSetup.Do(func() {
+		document := apiDocument()
+
 		// creating the controllers
 		healthCheckCtrl := controller.NewHealthCheck(controller.NewHealthChecker(proxyPort))
 		authConfigCtrl := controller.NewAuthConfig()
 		analyticsCtrl := controller.NewAnalytics()
 		signupCtrl := controller.NewSignup(srv.application)
-		namespacesCtrl := controller.NewNamespacesController(namespaces.NewNamespacesManager(cluster.GetMemberClusters, nsClient, srv.application.SignupService()))
+		signupEventsCtrl := controller.NewSignupEvents(srv.application, srv.signupEvents)
+		namespacesManager := namespaces.NewNamespacesManager(cluster.GetMemberClusters, nsClient, srv.application.SignupService())
+		namespacesCtrl := controller.NewNamespacesController(namespacesManager)
+		signupDeactivationCtrl := controller.NewSignupDeactivation(srv.application, namespacesManager)
+		signupExportCtrl := controller.NewSignupExport(nsClient)
+		signupClustersCtrl := controller.NewSignupClusters(nsClient)
 		usernamesCtrl := controller.NewUsernames(nsClient)
 		uiConfigCtrl := controller.NewUIConfig()
-
-		// unsecured routes
-		unsecuredV1 := srv.router.Group("/api/v1")
-		unsecuredV1.Use(
-			middleware.InstrumentRoundTripperInFlight(inFlightGauge),
-			middleware.InstrumentRoundTripperCounter(counter),
-			middleware.InstrumentRoundTripperDuration(histVec))
-		unsecuredV1.GET("/health", healthCheckCtrl.GetHandler) // TODO: move to root (`/`)?
-		unsecuredV1.GET("/authconfig", authConfigCtrl.GetHandler)
-		// segment keys endpoints
-		unsecuredV1.GET("/segment-write-key", analyticsCtrl.GetDevSpacesSegmentWriteKey)         // expose the devspaces segment key
-		unsecuredV1.GET("/analytics/segment-write-key", analyticsCtrl.GetSandboxSegmentWriteKey) // expose the sandbox segment key.We had the create a new analytics endpoint to keep backward compatibility with devspaces.
+		tokenRevocationsCtrl := controller.NewTokenRevocations(nsClient)
+		adminSignupsCtrl := controller.NewAdminSignups(nsClient)
+		adminBansCtrl := controller.NewAdminBans(nsClient)
+		adminTrialExtensionsCtrl := controller.NewAdminTrialExtensions(nsClient)
+		socialEventsCtrl := controller.NewSocialEvents(nsClient)
+		openAPICtrl := controller.NewOpenAPI(document)
+		idempotencyCfg := configuration.GetRegistrationServiceConfig().Idempotency()
+		var idempotencyPersistence idempotency.Persistence
+		if idempotencyCfg.Persisted() {
+			idempotencyPersistence = idempotency.NewSignupPersistence(nsClient)
+		}
+		idempotencyStore := idempotency.NewStore(idempotencyCfg.TTL(), idempotencyPersistence)
+		// the requests are limited by client IP address on the unsecured routes, and by user on the secured routes
+		rateLimitsCfg := configuration.GetRegistrationServiceConfig().RateLimits()
+		// the client IP address is only taken from the X-Forwarded-For header set by the trusted proxies
+		if err = srv.router.SetTrustedProxies(rateLimitsCfg.TrustedProxies()); err != nil {
+			err = errs.Wrapf(err, "failed to set the trusted proxies")
+			return
+		}
+		// without trusted proxies, the client IP addresses are unknown and all the clients would share the limit of the
+		// proxy in front of the service, so the unsecured routes are not limited
+		clientIPRateLimit := func(*gin.Context) {}
+		if rateLimitsCfg.ClientIPsLimited() {
+			clientIPRateLimit = middleware.NewClientIPRateLimiter(rateLimitsCfg.Unsecured(), rateLimitsCfg.Routes(), rateLimitedCounter).HandlerFunc()
+		}
+		userRateLimiter := middleware.NewUserRateLimiter(rateLimitsCfg.Secured(), rateLimitsCfg.Routes(), rateLimitedCounter)
 
 		// create the auth middleware
 		var authMiddleware *middleware.JWTMiddleware
-		authMiddleware, err = middleware.NewAuthMiddleware()
+		authMiddleware, err = middleware.NewAuthMiddleware(nsClient)
 		if err != nil {
 			err = errs.Wrapf(err, "failed to init auth middleware")
 			return
@@ -86,27 +120,98 @@ func (srv *RegistrationServer) SetupRoutes(proxyPort string, reg *prometheus.Reg
 		receivedTimeMw := func(ctx *gin.Context) {
 			ctx.Set(rcontext.RequestReceivedTime, time.Now())
 		}
-		// secured routes
-		securedV1 := srv.router.Group("/api/v1")
-		securedV1.Use(
-			middleware.InstrumentRoundTripperInFlight(inFlightGauge),
-			middleware.InstrumentRoundTripperCounter(counter),
-			middleware.InstrumentRoundTripperDuration(histVec),
-			authMiddleware.HandlerFunc(),
-			receivedTimeMw)
-		securedV1.POST("/reset-namespaces", namespacesCtrl.ResetNamespaces)
-		securedV1.POST("/signup", signupCtrl.PostHandler)
-		// requires a ctx body containing the country_code and phone_number
-		securedV1.PUT("/signup/verification", signupCtrl.InitVerificationHandler)
-		securedV1.GET("/signup", signupCtrl.GetHandler)
-		securedV1.GET("/signup/verification/:code", signupCtrl.VerifyPhoneCodeHandler) // TODO: also provide a `POST /signup/verification/phone-code` +deprecate this one + migrate UI?
-		securedV1.POST("/signup/verification/activation-code", signupCtrl.VerifyActivationCodeHandler)
-		securedV1.GET("/usernames/:username", usernamesCtrl.GetHandler)
-		securedV1.GET("/uiconfig", uiConfigCtrl.GetHandler)
-
-		// if we are in testing mode, we also add a secured health route for testing
-		if configuration.IsTestingMode() {
-			securedV1.GET("/auth_test", healthCheckCtrl.GetHandler)
+
+		// the v2 API serves the same routes as the v1 API, with the errors returned as RFC 7807 problem details
+		for _, api := range []struct {
+			version     string
+			middlewares []gin.HandlerFunc
+		}{
+			{version: "v1"},
+			{version: "v2", middlewares: []gin.HandlerFunc{middleware.ProblemDetails()}},
+		} {
+			// unsecured routes
+			unsecured := srv.router.Group("/api/" + api.version)
+			unsecured.Use(api.middlewares...)
+			unsecured.Use(
+				middleware.InstrumentRoundTripperInFlight(inFlightGauge),
+				middleware.InstrumentRoundTripperCounter(counter),
+				middleware.InstrumentRoundTripperDuration(histVec),
+				clientIPRateLimit)
+			unsecured.GET("/health", healthCheckCtrl.GetHandler) // TODO: move to root (`/`)?
+			unsecured.GET("/authconfig", authConfigCtrl.GetHandler)
+			// segment keys endpoints
+			unsecured.GET("/segment-write-key", analyticsCtrl.GetDevSpacesSegmentWriteKey)         // expose the devspaces segment key
+			unsecured.GET("/analytics/segment-write-key", analyticsCtrl.GetSandboxSegmentWriteKey) // expose the sandbox segment key.We had the create a new analytics endpoint to keep backward compatibility with devspaces.
+			unsecured.GET("/openapi.json", openAPICtrl.GetHandler)
+
+			// secured routes
+			secured := srv.router.Group("/api/" + api.version)
+			secured.Use(api.middlewares...)
+			secured.Use(
+				middleware.InstrumentRoundTripperInFlight(inFlightGauge),
+				middleware.InstrumentRoundTripperCounter(counter),
+				middleware.InstrumentRoundTripperDuration(histVec),
+				authMiddleware.HandlerFunc(),
+				receivedTimeMw,
+				userRateLimiter.HandlerFunc(),
+				document.ValidateRequest()) // the request bodies are validated against the OpenAPI document
+			secured.POST("/reset-namespaces", namespacesCtrl.ResetNamespaces)
+			secured.GET("/reset-namespaces/:id", namespacesCtrl.ResetNamespacesStatus)
+			secured.GET("/namespaces/usage", namespacesCtrl.Usage)
+			// the signup and the phone verification are replayed when they are repeated with the same idempotency key
+			secured.POST("/signup", idempotencyStore.Handler(), signupCtrl.PostHandler)
+			// requires a ctx body containing the country_code and phone_number
+			secured.PUT("/signup/verification", idempotencyStore.Handler(), signupCtrl.InitVerificationHandler)
+			secured.GET("/signup", signupCtrl.GetHandler)
+			secured.GET("/signup/events", signupEventsCtrl.GetHandler) // same as above, as a stream of Server-Sent Events
+			secured.DELETE("/signup", signupDeactivationCtrl.DeleteHandler)
+			secured.POST("/signup/extension", signupCtrl.RequestTrialExtensionHandler)
+			secured.GET("/signup/clusters", signupClustersCtrl.GetHandler)
+			secured.GET("/signup/export", signupExportCtrl.GetHandler)
+			if api.version == "v1" {
+				secured.GET("/signup/verification/:code", signupCtrl.VerifyPhoneCodeHandler) // replaced by `POST /signup/verification/phone-code` in v2
+			} else {
+				secured.POST("/signup/verification/phone-code", signupCtrl.PostPhoneCodeHandler)
+			}
+			secured.POST("/signup/verification/activation-code", signupCtrl.VerifyActivationCodeHandler)
+			secured.GET("/activation-codes/:code", signupCtrl.CheckActivationCodeHandler) // checks the code without activating it
+			secured.GET("/usernames/availability", usernamesCtrl.AvailabilityHandler)
+			secured.GET("/usernames/:username", usernamesCtrl.GetHandler)
+			secured.GET("/uiconfig", uiConfigCtrl.GetHandler)
+
+			// admin routes, restricted to the members of the configured admin groups/roles
+			admins := secured.Group("/admin", middleware.RequireAdmin())
+			admins.POST("/token-revocations", tokenRevocationsCtrl.PostHandler)
+			admins.GET("/token-revocations", tokenRevocationsCtrl.ListHandler)
+			admins.DELETE("/token-revocations/:name", tokenRevocationsCtrl.DeleteHandler)
+			admins.GET("/signups", adminSignupsCtrl.ListHandler)
+			admins.POST("/signups/:name/approve", adminSignupsCtrl.ActionHandler(admin.ActionApprove))
+			admins.POST("/signups/:name/reject", adminSignupsCtrl.ActionHandler(admin.ActionReject))
+			admins.POST("/signups/:name/deactivate", adminSignupsCtrl.ActionHandler(admin.ActionDeactivate))
+			admins.POST("/banned-users", adminBansCtrl.PostHandler)
+			admins.GET("/banned-users", adminBansCtrl.ListHandler)
+			admins.DELETE("/banned-users/:name", adminBansCtrl.DeleteHandler)
+			admins.GET("/trial-extensions", adminTrialExtensionsCtrl.ListHandler)
+			admins.POST("/trial-extensions/:name/approve", adminTrialExtensionsCtrl.DecisionHandler(admin.ActionApprove))
+			admins.POST("/trial-extensions/:name/reject", adminTrialExtensionsCtrl.DecisionHandler(admin.ActionReject))
+
+			// event organizer routes, restricted to the members of the configured organizer (or admin) groups/roles
+			organizers := secured.Group("/social-events", middleware.RequireOrganizer())
+			organizers.POST("", socialEventsCtrl.PostHandler)
+			organizers.GET("", socialEventsCtrl.ListHandler)
+			organizers.GET("/:code", socialEventsCtrl.GetHandler)
+			organizers.PATCH("/:code", socialEventsCtrl.PatchHandler)
+			organizers.POST("/:code/close", socialEventsCtrl.CloseHandler)
+			organizers.GET("/:code/attendees", socialEventsCtrl.AttendeesHandler)
+			organizers.POST("/:code/personal-codes", socialEventsCtrl.PostPersonalCodesHandler)
+			organizers.GET("/:code/personal-codes", socialEventsCtrl.PersonalCodesUsageHandler)
+			organizers.DELETE("/:code/personal-codes", socialEventsCtrl.DeletePersonalCodesHandler)
+			organizers.DELETE("/:code/personal-codes/:id", socialEventsCtrl.DeletePersonalCodesHandler)
+
+			// if we are in testing mode, we also add a secured health route for testing
+			if configuration.IsTestingMode() {
+				secured.GET("/auth_test", healthCheckCtrl.GetHandler)
+			}
 		}
 
 		// Create the route for static content, served from /
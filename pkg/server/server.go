@@ -12,6 +12,7 @@ import (
 
 	"github.com/codeready-toolchain/registration-service/pkg/application"
 	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	"github.com/codeready-toolchain/registration-service/pkg/signup/events"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/gzip"
@@ -27,11 +28,20 @@ type RegistrationServer struct {
 	httpServer  *http.Server
 	routesSetup sync.Once
 	//applicationProducerFunc func() application.Application
-	application application.Application
+	application  application.Application
+	signupEvents *events.Broadcaster
+}
+
+// WithSignupEvents sets the broadcaster of the changes of the signups, which feeds the stream of signup events.
+// By default, the stream of signup events only contains the initial status of the signup and the heartbeats.
+func WithSignupEvents(broadcaster *events.Broadcaster) ServerOption {
+	return func(server *RegistrationServer) {
+		server.signupEvents = broadcaster
+	}
 }
 
 // New creates a new RegistrationServer object with reasonable defaults.
-func New(application application.Application) *RegistrationServer {
+func New(application application.Application, options ...ServerOption) *RegistrationServer {
 
 	gin.SetMode(gin.ReleaseMode)
 	ginRouter := gin.New()
@@ -71,8 +81,12 @@ func New(application application.Application) *RegistrationServer {
 	)
 
 	srv := &RegistrationServer{
-		router:      ginRouter,
-		application: application,
+		router:       ginRouter,
+		application:  application,
+		signupEvents: events.NewBroadcaster(),
+	}
+	for _, apply := range options {
+		apply(srv)
 	}
 
 	gin.DefaultWriter = io.MultiWriter(os.Stdout)
@@ -90,7 +104,8 @@ func New(application application.Application) *RegistrationServer {
 		},
 	}
 	if configuration.HTTPCompressResponses {
-		srv.router.Use(gzip.Gzip(gzip.DefaultCompression))
+		// the stream of signup events must not be buffered by the compression
+		srv.router.Use(gzip.Gzip(gzip.DefaultCompression, gzip.WithExcludedPaths(signupEventsPaths)))
 	}
 	return srv
 }
@@ -2,8 +2,12 @@ package server_test
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -56,6 +60,37 @@ func (s *TestServerSuite) TestServer() {
 	// Check that Engine() returns the router object.
 	require.NotNil(s.T(), srv.Engine())
 
+	s.Run("OpenAPI document matches the routes", func() {
+		// given
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil)
+		require.NoError(s.T(), err)
+		rr := httptest.NewRecorder()
+
+		// when
+		srv.Engine().ServeHTTP(rr, req)
+
+		// then
+		require.Equal(s.T(), http.StatusOK, rr.Code)
+		document := struct {
+			Paths map[string]map[string]interface{} `json:"paths"`
+		}{}
+		require.NoError(s.T(), json.Unmarshal(rr.Body.Bytes(), &document))
+		var documented []string
+		for path, item := range document.Paths {
+			for method := range item {
+				documented = append(documented, strings.ToUpper(method)+" "+path)
+			}
+		}
+		var registered []string
+		pathParam := regexp.MustCompile(`[:*]([^/]+)`)
+		for _, route := range srv.Engine().Routes() {
+			if strings.HasPrefix(route.Path, "/api/") {
+				registered = append(registered, route.Method+" "+pathParam.ReplaceAllString(route.Path, "{$1}"))
+			}
+		}
+		assert.ElementsMatch(s.T(), registered, documented)
+	})
+
 	client := &http.Client{
 		CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
 			return http.ErrUseLastResponse
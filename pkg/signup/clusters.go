@@ -0,0 +1,192 @@
+package signup
+
+import (
+	"fmt"
+	"slices"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// RegionLabelKey is the label of a ToolchainCluster holding the region of the member cluster, which the users
+	// can choose when they sign up
+	RegionLabelKey = toolchainv1alpha1.LabelKeyPrefix + "region"
+	// PreferredRegionAnnotationKey is the annotation of a UserSignup holding the region chosen by the user
+	PreferredRegionAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "preferred-region"
+	// PreferredClusterAnnotationKey is the annotation of a UserSignup holding the member cluster chosen by the user
+	PreferredClusterAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "preferred-cluster"
+
+	workerNodeRole = "worker"
+)
+
+// ClusterOption is a member cluster which the users can choose when they sign up
+type ClusterOption struct {
+	// Name is the name of the member cluster
+	Name string `json:"name"`
+	// Region is the region of the member cluster, if labelled
+	Region string `json:"region,omitempty"`
+	// MemoryUsage is the percentage of the memory of the worker nodes which is used, if known
+	MemoryUsage *int `json:"memoryUsage,omitempty"`
+	// Available is true if new users can currently be provisioned to the member cluster
+	Available bool `json:"available"`
+}
+
+// ClusterPreference is the region or the member cluster chosen by a user when they signed up
+type ClusterPreference struct {
+	Region  string
+	Cluster string
+}
+
+// IsEmpty returns true if the user did not choose any region or member cluster
+func (p ClusterPreference) IsEmpty() bool {
+	return p.Region == "" && p.Cluster == ""
+}
+
+// GetClusterPreference returns the region or the member cluster chosen by the user of the given UserSignup
+func GetClusterPreference(userSignup *toolchainv1alpha1.UserSignup) ClusterPreference {
+	return ClusterPreference{
+		Region:  userSignup.Annotations[PreferredRegionAnnotationKey],
+		Cluster: userSignup.Annotations[PreferredClusterAnnotationKey],
+	}
+}
+
+// ListClusterOptions returns the member clusters listed in the ToolchainStatus, along with their region and capacity.
+// A member cluster is available if it is ready and if the host operator would place new users on it (see canPlaceUsers).
+func ListClusterOptions(ctx *gin.Context, cl namespaced.Client) ([]ClusterOption, error) {
+	status := &toolchainv1alpha1.ToolchainStatus{}
+	if err := cl.Get(ctx, cl.NamespacedName("toolchain-status"), status); err != nil {
+		return nil, fmt.Errorf("unable to get the ToolchainStatus: %w", err)
+	}
+	toolchainClusters := &toolchainv1alpha1.ToolchainClusterList{}
+	if err := cl.List(ctx, toolchainClusters, client.InNamespace(cl.Namespace)); err != nil {
+		return nil, fmt.Errorf("unable to list the ToolchainClusters: %w", err)
+	}
+	provisionerConfigs := &toolchainv1alpha1.SpaceProvisionerConfigList{}
+	if err := cl.List(ctx, provisionerConfigs, client.InNamespace(cl.Namespace)); err != nil {
+		return nil, fmt.Errorf("unable to list the SpaceProvisionerConfigs: %w", err)
+	}
+
+	options := make([]ClusterOption, 0, len(status.Status.Members))
+	for _, member := range status.Status.Members {
+		option := ClusterOption{Name: member.ClusterName}
+		for _, tc := range toolchainClusters.Items {
+			if tc.Name == member.ClusterName {
+				option.Region = tc.Labels[RegionLabelKey]
+			}
+		}
+		if usage, found := member.MemberStatus.ResourceUsage.MemoryUsagePerNodeRole[workerNodeRole]; found {
+			option.MemoryUsage = &usage
+		}
+		option.Available = condition.IsTrue(member.MemberStatus.Conditions, toolchainv1alpha1.ConditionReady) &&
+			slices.ContainsFunc(provisionerConfigs.Items, func(spc toolchainv1alpha1.SpaceProvisionerConfig) bool {
+				return spc.Spec.ToolchainCluster == member.ClusterName && canPlaceUsers(spc)
+			})
+		options = append(options, option)
+	}
+	return options, nil
+}
+
+// canPlaceUsers applies the rules of the host operator to place the spaces of the new users: the given
+// SpaceProvisionerConfig must be enabled and ready, have the tenant placement role, and its capacity thresholds must
+// not be reached. The thresholds are considered reached when the consumed capacity is unknown.
+func canPlaceUsers(spc toolchainv1alpha1.SpaceProvisionerConfig) bool {
+	if !spc.Spec.Enabled || !condition.IsTrue(spc.Status.Conditions, toolchainv1alpha1.ConditionReady) ||
+		!slices.Contains(spc.Spec.PlacementRoles, cluster.RoleLabel(cluster.Tenant)) {
+		return false
+	}
+	thresholds := spc.Spec.CapacityThresholds
+	if thresholds.MaxNumberOfSpaces == 0 && thresholds.MaxMemoryUtilizationPercent == 0 {
+		return true
+	}
+	consumed := spc.Status.ConsumedCapacity
+	if consumed == nil {
+		return false
+	}
+	if thresholds.MaxNumberOfSpaces > 0 && uint(consumed.SpaceCount) >= thresholds.MaxNumberOfSpaces {
+		return false
+	}
+	if thresholds.MaxMemoryUtilizationPercent > 0 {
+		for _, usage := range consumed.MemoryUsagePercentPerNodeRole {
+			if uint(usage) >= thresholds.MaxMemoryUtilizationPercent {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ApplyClusterPreference records the given region or member cluster on the given UserSignup, and sets the target
+// cluster of the UserSignup accordingly: either the chosen cluster, or the least used available cluster of the chosen
+// region. Since the host operator does not check the capacity of the target cluster of a UserSignup, it is only set to
+// a cluster on which the host operator would place the user anyway, and left unset if the chosen cluster, or all the
+// clusters of the chosen region, are not available, so that the user is provisioned elsewhere rather than not at all.
+// A target cluster already set (eg, by a SocialEvent) is not overridden.
+// Returns a BadRequest error if both a region and a cluster are given, or if the region or cluster is unknown.
+func ApplyClusterPreference(ctx *gin.Context, cl namespaced.Client, userSignup *toolchainv1alpha1.UserSignup, preference ClusterPreference) error {
+	if preference.Region != "" && preference.Cluster != "" {
+		return apierrors.NewBadRequest("only one of the preferred region and cluster can be specified")
+	}
+	options, err := ListClusterOptions(ctx, cl)
+	if err != nil {
+		return err
+	}
+	var candidates []ClusterOption
+	if preference.Cluster != "" {
+		candidates = slices.DeleteFunc(options, func(option ClusterOption) bool {
+			return option.Name != preference.Cluster
+		})
+		if len(candidates) == 0 {
+			return apierrors.NewBadRequest(fmt.Sprintf("unknown cluster '%s'", preference.Cluster))
+		}
+	} else {
+		candidates = slices.DeleteFunc(options, func(option ClusterOption) bool {
+			return option.Region != preference.Region
+		})
+		if len(candidates) == 0 {
+			return apierrors.NewBadRequest(fmt.Sprintf("unknown region '%s'", preference.Region))
+		}
+	}
+
+	if userSignup.Annotations == nil {
+		userSignup.Annotations = map[string]string{}
+	}
+	delete(userSignup.Annotations, PreferredRegionAnnotationKey)
+	delete(userSignup.Annotations, PreferredClusterAnnotationKey)
+	if preference.Cluster != "" {
+		userSignup.Annotations[PreferredClusterAnnotationKey] = preference.Cluster
+	} else {
+		userSignup.Annotations[PreferredRegionAnnotationKey] = preference.Region
+	}
+	if userSignup.Spec.TargetCluster != "" {
+		return nil
+	}
+
+	candidates = slices.DeleteFunc(candidates, func(option ClusterOption) bool {
+		return !option.Available
+	})
+	if len(candidates) == 0 {
+		log.Info(ctx, fmt.Sprintf("no available cluster for the preference of usersignup '%s', leaving the placement to the host operator", userSignup.Name))
+		return nil
+	}
+	// the clusters with an unknown usage come last
+	target := slices.MinFunc(candidates, func(a, b ClusterOption) int {
+		switch {
+		case a.MemoryUsage == nil && b.MemoryUsage == nil:
+			return 0
+		case a.MemoryUsage == nil:
+			return 1
+		case b.MemoryUsage == nil:
+			return -1
+		}
+		return *a.MemoryUsage - *b.MemoryUsage
+	})
+	userSignup.Spec.TargetCluster = target.Name
+	return nil
+}
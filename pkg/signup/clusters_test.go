@@ -0,0 +1,293 @@
+package signup
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/util"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test/spaceprovisionerconfig"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// newClusterObjects returns the ToolchainStatus, ToolchainClusters and SpaceProvisionerConfigs of the following members:
+// - `member-us-1`, in region `us`, 70% used
+// - `member-us-2`, in region `us`, 40% used
+// - `member-apac-1`, in region `apac`, 10% used but not ready
+// - `member-other`, without region nor SpaceProvisionerConfig
+func newClusterObjects() []client.Object {
+	member := func(name string, usage int, ready bool) toolchainv1alpha1.Member {
+		status := corev1.ConditionTrue
+		if !ready {
+			status = corev1.ConditionFalse
+		}
+		return toolchainv1alpha1.Member{
+			ClusterName: name,
+			MemberStatus: toolchainv1alpha1.MemberStatusStatus{
+				Conditions:    []toolchainv1alpha1.Condition{{Type: toolchainv1alpha1.ConditionReady, Status: status}},
+				ResourceUsage: toolchainv1alpha1.ResourceUsage{MemoryUsagePerNodeRole: map[string]int{"worker": usage, "master": 90}},
+			},
+		}
+	}
+	toolchainCluster := func(name, region string) *toolchainv1alpha1.ToolchainCluster {
+		tc := &toolchainv1alpha1.ToolchainCluster{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: commontest.HostOperatorNs}}
+		if region != "" {
+			tc.Labels = map[string]string{RegionLabelKey: region}
+		}
+		return tc
+	}
+	provisionerConfig := func(cluster string) *toolchainv1alpha1.SpaceProvisionerConfig {
+		return &toolchainv1alpha1.SpaceProvisionerConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: cluster, Namespace: commontest.HostOperatorNs},
+			Spec: toolchainv1alpha1.SpaceProvisionerConfigSpec{
+				ToolchainCluster: cluster,
+				Enabled:          true,
+				PlacementRoles:   []string{spaceprovisionerconfig.PlacementRole("tenant")},
+			},
+			Status: toolchainv1alpha1.SpaceProvisionerConfigStatus{
+				Conditions: []toolchainv1alpha1.Condition{{Type: toolchainv1alpha1.ConditionReady, Status: corev1.ConditionTrue}},
+			},
+		}
+	}
+	return []client.Object{
+		&toolchainv1alpha1.ToolchainStatus{
+			ObjectMeta: metav1.ObjectMeta{Name: "toolchain-status", Namespace: commontest.HostOperatorNs},
+			Status: toolchainv1alpha1.ToolchainStatusStatus{
+				Members: []toolchainv1alpha1.Member{
+					member("member-us-1", 70, true),
+					member("member-us-2", 40, true),
+					member("member-apac-1", 10, false),
+					member("member-other", 20, true),
+				},
+			},
+		},
+		toolchainCluster("member-us-1", "us"),
+		toolchainCluster("member-us-2", "us"),
+		toolchainCluster("member-apac-1", "apac"),
+		toolchainCluster("member-other", ""),
+		provisionerConfig("member-us-1"),
+		provisionerConfig("member-us-2"),
+		provisionerConfig("member-apac-1"),
+	}
+}
+
+func TestCanPlaceUsers(t *testing.T) {
+	newProvisionerConfig := func(opts ...spaceprovisionerconfig.CreateOption) toolchainv1alpha1.SpaceProvisionerConfig {
+		return *spaceprovisionerconfig.NewSpaceProvisionerConfig("member-1", commontest.HostOperatorNs, append([]spaceprovisionerconfig.CreateOption{
+			spaceprovisionerconfig.ReferencingToolchainCluster("member-1"),
+			spaceprovisionerconfig.Enabled(true),
+			spaceprovisionerconfig.WithReadyConditionValid(),
+			spaceprovisionerconfig.WithPlacementRoles(spaceprovisionerconfig.PlacementRole("tenant")),
+		}, opts...)...)
+	}
+
+	for name, tc := range map[string]struct {
+		spc      toolchainv1alpha1.SpaceProvisionerConfig
+		expected bool
+	}{
+		"no thresholds": {
+			spc:      newProvisionerConfig(),
+			expected: true,
+		},
+		"below the thresholds": {
+			spc: newProvisionerConfig(spaceprovisionerconfig.MaxNumberOfSpaces(100), spaceprovisionerconfig.MaxMemoryUtilizationPercent(80),
+				spaceprovisionerconfig.WithConsumedSpaceCount(99), spaceprovisionerconfig.WithConsumedMemoryUsagePercentInNode("worker", 79)),
+			expected: true,
+		},
+		"disabled": {
+			spc:      newProvisionerConfig(spaceprovisionerconfig.Enabled(false)),
+			expected: false,
+		},
+		"not ready": {
+			spc:      newProvisionerConfig(spaceprovisionerconfig.WithReadyConditionInvalid("ToolchainClusterNotReady")),
+			expected: false,
+		},
+		"without the tenant role": {
+			spc:      newProvisionerConfig(spaceprovisionerconfig.WithPlacementRoles(spaceprovisionerconfig.PlacementRole("workspace"))),
+			expected: false,
+		},
+		"max number of spaces reached": {
+			spc:      newProvisionerConfig(spaceprovisionerconfig.MaxNumberOfSpaces(100), spaceprovisionerconfig.WithConsumedSpaceCount(100)),
+			expected: false,
+		},
+		"max memory utilization reached": {
+			spc: newProvisionerConfig(spaceprovisionerconfig.MaxMemoryUtilizationPercent(80),
+				spaceprovisionerconfig.WithConsumedMemoryUsagePercentInNode("worker", 50), spaceprovisionerconfig.WithConsumedMemoryUsagePercentInNode("master", 80)),
+			expected: false,
+		},
+		"unknown consumed capacity": {
+			spc:      newProvisionerConfig(spaceprovisionerconfig.MaxNumberOfSpaces(100)),
+			expected: false,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, canPlaceUsers(tc.spc))
+		})
+	}
+}
+
+func TestListClusterOptions(t *testing.T) {
+	// given
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	t.Run("success", func(t *testing.T) {
+		// given
+		cl := namespaced.NewClient(commontest.NewFakeClient(t, newClusterObjects()...), commontest.HostOperatorNs)
+
+		// when
+		options, err := ListClusterOptions(ctx, cl)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []ClusterOption{
+			{Name: "member-us-1", Region: "us", MemoryUsage: util.Ptr(70), Available: true},
+			{Name: "member-us-2", Region: "us", MemoryUsage: util.Ptr(40), Available: true},
+			{Name: "member-apac-1", Region: "apac", MemoryUsage: util.Ptr(10), Available: false},
+			{Name: "member-other", MemoryUsage: util.Ptr(20), Available: false},
+		}, options)
+	})
+
+	t.Run("no ToolchainStatus", func(t *testing.T) {
+		// given
+		cl := namespaced.NewClient(commontest.NewFakeClient(t), commontest.HostOperatorNs)
+
+		// when
+		_, err := ListClusterOptions(ctx, cl)
+
+		// then
+		require.ErrorContains(t, err, "unable to get the ToolchainStatus")
+	})
+}
+
+func TestApplyClusterPreference(t *testing.T) {
+	// given
+	log.Init("cluster-preference-testing")
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	cl := namespaced.NewClient(commontest.NewFakeClient(t, newClusterObjects()...), commontest.HostOperatorNs)
+
+	for name, tc := range map[string]struct {
+		preference            ClusterPreference
+		expectedTargetCluster string
+		expectedAnnotations   map[string]string
+	}{
+		"region": {
+			preference:            ClusterPreference{Region: "us"},
+			expectedTargetCluster: "member-us-2", // the least used one
+			expectedAnnotations:   map[string]string{PreferredRegionAnnotationKey: "us"},
+		},
+		"region without available cluster": {
+			preference:            ClusterPreference{Region: "apac"},
+			expectedTargetCluster: "",
+			expectedAnnotations:   map[string]string{PreferredRegionAnnotationKey: "apac"},
+		},
+		"cluster": {
+			preference:            ClusterPreference{Cluster: "member-us-1"},
+			expectedTargetCluster: "member-us-1",
+			expectedAnnotations:   map[string]string{PreferredClusterAnnotationKey: "member-us-1"},
+		},
+		"unavailable cluster": {
+			preference:            ClusterPreference{Cluster: "member-other"},
+			expectedTargetCluster: "",
+			expectedAnnotations:   map[string]string{PreferredClusterAnnotationKey: "member-other"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// given
+			userSignup := &toolchainv1alpha1.UserSignup{}
+
+			// when
+			err := ApplyClusterPreference(ctx, cl, userSignup, tc.preference)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedTargetCluster, userSignup.Spec.TargetCluster)
+			assert.Equal(t, tc.expectedAnnotations, userSignup.Annotations)
+			assert.Equal(t, tc.preference, GetClusterPreference(userSignup))
+		})
+	}
+
+	t.Run("the target cluster of a SocialEvent is not overridden", func(t *testing.T) {
+		// given
+		userSignup := &toolchainv1alpha1.UserSignup{Spec: toolchainv1alpha1.UserSignupSpec{TargetCluster: "member-us-1"}}
+
+		// when
+		err := ApplyClusterPreference(ctx, cl, userSignup, ClusterPreference{Region: "us"})
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "member-us-1", userSignup.Spec.TargetCluster)
+		assert.Equal(t, "us", userSignup.Annotations[PreferredRegionAnnotationKey])
+	})
+
+	t.Run("a new preference replaces the previous one", func(t *testing.T) {
+		// given
+		userSignup := &toolchainv1alpha1.UserSignup{}
+		require.NoError(t, ApplyClusterPreference(ctx, cl, userSignup, ClusterPreference{Region: "us"}))
+		userSignup.Spec.TargetCluster = ""
+
+		// when
+		err := ApplyClusterPreference(ctx, cl, userSignup, ClusterPreference{Cluster: "member-us-1"})
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, ClusterPreference{Cluster: "member-us-1"}, GetClusterPreference(userSignup))
+	})
+
+	for name, tc := range map[string]struct {
+		preference  ClusterPreference
+		expectedErr string
+	}{
+		"unknown region": {
+			preference:  ClusterPreference{Region: "emea"},
+			expectedErr: "unknown region 'emea'",
+		},
+		"unknown cluster": {
+			preference:  ClusterPreference{Cluster: "member-eu-1"},
+			expectedErr: "unknown cluster 'member-eu-1'",
+		},
+		"both region and cluster": {
+			preference:  ClusterPreference{Region: "us", Cluster: "member-us-1"},
+			expectedErr: "only one of the preferred region and cluster can be specified",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// given
+			userSignup := &toolchainv1alpha1.UserSignup{}
+
+			// when
+			err := ApplyClusterPreference(ctx, cl, userSignup, tc.preference)
+
+			// then
+			require.EqualError(t, err, tc.expectedErr)
+			assert.True(t, apierrors.IsBadRequest(err))
+			assert.Empty(t, userSignup.Annotations)
+			assert.Empty(t, userSignup.Spec.TargetCluster)
+		})
+	}
+
+	t.Run("listing fails", func(t *testing.T) {
+		// given
+		fakeClient := commontest.NewFakeClient(t, newClusterObjects()...)
+		fakeClient.MockList = func(_ context.Context, _ client.ObjectList, _ ...client.ListOption) error {
+			return errors.New("mock error")
+		}
+		userSignup := &toolchainv1alpha1.UserSignup{}
+
+		// when
+		err := ApplyClusterPreference(ctx, namespaced.NewClient(fakeClient, commontest.HostOperatorNs), userSignup, ClusterPreference{Region: "us"})
+
+		// then
+		require.EqualError(t, err, "unable to list the ToolchainClusters: mock error")
+	})
+}
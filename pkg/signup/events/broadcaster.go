@@ -0,0 +1,94 @@
+// Package events notifies the subscribers when the resources related to a UserSignup change in the informer cache,
+// so that the status of the signup can be pushed to the user instead of being polled.
+package events
+
+import (
+	"sync"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// Broadcaster dispatches the changes of the UserSignups, MasterUserRecords and Spaces to the subscribers
+// of the corresponding UserSignup.
+type Broadcaster struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan struct{}]struct{}
+}
+
+// NewBroadcaster returns a new Broadcaster without any subscriber
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{
+		subscribers: map[string]map[chan struct{}]struct{}{},
+	}
+}
+
+// Subscribe returns a channel which receives a notification when a resource related to the UserSignup with the
+// given name changes, along with the function to call to unsubscribe.
+// Consecutive notifications are merged if the subscriber does not keep up with them.
+func (b *Broadcaster) Subscribe(userSignupName string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subscribers[userSignupName] == nil {
+		b.subscribers[userSignupName] = map[chan struct{}]struct{}{}
+	}
+	b.subscribers[userSignupName][ch] = struct{}{}
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers[userSignupName], ch)
+		if len(b.subscribers[userSignupName]) == 0 {
+			delete(b.subscribers, userSignupName)
+		}
+	}
+}
+
+// Notify notifies the subscribers of the UserSignup with the given name
+func (b *Broadcaster) Notify(userSignupName string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers[userSignupName] {
+		select {
+		case ch <- struct{}{}:
+		default:
+			// a notification is already pending
+		}
+	}
+}
+
+// EventHandler returns the handler to register in the informers of the UserSignups, MasterUserRecords and Spaces
+func (b *Broadcaster) EventHandler() toolscache.ResourceEventHandler {
+	return toolscache.ResourceEventHandlerFuncs{
+		AddFunc: b.notifyOwner,
+		UpdateFunc: func(_, newObj interface{}) {
+			b.notifyOwner(newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if unknown, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = unknown.Obj
+			}
+			b.notifyOwner(obj)
+		},
+	}
+}
+
+func (b *Broadcaster) notifyOwner(obj interface{}) {
+	if name := userSignupName(obj); name != "" {
+		b.Notify(name)
+	}
+}
+
+// userSignupName returns the name of the UserSignup which the given resource belongs to
+func userSignupName(obj interface{}) string {
+	switch o := obj.(type) {
+	case *toolchainv1alpha1.UserSignup:
+		return o.Name
+	case *toolchainv1alpha1.MasterUserRecord:
+		return o.Labels[toolchainv1alpha1.MasterUserRecordOwnerLabelKey]
+	case *toolchainv1alpha1.Space:
+		return o.Labels[toolchainv1alpha1.SpaceCreatorLabelKey]
+	default:
+		return ""
+	}
+}
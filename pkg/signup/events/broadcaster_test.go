@@ -0,0 +1,135 @@
+package events_test
+
+import (
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/signup/events"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+func TestBroadcaster(t *testing.T) {
+	notified := func(ch <-chan struct{}) bool {
+		select {
+		case <-ch:
+			return true
+		default:
+			return false
+		}
+	}
+
+	t.Run("notify the subscribers of the UserSignup", func(t *testing.T) {
+		// given
+		broadcaster := events.NewBroadcaster()
+		johnny1, unsubscribe1 := broadcaster.Subscribe("johnny")
+		defer unsubscribe1()
+		johnny2, unsubscribe2 := broadcaster.Subscribe("johnny")
+		defer unsubscribe2()
+		jane, unsubscribe3 := broadcaster.Subscribe("jane")
+		defer unsubscribe3()
+
+		// when
+		broadcaster.Notify("johnny")
+
+		// then
+		assert.True(t, notified(johnny1))
+		assert.True(t, notified(johnny2))
+		assert.False(t, notified(jane))
+	})
+
+	t.Run("pending notifications are merged", func(t *testing.T) {
+		// given
+		broadcaster := events.NewBroadcaster()
+		johnny, unsubscribe := broadcaster.Subscribe("johnny")
+		defer unsubscribe()
+
+		// when
+		broadcaster.Notify("johnny")
+		broadcaster.Notify("johnny")
+
+		// then
+		assert.True(t, notified(johnny))
+		assert.False(t, notified(johnny))
+	})
+
+	t.Run("no notification after unsubscribing", func(t *testing.T) {
+		// given
+		broadcaster := events.NewBroadcaster()
+		johnny, unsubscribe := broadcaster.Subscribe("johnny")
+
+		// when
+		unsubscribe()
+		broadcaster.Notify("johnny")
+
+		// then
+		assert.False(t, notified(johnny))
+	})
+
+	t.Run("event handler", func(t *testing.T) {
+		broadcaster := events.NewBroadcaster()
+		handler := broadcaster.EventHandler()
+		johnny, unsubscribe := broadcaster.Subscribe("johnny")
+		defer unsubscribe()
+
+		userSignup := &toolchainv1alpha1.UserSignup{
+			ObjectMeta: metav1.ObjectMeta{Name: "johnny"},
+		}
+		mur := &toolchainv1alpha1.MasterUserRecord{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "johnny-1",
+				Labels: map[string]string{toolchainv1alpha1.MasterUserRecordOwnerLabelKey: "johnny"},
+			},
+		}
+		space := &toolchainv1alpha1.Space{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "johnny-1",
+				Labels: map[string]string{toolchainv1alpha1.SpaceCreatorLabelKey: "johnny"},
+			},
+		}
+
+		t.Run("UserSignup added", func(t *testing.T) {
+			// when
+			handler.OnAdd(userSignup, false)
+
+			// then
+			assert.True(t, notified(johnny))
+		})
+
+		t.Run("MasterUserRecord updated", func(t *testing.T) {
+			// when
+			handler.OnUpdate(mur, mur)
+
+			// then
+			assert.True(t, notified(johnny))
+		})
+
+		t.Run("Space deleted", func(t *testing.T) {
+			// when
+			handler.OnDelete(space)
+
+			// then
+			assert.True(t, notified(johnny))
+		})
+
+		t.Run("Space deleted while disconnected", func(t *testing.T) {
+			// when
+			handler.OnDelete(toolscache.DeletedFinalStateUnknown{Key: "johnny-1", Obj: space})
+
+			// then
+			assert.True(t, notified(johnny))
+		})
+
+		t.Run("resources of other users are ignored", func(t *testing.T) {
+			// when
+			handler.OnAdd(&toolchainv1alpha1.UserSignup{ObjectMeta: metav1.ObjectMeta{Name: "jane"}}, false)
+			handler.OnAdd(&toolchainv1alpha1.Space{ObjectMeta: metav1.ObjectMeta{Name: "johnny"}}, false)
+			handler.OnAdd(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "johnny"}}, false)
+
+			// then
+			assert.False(t, notified(johnny))
+		})
+	})
+}
@@ -0,0 +1,165 @@
+package signup
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	signupcommon "github.com/codeready-toolchain/toolchain-common/pkg/usersignup"
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// exportedAnnotations are the annotations of the UserSignup which are part of the exported data, including the captcha
+// score computed for the user. The other annotations are not exported, since they may hold secrets (eg, the active
+// verification code).
+var exportedAnnotations = []string{
+	toolchainv1alpha1.UserSignupCaptchaScoreAnnotationKey,
+	toolchainv1alpha1.UserSignupLastTargetClusterAnnotationKey,
+	toolchainv1alpha1.UserSignupVerificationInitTimestampAnnotationKey,
+	toolchainv1alpha1.UserSignupVerificationTimestampAnnotationKey,
+	toolchainv1alpha1.UserSignupVerificationCounterAnnotationKey,
+	toolchainv1alpha1.UserSignupActivationCounterAnnotationKey,
+	toolchainv1alpha1.UserSignupRequestReceivedTimeAnnotationKey,
+	TrialExtensionJustificationAnnotationKey,
+	TrialExtensionRequestedAnnotationKey,
+	TrialExtensionsAnnotationKey,
+	TrialExtensionEndDateAnnotationKey,
+	UserTierAnnotationKey,
+	PreferredRegionAnnotationKey,
+	PreferredClusterAnnotationKey,
+}
+
+// Export contains all the personal data held about a user
+type Export struct {
+	// The time at which the data was exported
+	ExportedAt time.Time `json:"exportedAt"`
+	// The UserSignup of the user
+	UserSignup ExportedUserSignup `json:"userSignup"`
+	// The MasterUserRecord of the user, if the user has been provisioned
+	MasterUserRecord *ExportedMasterUserRecord `json:"masterUserRecord,omitempty"`
+	// The bindings of the user to spaces
+	SpaceBindings []ExportedSpaceBinding `json:"spaceBindings"`
+}
+
+// ExportedUserSignup contains the personal data held in the UserSignup of the user
+type ExportedUserSignup struct {
+	Name              string                                   `json:"name"`
+	CreatedAt         time.Time                                `json:"createdAt"`
+	IdentityClaims    toolchainv1alpha1.IdentityClaimsEmbedded `json:"identityClaims"`
+	States            []toolchainv1alpha1.UserSignupState      `json:"states,omitempty"`
+	Labels            map[string]string                        `json:"labels,omitempty"`
+	Annotations       map[string]string                        `json:"annotations,omitempty"`
+	CompliantUsername string                                   `json:"compliantUsername,omitempty"`
+	Conditions        []toolchainv1alpha1.Condition            `json:"conditions,omitempty"`
+}
+
+// ExportedMasterUserRecord contains the personal data held in the MasterUserRecord of the user
+type ExportedMasterUserRecord struct {
+	Name            string                        `json:"name"`
+	TierName        string                        `json:"tierName,omitempty"`
+	Disabled        bool                          `json:"disabled,omitempty"`
+	TargetClusters  []string                      `json:"targetClusters,omitempty"`
+	ProvisionedTime *time.Time                    `json:"provisionedTime,omitempty"`
+	Conditions      []toolchainv1alpha1.Condition `json:"conditions,omitempty"`
+}
+
+// ExportedSpaceBinding contains the role of the user in a space
+type ExportedSpaceBinding struct {
+	Name      string `json:"name"`
+	Space     string `json:"space"`
+	SpaceRole string `json:"spaceRole"`
+}
+
+// ExportPersonalData collects all the personal data held about the user with the given username.
+// Returns nil if the user has no UserSignup.
+func ExportPersonalData(ctx *gin.Context, cl namespaced.Client, username string) (*Export, error) {
+	userSignup := &toolchainv1alpha1.UserSignup{}
+	if err := cl.Get(ctx, cl.NamespacedName(signupcommon.EncodeUserIdentifier(username)), userSignup); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, crterrors.NewInternalError(err, "error retrieving UserSignup resource")
+	}
+
+	export := &Export{
+		ExportedAt: time.Now().UTC(),
+		UserSignup: ExportedUserSignup{
+			Name:              userSignup.Name,
+			CreatedAt:         userSignup.CreationTimestamp.UTC(),
+			IdentityClaims:    userSignup.Spec.IdentityClaims,
+			States:            userSignup.Spec.States,
+			Labels:            userSignup.Labels,
+			Annotations:       exportAnnotations(userSignup.Annotations),
+			CompliantUsername: userSignup.Status.CompliantUsername,
+			Conditions:        userSignup.Status.Conditions,
+		},
+		SpaceBindings: []ExportedSpaceBinding{},
+	}
+
+	murName := userSignup.Status.CompliantUsername
+	if murName == "" {
+		// the user has not been provisioned yet
+		return export, nil
+	}
+	mur := &toolchainv1alpha1.MasterUserRecord{}
+	if err := cl.Get(ctx, cl.NamespacedName(murName), mur); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, crterrors.NewInternalError(err, fmt.Sprintf("error retrieving MasterUserRecord '%s'", murName))
+		}
+	} else {
+		export.MasterUserRecord = exportMasterUserRecord(mur)
+	}
+
+	bindings := &toolchainv1alpha1.SpaceBindingList{}
+	if err := cl.List(ctx, bindings, client.InNamespace(cl.Namespace),
+		client.MatchingLabels{toolchainv1alpha1.SpaceBindingMasterUserRecordLabelKey: murName}); err != nil {
+		return nil, crterrors.NewInternalError(err, fmt.Sprintf("error listing SpaceBindings of MasterUserRecord '%s'", murName))
+	}
+	for _, binding := range bindings.Items {
+		export.SpaceBindings = append(export.SpaceBindings, ExportedSpaceBinding{
+			Name:      binding.Name,
+			Space:     binding.Spec.Space,
+			SpaceRole: binding.Spec.SpaceRole,
+		})
+	}
+	sort.Slice(export.SpaceBindings, func(i, j int) bool {
+		return export.SpaceBindings[i].Name < export.SpaceBindings[j].Name
+	})
+	return export, nil
+}
+
+func exportMasterUserRecord(mur *toolchainv1alpha1.MasterUserRecord) *ExportedMasterUserRecord {
+	exported := &ExportedMasterUserRecord{
+		Name:       mur.Name,
+		TierName:   mur.Spec.TierName,
+		Disabled:   mur.Spec.Disabled,
+		Conditions: mur.Status.Conditions,
+	}
+	for _, ua := range mur.Spec.UserAccounts {
+		exported.TargetClusters = append(exported.TargetClusters, ua.TargetCluster)
+	}
+	if mur.Status.ProvisionedTime != nil {
+		provisionedTime := mur.Status.ProvisionedTime.UTC()
+		exported.ProvisionedTime = &provisionedTime
+	}
+	return exported
+}
+
+// exportAnnotations returns the annotations among the given ones which are part of the exported data
+func exportAnnotations(annotations map[string]string) map[string]string {
+	exported := map[string]string{}
+	for _, key := range exportedAnnotations {
+		if value, found := annotations[key]; found {
+			exported[key] = value
+		}
+	}
+	if len(exported) == 0 {
+		return nil
+	}
+	return exported
+}
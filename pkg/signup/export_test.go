@@ -0,0 +1,161 @@
+package signup
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestExportPersonalData(t *testing.T) {
+	// given
+	log.Init("export-testing")
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	newUserSignup := func(modifiers ...usersignup.Modifier) *toolchainv1alpha1.UserSignup {
+		return usersignup.NewUserSignup(append([]usersignup.Modifier{
+			usersignup.WithEncodedName("johnny@kubesaw"),
+			usersignup.WithAnnotation(toolchainv1alpha1.UserSignupVerificationCodeAnnotationKey, "123456"),
+			usersignup.WithAnnotation(toolchainv1alpha1.UserSignupCaptchaScoreAnnotationKey, "0.9"),
+			usersignup.WithAnnotation(toolchainv1alpha1.UserSignupVerificationTimestampAnnotationKey, "2026-10-01T10:00:00.000Z"),
+			usersignup.WithAnnotation(PreferredRegionAnnotationKey, "eu"),
+			usersignup.WithLabel(toolchainv1alpha1.UserSignupUserPhoneHashLabelKey, "fd276563a8232d16620da8ec85d0575f"),
+		}, modifiers...)...)
+	}
+
+	t.Run("provisioned user", func(t *testing.T) {
+		// given
+		userSignup := newUserSignup(usersignup.WithCompliantUsername("johnny"))
+		mur := &toolchainv1alpha1.MasterUserRecord{
+			ObjectMeta: metav1.ObjectMeta{Name: "johnny", Namespace: commontest.HostOperatorNs},
+			Spec: toolchainv1alpha1.MasterUserRecordSpec{
+				TierName:     "deactivate30",
+				UserAccounts: []toolchainv1alpha1.UserAccountEmbedded{{TargetCluster: "member-123"}},
+			},
+			Status: toolchainv1alpha1.MasterUserRecordStatus{
+				Conditions: []toolchainv1alpha1.Condition{{Type: toolchainv1alpha1.MasterUserRecordReady, Status: "True"}},
+			},
+		}
+		nsdClient := namespaced.NewClient(commontest.NewFakeClient(t, userSignup, mur,
+			newSpaceBinding("johnny-2", "johnny", "jane", "viewer"),
+			newSpaceBinding("johnny-1", "johnny", "johnny", "admin"),
+			newSpaceBinding("jane-1", "jane", "jane", "admin"),
+		), commontest.HostOperatorNs)
+
+		// when
+		export, err := ExportPersonalData(ctx, nsdClient, "johnny@kubesaw")
+
+		// then
+		require.NoError(t, err)
+		require.NotNil(t, export)
+		assert.Equal(t, userSignup.Name, export.UserSignup.Name)
+		assert.Equal(t, userSignup.Spec.IdentityClaims, export.UserSignup.IdentityClaims)
+		assert.Equal(t, "johnny", export.UserSignup.CompliantUsername)
+		assert.Equal(t, "fd276563a8232d16620da8ec85d0575f", export.UserSignup.Labels[toolchainv1alpha1.UserSignupUserPhoneHashLabelKey])
+		// only the allowed annotations are exported
+		assert.Equal(t, map[string]string{
+			toolchainv1alpha1.UserSignupCaptchaScoreAnnotationKey:          "0.9",
+			toolchainv1alpha1.UserSignupVerificationTimestampAnnotationKey: "2026-10-01T10:00:00.000Z",
+			PreferredRegionAnnotationKey:                                   "eu",
+		}, export.UserSignup.Annotations)
+		// the UserSignup itself is not modified
+		assert.Equal(t, "123456", userSignup.Annotations[toolchainv1alpha1.UserSignupVerificationCodeAnnotationKey])
+		require.NotNil(t, export.MasterUserRecord)
+		assert.Equal(t, "johnny", export.MasterUserRecord.Name)
+		assert.Equal(t, "deactivate30", export.MasterUserRecord.TierName)
+		assert.Equal(t, []string{"member-123"}, export.MasterUserRecord.TargetClusters)
+		assert.Equal(t, mur.Status.Conditions, export.MasterUserRecord.Conditions)
+		assert.Equal(t, []ExportedSpaceBinding{
+			{Name: "johnny-1", Space: "johnny", SpaceRole: "admin"},
+			{Name: "johnny-2", Space: "jane", SpaceRole: "viewer"},
+		}, export.SpaceBindings)
+	})
+
+	t.Run("user not provisioned yet", func(t *testing.T) {
+		// given
+		nsdClient := namespaced.NewClient(commontest.NewFakeClient(t, newUserSignup()), commontest.HostOperatorNs)
+
+		// when
+		export, err := ExportPersonalData(ctx, nsdClient, "johnny@kubesaw")
+
+		// then
+		require.NoError(t, err)
+		require.NotNil(t, export)
+		assert.Nil(t, export.MasterUserRecord)
+		assert.Empty(t, export.SpaceBindings)
+	})
+
+	t.Run("UserSignup not found", func(t *testing.T) {
+		// given
+		nsdClient := namespaced.NewClient(commontest.NewFakeClient(t), commontest.HostOperatorNs)
+
+		// when
+		export, err := ExportPersonalData(ctx, nsdClient, "johnny@kubesaw")
+
+		// then
+		require.NoError(t, err)
+		assert.Nil(t, export)
+	})
+
+	t.Run("error listing the SpaceBindings", func(t *testing.T) {
+		// given
+		fakeClient := commontest.NewFakeClient(t, newUserSignup(usersignup.WithCompliantUsername("johnny")))
+		fakeClient.MockList = func(_ context.Context, _ client.ObjectList, _ ...client.ListOption) error {
+			return errors.New("oopsie woopsie")
+		}
+		nsdClient := namespaced.NewClient(fakeClient, commontest.HostOperatorNs)
+
+		// when
+		export, err := ExportPersonalData(ctx, nsdClient, "johnny@kubesaw")
+
+		// then
+		require.EqualError(t, err, "oopsie woopsie: error listing SpaceBindings of MasterUserRecord 'johnny'")
+		e := &crterrors.Error{}
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, http.StatusInternalServerError, e.Code)
+		assert.Nil(t, export)
+	})
+
+	t.Run("exported timestamps are in UTC", func(t *testing.T) {
+		// given
+		nsdClient := namespaced.NewClient(commontest.NewFakeClient(t, newUserSignup()), commontest.HostOperatorNs)
+
+		// when
+		export, err := ExportPersonalData(ctx, nsdClient, "johnny@kubesaw")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, time.UTC, export.ExportedAt.Location())
+	})
+}
+
+func newSpaceBinding(name, mur, space, role string) *toolchainv1alpha1.SpaceBinding {
+	return &toolchainv1alpha1.SpaceBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: commontest.HostOperatorNs,
+			Labels: map[string]string{
+				toolchainv1alpha1.SpaceBindingMasterUserRecordLabelKey: mur,
+				toolchainv1alpha1.SpaceBindingSpaceLabelKey:            space,
+			},
+		},
+		Spec: toolchainv1alpha1.SpaceBindingSpec{
+			MasterUserRecord: mur,
+			Space:            space,
+			SpaceRole:        role,
+		},
+	}
+}
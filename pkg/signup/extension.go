@@ -0,0 +1,200 @@
+package signup
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// TrialExtensionLabelKey is the label of a UserSignup holding the state of its last trial extension request,
+	// so that the administrators can list the pending requests
+	TrialExtensionLabelKey = toolchainv1alpha1.LabelKeyPrefix + "trial-extension"
+	// TrialExtensionJustificationAnnotationKey is the annotation of a UserSignup holding the justification given by the
+	// user in their last trial extension request
+	TrialExtensionJustificationAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "trial-extension-justification"
+	// TrialExtensionRequestedAnnotationKey is the annotation of a UserSignup holding the time of the last trial extension request
+	TrialExtensionRequestedAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "trial-extension-requested"
+	// TrialExtensionsAnnotationKey is the annotation of a UserSignup holding the number of trial extensions granted to the user
+	TrialExtensionsAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "trial-extensions"
+	// TrialExtensionEndDateAnnotationKey is the annotation of a UserSignup holding the end date of the trial of the user,
+	// as extended by the granted trial extensions (in RFC3339 format). It is only a record of the extensions: the trial
+	// is actually extended by ExtendTrial.
+	TrialExtensionEndDateAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "trial-extension-end-date"
+	// TrialExtensionDecidedByAnnotationKey is the annotation of a UserSignup holding who decided on the last trial
+	// extension request: either the username of an administrator, or the policy which approved it automatically
+	TrialExtensionDecidedByAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "trial-extension-decided-by"
+	// TrialExtensionUnappliedAnnotationKey is the annotation of a UserSignup holding the end date of the last granted
+	// trial extension as long as the trial was not actually extended up to it (see ApplyTrialExtension)
+	TrialExtensionUnappliedAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "trial-extension-unapplied"
+)
+
+// The states of a trial extension request, as set in the TrialExtensionLabelKey label
+const (
+	TrialExtensionPending  = "pending"
+	TrialExtensionApproved = "approved"
+	TrialExtensionRejected = "rejected"
+)
+
+// The policies which approve the trial extension requests automatically, as recorded in the
+// TrialExtensionDecidedByAnnotationKey annotation
+const (
+	// TrialExtensionPolicyEmailDomain approves the requests of the users with an email address in one of the configured domains
+	TrialExtensionPolicyEmailDomain = "policy:email-domain"
+	// TrialExtensionPolicySocialEvent approves the requests of the users who joined through a SocialEvent
+	TrialExtensionPolicySocialEvent = "policy:social-event"
+)
+
+// TrialExtension is the state of the trial extension requests of a user
+type TrialExtension struct {
+	// State is the state of the last request: pending, approved or rejected
+	State string `json:"state"`
+	// Justification is the justification given by the user in the last request
+	Justification string `json:"justification,omitempty"`
+	// Requested is the time of the last request, in RFC3339 format
+	Requested string `json:"requested,omitempty"`
+	// EndDate is the end date of the trial as extended by the granted extensions, in RFC3339 format
+	EndDate string `json:"endDate,omitempty"`
+	// Extensions is the number of extensions granted to the user
+	Extensions int `json:"extensions"`
+	// Remaining is the number of extensions the user can still request
+	Remaining int `json:"remaining"`
+}
+
+// GetTrialExtension returns the state of the trial extension requests recorded on the given UserSignup, given the
+// maximum number of extensions a user can get, or nil if the user never requested an extension
+func GetTrialExtension(userSignup *toolchainv1alpha1.UserSignup, maxExtensions int) *TrialExtension {
+	state, found := userSignup.Labels[TrialExtensionLabelKey]
+	if !found {
+		return nil
+	}
+	extensions := TrialExtensions(userSignup)
+	return &TrialExtension{
+		State:         state,
+		Justification: userSignup.Annotations[TrialExtensionJustificationAnnotationKey],
+		Requested:     userSignup.Annotations[TrialExtensionRequestedAnnotationKey],
+		EndDate:       userSignup.Annotations[TrialExtensionEndDateAnnotationKey],
+		Extensions:    extensions,
+		Remaining:     max(maxExtensions-extensions, 0),
+	}
+}
+
+// TrialExtensions returns the number of trial extensions granted to the user of the given UserSignup
+func TrialExtensions(userSignup *toolchainv1alpha1.UserSignup) int {
+	extensions, err := strconv.Atoi(userSignup.Annotations[TrialExtensionsAnnotationKey])
+	if err != nil {
+		return 0
+	}
+	return extensions
+}
+
+// RequestTrialExtension records a pending trial extension request with the given justification on the given UserSignup
+func RequestTrialExtension(userSignup *toolchainv1alpha1.UserSignup, justification string, now time.Time) {
+	setTrialExtensionState(userSignup, TrialExtensionPending)
+	userSignup.Annotations[TrialExtensionJustificationAnnotationKey] = justification
+	userSignup.Annotations[TrialExtensionRequestedAnnotationKey] = now.UTC().Format(time.RFC3339)
+	delete(userSignup.Annotations, TrialExtensionDecidedByAnnotationKey)
+}
+
+// ApproveTrialExtension approves the pending trial extension request of the given UserSignup: the end date of the trial
+// is pushed back by the given duration, from the latest of now, the scheduled deactivation and the end date of the
+// previous extension. Once the UserSignup is updated, the trial must be extended with ApplyTrialExtension.
+func ApproveTrialExtension(userSignup *toolchainv1alpha1.UserSignup, duration time.Duration, by string, now time.Time) {
+	base := now
+	if scheduled := userSignup.Status.ScheduledDeactivationTimestamp; scheduled != nil && scheduled.After(base) {
+		base = scheduled.Time
+	}
+	if previous, err := time.Parse(time.RFC3339, userSignup.Annotations[TrialExtensionEndDateAnnotationKey]); err == nil && previous.After(base) {
+		base = previous
+	}
+	setTrialExtensionState(userSignup, TrialExtensionApproved)
+	userSignup.Annotations[TrialExtensionsAnnotationKey] = strconv.Itoa(TrialExtensions(userSignup) + 1)
+	userSignup.Annotations[TrialExtensionEndDateAnnotationKey] = base.Add(duration).UTC().Format(time.RFC3339)
+	userSignup.Annotations[TrialExtensionUnappliedAnnotationKey] = userSignup.Annotations[TrialExtensionEndDateAnnotationKey]
+	userSignup.Annotations[TrialExtensionDecidedByAnnotationKey] = by
+}
+
+// TrialExtensionUnapplied returns true if the last trial extension granted to the user of the given UserSignup was
+// recorded but the trial was not extended yet
+func TrialExtensionUnapplied(userSignup *toolchainv1alpha1.UserSignup) bool {
+	_, found := userSignup.Annotations[TrialExtensionUnappliedAnnotationKey]
+	return found
+}
+
+// ApplyTrialExtension extends the trial of the user of the given UserSignup with ExtendTrial if the last granted
+// extension was not applied yet, and then removes the TrialExtensionUnappliedAnnotationKey annotation. If the trial
+// cannot be extended, the annotation is kept so that the extension can be applied again later on.
+func ApplyTrialExtension(ctx *gin.Context, cl namespaced.Client, userSignup *toolchainv1alpha1.UserSignup) error {
+	if !TrialExtensionUnapplied(userSignup) {
+		return nil
+	}
+	if err := ExtendTrial(ctx, cl, userSignup); err != nil {
+		return err
+	}
+	endDate := userSignup.Annotations[TrialExtensionUnappliedAnnotationKey]
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := cl.APIReader.Get(ctx, cl.NamespacedName(userSignup.Name), userSignup); err != nil {
+			return err
+		}
+		if userSignup.Annotations[TrialExtensionUnappliedAnnotationKey] != endDate {
+			// already applied, or another extension was granted in the meantime
+			return nil
+		}
+		delete(userSignup.Annotations, TrialExtensionUnappliedAnnotationKey)
+		return cl.Update(ctx, userSignup)
+	})
+}
+
+// ExtendTrial extends the trial of the user of the given UserSignup up to the end date recorded by ApproveTrialExtension.
+// The host operator deactivates the users once the deactivation timeout of their UserTier has elapsed since the
+// provisioned time of their MasterUserRecord, and schedules the deactivation of the UserSignup accordingly, so the
+// provisioned time is moved forward. Extending the trial again up to the same end date has no effect.
+func ExtendTrial(ctx *gin.Context, cl namespaced.Client, userSignup *toolchainv1alpha1.UserSignup) error {
+	end, err := time.Parse(time.RFC3339, userSignup.Annotations[TrialExtensionEndDateAnnotationKey])
+	if err != nil {
+		return fmt.Errorf("invalid end date of the trial of '%s': %w", userSignup.Name, err)
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		mur := &toolchainv1alpha1.MasterUserRecord{}
+		if err := cl.APIReader.Get(ctx, cl.NamespacedName(userSignup.Status.CompliantUsername), mur); err != nil {
+			return fmt.Errorf("unable to get the MasterUserRecord of '%s': %w", userSignup.Name, err)
+		}
+		tier := &toolchainv1alpha1.UserTier{}
+		if err := cl.Get(ctx, cl.NamespacedName(mur.Spec.TierName), tier); err != nil {
+			return fmt.Errorf("unable to get the UserTier '%s': %w", mur.Spec.TierName, err)
+		}
+		if tier.Spec.DeactivationTimeoutDays <= 0 {
+			// the users of the tier are never deactivated
+			return nil
+		}
+		provisioned := metav1.NewTime(end.Add(-time.Duration(tier.Spec.DeactivationTimeoutDays) * 24 * time.Hour))
+		if mur.Status.ProvisionedTime != nil && !provisioned.After(mur.Status.ProvisionedTime.Time) {
+			// already extended, and a trial is never shortened
+			return nil
+		}
+		mur.Status.ProvisionedTime = &provisioned
+		return cl.Status().Update(ctx, mur)
+	})
+}
+
+// RejectTrialExtension rejects the pending trial extension request of the given UserSignup
+func RejectTrialExtension(userSignup *toolchainv1alpha1.UserSignup, by string) {
+	setTrialExtensionState(userSignup, TrialExtensionRejected)
+	userSignup.Annotations[TrialExtensionDecidedByAnnotationKey] = by
+}
+
+func setTrialExtensionState(userSignup *toolchainv1alpha1.UserSignup, state string) {
+	if userSignup.Labels == nil {
+		userSignup.Labels = map[string]string{}
+	}
+	if userSignup.Annotations == nil {
+		userSignup.Annotations = map[string]string{}
+	}
+	userSignup.Labels[TrialExtensionLabelKey] = state
+}
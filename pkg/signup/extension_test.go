@@ -0,0 +1,288 @@
+package signup
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test/masteruserrecord"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test/tier"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestTrialExtension(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("never requested", func(t *testing.T) {
+		// given
+		userSignup := &toolchainv1alpha1.UserSignup{}
+
+		// when
+		extension := GetTrialExtension(userSignup, 2)
+
+		// then
+		assert.Nil(t, extension)
+	})
+
+	t.Run("requested", func(t *testing.T) {
+		// given
+		userSignup := &toolchainv1alpha1.UserSignup{}
+
+		// when
+		RequestTrialExtension(userSignup, "finishing my project", now)
+
+		// then
+		assert.Equal(t, &TrialExtension{
+			State:         TrialExtensionPending,
+			Justification: "finishing my project",
+			Requested:     "2026-10-01T12:00:00Z",
+			Remaining:     2,
+		}, GetTrialExtension(userSignup, 2))
+	})
+
+	t.Run("approved", func(t *testing.T) {
+		t.Run("from the scheduled deactivation", func(t *testing.T) {
+			// given
+			userSignup := &toolchainv1alpha1.UserSignup{}
+			userSignup.Status.ScheduledDeactivationTimestamp = &metav1.Time{Time: now.Add(48 * time.Hour)}
+			RequestTrialExtension(userSignup, "finishing my project", now)
+
+			// when
+			ApproveTrialExtension(userSignup, 24*time.Hour, "admin", now)
+
+			// then
+			extension := GetTrialExtension(userSignup, 2)
+			require.NotNil(t, extension)
+			assert.Equal(t, TrialExtensionApproved, extension.State)
+			assert.Equal(t, "2026-10-04T12:00:00Z", extension.EndDate)
+			assert.Equal(t, 1, extension.Extensions)
+			assert.Equal(t, 1, extension.Remaining)
+			assert.Equal(t, "admin", userSignup.Annotations[TrialExtensionDecidedByAnnotationKey])
+
+			t.Run("again, from the end of the previous extension", func(t *testing.T) {
+				// given
+				RequestTrialExtension(userSignup, "still finishing my project", now)
+				assert.NotContains(t, userSignup.Annotations, TrialExtensionDecidedByAnnotationKey)
+
+				// when
+				ApproveTrialExtension(userSignup, 24*time.Hour, TrialExtensionPolicyEmailDomain, now)
+
+				// then
+				extension := GetTrialExtension(userSignup, 2)
+				require.NotNil(t, extension)
+				assert.Equal(t, "2026-10-05T12:00:00Z", extension.EndDate)
+				assert.Equal(t, 2, extension.Extensions)
+				assert.Equal(t, 0, extension.Remaining)
+			})
+		})
+
+		t.Run("from now when the trial already ended", func(t *testing.T) {
+			// given
+			userSignup := &toolchainv1alpha1.UserSignup{}
+			userSignup.Status.ScheduledDeactivationTimestamp = &metav1.Time{Time: now.Add(-48 * time.Hour)}
+			RequestTrialExtension(userSignup, "finishing my project", now)
+
+			// when
+			ApproveTrialExtension(userSignup, 24*time.Hour, "admin", now)
+
+			// then
+			assert.Equal(t, "2026-10-02T12:00:00Z", userSignup.Annotations[TrialExtensionEndDateAnnotationKey])
+		})
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		// given
+		userSignup := &toolchainv1alpha1.UserSignup{}
+		RequestTrialExtension(userSignup, "just because", now)
+
+		// when
+		RejectTrialExtension(userSignup, "admin")
+
+		// then
+		extension := GetTrialExtension(userSignup, 1)
+		require.NotNil(t, extension)
+		assert.Equal(t, TrialExtensionRejected, extension.State)
+		assert.Empty(t, extension.EndDate)
+		assert.Equal(t, 0, extension.Extensions)
+		assert.Equal(t, 1, extension.Remaining)
+	})
+}
+
+func TestExtendTrial(t *testing.T) {
+	// given
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	provisioned := time.Now().Add(-10 * 24 * time.Hour).Truncate(time.Second)
+	newUserSignup := func(endDate string) *toolchainv1alpha1.UserSignup {
+		return usersignup.NewUserSignup(usersignup.WithName("johnny"), usersignup.WithCompliantUsername("johnny"),
+			usersignup.WithAnnotation(TrialExtensionEndDateAnnotationKey, endDate))
+	}
+	newMUR := func(tier string) *toolchainv1alpha1.MasterUserRecord {
+		return masteruserrecord.NewMasterUserRecord(t, "johnny", masteruserrecord.TierName(tier),
+			masteruserrecord.ProvisionedMur(&metav1.Time{Time: provisioned}))
+	}
+	getProvisionedTime := func(t *testing.T, cl client.Client) time.Time {
+		mur := &toolchainv1alpha1.MasterUserRecord{}
+		require.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Namespace: commontest.HostOperatorNs, Name: "johnny"}, mur))
+		return mur.Status.ProvisionedTime.Time
+	}
+	userTier := masteruserrecord.DefaultUserTier()
+	end := provisioned.Add(60 * 24 * time.Hour)
+
+	t.Run("the provisioned time is moved forward", func(t *testing.T) {
+		// given
+		fakeClient := commontest.NewFakeClient(t, newMUR(userTier.Name), &userTier)
+		nsdClient := namespaced.NewClient(fakeClient, commontest.HostOperatorNs)
+		userSignup := newUserSignup(end.UTC().Format(time.RFC3339))
+
+		// when
+		err := ExtendTrial(ctx, nsdClient, userSignup)
+
+		// then
+		require.NoError(t, err)
+		// the deactivation timeout of the tier is 30 days
+		assert.True(t, provisioned.Add(30*24*time.Hour).Equal(getProvisionedTime(t, fakeClient)))
+
+		t.Run("extending up to the same end date has no effect", func(t *testing.T) {
+			// when
+			err := ExtendTrial(ctx, nsdClient, userSignup)
+
+			// then
+			require.NoError(t, err)
+			assert.True(t, provisioned.Add(30*24*time.Hour).Equal(getProvisionedTime(t, fakeClient)))
+		})
+	})
+
+	t.Run("the trial is never shortened", func(t *testing.T) {
+		// given
+		fakeClient := commontest.NewFakeClient(t, newMUR(userTier.Name), &userTier)
+
+		// when
+		err := ExtendTrial(ctx, namespaced.NewClient(fakeClient, commontest.HostOperatorNs), newUserSignup(provisioned.UTC().Format(time.RFC3339)))
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, provisioned.Equal(getProvisionedTime(t, fakeClient)))
+	})
+
+	t.Run("no deactivation in the tier", func(t *testing.T) {
+		// given
+		noDeactivation := tier.NewUserTier(tier.WithName("nodeactivation"))
+		fakeClient := commontest.NewFakeClient(t, newMUR(noDeactivation.Name), noDeactivation)
+
+		// when
+		err := ExtendTrial(ctx, namespaced.NewClient(fakeClient, commontest.HostOperatorNs), newUserSignup(end.UTC().Format(time.RFC3339)))
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, provisioned.Equal(getProvisionedTime(t, fakeClient)))
+	})
+
+	t.Run("failures", func(t *testing.T) {
+		t.Run("no end date", func(t *testing.T) {
+			// given
+			fakeClient := commontest.NewFakeClient(t, newMUR(userTier.Name), &userTier)
+
+			// when
+			err := ExtendTrial(ctx, namespaced.NewClient(fakeClient, commontest.HostOperatorNs), newUserSignup(""))
+
+			// then
+			require.ErrorContains(t, err, "invalid end date of the trial of 'johnny'")
+		})
+
+		t.Run("no MasterUserRecord", func(t *testing.T) {
+			// given
+			fakeClient := commontest.NewFakeClient(t, &userTier)
+
+			// when
+			err := ExtendTrial(ctx, namespaced.NewClient(fakeClient, commontest.HostOperatorNs), newUserSignup(end.UTC().Format(time.RFC3339)))
+
+			// then
+			require.ErrorContains(t, err, "unable to get the MasterUserRecord of 'johnny'")
+		})
+
+		t.Run("no UserTier", func(t *testing.T) {
+			// given
+			fakeClient := commontest.NewFakeClient(t, newMUR(userTier.Name))
+
+			// when
+			err := ExtendTrial(ctx, namespaced.NewClient(fakeClient, commontest.HostOperatorNs), newUserSignup(end.UTC().Format(time.RFC3339)))
+
+			// then
+			require.ErrorContains(t, err, "unable to get the UserTier 'deactivate30'")
+		})
+	})
+}
+
+func TestApplyTrialExtension(t *testing.T) {
+	// given
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	provisioned := time.Now().Add(-10 * 24 * time.Hour).Truncate(time.Second)
+	userTier := masteruserrecord.DefaultUserTier()
+	newObjects := func() (*toolchainv1alpha1.UserSignup, *toolchainv1alpha1.MasterUserRecord) {
+		userSignup := usersignup.NewUserSignup(usersignup.WithName("johnny"), usersignup.WithCompliantUsername("johnny"))
+		RequestTrialExtension(userSignup, "finishing my project", time.Now())
+		ApproveTrialExtension(userSignup, 30*24*time.Hour, "admin", time.Now())
+		mur := masteruserrecord.NewMasterUserRecord(t, "johnny", masteruserrecord.TierName(userTier.Name),
+			masteruserrecord.ProvisionedMur(&metav1.Time{Time: provisioned}))
+		return userSignup, mur
+	}
+	getUserSignup := func(t *testing.T, cl client.Client) *toolchainv1alpha1.UserSignup {
+		userSignup := &toolchainv1alpha1.UserSignup{}
+		require.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Namespace: commontest.HostOperatorNs, Name: "johnny"}, userSignup))
+		return userSignup
+	}
+
+	t.Run("the trial is extended and the extension is marked as applied", func(t *testing.T) {
+		// given
+		userSignup, mur := newObjects()
+		fakeClient := commontest.NewFakeClient(t, userSignup, mur, &userTier)
+
+		// when
+		err := ApplyTrialExtension(ctx, namespaced.NewClient(fakeClient, commontest.HostOperatorNs), userSignup)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, TrialExtensionUnapplied(getUserSignup(t, fakeClient)))
+		updated := &toolchainv1alpha1.MasterUserRecord{}
+		require.NoError(t, fakeClient.Get(context.TODO(), client.ObjectKeyFromObject(mur), updated))
+		assert.True(t, updated.Status.ProvisionedTime.After(provisioned))
+	})
+
+	t.Run("the extension stays unapplied when the trial cannot be extended", func(t *testing.T) {
+		// given
+		userSignup, mur := newObjects()
+		fakeClient := commontest.NewFakeClient(t, userSignup, mur, &userTier)
+		fakeClient.MockStatusUpdate = func(_ context.Context, _ client.Object, _ ...client.SubResourceUpdateOption) error {
+			return errors.New("an error occurred")
+		}
+
+		// when
+		err := ApplyTrialExtension(ctx, namespaced.NewClient(fakeClient, commontest.HostOperatorNs), userSignup)
+
+		// then
+		require.EqualError(t, err, "an error occurred")
+		assert.True(t, TrialExtensionUnapplied(getUserSignup(t, fakeClient)))
+	})
+
+	t.Run("nothing to apply", func(t *testing.T) {
+		// given
+		userSignup := usersignup.NewUserSignup(usersignup.WithName("johnny"), usersignup.WithCompliantUsername("johnny"))
+		fakeClient := commontest.NewFakeClient(t, userSignup)
+
+		// when
+		err := ApplyTrialExtension(ctx, namespaced.NewClient(fakeClient, commontest.HostOperatorNs), userSignup)
+
+		// then
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,154 @@
+package signup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// PersonalCodesAnnotationKey is the annotation of a SocialEvent containing its pool of single-use personal codes.
+	// The value is a JSON object whose keys are the hashes of the codes (see HashPersonalCode) and whose values are
+	// the names of the UserSignups which used the codes, or empty for the unused codes.
+	// An event with this annotation can only be joined with a personal code, not with the name of the event.
+	PersonalCodesAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "personal-codes"
+	// PersonalCodeAnnotationKey is the annotation of a UserSignup containing the hash of the personal code used to join the event
+	PersonalCodeAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "personal-code"
+
+	// PersonalCodeSeparator separates the name of the event from the personal part in a personal code,
+	// eg `abcdef-k3m9pq7x` is a personal code of the `abcdef` event
+	PersonalCodeSeparator = "-"
+)
+
+// PersonalCodes is the pool of personal codes of a SocialEvent: the hashes of the codes
+// and the names of the UserSignups which used them (empty for the unused codes)
+type PersonalCodes map[string]string
+
+// HashPersonalCode returns the hash of the given personal part of a code of the given event,
+// as stored in the pool of personal codes of the event
+func HashPersonalCode(eventName, personal string) string {
+	sum := sha256.Sum256([]byte(eventName + PersonalCodeSeparator + personal))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetPersonalCodes returns the pool of personal codes of the given event, or nil if the event has no such pool
+func GetPersonalCodes(event *toolchainv1alpha1.SocialEvent) (PersonalCodes, error) {
+	value, found := event.Annotations[PersonalCodesAnnotationKey]
+	if !found {
+		return nil, nil
+	}
+	codes := PersonalCodes{}
+	if err := json.Unmarshal([]byte(value), &codes); err != nil {
+		return nil, fmt.Errorf("invalid personal codes of event '%s': %w", event.Name, err)
+	}
+	return codes, nil
+}
+
+// SetPersonalCodes stores the given pool of personal codes in the given event
+func SetPersonalCodes(event *toolchainv1alpha1.SocialEvent, codes PersonalCodes) error {
+	value, err := json.Marshal(codes)
+	if err != nil {
+		return err
+	}
+	if event.Annotations == nil {
+		event.Annotations = map[string]string{}
+	}
+	event.Annotations[PersonalCodesAnnotationKey] = string(value)
+	return nil
+}
+
+// ConsumePersonalCode marks the personal code given by the user as used by the given UserSignup, and binds the code to
+// the UserSignup (which must be persisted by the caller). The pool of codes is updated with optimistic concurrency,
+// so that a code cannot be used by two users, even if they activate it at the same time. Using a code again with
+// the same UserSignup has no effect, so that the activation can be retried.
+// This is a no-op if the event has no pool of personal codes.
+func ConsumePersonalCode(ctx *gin.Context, cl namespaced.Client, event *toolchainv1alpha1.SocialEvent, code string, userSignup *toolchainv1alpha1.UserSignup) error {
+	if _, found := event.Annotations[PersonalCodesAnnotationKey]; !found {
+		return nil
+	}
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		// read without the cache, which may not contain the update of a concurrent activation yet, in which case the
+		// update would conflict again until the retries are exhausted
+		latest := &toolchainv1alpha1.SocialEvent{}
+		if err := cl.APIReader.Get(ctx, cl.NamespacedName(event.Name), latest); err != nil {
+			return err
+		}
+		codes, codeHash, err := checkPersonalCode(latest, code)
+		if err != nil {
+			return err
+		}
+		switch usedBy := codes[codeHash]; usedBy {
+		case userSignup.Name:
+			return nil
+		case "":
+			codes[codeHash] = userSignup.Name
+		default:
+			log.Infof(ctx, "the personal code of event '%s' was already used by '%s'", event.Name, usedBy)
+			return crterrors.WithErrorCode(crterrors.NewForbiddenError("invalid code", "the provided code has already been used"), crterrors.ActivationCodeUsed)
+		}
+		if err := SetPersonalCodes(latest, codes); err != nil {
+			return err
+		}
+		// fails with a conflict if the pool was updated since it was read, in which case the update is retried
+		return cl.Update(ctx, latest)
+	})
+	if err != nil {
+		return err
+	}
+	if userSignup.Annotations == nil {
+		userSignup.Annotations = map[string]string{}
+	}
+	userSignup.Annotations[PersonalCodeAnnotationKey] = HashPersonalCode(event.Name, strings.TrimPrefix(code, event.Name+PersonalCodeSeparator))
+	return nil
+}
+
+// lookupSocialEvent returns the SocialEvent of the given activation code, which is either the name of the event
+// or a personal code of an event with a pool of personal codes. Returns a NotFound error if there is no such event.
+func lookupSocialEvent(ctx *gin.Context, cl namespaced.Client, code string) (*toolchainv1alpha1.SocialEvent, error) {
+	event := &toolchainv1alpha1.SocialEvent{}
+	err := cl.Get(ctx, cl.NamespacedName(code), event)
+	if !apierrors.IsNotFound(err) {
+		return event, err
+	}
+	i := strings.LastIndex(code, PersonalCodeSeparator)
+	if i <= 0 || i == len(code)-1 {
+		return nil, err
+	}
+	personalEvent := &toolchainv1alpha1.SocialEvent{}
+	if err := cl.Get(ctx, cl.NamespacedName(code[:i]), personalEvent); err != nil {
+		return nil, err
+	}
+	if _, found := personalEvent.Annotations[PersonalCodesAnnotationKey]; !found {
+		// not an event with personal codes, so the code is unknown
+		return nil, err
+	}
+	return personalEvent, nil
+}
+
+// checkPersonalCode checks that the given code can be used to join the given event, which has a pool of personal codes.
+// It returns the pool along with the hash of the code.
+func checkPersonalCode(event *toolchainv1alpha1.SocialEvent, code string) (PersonalCodes, string, error) {
+	codes, err := GetPersonalCodes(event)
+	if err != nil {
+		return nil, "", crterrors.NewInternalError(err, fmt.Sprintf("error retrieving the personal codes of event '%s'", event.Name))
+	}
+	personal, found := strings.CutPrefix(code, event.Name+PersonalCodeSeparator)
+	if !found {
+		return nil, "", crterrors.WithErrorCode(crterrors.NewForbiddenError("invalid code", "a personal code is required to join this event"), crterrors.ActivationCodeRequired)
+	}
+	codeHash := HashPersonalCode(event.Name, personal)
+	if _, found := codes[codeHash]; !found {
+		return nil, "", crterrors.WithErrorCode(crterrors.NewForbiddenError("invalid code", "the provided code is invalid"), crterrors.ActivationCodeInvalid)
+	}
+	return codes, codeHash, nil
+}
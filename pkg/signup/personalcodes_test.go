@@ -0,0 +1,242 @@
+package signup
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	testsocialevent "github.com/codeready-toolchain/toolchain-common/pkg/test/socialevent"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func withPersonalCodes(codes PersonalCodes) testsocialevent.Option {
+	return func(event *toolchainv1alpha1.SocialEvent) {
+		if err := SetPersonalCodes(event, codes); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func TestPersonalCodes(t *testing.T) {
+	// given
+	log.Init("personal-codes-testing")
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	newEvent := func() *toolchainv1alpha1.SocialEvent {
+		return testsocialevent.NewSocialEvent(commontest.HostOperatorNs, "event1", withPersonalCodes(PersonalCodes{
+			HashPersonalCode("event1", "unused01"): "",
+			HashPersonalCode("event1", "unused02"): "",
+			HashPersonalCode("event1", "used0001"): "jane",
+		}))
+	}
+
+	t.Run("validate", func(t *testing.T) {
+		nsdClient := namespaced.NewClient(commontest.NewFakeClient(t, newEvent(),
+			testsocialevent.NewSocialEvent(commontest.HostOperatorNs, "event2")), commontest.HostOperatorNs)
+
+		t.Run("unused personal code", func(t *testing.T) {
+			// when
+			event, err := GetAndValidateSocialEvent(ctx, nsdClient, "event1-unused01")
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "event1", event.Name)
+		})
+
+		for name, tc := range map[string]struct {
+			code     string
+			expected string
+		}{
+			"name of the event": {
+				code:     "event1",
+				expected: "invalid code: a personal code is required to join this event",
+			},
+			"unknown personal code": {
+				code:     "event1-unknown1",
+				expected: "invalid code: the provided code is invalid",
+			},
+			"personal code of an event without personal codes": {
+				code:     "event2-unused01",
+				expected: "invalid code: the provided code is invalid",
+			},
+			"unknown event": {
+				code:     "event3-unused01",
+				expected: "invalid code: the provided code is invalid",
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				// when
+				_, err := GetAndValidateSocialEvent(ctx, nsdClient, tc.code)
+
+				// then
+				require.EqualError(t, err, tc.expected)
+			})
+		}
+	})
+
+	t.Run("check", func(t *testing.T) {
+		nsdClient := namespaced.NewClient(commontest.NewFakeClient(t, newEvent()), commontest.HostOperatorNs)
+
+		t.Run("unused personal code", func(t *testing.T) {
+			// when
+			code, err := CheckActivationCode(ctx, nsdClient, "event1-unused01")
+
+			// then
+			require.NoError(t, err)
+			assert.True(t, code.Exists)
+			assert.Equal(t, ActivationCodeRunning, code.Status)
+			assert.False(t, code.PersonalCodeRequired)
+		})
+
+		t.Run("used personal code", func(t *testing.T) {
+			// when
+			code, err := CheckActivationCode(ctx, nsdClient, "event1-used0001")
+
+			// then
+			require.NoError(t, err)
+			assert.True(t, code.Exists)
+			assert.Equal(t, ActivationCodeUsed, code.Status)
+		})
+
+		t.Run("name of the event", func(t *testing.T) {
+			// when
+			code, err := CheckActivationCode(ctx, nsdClient, "event1")
+
+			// then
+			require.NoError(t, err)
+			assert.True(t, code.Exists)
+			assert.True(t, code.PersonalCodeRequired)
+		})
+
+		t.Run("unknown personal code", func(t *testing.T) {
+			// when
+			code, err := CheckActivationCode(ctx, nsdClient, "event1-unknown1")
+
+			// then
+			require.NoError(t, err)
+			assert.False(t, code.Exists)
+		})
+	})
+
+	t.Run("consume", func(t *testing.T) {
+		getPersonalCodes := func(t *testing.T, cl client.Client) PersonalCodes {
+			event := &toolchainv1alpha1.SocialEvent{}
+			require.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Namespace: commontest.HostOperatorNs, Name: "event1"}, event))
+			codes, err := GetPersonalCodes(event)
+			require.NoError(t, err)
+			return codes
+		}
+
+		t.Run("unused personal code", func(t *testing.T) {
+			// given
+			event := newEvent()
+			fakeClient := commontest.NewFakeClient(t, event)
+			nsdClient := namespaced.NewClient(fakeClient, commontest.HostOperatorNs)
+			userSignup := usersignup.NewUserSignup(usersignup.WithName("johnny"))
+
+			// when
+			err := ConsumePersonalCode(ctx, nsdClient, event, "event1-unused01", userSignup)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, HashPersonalCode("event1", "unused01"), userSignup.Annotations[PersonalCodeAnnotationKey])
+			codes := getPersonalCodes(t, fakeClient)
+			assert.Equal(t, "johnny", codes[HashPersonalCode("event1", "unused01")])
+			assert.Empty(t, codes[HashPersonalCode("event1", "unused02")])
+
+			t.Run("using the code again has no effect", func(t *testing.T) {
+				// when
+				err := ConsumePersonalCode(ctx, nsdClient, event, "event1-unused01", userSignup)
+
+				// then
+				require.NoError(t, err)
+			})
+
+			t.Run("the code cannot be used by another user", func(t *testing.T) {
+				// when
+				err := ConsumePersonalCode(ctx, nsdClient, event, "event1-unused01", usersignup.NewUserSignup(usersignup.WithName("ted")))
+
+				// then
+				require.EqualError(t, err, "invalid code: the provided code has already been used")
+				assert.Equal(t, "johnny", getPersonalCodes(t, fakeClient)[HashPersonalCode("event1", "unused01")])
+			})
+		})
+
+		t.Run("retried on conflict", func(t *testing.T) {
+			// given
+			event := newEvent()
+			fakeClient := commontest.NewFakeClient(t, event)
+			conflicts := 0
+			fakeClient.MockUpdate = func(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+				if conflicts < 2 {
+					conflicts++
+					return apierrors.NewConflict(schema.GroupResource{}, obj.GetName(), nil)
+				}
+				return fakeClient.Client.Update(ctx, obj, opts...)
+			}
+			nsdClient := namespaced.NewClient(fakeClient, commontest.HostOperatorNs)
+
+			// when
+			err := ConsumePersonalCode(ctx, nsdClient, event, "event1-unused02", usersignup.NewUserSignup(usersignup.WithName("johnny")))
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, 2, conflicts)
+			assert.Equal(t, "johnny", getPersonalCodes(t, fakeClient)[HashPersonalCode("event1", "unused02")])
+		})
+
+		t.Run("reads the latest pool of codes without the cache", func(t *testing.T) {
+			// given
+			event := newEvent()
+			// the cache does not contain the activation of the code by another user yet
+			cachedClient := commontest.NewFakeClient(t, event)
+			apiReader := commontest.NewFakeClient(t, testsocialevent.NewSocialEvent(commontest.HostOperatorNs, "event1", withPersonalCodes(PersonalCodes{
+				HashPersonalCode("event1", "unused01"): "jane",
+			})))
+			nsdClient := namespaced.NewClientWithAPIReader(cachedClient, apiReader, commontest.HostOperatorNs)
+
+			// when
+			err := ConsumePersonalCode(ctx, nsdClient, event, "event1-unused01", usersignup.NewUserSignup(usersignup.WithName("johnny")))
+
+			// then
+			require.EqualError(t, err, "invalid code: the provided code has already been used")
+			assert.Empty(t, getPersonalCodes(t, cachedClient)[HashPersonalCode("event1", "unused01")])
+		})
+
+		t.Run("code revoked in the meantime", func(t *testing.T) {
+			// given
+			event := newEvent()
+			nsdClient := namespaced.NewClient(commontest.NewFakeClient(t,
+				testsocialevent.NewSocialEvent(commontest.HostOperatorNs, "event1", withPersonalCodes(PersonalCodes{}))), commontest.HostOperatorNs)
+
+			// when
+			err := ConsumePersonalCode(ctx, nsdClient, event, "event1-unused01", usersignup.NewUserSignup(usersignup.WithName("johnny")))
+
+			// then
+			require.EqualError(t, err, "invalid code: the provided code is invalid")
+		})
+
+		t.Run("no-op for events without personal codes", func(t *testing.T) {
+			// given
+			event := testsocialevent.NewSocialEvent(commontest.HostOperatorNs, "event2")
+			nsdClient := namespaced.NewClient(commontest.NewFakeClient(t, event), commontest.HostOperatorNs)
+			userSignup := usersignup.NewUserSignup(usersignup.WithName("johnny"))
+
+			// when
+			err := ConsumePersonalCode(ctx, nsdClient, event, "event2", userSignup)
+
+			// then
+			require.NoError(t, err)
+			assert.NotContains(t, userSignup.Annotations, PersonalCodeAnnotationKey)
+		})
+	})
+}
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"regexp"
 	"sort"
 	"strings"
 	"time"
@@ -15,6 +14,7 @@ import (
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	"github.com/codeready-toolchain/registration-service/pkg/configuration"
 	"github.com/codeready-toolchain/registration-service/pkg/context"
+	crterrors "github.com/codeready-toolchain/registration-service/pkg/errors"
 	"github.com/codeready-toolchain/registration-service/pkg/log"
 	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
 	"github.com/codeready-toolchain/registration-service/pkg/signup"
@@ -29,19 +29,29 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
 	// NoSpaceKey is the query key for specifying whether the UserSignup should be created without a Space
 	NoSpaceKey = "no-space"
+	// TierKey is the query key for specifying the UserTier chosen by the user, among the selectable ones
+	TierKey = "tier"
+	// RegionKey is the query key for specifying the region in which the user prefers to be provisioned
+	RegionKey = "region"
+	// ClusterKey is the query key for specifying the member cluster in which the user prefers to be provisioned
+	ClusterKey = "cluster"
 )
 
-var ForbiddenBannedError = apierrors.NewForbidden(schema.GroupResource{}, "",
-	errs.New("Access to the Developer Sandbox has been suspended due to suspicious activity or detected abuse."))
+var ForbiddenBannedError = crterrors.WithErrorCode(apierrors.NewForbidden(schema.GroupResource{}, "",
+	errs.New("Access to the Developer Sandbox has been suspended due to suspicious activity or detected abuse.")), crterrors.SignupBanned)
 
-var ForbiddenRejectedError = apierrors.NewForbidden(schema.GroupResource{}, "",
-	errs.New("Access to the Developer Sandbox has been denied."))
+var ForbiddenRejectedError = crterrors.WithErrorCode(apierrors.NewForbidden(schema.GroupResource{}, "",
+	errs.New("Access to the Developer Sandbox has been denied.")), crterrors.SignupRejected)
+
+// SelfDeactivationTimestampAnnotationKey is the annotation recording when the user deactivated their signup
+const SelfDeactivationTimestampAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "self-deactivation-timestamp"
 
 var annotationsToRetain = []string{
 	toolchainv1alpha1.UserSignupActivationCounterAnnotationKey,
@@ -77,7 +87,7 @@ func (s *ServiceImpl) newUserSignup(ctx *gin.Context, accountVerifierResp *toolc
 			userID, accountID, username, ctx.GetString(context.SubKey))
 	}
 
-	if isCRTAdmin(username) {
+	if signup.IsCRTAdmin(username) {
 		log.Info(ctx, fmt.Sprintf("A crtadmin user '%s' just tried to signup", ctx.GetString(context.UsernameKey)))
 		return nil, apierrors.NewForbidden(schema.GroupResource{}, "", fmt.Errorf("failed to create usersignup for %s", username))
 	}
@@ -173,15 +183,29 @@ func (s *ServiceImpl) newUserSignup(ctx *gin.Context, accountVerifierResp *toolc
 		if err != nil {
 			return nil, err
 		}
+		if err := signup.ConsumePersonalCode(ctx, s.Client, event, socialEvent, userSignup); err != nil {
+			return nil, err
+		}
 		signup.UpdateUserSignupWithSocialEvent(event, userSignup)
 	}
 
-	return userSignup, nil
-}
+	// the tier is checked last, as the user may be eligible to it through the SocialEvent they just joined
+	if tier := ctx.Query(TierKey); tier != "" {
+		if err := signup.SelectTier(ctx, s.Client, userSignup, tier); err != nil {
+			return nil, err
+		}
+		log.Info(ctx, fmt.Sprintf("setting '%s' annotation to '%s'", signup.UserTierAnnotationKey, tier))
+	}
 
-func isCRTAdmin(username string) bool {
-	newUsername := regexp.MustCompile("[^A-Za-z0-9]").ReplaceAllString(strings.Split(username, "@")[0], "-")
-	return strings.HasSuffix(newUsername, "crtadmin")
+	// the target cluster of the SocialEvent, if any, takes precedence over the one preferred by the user
+	preference := signup.ClusterPreference{Region: ctx.Query(RegionKey), Cluster: ctx.Query(ClusterKey)}
+	if !preference.IsEmpty() {
+		if err := signup.ApplyClusterPreference(ctx, s.Client, userSignup, preference); err != nil {
+			return nil, err
+		}
+	}
+
+	return userSignup, nil
 }
 
 /*
@@ -410,6 +434,14 @@ func (s *ServiceImpl) reactivateUserSignup(ctx *gin.Context, existing *toolchain
 	log.WithValues(map[string]interface{}{toolchainv1alpha1.UserSignupActivationCounterAnnotationKey: existing.Annotations[toolchainv1alpha1.UserSignupActivationCounterAnnotationKey]}).
 		Info(ctx, "reactivating user")
 
+	// honour the region or cluster chosen at the previous signup, unless the user chose a new one
+	if preference := signup.GetClusterPreference(existing); !preference.IsEmpty() && signup.GetClusterPreference(newUserSignup).IsEmpty() {
+		if err := signup.ApplyClusterPreference(ctx, s.Client, newUserSignup, preference); err != nil {
+			// the preference is only a hint, which must not prevent the reactivation
+			log.Error(ctx, err, "unable to apply the preferred region or cluster on reactivation")
+		}
+	}
+
 	// don't override any of the annotations that need to be retained if they are already set in the existing UserSignup
 	for _, a := range annotationsToRetain {
 		if c, exists := existing.Annotations[a]; exists {
@@ -424,6 +456,117 @@ func (s *ServiceImpl) reactivateUserSignup(ctx *gin.Context, existing *toolchain
 	return existing, s.Update(ctx, existing)
 }
 
+// DeactivateSignup deactivates the UserSignup of the user with the given username on behalf of the user. The annotations
+// of the UserSignup are left intact, so that the signup can be reactivated later on.
+// Returns false if the UserSignup was already deactivated, or a NotFound error if there is no UserSignup for the user.
+func (s *ServiceImpl) DeactivateSignup(ctx *gin.Context, username string) (bool, error) {
+	encodedUsername := signupcommon.EncodeUserIdentifier(username)
+	found, deactivated := false, false
+	err := signup.PollUpdateSignup(ctx, func() error {
+		userSignup := &toolchainv1alpha1.UserSignup{}
+		if err := s.Get(ctx, s.NamespacedName(encodedUsername), userSignup); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		found = true
+		signupCondition, _ := condition.FindConditionByType(userSignup.Status.Conditions, toolchainv1alpha1.UserSignupComplete)
+		if states.Deactivated(userSignup) ||
+			(signupCondition.Status == apiv1.ConditionTrue && signupCondition.Reason == toolchainv1alpha1.UserSignupUserDeactivatedReason) {
+			return nil
+		}
+		states.SetDeactivated(userSignup, true)
+		if userSignup.Annotations == nil {
+			userSignup.Annotations = map[string]string{}
+		}
+		userSignup.Annotations[SelfDeactivationTimestampAnnotationKey] = time.Now().UTC().Format(time.RFC3339)
+		if err := s.Update(ctx, userSignup); err != nil {
+			return err
+		}
+		deactivated = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	if !found {
+		return false, apierrors.NewNotFound(toolchainv1alpha1.GroupVersion.WithResource("usersignups").GroupResource(), encodedUsername)
+	}
+	return deactivated, nil
+}
+
+// RequestTrialExtension records a trial extension request with the given justification on the UserSignup of the user
+// with the given username. The request is approved right away if one of the configured auto-approval policies applies
+// to the user, otherwise it stays pending until an administrator approves or rejects it.
+func (s *ServiceImpl) RequestTrialExtension(ctx *gin.Context, username, justification string) (*signup.TrialExtension, error) {
+	cfg := configuration.GetRegistrationServiceConfig().TrialExtensions()
+	encodedUsername := signupcommon.EncodeUserIdentifier(username)
+	var extension *signup.TrialExtension
+	userSignup := &toolchainv1alpha1.UserSignup{}
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := s.Get(ctx, s.NamespacedName(encodedUsername), userSignup); err != nil {
+			if apierrors.IsNotFound(err) {
+				return crterrors.NewNotFoundError(err, "usersignup not found")
+			}
+			return err
+		}
+		completeCondition, _ := condition.FindConditionByType(userSignup.Status.Conditions, toolchainv1alpha1.UserSignupComplete)
+		if states.Deactivated(userSignup) || !condition.IsTrue(userSignup.Status.Conditions, toolchainv1alpha1.UserSignupApproved) ||
+			completeCondition.Reason == toolchainv1alpha1.UserSignupUserDeactivatedReason ||
+			completeCondition.Reason == toolchainv1alpha1.UserSignupUserBannedReason {
+			return crterrors.WithErrorCode(crterrors.NewForbiddenError("trial extension not allowed", "the signup is not active"), crterrors.TrialExtensionNotAllowed)
+		}
+		if signup.TrialExtensionUnapplied(userSignup) {
+			// the previous extension was approved but the trial was not extended, which is done again below
+			// instead of granting another extension
+			extension = signup.GetTrialExtension(userSignup, cfg.Max())
+			return nil
+		}
+		if userSignup.Labels[signup.TrialExtensionLabelKey] == signup.TrialExtensionPending {
+			return crterrors.WithErrorCode(crterrors.NewConflictError("trial extension already requested", "the previous request is still pending"), crterrors.TrialExtensionPending)
+		}
+		if signup.TrialExtensions(userSignup) >= cfg.Max() {
+			return crterrors.WithErrorCode(crterrors.NewForbiddenError("trial extension not allowed", fmt.Sprintf("the maximum number of %d extensions is reached", cfg.Max())),
+				crterrors.TrialExtensionNotAllowed)
+		}
+
+		now := time.Now()
+		signup.RequestTrialExtension(userSignup, justification, now)
+		if policy := trialExtensionPolicy(userSignup, cfg); policy != "" {
+			signup.ApproveTrialExtension(userSignup, cfg.Duration(), policy, now)
+		}
+		if err := s.Update(ctx, userSignup); err != nil {
+			return err
+		}
+		extension = signup.GetTrialExtension(userSignup, cfg.Max())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := signup.ApplyTrialExtension(ctx, s.Client, userSignup); err != nil {
+		return nil, err
+	}
+	return extension, nil
+}
+
+// trialExtensionPolicy returns the auto-approval policy which applies to the user of the given UserSignup, if any
+func trialExtensionPolicy(userSignup *toolchainv1alpha1.UserSignup, cfg configuration.TrialExtensionsConfig) string {
+	email := userSignup.Spec.IdentityClaims.Email
+	if i := strings.LastIndex(email, "@"); i >= 0 {
+		for _, domain := range cfg.AutoApprovedEmailDomains() {
+			if strings.EqualFold(email[i+1:], domain) {
+				return signup.TrialExtensionPolicyEmailDomain
+			}
+		}
+	}
+	if cfg.AutoApproveEventAttendees() && userSignup.Labels[toolchainv1alpha1.SocialEventUserSignupLabelKey] != "" {
+		return signup.TrialExtensionPolicySocialEvent
+	}
+	return ""
+}
+
 // GetSignup returns Signup resource which represents the corresponding K8s UserSignup
 // and MasterUserRecord resources in the host cluster.
 // The checkUserSignupCompleted was introduced in order to avoid checking the readiness of the complete condition on the UserSignup in certain situations,
@@ -529,6 +672,7 @@ func (s *ServiceImpl) DoGetSignup(ctx *gin.Context, cl namespaced.Client, userna
 	if !userSignup.Status.ScheduledDeactivationTimestamp.IsZero() {
 		signupResponse.EndDate = userSignup.Status.ScheduledDeactivationTimestamp.UTC().Format(time.RFC3339)
 	}
+	signupResponse.TrialExtension = signup.GetTrialExtension(userSignup, configuration.GetRegistrationServiceConfig().TrialExtensions().Max())
 
 	// If UserSignup status is complete as active
 	// Retrieve MasterUserRecord resource from the host cluster and use its status
@@ -17,6 +17,7 @@ import (
 	"github.com/codeready-toolchain/registration-service/pkg/context"
 	errors2 "github.com/codeready-toolchain/registration-service/pkg/errors"
 	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/signup"
 	"github.com/codeready-toolchain/registration-service/pkg/signup/service"
 	"github.com/codeready-toolchain/registration-service/pkg/util"
 	"github.com/codeready-toolchain/registration-service/test"
@@ -25,6 +26,7 @@ import (
 	"github.com/codeready-toolchain/toolchain-common/pkg/test/masteruserrecord"
 	testsocialevent "github.com/codeready-toolchain/toolchain-common/pkg/test/socialevent"
 	"github.com/codeready-toolchain/toolchain-common/pkg/test/space"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test/spaceprovisionerconfig"
 	testusersignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
 	signupcommon "github.com/codeready-toolchain/toolchain-common/pkg/usersignup"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -42,6 +44,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
@@ -341,6 +344,243 @@ func (s *TestSignupServiceSuite) TestSignupNoSpaces() {
 	require.Equal(s.T(), "true", val.Annotations[toolchainv1alpha1.SkipAutoCreateSpaceAnnotationKey]) // skip auto create space annotation is set
 }
 
+func (s *TestSignupServiceSuite) TestSignupWithTier() {
+	s.ServiceConfiguration(true, "", 5)
+	test.SetSettings(s.T(), `
+tiers:
+  selectable:
+  - name: deactivate30
+  - name: ai
+    emailDomains: [kubesaw.io]
+  - name: missing`)
+	newContext := func(email, tier string) *gin.Context {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Set(context.UsernameKey, "jsmith")
+		ctx.Set(context.SubKey, "987654321")
+		ctx.Set(context.EmailKey, email)
+		ctx.Request, _ = http.NewRequest("POST", "/?tier="+tier, bytes.NewBufferString(""))
+		return ctx
+	}
+	newUserTier := func(name string) *toolchainv1alpha1.UserTier {
+		return &toolchainv1alpha1.UserTier{ObjectMeta: v1.ObjectMeta{Name: name, Namespace: commontest.HostOperatorNs}}
+	}
+
+	for name, tc := range map[string]struct {
+		email string
+		tier  string
+	}{
+		"unrestricted tier": {email: "jsmith@gmail.com", tier: "deactivate30"},
+		"restricted tier":   {email: "jsmith@kubesaw.io", tier: "ai"},
+	} {
+		s.Run(name, func() {
+			// given
+			_, application := testutil.PrepareInClusterApp(s.T(), newUserTier("deactivate30"), newUserTier("ai"))
+
+			// when
+			userSignup, err := application.SignupService().Signup(newContext(tc.email, tc.tier))
+
+			// then
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), tc.tier, userSignup.Annotations[signup.UserTierAnnotationKey])
+		})
+	}
+
+	s.Run("no tier", func() {
+		// given
+		_, application := testutil.PrepareInClusterApp(s.T(), newUserTier("deactivate30"))
+
+		// when
+		userSignup, err := application.SignupService().Signup(newContext("jsmith@gmail.com", ""))
+
+		// then
+		require.NoError(s.T(), err)
+		assert.NotContains(s.T(), userSignup.Annotations, signup.UserTierAnnotationKey)
+	})
+
+	for name, tc := range map[string]struct {
+		email       string
+		tier        string
+		expectedErr string
+		check       func(error) bool
+	}{
+		"tier not selectable": {
+			email:       "jsmith@gmail.com",
+			tier:        "deactivate90",
+			expectedErr: "tier 'deactivate90' cannot be selected",
+			check:       apierrors.IsBadRequest,
+		},
+		"user not eligible": {
+			email:       "jsmith@gmail.com",
+			tier:        "ai",
+			expectedErr: "forbidden: user is not eligible to tier 'ai'",
+			check:       apierrors.IsForbidden,
+		},
+		"UserTier not found": {
+			email:       "jsmith@gmail.com",
+			tier:        "missing",
+			expectedErr: "tier 'missing' does not exist",
+			check:       apierrors.IsBadRequest,
+		},
+	} {
+		s.Run(name, func() {
+			// given
+			fakeClient, application := testutil.PrepareInClusterApp(s.T(), newUserTier("deactivate30"), newUserTier("ai"))
+
+			// when
+			_, err := application.SignupService().Signup(newContext(tc.email, tc.tier))
+
+			// then
+			require.EqualError(s.T(), err, tc.expectedErr)
+			assert.True(s.T(), tc.check(err))
+			userSignups := &toolchainv1alpha1.UserSignupList{}
+			require.NoError(s.T(), fakeClient.List(gocontext.TODO(), userSignups, client.InNamespace(commontest.HostOperatorNs)))
+			assert.Empty(s.T(), userSignups.Items)
+		})
+	}
+}
+
+func (s *TestSignupServiceSuite) TestSignupWithPreferredCluster() {
+	s.ServiceConfiguration(true, "", 5)
+	newContext := func(query string) *gin.Context {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Set(context.UsernameKey, "jsmith")
+		ctx.Set(context.SubKey, "987654321")
+		ctx.Set(context.EmailKey, "jsmith@gmail.com")
+		ctx.Request, _ = http.NewRequest("POST", "/?"+query, bytes.NewBufferString(""))
+		return ctx
+	}
+	toolchainStatus := s.newToolchainStatus(".apps.")
+	for i, member := range toolchainStatus.Status.Members {
+		member.MemberStatus.Conditions = []toolchainv1alpha1.Condition{{Type: toolchainv1alpha1.ConditionReady, Status: apiv1.ConditionTrue}}
+		toolchainStatus.Status.Members[i] = member
+	}
+	objs := []client.Object{
+		toolchainStatus,
+		&toolchainv1alpha1.ToolchainCluster{ObjectMeta: v1.ObjectMeta{
+			Name:      "member-1",
+			Namespace: commontest.HostOperatorNs,
+			Labels:    map[string]string{signup.RegionLabelKey: "us"},
+		}},
+		&toolchainv1alpha1.ToolchainCluster{ObjectMeta: v1.ObjectMeta{
+			Name:      "member-123",
+			Namespace: commontest.HostOperatorNs,
+			Labels:    map[string]string{signup.RegionLabelKey: "emea"},
+		}},
+		spaceprovisionerconfig.NewSpaceProvisionerConfig("member-1", commontest.HostOperatorNs,
+			spaceprovisionerconfig.ReferencingToolchainCluster("member-1"),
+			spaceprovisionerconfig.Enabled(true),
+			spaceprovisionerconfig.WithPlacementRoles(spaceprovisionerconfig.PlacementRole("tenant")),
+			spaceprovisionerconfig.WithReadyConditionValid()),
+		spaceprovisionerconfig.NewSpaceProvisionerConfig("member-123", commontest.HostOperatorNs,
+			spaceprovisionerconfig.ReferencingToolchainCluster("member-123"),
+			spaceprovisionerconfig.Enabled(true),
+			spaceprovisionerconfig.WithPlacementRoles(spaceprovisionerconfig.PlacementRole("tenant")),
+			spaceprovisionerconfig.WithReadyConditionValid()),
+	}
+
+	for name, tc := range map[string]struct {
+		query                 string
+		expectedTargetCluster string
+		expectedAnnotation    string
+	}{
+		"region":  {query: "region=emea", expectedTargetCluster: "member-123", expectedAnnotation: signup.PreferredRegionAnnotationKey},
+		"cluster": {query: "cluster=member-1", expectedTargetCluster: "member-1", expectedAnnotation: signup.PreferredClusterAnnotationKey},
+	} {
+		s.Run(name, func() {
+			// given
+			_, application := testutil.PrepareInClusterApp(s.T(), objs...)
+
+			// when
+			userSignup, err := application.SignupService().Signup(newContext(tc.query))
+
+			// then
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), tc.expectedTargetCluster, userSignup.Spec.TargetCluster)
+			assert.Contains(s.T(), userSignup.Annotations, tc.expectedAnnotation)
+		})
+	}
+
+	s.Run("no preference", func() {
+		// given
+		_, application := testutil.PrepareInClusterApp(s.T(), objs...)
+
+		// when
+		userSignup, err := application.SignupService().Signup(newContext(""))
+
+		// then
+		require.NoError(s.T(), err)
+		assert.Empty(s.T(), userSignup.Spec.TargetCluster)
+		assert.Equal(s.T(), signup.ClusterPreference{}, signup.GetClusterPreference(userSignup))
+	})
+
+	s.Run("unknown region", func() {
+		// given
+		fakeClient, application := testutil.PrepareInClusterApp(s.T(), objs...)
+
+		// when
+		_, err := application.SignupService().Signup(newContext("region=apac"))
+
+		// then
+		require.EqualError(s.T(), err, "unknown region 'apac'")
+		assert.True(s.T(), apierrors.IsBadRequest(err))
+		userSignups := &toolchainv1alpha1.UserSignupList{}
+		require.NoError(s.T(), fakeClient.List(gocontext.TODO(), userSignups, client.InNamespace(commontest.HostOperatorNs)))
+		assert.Empty(s.T(), userSignups.Items)
+	})
+
+	s.Run("reactivation", func() {
+		deactivated := func() *toolchainv1alpha1.UserSignup {
+			userSignup := testusersignup.NewUserSignup(
+				testusersignup.WithEncodedName("jsmith"),
+				testusersignup.WithAnnotation(signup.PreferredRegionAnnotationKey, "emea"),
+				testusersignup.WithTargetCluster("member-1"))
+			states.SetDeactivated(userSignup, true)
+			userSignup.Status.Conditions = fake.Deactivated()
+			return userSignup
+		}
+
+		s.Run("previous preference is honoured", func() {
+			// given
+			_, application := testutil.PrepareInClusterApp(s.T(), append(objs, deactivated())...)
+
+			// when
+			userSignup, err := application.SignupService().Signup(newContext(""))
+
+			// then
+			require.NoError(s.T(), err)
+			assert.False(s.T(), states.Deactivated(userSignup))
+			assert.Equal(s.T(), "member-123", userSignup.Spec.TargetCluster)
+			assert.Equal(s.T(), signup.ClusterPreference{Region: "emea"}, signup.GetClusterPreference(userSignup))
+		})
+
+		s.Run("new preference replaces the previous one", func() {
+			// given
+			_, application := testutil.PrepareInClusterApp(s.T(), append(objs, deactivated())...)
+
+			// when
+			userSignup, err := application.SignupService().Signup(newContext("cluster=member-1"))
+
+			// then
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), "member-1", userSignup.Spec.TargetCluster)
+			assert.Equal(s.T(), signup.ClusterPreference{Cluster: "member-1"}, signup.GetClusterPreference(userSignup))
+		})
+
+		s.Run("previous preference no longer valid", func() {
+			// given
+			_, application := testutil.PrepareInClusterApp(s.T(), deactivated())
+
+			// when
+			userSignup, err := application.SignupService().Signup(newContext(""))
+
+			// then
+			require.NoError(s.T(), err)
+			assert.False(s.T(), states.Deactivated(userSignup))
+			assert.Empty(s.T(), userSignup.Spec.TargetCluster)
+		})
+	})
+}
+
 func (s *TestSignupServiceSuite) TestSignupWithCaptchaEnabled() {
 	commontest.SetEnvVarAndRestore(s.T(), commonconfig.WatchNamespaceEnvVar, commontest.HostOperatorNs)
 
@@ -772,6 +1012,285 @@ func (s *TestSignupServiceSuite) TestGetSignupDeactivated() {
 	require.NoError(s.T(), err)
 }
 
+func (s *TestSignupServiceSuite) TestDeactivateSignup() {
+	s.Run("deactivate signup", func() {
+		// given
+		username, us := s.newUserSignupComplete()
+		us.Annotations[toolchainv1alpha1.UserSignupActivationCounterAnnotationKey] = "2"
+		us.Annotations[toolchainv1alpha1.UserSignupLastTargetClusterAnnotationKey] = "member-1"
+		fakeClient, application := testutil.PrepareInClusterApp(s.T(), us)
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+		// when
+		deactivated, err := application.SignupService().DeactivateSignup(c, username)
+
+		// then
+		require.NoError(s.T(), err)
+		assert.True(s.T(), deactivated)
+		userSignup := &toolchainv1alpha1.UserSignup{}
+		require.NoError(s.T(), fakeClient.Get(gocontext.TODO(), client.ObjectKeyFromObject(us), userSignup))
+		assert.True(s.T(), states.Deactivated(userSignup))
+		assert.False(s.T(), states.ApprovedManually(userSignup))
+		assert.NotEmpty(s.T(), userSignup.Annotations[service.SelfDeactivationTimestampAnnotationKey])
+		// the annotations used on reactivation are retained
+		assert.Equal(s.T(), "2", userSignup.Annotations[toolchainv1alpha1.UserSignupActivationCounterAnnotationKey])
+		assert.Equal(s.T(), "member-1", userSignup.Annotations[toolchainv1alpha1.UserSignupLastTargetClusterAnnotationKey])
+
+		s.Run("deactivating again has no effect", func() {
+			// when
+			deactivated, err := application.SignupService().DeactivateSignup(c, username)
+
+			// then
+			require.NoError(s.T(), err)
+			assert.False(s.T(), deactivated)
+			unchanged := &toolchainv1alpha1.UserSignup{}
+			require.NoError(s.T(), fakeClient.Get(gocontext.TODO(), client.ObjectKeyFromObject(us), unchanged))
+			assert.Equal(s.T(), userSignup.ResourceVersion, unchanged.ResourceVersion)
+		})
+	})
+
+	s.Run("signup already deactivated by the host operator", func() {
+		// given
+		username, us := s.newUserSignupComplete()
+		us.Status.Conditions = fake.Deactivated()
+		fakeClient, application := testutil.PrepareInClusterApp(s.T(), us)
+		fakeClient.MockUpdate = func(_ gocontext.Context, _ client.Object, _ ...client.UpdateOption) error {
+			return errors.New("should not be updated")
+		}
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+		// when
+		deactivated, err := application.SignupService().DeactivateSignup(c, username)
+
+		// then
+		require.NoError(s.T(), err)
+		assert.False(s.T(), deactivated)
+	})
+
+	s.Run("signup not found", func() {
+		// given
+		_, application := testutil.PrepareInClusterApp(s.T())
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+		// when
+		deactivated, err := application.SignupService().DeactivateSignup(c, "does-not-exist")
+
+		// then
+		require.Error(s.T(), err)
+		assert.True(s.T(), apierrors.IsNotFound(err))
+		assert.False(s.T(), deactivated)
+	})
+
+	s.Run("update fails", func() {
+		// given
+		username, us := s.newUserSignupComplete()
+		fakeClient, application := testutil.PrepareInClusterApp(s.T(), us)
+		fakeClient.MockUpdate = func(_ gocontext.Context, _ client.Object, _ ...client.UpdateOption) error {
+			return errors.New("an error occurred")
+		}
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+		// when
+		deactivated, err := application.SignupService().DeactivateSignup(c, username)
+
+		// then
+		require.EqualError(s.T(), err, "an error occurred")
+		assert.False(s.T(), deactivated)
+	})
+}
+
+func (s *TestSignupServiceSuite) TestRequestTrialExtension() {
+	getUserSignup := func(fakeClient client.Client, us *toolchainv1alpha1.UserSignup) *toolchainv1alpha1.UserSignup {
+		userSignup := &toolchainv1alpha1.UserSignup{}
+		require.NoError(s.T(), fakeClient.Get(gocontext.TODO(), client.ObjectKeyFromObject(us), userSignup))
+		return userSignup
+	}
+
+	s.Run("request pending a review", func() {
+		// given
+		username, us := s.newUserSignupComplete()
+		mur := s.newProvisionedMUR("ted")
+		fakeClient, application := testutil.PrepareInClusterApp(s.T(), us, mur, s.newToolchainStatus(".apps."))
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+		// when
+		extension, err := application.SignupService().RequestTrialExtension(c, username, "finishing my project")
+
+		// then
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), signup.TrialExtensionPending, extension.State)
+		assert.Equal(s.T(), "finishing my project", extension.Justification)
+		assert.Equal(s.T(), 1, extension.Remaining)
+		userSignup := getUserSignup(fakeClient, us)
+		assert.Equal(s.T(), signup.TrialExtensionPending, userSignup.Labels[signup.TrialExtensionLabelKey])
+		assert.NotContains(s.T(), userSignup.Annotations, signup.TrialExtensionEndDateAnnotationKey)
+
+		s.Run("the state of the request is reported with the signup", func() {
+			// when
+			response, err := application.SignupService().GetSignup(c, username, true)
+
+			// then
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), extension, response.TrialExtension)
+		})
+
+		s.Run("a second request is rejected while the first one is pending", func() {
+			// when
+			_, err := application.SignupService().RequestTrialExtension(c, username, "please")
+
+			// then
+			e := &errors2.Error{}
+			require.ErrorAs(s.T(), err, &e)
+			assert.Equal(s.T(), http.StatusConflict, e.Code)
+		})
+	})
+
+	s.Run("requests approved automatically", func() {
+		for name, tc := range map[string]struct {
+			settings string
+			modifier testusersignup.Modifier
+			policy   string
+		}{
+			"email domain": {
+				settings: "trialExtensions: {autoApprovedEmailDomains: [example.com, KubeSaw.io]}",
+				modifier: testusersignup.WithEmail("ted@kubesaw.io"),
+				policy:   signup.TrialExtensionPolicyEmailDomain,
+			},
+			"event attendee": {
+				settings: "trialExtensions: {autoApproveEventAttendees: true}",
+				modifier: testusersignup.WithLabel(toolchainv1alpha1.SocialEventUserSignupLabelKey, "summit"),
+				policy:   signup.TrialExtensionPolicySocialEvent,
+			},
+		} {
+			s.Run(name, func() {
+				// given
+				test.SetSettings(s.T(), tc.settings)
+				username, us := s.newUserSignupComplete()
+				tc.modifier(us)
+				userTier := masteruserrecord.DefaultUserTier()
+				fakeClient, application := testutil.PrepareInClusterApp(s.T(), us, s.newProvisionedMUR("ted"), &userTier)
+				c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+				// when
+				extension, err := application.SignupService().RequestTrialExtension(c, username, "finishing my project")
+
+				// then
+				require.NoError(s.T(), err)
+				assert.Equal(s.T(), signup.TrialExtensionApproved, extension.State)
+				assert.Equal(s.T(), 1, extension.Extensions)
+				assert.Equal(s.T(), 0, extension.Remaining)
+				// the trial is extended from the scheduled deactivation
+				expected := us.Status.ScheduledDeactivationTimestamp.Add(30 * 24 * time.Hour).UTC().Format(time.RFC3339)
+				assert.Equal(s.T(), expected, extension.EndDate)
+				assert.Equal(s.T(), tc.policy, getUserSignup(fakeClient, us).Annotations[signup.TrialExtensionDecidedByAnnotationKey])
+				// the deactivation of the user is computed from the provisioned time of their MasterUserRecord
+				mur := &toolchainv1alpha1.MasterUserRecord{}
+				require.NoError(s.T(), fakeClient.Get(gocontext.TODO(), client.ObjectKey{Namespace: commontest.HostOperatorNs, Name: "ted"}, mur))
+				assert.Equal(s.T(), expected, mur.Status.ProvisionedTime.Add(30*24*time.Hour).UTC().Format(time.RFC3339))
+
+				s.Run("the maximum number of extensions is reached", func() {
+					// when
+					_, err := application.SignupService().RequestTrialExtension(c, username, "still finishing my project")
+
+					// then
+					require.EqualError(s.T(), err, "trial extension not allowed: the maximum number of 1 extensions is reached")
+				})
+			})
+		}
+	})
+
+	s.Run("request approved automatically again when the trial could not be extended", func() {
+		// given
+		test.SetSettings(s.T(), "trialExtensions: {autoApprovedEmailDomains: [kubesaw.io]}")
+		username, us := s.newUserSignupComplete()
+		testusersignup.WithEmail("ted@kubesaw.io")(us)
+		userTier := masteruserrecord.DefaultUserTier()
+		fakeClient, application := testutil.PrepareInClusterApp(s.T(), us, s.newProvisionedMUR("ted"), &userTier)
+		fakeClient.MockStatusUpdate = func(_ gocontext.Context, _ client.Object, _ ...client.SubResourceUpdateOption) error {
+			return errors.New("an error occurred")
+		}
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		_, err := application.SignupService().RequestTrialExtension(c, username, "finishing my project")
+		require.EqualError(s.T(), err, "an error occurred")
+		assert.True(s.T(), signup.TrialExtensionUnapplied(getUserSignup(fakeClient, us)))
+		fakeClient.MockStatusUpdate = nil
+
+		// when
+		extension, err := application.SignupService().RequestTrialExtension(c, username, "finishing my project")
+
+		// then
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), signup.TrialExtensionApproved, extension.State)
+		// the same extension is applied, instead of granting another one
+		assert.Equal(s.T(), 1, extension.Extensions)
+		assert.False(s.T(), signup.TrialExtensionUnapplied(getUserSignup(fakeClient, us)))
+		mur := &toolchainv1alpha1.MasterUserRecord{}
+		require.NoError(s.T(), fakeClient.Get(gocontext.TODO(), client.ObjectKey{Namespace: commontest.HostOperatorNs, Name: "ted"}, mur))
+		assert.Equal(s.T(), extension.EndDate, mur.Status.ProvisionedTime.Add(30*24*time.Hour).UTC().Format(time.RFC3339))
+	})
+
+	s.Run("request not approved automatically when the policies do not apply", func() {
+		// given
+		test.SetSettings(s.T(), "trialExtensions: {autoApprovedEmailDomains: [kubesaw.io]}")
+		username, us := s.newUserSignupComplete()
+		testusersignup.WithEmail("ted@notkubesaw.io")(us)
+		testusersignup.WithLabel(toolchainv1alpha1.SocialEventUserSignupLabelKey, "summit")(us)
+		_, application := testutil.PrepareInClusterApp(s.T(), us)
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+		// when
+		extension, err := application.SignupService().RequestTrialExtension(c, username, "finishing my project")
+
+		// then
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), signup.TrialExtensionPending, extension.State)
+	})
+
+	s.Run("signup deactivated", func() {
+		// given
+		username, us := s.newUserSignupComplete()
+		states.SetDeactivated(us, true)
+		_, application := testutil.PrepareInClusterApp(s.T(), us)
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+		// when
+		_, err := application.SignupService().RequestTrialExtension(c, username, "finishing my project")
+
+		// then
+		require.EqualError(s.T(), err, "trial extension not allowed: the signup is not active")
+	})
+
+	s.Run("signup not found", func() {
+		// given
+		_, application := testutil.PrepareInClusterApp(s.T())
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+		// when
+		_, err := application.SignupService().RequestTrialExtension(c, "does-not-exist", "finishing my project")
+
+		// then
+		e := &errors2.Error{}
+		require.ErrorAs(s.T(), err, &e)
+		assert.Equal(s.T(), http.StatusNotFound, e.Code)
+	})
+
+	s.Run("update fails", func() {
+		// given
+		username, us := s.newUserSignupComplete()
+		fakeClient, application := testutil.PrepareInClusterApp(s.T(), us)
+		fakeClient.MockUpdate = func(_ gocontext.Context, _ client.Object, _ ...client.UpdateOption) error {
+			return errors.New("an error occurred")
+		}
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+		// when
+		_, err := application.SignupService().RequestTrialExtension(c, username, "finishing my project")
+
+		// then
+		require.EqualError(s.T(), err, "an error occurred")
+	})
+}
+
 func (s *TestSignupServiceSuite) TestGetSignupStatusOK() {
 	// given
 	for _, appsSubDomain := range []string{".apps.", ".apps-"} {
@@ -816,6 +1335,7 @@ func (s *TestSignupServiceSuite) TestGetSignupStatusOK() {
 			assert.Equal(s.T(), "https://proxy-url.com", response.ProxyURL)
 			assert.Equal(s.T(), "ted-dev", response.DefaultUserNamespace)
 			assert.Equal(s.T(), fmt.Sprintf("https://rhods-dashboard-redhat-ods-applications%smember-123.com", appsSubDomain), response.RHODSMemberURL)
+			assert.Nil(s.T(), response.TrialExtension)
 		})
 	}
 }
@@ -856,6 +1376,42 @@ func (s *TestSignupServiceSuite) newToolchainStatus(appsSubDomain string) *toolc
 	return toolchainStatus
 }
 
+func (s *TestSignupServiceSuite) TestGetSignupWithChosenTierDoesNotUpdateMUR() {
+	// given
+	username, us := s.newUserSignupComplete()
+	testusersignup.WithAnnotation(signup.UserTierAnnotationKey, "ai")(us)
+	mur := s.newProvisionedMUR("ted")
+	fakeClient, application := testutil.PrepareInClusterApp(s.T(), us, mur, s.newToolchainStatus(".apps."))
+	fakeClient.MockUpdate = func(_ gocontext.Context, _ client.Object, _ ...client.UpdateOption) error {
+		return errors.New("unexpected update")
+	}
+
+	s.Run("proxy", func() {
+		// when
+		// the proxy gets the signup without any request context
+		response, err := application.SignupService().GetSignup(nil, username, false) // nolint:staticcheck
+
+		// then
+		require.NoError(s.T(), err)
+		assert.True(s.T(), response.Status.Ready)
+		updated := &toolchainv1alpha1.MasterUserRecord{}
+		require.NoError(s.T(), fakeClient.Get(gocontext.TODO(), client.ObjectKeyFromObject(mur), updated))
+		assert.Equal(s.T(), mur.Spec.TierName, updated.Spec.TierName)
+	})
+
+	s.Run("registration service", func() {
+		// given
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+		// when
+		response, err := application.SignupService().GetSignup(c, username, true)
+
+		// then
+		require.NoError(s.T(), err)
+		assert.True(s.T(), response.Status.Ready)
+	})
+}
+
 func (s *TestSignupServiceSuite) TestGetSignupStatusFailGetToolchainStatus() {
 	// given
 	s.ServiceConfiguration(true, "", 5)
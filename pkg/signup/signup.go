@@ -53,6 +53,8 @@ type Signup struct {
 	StartDate string `json:"startDate,omitempty"`
 	// End Date is the date that the user's current subscription will end, in RFC3339 format
 	EndDate string `json:"endDate,omitempty"`
+	// TrialExtension is the state of the trial extension requests of the user, if the user ever requested one
+	TrialExtension *TrialExtension `json:"trialExtension,omitempty"`
 }
 
 // Status represents UserSignup resource status
@@ -1,7 +1,9 @@
 package signup
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
 	"time"
 
@@ -12,38 +14,141 @@ import (
 	"github.com/codeready-toolchain/toolchain-common/pkg/states"
 	"github.com/gin-gonic/gin"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-// GetAndValidateSocialEvent returns a SocialEvent with the given name.
-// If the event is already full, not yet started, already finished, or not found then it returns error
+// ActivationCodeStatus is the status of the SocialEvent of an activation code
+type ActivationCodeStatus string
+
+const (
+	// ActivationCodeNotStarted is the status of an event which has not started yet
+	ActivationCodeNotStarted ActivationCodeStatus = "NotStarted"
+	// ActivationCodeRunning is the status of an event which is running and has room for more attendees
+	ActivationCodeRunning ActivationCodeStatus = "Running"
+	// ActivationCodeFull is the status of an event which has reached its maximum number of attendees
+	ActivationCodeFull ActivationCodeStatus = "Full"
+	// ActivationCodeExpired is the status of an event which is already past
+	ActivationCodeExpired ActivationCodeStatus = "Expired"
+	// ActivationCodeUsed is the status of a personal code which was already used
+	ActivationCodeUsed ActivationCodeStatus = "Used"
+)
+
+// ActivationCode is the result of the check of an activation code
+type ActivationCode struct {
+	// Code is the activation code
+	Code string `json:"code"`
+	// Exists is true if there is a SocialEvent for the activation code
+	Exists bool `json:"exists"`
+	// Status is the status of the event. Only the codes of running events can be activated.
+	Status ActivationCodeStatus `json:"status,omitempty"`
+	// StartTime is the time from which the code can be activated
+	StartTime *time.Time `json:"startTime,omitempty"`
+	// EndTime is the time after which the code can no longer be activated
+	EndTime *time.Time `json:"endTime,omitempty"`
+	// VerificationRequired is true if the users who activate the code must also complete the phone verification
+	VerificationRequired bool `json:"verificationRequired"`
+	// TargetCluster is the cluster in which the users who activate the code are provisioned, if any
+	TargetCluster string `json:"targetCluster,omitempty"`
+	// PersonalCodeRequired is true if the code is the name of an event which can only be joined with personal codes
+	PersonalCodeRequired bool `json:"personalCodeRequired,omitempty"`
+}
+
+// CheckActivationCode returns the status of the SocialEvent of the given activation code (either the name of the event
+// or a personal code of the event), without activating it
+func CheckActivationCode(ctx *gin.Context, cl namespaced.Client, code string) (*ActivationCode, error) {
+	event, err := lookupSocialEvent(ctx, cl, code)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return &ActivationCode{
+				Code:   code,
+				Exists: false,
+			}, nil
+		}
+		return nil, crterrors.NewInternalError(err, fmt.Sprintf("error retrieving event '%s'", code))
+	}
+	startTime := event.Spec.StartTime.UTC()
+	endTime := event.Spec.EndTime.UTC()
+	activationCode := &ActivationCode{
+		Code:                 code,
+		Exists:               true,
+		Status:               socialEventStatus(event, time.Now()),
+		StartTime:            &startTime,
+		EndTime:              &endTime,
+		VerificationRequired: event.Spec.VerificationRequired,
+		TargetCluster:        event.Spec.TargetCluster,
+	}
+	if _, found := event.Annotations[PersonalCodesAnnotationKey]; found {
+		if code == event.Name {
+			activationCode.PersonalCodeRequired = true
+			return activationCode, nil
+		}
+		codes, codeHash, err := checkPersonalCode(event, code)
+		if err != nil {
+			e := &crterrors.Error{}
+			if errors.As(err, &e) && e.Code == http.StatusForbidden {
+				return &ActivationCode{
+					Code:   code,
+					Exists: false,
+				}, nil
+			}
+			return nil, err
+		}
+		if codes[codeHash] != "" {
+			activationCode.Status = ActivationCodeUsed
+		}
+	}
+	return activationCode, nil
+}
+
+// GetAndValidateSocialEvent returns the SocialEvent of the given activation code, which is either the name of the event
+// or a personal code of the event (see ConsumePersonalCode).
+// If the event is already full, not yet started, already finished, or not found, or if the personal code is unknown,
+// then it returns error
 func GetAndValidateSocialEvent(ctx *gin.Context, cl namespaced.Client, code string) (*toolchainv1alpha1.SocialEvent, error) {
 	// look-up the SocialEvent
-	event := &toolchainv1alpha1.SocialEvent{}
-	if err := cl.Get(ctx, cl.NamespacedName(code), event); err != nil {
+	event, err := lookupSocialEvent(ctx, cl, code)
+	if err != nil {
 		if apierrors.IsNotFound(err) {
 			// a SocialEvent was not found for the provided code
-			return nil, crterrors.NewForbiddenError("invalid code", "the provided code is invalid")
+			return nil, crterrors.WithErrorCode(crterrors.NewForbiddenError("invalid code", "the provided code is invalid"), crterrors.ActivationCodeInvalid)
 		}
 		return nil, crterrors.NewInternalError(err, fmt.Sprintf("error retrieving event '%s'", code))
 	}
 	// if there is room for the user and if the "time window" to signup is valid
-	now := metav1.NewTime(time.Now())
 	log.Infof(ctx, "verifying activation code '%s': event.Status.ActivationCount=%s, event.Spec.MaxAttendees=%s, event.Spec.StartTime=%s, event.Spec.EndTime=%s",
 		code, strconv.Itoa(event.Status.ActivationCount), strconv.Itoa(event.Spec.MaxAttendees), event.Spec.StartTime.Format("2006-01-02:03:04:05"), event.Spec.EndTime.Format("2006-01-02:03:04:05"))
 
-	if event.Status.ActivationCount >= event.Spec.MaxAttendees {
-		return nil, crterrors.NewForbiddenError("invalid code", "the event is full")
-	} else if event.Spec.StartTime.After(now.Time) {
+	switch socialEventStatus(event, time.Now()) {
+	case ActivationCodeFull:
+		return nil, crterrors.WithErrorCode(crterrors.NewForbiddenError("invalid code", "the event is full"), crterrors.ActivationCodeEventFull)
+	case ActivationCodeNotStarted:
 		log.Infof(ctx, "the event with code '%s' has not started yet", code)
-		return nil, crterrors.NewForbiddenError("invalid code", "the provided code is not valid yet")
-	} else if event.Spec.EndTime.Before(&now) {
+		return nil, crterrors.WithErrorCode(crterrors.NewForbiddenError("invalid code", "the provided code is not valid yet"), crterrors.ActivationCodeNotYetValid)
+	case ActivationCodeExpired:
 		log.Infof(ctx, "the event with code '%s' is already past", code)
-		return nil, crterrors.NewForbiddenError("invalid code", "the provided code has expired")
+		return nil, crterrors.WithErrorCode(crterrors.NewForbiddenError("invalid code", "the provided code has expired"), crterrors.ActivationCodeExpired)
+	}
+	if _, found := event.Annotations[PersonalCodesAnnotationKey]; found {
+		if _, _, err := checkPersonalCode(event, code); err != nil {
+			return nil, err
+		}
 	}
 	return event, nil
 }
 
+// socialEventStatus returns the status of the given event at the given time.
+// An event which is full is reported as such, even if it is not started yet or already past.
+func socialEventStatus(event *toolchainv1alpha1.SocialEvent, now time.Time) ActivationCodeStatus {
+	switch {
+	case event.Status.ActivationCount >= event.Spec.MaxAttendees:
+		return ActivationCodeFull
+	case event.Spec.StartTime.After(now):
+		return ActivationCodeNotStarted
+	case event.Spec.EndTime.Time.Before(now):
+		return ActivationCodeExpired
+	}
+	return ActivationCodeRunning
+}
+
 // UpdateUserSignupWithSocialEvent updates fields in the userSignup with values from the given SocialEvent
 func UpdateUserSignupWithSocialEvent(event *toolchainv1alpha1.SocialEvent, userSignup *toolchainv1alpha1.UserSignup) {
 	if !event.Spec.VerificationRequired {
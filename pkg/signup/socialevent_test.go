@@ -102,6 +102,72 @@ func TestGetSocialEvent(t *testing.T) {
 	})
 }
 
+func TestCheckActivationCode(t *testing.T) {
+	// given
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	now := time.Now()
+
+	tests := map[string]struct {
+		eventOptions   []testsocialevent.Option
+		expectedStatus ActivationCodeStatus
+	}{
+		"running": {
+			eventOptions:   []testsocialevent.Option{testsocialevent.WithActivationCount(9)},
+			expectedStatus: ActivationCodeRunning,
+		},
+		"not started": {
+			eventOptions:   []testsocialevent.Option{testsocialevent.WithStartTime(now.Add(time.Hour))},
+			expectedStatus: ActivationCodeNotStarted,
+		},
+		"full": {
+			eventOptions:   []testsocialevent.Option{testsocialevent.WithActivationCount(10)},
+			expectedStatus: ActivationCodeFull,
+		},
+		"expired": {
+			eventOptions:   []testsocialevent.Option{testsocialevent.WithEndTime(now.Add(-time.Hour))},
+			expectedStatus: ActivationCodeExpired,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			event := testsocialevent.NewSocialEvent(commontest.HostOperatorNs, "event1", append(tc.eventOptions,
+				testsocialevent.WithTargetCluster("member"),
+				func(event *toolchainv1alpha1.SocialEvent) {
+					event.Spec.VerificationRequired = true
+				})...)
+			fakeClient := commontest.NewFakeClient(t, event)
+			nsdClient := namespaced.NewClient(fakeClient, commontest.HostOperatorNs)
+
+			// when
+			code, err := CheckActivationCode(ctx, nsdClient, "event1")
+
+			// then
+			require.NoError(t, err)
+			assert.True(t, code.Exists)
+			assert.Equal(t, tc.expectedStatus, code.Status)
+			assert.WithinDuration(t, event.Spec.StartTime.Time, *code.StartTime, time.Second)
+			assert.WithinDuration(t, event.Spec.EndTime.Time, *code.EndTime, time.Second)
+			assert.True(t, code.VerificationRequired)
+			assert.Equal(t, "member", code.TargetCluster)
+			// no side effect on the event
+			actual := &toolchainv1alpha1.SocialEvent{}
+			require.NoError(t, fakeClient.Get(ctx, nsdClient.NamespacedName("event1"), actual))
+			assert.Equal(t, event.Status.ActivationCount, actual.Status.ActivationCount)
+		})
+	}
+
+	t.Run("unknown code", func(t *testing.T) {
+		nsdClient := namespaced.NewClient(commontest.NewFakeClient(t), commontest.HostOperatorNs)
+
+		// when
+		code, err := CheckActivationCode(ctx, nsdClient, "unknown")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, &ActivationCode{Code: "unknown", Exists: false}, code)
+	})
+}
+
 func TestUpdateUserSignupWithSocialEvent(t *testing.T) {
 	tests := map[string]struct {
 		eventOptions            []testsocialevent.Option
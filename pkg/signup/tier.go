@@ -0,0 +1,150 @@
+package signup
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+)
+
+// UserTierAnnotationKey is the annotation of a UserSignup holding the name of the UserTier chosen by the user
+// when they signed up, to be assigned instead of the default tier (see AssignTier).
+// The same annotation is set on the MasterUserRecord once the tier is assigned.
+const UserTierAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "user-tier"
+
+// SelectTier records the given tier on the given UserSignup, after checking that the tier is selectable, that the
+// user is eligible to it and that the UserTier exists.
+// Returns a BadRequest error if the tier cannot be selected or does not exist, or a Forbidden error if the user is
+// not eligible to it.
+func SelectTier(ctx *gin.Context, cl namespaced.Client, userSignup *toolchainv1alpha1.UserSignup, name string) error {
+	tiers := configuration.GetRegistrationServiceConfig().Tiers().Selectable()
+	i := slices.IndexFunc(tiers, func(tier configuration.SelectableTier) bool {
+		return tier.Name == name
+	})
+	if i < 0 {
+		return apierrors.NewBadRequest(fmt.Sprintf("tier '%s' cannot be selected", name))
+	}
+	if !IsEligibleToTier(tiers[i], userSignup) {
+		return apierrors.NewForbidden(schema.GroupResource{}, "", fmt.Errorf("user is not eligible to tier '%s'", name))
+	}
+	if err := cl.Get(ctx, cl.NamespacedName(name), &toolchainv1alpha1.UserTier{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return apierrors.NewBadRequest(fmt.Sprintf("tier '%s' does not exist", name))
+		}
+		return err
+	}
+	if userSignup.Annotations == nil {
+		userSignup.Annotations = map[string]string{}
+	}
+	userSignup.Annotations[UserTierAnnotationKey] = name
+	return nil
+}
+
+// AssignTier assigns the UserTier chosen by the user of the UserSignup with the given name to their MasterUserRecord,
+// which the host operator creates with the default tier once the UserSignup is approved. The tier is only assigned
+// once, as recorded by the UserTierAnnotationKey annotation of the MasterUserRecord, so that a tier assigned later on by
+// the administrators is not overridden. This is a no-op if the user did not choose a tier.
+func AssignTier(ctx context.Context, cl namespaced.Client, userSignupName, murName string) error {
+	userSignup := &toolchainv1alpha1.UserSignup{}
+	if err := cl.Get(ctx, cl.NamespacedName(userSignupName), userSignup); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	name := userSignup.Annotations[UserTierAnnotationKey]
+	if name == "" {
+		return nil
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		// the MasterUserRecord is read without the cache, which may hold a version older than the one in the event
+		mur := &toolchainv1alpha1.MasterUserRecord{}
+		if err := cl.APIReader.Get(ctx, cl.NamespacedName(murName), mur); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		if _, assigned := mur.Annotations[UserTierAnnotationKey]; assigned {
+			return nil
+		}
+		if mur.Annotations == nil {
+			mur.Annotations = map[string]string{}
+		}
+		mur.Annotations[UserTierAnnotationKey] = name
+		mur.Spec.TierName = name
+		return cl.Update(ctx, mur)
+	})
+}
+
+// TierAssigner assigns the UserTier chosen by the users to their MasterUserRecord as soon as the host operator created
+// it (see AssignTier), independently of the requests of the users
+type TierAssigner struct {
+	ctx context.Context
+	cl  namespaced.Client
+}
+
+// NewTierAssigner returns a new TierAssigner which updates the MasterUserRecords with the given client, until the
+// given context is done
+func NewTierAssigner(ctx context.Context, cl namespaced.Client) *TierAssigner {
+	return &TierAssigner{
+		ctx: ctx,
+		cl:  cl,
+	}
+}
+
+// EventHandler returns the handler to register in the informer of the MasterUserRecords. A failed assignment is
+// retried on the next change of the MasterUserRecord.
+func (a *TierAssigner) EventHandler() toolscache.ResourceEventHandler {
+	return toolscache.ResourceEventHandlerFuncs{
+		AddFunc: a.assign,
+		UpdateFunc: func(_, newObj interface{}) {
+			a.assign(newObj)
+		},
+	}
+}
+
+func (a *TierAssigner) assign(obj interface{}) {
+	mur, ok := obj.(*toolchainv1alpha1.MasterUserRecord)
+	if !ok {
+		return
+	}
+	if _, assigned := mur.Annotations[UserTierAnnotationKey]; assigned {
+		return
+	}
+	owner := mur.Labels[toolchainv1alpha1.MasterUserRecordOwnerLabelKey]
+	if owner == "" {
+		return
+	}
+	if err := AssignTier(a.ctx, a.cl, owner, mur.Name); err != nil {
+		log.Error(nil, err, fmt.Sprintf("unable to assign the tier chosen by the user to the MasterUserRecord %s", mur.Name))
+	}
+}
+
+// IsEligibleToTier returns true if the user of the given UserSignup can choose the given tier: either the tier is not
+// restricted, or the email address of the user is in one of its domains, or the user joined one of its SocialEvents
+func IsEligibleToTier(tier configuration.SelectableTier, userSignup *toolchainv1alpha1.UserSignup) bool {
+	if len(tier.EmailDomains) == 0 && len(tier.SocialEvents) == 0 {
+		return true
+	}
+	email := userSignup.Spec.IdentityClaims.Email
+	if i := strings.LastIndex(email, "@"); i >= 0 {
+		for _, domain := range tier.EmailDomains {
+			if strings.EqualFold(email[i+1:], domain) {
+				return true
+			}
+		}
+	}
+	event := userSignup.Labels[toolchainv1alpha1.SocialEventUserSignupLabelKey]
+	return event != "" && slices.Contains(tier.SocialEvents, event)
+}
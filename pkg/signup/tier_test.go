@@ -0,0 +1,214 @@
+package signup
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test/masteruserrecord"
+	testusersignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestIsEligibleToTier(t *testing.T) {
+	restricted := configuration.SelectableTier{
+		Name:         "ai",
+		EmailDomains: []string{"kubesaw.io"},
+		SocialEvents: []string{"summit"},
+	}
+
+	for name, tc := range map[string]struct {
+		tier      configuration.SelectableTier
+		modifiers []testusersignup.Modifier
+		expected  bool
+	}{
+		"unrestricted tier": {
+			tier:      configuration.SelectableTier{Name: "deactivate30"},
+			modifiers: []testusersignup.Modifier{testusersignup.WithEmail("ted@gmail.com")},
+			expected:  true,
+		},
+		"email domain": {
+			tier:      restricted,
+			modifiers: []testusersignup.Modifier{testusersignup.WithEmail("ted@KubeSaw.io")},
+			expected:  true,
+		},
+		"social event": {
+			tier: restricted,
+			modifiers: []testusersignup.Modifier{
+				testusersignup.WithEmail("ted@gmail.com"),
+				testusersignup.WithLabel(toolchainv1alpha1.SocialEventUserSignupLabelKey, "summit"),
+			},
+			expected: true,
+		},
+		"other email domain": {
+			tier:      restricted,
+			modifiers: []testusersignup.Modifier{testusersignup.WithEmail("ted@notkubesaw.io")},
+			expected:  false,
+		},
+		"other social event": {
+			tier: restricted,
+			modifiers: []testusersignup.Modifier{
+				testusersignup.WithEmail("ted@gmail.com"),
+				testusersignup.WithLabel(toolchainv1alpha1.SocialEventUserSignupLabelKey, "workshop"),
+			},
+			expected: false,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// given
+			userSignup := testusersignup.NewUserSignup(tc.modifiers...)
+
+			// when
+			eligible := IsEligibleToTier(tc.tier, userSignup)
+
+			// then
+			assert.Equal(t, tc.expected, eligible)
+		})
+	}
+}
+
+func TestAssignTier(t *testing.T) {
+	// given
+	getMUR := func(t *testing.T, cl client.Client) *toolchainv1alpha1.MasterUserRecord {
+		mur := &toolchainv1alpha1.MasterUserRecord{}
+		require.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Namespace: commontest.HostOperatorNs, Name: "johnny"}, mur))
+		return mur
+	}
+
+	t.Run("the chosen tier is assigned", func(t *testing.T) {
+		// given
+		fakeClient := commontest.NewFakeClient(t, masteruserrecord.NewMasterUserRecord(t, "johnny"),
+			testusersignup.NewUserSignup(testusersignup.WithName("johnny"), testusersignup.WithAnnotation(UserTierAnnotationKey, "ai")))
+		nsdClient := namespaced.NewClient(fakeClient, commontest.HostOperatorNs)
+
+		// when
+		err := AssignTier(context.TODO(), nsdClient, "johnny", "johnny")
+
+		// then
+		require.NoError(t, err)
+		mur := getMUR(t, fakeClient)
+		assert.Equal(t, "ai", mur.Spec.TierName)
+		assert.Equal(t, "ai", mur.Annotations[UserTierAnnotationKey])
+
+		t.Run("the tier is only assigned once", func(t *testing.T) {
+			// given
+			mur.Spec.TierName = "deactivate80" // promoted by an administrator
+			require.NoError(t, fakeClient.Update(context.TODO(), mur))
+
+			// when
+			err := AssignTier(context.TODO(), nsdClient, "johnny", "johnny")
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "deactivate80", getMUR(t, fakeClient).Spec.TierName)
+		})
+	})
+
+	t.Run("no chosen tier", func(t *testing.T) {
+		// given
+		fakeClient := commontest.NewFakeClient(t, masteruserrecord.NewMasterUserRecord(t, "johnny"),
+			testusersignup.NewUserSignup(testusersignup.WithName("johnny")))
+		fakeClient.MockUpdate = func(_ context.Context, _ client.Object, _ ...client.UpdateOption) error {
+			return errors.New("unexpected update")
+		}
+
+		// when
+		err := AssignTier(context.TODO(), namespaced.NewClient(fakeClient, commontest.HostOperatorNs), "johnny", "johnny")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "deactivate30", getMUR(t, fakeClient).Spec.TierName)
+	})
+
+	t.Run("no signup", func(t *testing.T) {
+		// given
+		fakeClient := commontest.NewFakeClient(t, masteruserrecord.NewMasterUserRecord(t, "johnny"))
+
+		// when
+		err := AssignTier(context.TODO(), namespaced.NewClient(fakeClient, commontest.HostOperatorNs), "johnny", "johnny")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "deactivate30", getMUR(t, fakeClient).Spec.TierName)
+	})
+
+	t.Run("update fails", func(t *testing.T) {
+		// given
+		fakeClient := commontest.NewFakeClient(t, masteruserrecord.NewMasterUserRecord(t, "johnny"),
+			testusersignup.NewUserSignup(testusersignup.WithName("johnny"), testusersignup.WithAnnotation(UserTierAnnotationKey, "ai")))
+		fakeClient.MockUpdate = func(_ context.Context, _ client.Object, _ ...client.UpdateOption) error {
+			return errors.New("an error occurred")
+		}
+
+		// when
+		err := AssignTier(context.TODO(), namespaced.NewClient(fakeClient, commontest.HostOperatorNs), "johnny", "johnny")
+
+		// then
+		require.EqualError(t, err, "an error occurred")
+	})
+}
+
+func TestTierAssigner(t *testing.T) {
+	// given
+	newMUR := func(t *testing.T) *toolchainv1alpha1.MasterUserRecord {
+		return masteruserrecord.NewMasterUserRecord(t, "johnny", masteruserrecord.WithOwnerLabel("johnny"))
+	}
+	getMUR := func(t *testing.T, cl client.Client) *toolchainv1alpha1.MasterUserRecord {
+		mur := &toolchainv1alpha1.MasterUserRecord{}
+		require.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Namespace: commontest.HostOperatorNs, Name: "johnny"}, mur))
+		return mur
+	}
+
+	t.Run("the chosen tier is assigned when the MasterUserRecord is created", func(t *testing.T) {
+		// given
+		mur := newMUR(t)
+		fakeClient := commontest.NewFakeClient(t, mur,
+			testusersignup.NewUserSignup(testusersignup.WithName("johnny"), testusersignup.WithAnnotation(UserTierAnnotationKey, "ai")))
+		handler := NewTierAssigner(context.TODO(), namespaced.NewClient(fakeClient, commontest.HostOperatorNs)).EventHandler()
+
+		// when
+		handler.OnAdd(mur, false)
+
+		// then
+		assert.Equal(t, "ai", getMUR(t, fakeClient).Spec.TierName)
+	})
+
+	t.Run("the chosen tier is assigned when the MasterUserRecord is updated", func(t *testing.T) {
+		// given
+		mur := newMUR(t)
+		fakeClient := commontest.NewFakeClient(t, mur,
+			testusersignup.NewUserSignup(testusersignup.WithName("johnny"), testusersignup.WithAnnotation(UserTierAnnotationKey, "ai")))
+		handler := NewTierAssigner(context.TODO(), namespaced.NewClient(fakeClient, commontest.HostOperatorNs)).EventHandler()
+
+		// when
+		handler.OnUpdate(mur, mur)
+
+		// then
+		assert.Equal(t, "ai", getMUR(t, fakeClient).Spec.TierName)
+	})
+
+	t.Run("assigned MasterUserRecord is ignored", func(t *testing.T) {
+		// given
+		mur := newMUR(t)
+		mur.Annotations = map[string]string{UserTierAnnotationKey: "ai"}
+		fakeClient := commontest.NewFakeClient(t, mur)
+		reads := 0
+		fakeClient.MockGet = func(_ context.Context, _ client.ObjectKey, _ client.Object, _ ...client.GetOption) error {
+			reads++
+			return errors.New("unexpected get")
+		}
+		handler := NewTierAssigner(context.TODO(), namespaced.NewClient(fakeClient, commontest.HostOperatorNs)).EventHandler()
+
+		// when
+		handler.OnUpdate(mur, mur)
+
+		// then
+		assert.Zero(t, reads)
+	})
+}
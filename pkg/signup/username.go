@@ -0,0 +1,86 @@
+package signup
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/username"
+	signupcommon "github.com/codeready-toolchain/toolchain-common/pkg/usersignup"
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// maxSuggestionAttempts is the maximum number of alternative usernames checked for suggestions,
+// which is also the number of attempts made by the host operator to find a vacant compliant username
+const maxSuggestionAttempts = 100
+
+var nonAlphanumericRegexp = regexp.MustCompile("[^A-Za-z0-9]")
+
+// IsCRTAdmin returns true if the given username is reserved to the administrators, in which case the user is not allowed to sign up
+func IsCRTAdmin(username string) bool {
+	newUsername := nonAlphanumericRegexp.ReplaceAllString(strings.Split(username, "@")[0], "-")
+	return strings.HasSuffix(newUsername, "crtadmin")
+}
+
+// CheckUsernameAvailability checks if the compliant username derived from the given username by the host operator is available,
+// by applying the same transformation rules. If the compliant username is already taken, then up to `maxSuggestions` available
+// alternatives are suggested.
+func CheckUsernameAvailability(ctx *gin.Context, cl namespaced.Client, name string, maxSuggestions int) (*username.Availability, error) {
+	availability := &username.Availability{
+		Name: name,
+	}
+	if IsCRTAdmin(name) {
+		availability.Reason = username.ReasonForbidden
+		return availability, nil
+	}
+
+	cfg := configuration.GetRegistrationServiceConfig().Users()
+	compliantUsername := signupcommon.TransformUsername(name, cfg.ForbiddenUsernamePrefixes(), cfg.ForbiddenUsernameSuffixes())
+	availability.CompliantUsername = compliantUsername
+	taken, err := isUsernameTaken(ctx, cl, compliantUsername)
+	if err != nil {
+		return nil, err
+	}
+	if !taken {
+		availability.Available = true
+		return availability, nil
+	}
+
+	availability.Reason = username.ReasonTaken
+	for i := 2; i <= maxSuggestionAttempts && len(availability.Suggestions) < maxSuggestions; i++ {
+		suggestion := alternativeUsername(compliantUsername, i)
+		taken, err := isUsernameTaken(ctx, cl, suggestion)
+		if err != nil {
+			return nil, err
+		}
+		if !taken {
+			availability.Suggestions = append(availability.Suggestions, suggestion)
+		}
+	}
+	return availability, nil
+}
+
+// alternativeUsername returns the given compliant username with the given numerical suffix, in the same form as
+// the alternatives tried by the host operator when the compliant username is already taken
+func alternativeUsername(compliantUsername string, i int) string {
+	suffix := fmt.Sprintf("-%d", i)
+	if len(compliantUsername)+len(suffix) > signupcommon.MaxLength {
+		compliantUsername = strings.TrimSuffix(compliantUsername[:signupcommon.MaxLength-len(suffix)], "-")
+	}
+	return compliantUsername + suffix
+}
+
+func isUsernameTaken(ctx *gin.Context, cl namespaced.Client, compliantUsername string) (bool, error) {
+	mur := &toolchainv1alpha1.MasterUserRecord{}
+	if err := cl.Get(ctx, cl.NamespacedName(compliantUsername), mur); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,127 @@
+package signup
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	"github.com/codeready-toolchain/registration-service/pkg/log"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/username"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestIsCRTAdmin(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"crtadmin":            true,
+		"johnny-crtadmin":     true,
+		"johnny.crtadmin@foo": true,
+		"johnny":              false,
+		"crtadmin-johnny":     false,
+		"johnny@crtadmin":     false,
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, expected, IsCRTAdmin(name))
+		})
+	}
+}
+
+func TestCheckUsernameAvailability(t *testing.T) {
+	// given
+	log.Init("username-testing")
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	newMUR := func(name string) *toolchainv1alpha1.MasterUserRecord {
+		return &toolchainv1alpha1.MasterUserRecord{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: configuration.Namespace()},
+		}
+	}
+	nsdClient := namespaced.NewClient(commontest.NewFakeClient(t,
+		newMUR("johnny"),
+		newMUR("johnny-2"),
+		newMUR("johnny-4"),
+		newMUR("crt-openshift-johnny"),
+		newMUR("abcdefghijklmnopqrst"),
+	), configuration.Namespace())
+
+	t.Run("available", func(t *testing.T) {
+		// when
+		availability, err := CheckUsernameAvailability(ctx, nsdClient, "jane.doe@kubesaw.io", 3)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, &username.Availability{
+			Name:              "jane.doe@kubesaw.io",
+			CompliantUsername: "jane-doe",
+			Available:         true,
+		}, availability)
+	})
+
+	t.Run("taken", func(t *testing.T) {
+		// when
+		availability, err := CheckUsernameAvailability(ctx, nsdClient, "johnny", 3)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, &username.Availability{
+			Name:              "johnny",
+			CompliantUsername: "johnny",
+			Reason:            username.ReasonTaken,
+			Suggestions:       []string{"johnny-3", "johnny-5", "johnny-6"},
+		}, availability)
+	})
+
+	t.Run("taken with forbidden prefix", func(t *testing.T) {
+		// when
+		availability, err := CheckUsernameAvailability(ctx, nsdClient, "openshift-johnny", 1)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "crt-openshift-johnny", availability.CompliantUsername)
+		assert.False(t, availability.Available)
+		assert.Equal(t, []string{"crt-openshift-john-2"}, availability.Suggestions)
+	})
+
+	t.Run("suggestions of a long username are truncated", func(t *testing.T) {
+		// when
+		availability, err := CheckUsernameAvailability(ctx, nsdClient, "abcdefghijklmnopqrstuvwxyz", 2)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "abcdefghijklmnopqrst", availability.CompliantUsername)
+		assert.Equal(t, []string{"abcdefghijklmnopqr-2", "abcdefghijklmnopqr-3"}, availability.Suggestions)
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		// when
+		availability, err := CheckUsernameAvailability(ctx, nsdClient, "johnny-crtadmin", 3)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, &username.Availability{
+			Name:   "johnny-crtadmin",
+			Reason: username.ReasonForbidden,
+		}, availability)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		// given
+		fakeClient := commontest.NewFakeClient(t)
+		fakeClient.MockGet = func(_ context.Context, _ client.ObjectKey, _ client.Object, _ ...client.GetOption) error {
+			return errors.New("oopsie woopsie")
+		}
+
+		// when
+		_, err := CheckUsernameAvailability(ctx, namespaced.NewClient(fakeClient, configuration.Namespace()), "johnny", 3)
+
+		// then
+		require.EqualError(t, err, "oopsie woopsie")
+	})
+}
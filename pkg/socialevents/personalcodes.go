@@ -0,0 +1,163 @@
+package socialevents
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/signup"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// MaxPersonalCodes is the maximum number of personal codes of an event. The hashes of the codes are stored in an
+	// annotation of the SocialEvent, whose size is limited.
+	MaxPersonalCodes = 1000
+	// PersonalCodeLength is the length of the personal part of the generated personal codes
+	PersonalCodeLength = 8
+)
+
+var (
+	// ErrPersonalCodeNotFound is returned when the personal code to revoke does not exist
+	ErrPersonalCodeNotFound = errors.New("personal code not found")
+	// ErrPersonalCodeUsed is returned when the personal code to revoke was already used
+	ErrPersonalCodeUsed = errors.New("personal code already used")
+)
+
+// PersonalCode is a single-use code of an event. The code itself is only known when it is generated,
+// since only its hash is stored.
+type PersonalCode struct {
+	// ID identifies the code. It is the hash of the code.
+	ID string `json:"id"`
+	// Code is the code to give to a single attendee, only returned when the code is generated
+	Code string `json:"code,omitempty"`
+	// UsedBy is the name of the UserSignup which used the code, if any
+	UsedBy string `json:"usedBy,omitempty"`
+}
+
+// GeneratePersonalCodes adds the given number of single-use codes to the pool of personal codes of the event with the
+// given activation code, and returns them. Once an event has personal codes, it can no longer be joined with its
+// activation code, only with one of its personal codes. Since only the hashes of the codes are stored, this is the
+// only time the codes are returned: they must be handed out to the attendees, or revoked if they are lost.
+func (s *SocialEvents) GeneratePersonalCodes(ctx *gin.Context, code string, count int, organizer string) ([]PersonalCode, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("%w: the number of personal codes must be positive", ErrInvalidEvent)
+	}
+	var generated []PersonalCode
+	_, err := s.update(ctx, code, organizer, func(event *toolchainv1alpha1.SocialEvent) error {
+		codes, err := signup.GetPersonalCodes(event)
+		if err != nil {
+			return err
+		}
+		if codes == nil {
+			codes = signup.PersonalCodes{}
+		}
+		if len(codes)+count > MaxPersonalCodes {
+			return fmt.Errorf("%w: an event cannot have more than %d personal codes", ErrInvalidEvent, MaxPersonalCodes)
+		}
+		generated = make([]PersonalCode, 0, count)
+		for len(generated) < count {
+			personal, err := randomString(PersonalCodeLength)
+			if err != nil {
+				return err
+			}
+			codeHash := signup.HashPersonalCode(event.Name, personal)
+			if _, exists := codes[codeHash]; exists {
+				continue
+			}
+			codes[codeHash] = ""
+			generated = append(generated, PersonalCode{
+				ID:   codeHash,
+				Code: event.Name + signup.PersonalCodeSeparator + personal,
+			})
+		}
+		return signup.SetPersonalCodes(event, codes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return generated, nil
+}
+
+// PersonalCodesUsage returns the IDs of the personal codes of the event with the given activation code, along with the
+// UserSignups which used them. The codes themselves cannot be exported, since only their hashes are stored: the
+// response of GeneratePersonalCodes is their only export.
+func (s *SocialEvents) PersonalCodesUsage(ctx *gin.Context, code, organizer string) ([]PersonalCode, error) {
+	event, err := s.get(ctx, code, organizer)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := signup.GetPersonalCodes(event)
+	if err != nil {
+		return nil, err
+	}
+	personalCodes := make([]PersonalCode, 0, len(codes))
+	for codeHash, usedBy := range codes {
+		personalCodes = append(personalCodes, PersonalCode{
+			ID:     codeHash,
+			UsedBy: usedBy,
+		})
+	}
+	sort.Slice(personalCodes, func(i, j int) bool {
+		return personalCodes[i].ID < personalCodes[j].ID
+	})
+	return personalCodes, nil
+}
+
+// RevokePersonalCodes removes the personal codes with the given IDs from the pool of the event with the given
+// activation code, or all the unused codes if no ID is given. The codes which were already used cannot be revoked.
+// Returns the number of revoked codes.
+func (s *SocialEvents) RevokePersonalCodes(ctx *gin.Context, code string, ids []string, organizer string) (int, error) {
+	revoked := 0
+	_, err := s.update(ctx, code, organizer, func(event *toolchainv1alpha1.SocialEvent) error {
+		codes, err := signup.GetPersonalCodes(event)
+		if err != nil {
+			return err
+		}
+		revoked = 0
+		if len(ids) == 0 {
+			for codeHash, usedBy := range codes {
+				if usedBy == "" {
+					delete(codes, codeHash)
+					revoked++
+				}
+			}
+		}
+		for _, id := range ids {
+			usedBy, found := codes[id]
+			switch {
+			case !found:
+				return fmt.Errorf("%w: '%s'", ErrPersonalCodeNotFound, id)
+			case usedBy != "":
+				return fmt.Errorf("%w: '%s'", ErrPersonalCodeUsed, id)
+			}
+			delete(codes, id)
+			revoked++
+		}
+		if codes == nil {
+			// no pool of personal codes, nothing to revoke
+			return nil
+		}
+		return signup.SetPersonalCodes(event, codes)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return revoked, nil
+}
+
+// randomString returns a random string of the given length, made of the characters of the codeAlphabet
+func randomString(length int) (string, error) {
+	s := make([]byte, length)
+	for i := range s {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(codeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		s[i] = codeAlphabet[n.Int64()]
+	}
+	return string(s), nil
+}
@@ -0,0 +1,201 @@
+package socialevents_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"regexp"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/signup"
+	"github.com/codeready-toolchain/registration-service/pkg/socialevents"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func (s *TestSocialEventsSuite) TestPersonalCodes() {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	now := time.Now()
+	getPersonalCodes := func(cl client.Client) signup.PersonalCodes {
+		event := &toolchainv1alpha1.SocialEvent{}
+		require.NoError(s.T(), cl.Get(context.TODO(), client.ObjectKey{Namespace: commontest.HostOperatorNs, Name: "event1"}, event))
+		codes, err := signup.GetPersonalCodes(event)
+		require.NoError(s.T(), err)
+		return codes
+	}
+	newEventWithCodes := func() *toolchainv1alpha1.SocialEvent {
+		event := newSocialEvent("event1", "johnny", now, now.Add(time.Hour), 10, 1)
+		require.NoError(s.T(), signup.SetPersonalCodes(event, signup.PersonalCodes{
+			signup.HashPersonalCode("event1", "unused01"): "",
+			signup.HashPersonalCode("event1", "unused02"): "",
+			signup.HashPersonalCode("event1", "used0001"): "jane",
+		}))
+		return event
+	}
+
+	s.Run("generate", func() {
+		s.Run("first personal codes of the event", func() {
+			// given
+			fakeClient := commontest.NewFakeClient(s.T(), newSocialEvent("event1", "johnny", now, now.Add(time.Hour), 10, 0))
+			events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+			// when
+			codes, err := events.GeneratePersonalCodes(ctx, "event1", 5, "johnny")
+
+			// then
+			require.NoError(s.T(), err)
+			require.Len(s.T(), codes, 5)
+			pool := getPersonalCodes(fakeClient)
+			assert.Len(s.T(), pool, 5)
+			for _, code := range codes {
+				assert.Regexp(s.T(), regexp.MustCompile(`^event1-[a-hjkmnp-z2-9]{8}$`), code.Code)
+				assert.Equal(s.T(), signup.HashPersonalCode("event1", code.Code[len("event1-"):]), code.ID)
+				assert.Contains(s.T(), pool, code.ID)
+			}
+			event, err := events.Get(ctx, "event1", "johnny")
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), 5, event.PersonalCodes)
+			assert.Zero(s.T(), event.UsedPersonalCodes)
+		})
+
+		s.Run("added to the existing personal codes", func() {
+			// given
+			fakeClient := commontest.NewFakeClient(s.T(), newEventWithCodes())
+			events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+			// when
+			codes, err := events.GeneratePersonalCodes(ctx, "event1", 2, "johnny")
+
+			// then
+			require.NoError(s.T(), err)
+			require.Len(s.T(), codes, 2)
+			pool := getPersonalCodes(fakeClient)
+			assert.Len(s.T(), pool, 5)
+			assert.Equal(s.T(), "jane", pool[signup.HashPersonalCode("event1", "used0001")])
+		})
+
+		s.Run("too many personal codes", func() {
+			// given
+			fakeClient := commontest.NewFakeClient(s.T(), newEventWithCodes())
+			events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+			// when
+			_, err := events.GeneratePersonalCodes(ctx, "event1", socialevents.MaxPersonalCodes-2, "johnny")
+
+			// then
+			require.ErrorIs(s.T(), err, socialevents.ErrInvalidEvent)
+			assert.Len(s.T(), getPersonalCodes(fakeClient), 3)
+		})
+
+		s.Run("invalid number of personal codes", func() {
+			// given
+			fakeClient := commontest.NewFakeClient(s.T(), newEventWithCodes())
+			events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+			// when
+			_, err := events.GeneratePersonalCodes(ctx, "event1", 0, "johnny")
+
+			// then
+			require.ErrorIs(s.T(), err, socialevents.ErrInvalidEvent)
+		})
+
+		s.Run("event of another organizer", func() {
+			// given
+			fakeClient := commontest.NewFakeClient(s.T(), newEventWithCodes())
+			events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+			// when
+			_, err := events.GeneratePersonalCodes(ctx, "event1", 1, "jane")
+
+			// then
+			require.ErrorIs(s.T(), err, socialevents.ErrNotFound)
+		})
+	})
+
+	s.Run("usage", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(s.T(), newEventWithCodes())
+		events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		codes, err := events.PersonalCodesUsage(ctx, "event1", "johnny")
+
+		// then
+		require.NoError(s.T(), err)
+		require.Len(s.T(), codes, 3)
+		for _, code := range codes {
+			assert.Empty(s.T(), code.Code) // only the hashes of the codes are stored
+			if code.ID == signup.HashPersonalCode("event1", "used0001") {
+				assert.Equal(s.T(), "jane", code.UsedBy)
+			} else {
+				assert.Empty(s.T(), code.UsedBy)
+			}
+		}
+		event, err := events.Get(ctx, "event1", "johnny")
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), 3, event.PersonalCodes)
+		assert.Equal(s.T(), 1, event.UsedPersonalCodes)
+	})
+
+	s.Run("revoke", func() {
+		s.Run("all the unused codes", func() {
+			// given
+			fakeClient := commontest.NewFakeClient(s.T(), newEventWithCodes())
+			events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+			// when
+			revoked, err := events.RevokePersonalCodes(ctx, "event1", nil, "johnny")
+
+			// then
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), 2, revoked)
+			assert.Equal(s.T(), signup.PersonalCodes{signup.HashPersonalCode("event1", "used0001"): "jane"}, getPersonalCodes(fakeClient))
+		})
+
+		s.Run("a single code", func() {
+			// given
+			fakeClient := commontest.NewFakeClient(s.T(), newEventWithCodes())
+			events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+			// when
+			revoked, err := events.RevokePersonalCodes(ctx, "event1", []string{signup.HashPersonalCode("event1", "unused01")}, "johnny")
+
+			// then
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), 1, revoked)
+			pool := getPersonalCodes(fakeClient)
+			assert.Len(s.T(), pool, 2)
+			assert.NotContains(s.T(), pool, signup.HashPersonalCode("event1", "unused01"))
+		})
+
+		s.Run("used code", func() {
+			// given
+			fakeClient := commontest.NewFakeClient(s.T(), newEventWithCodes())
+			events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+			// when
+			_, err := events.RevokePersonalCodes(ctx, "event1", []string{signup.HashPersonalCode("event1", "used0001")}, "johnny")
+
+			// then
+			require.ErrorIs(s.T(), err, socialevents.ErrPersonalCodeUsed)
+			assert.Len(s.T(), getPersonalCodes(fakeClient), 3)
+		})
+
+		s.Run("unknown code", func() {
+			// given
+			fakeClient := commontest.NewFakeClient(s.T(), newEventWithCodes())
+			events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+			// when
+			_, err := events.RevokePersonalCodes(ctx, "event1", []string{"unknown"}, "johnny")
+
+			// then
+			require.ErrorIs(s.T(), err, socialevents.ErrPersonalCodeNotFound)
+			assert.Len(s.T(), getPersonalCodes(fakeClient), 3)
+		})
+	})
+}
@@ -0,0 +1,415 @@
+// Package socialevents provides the operations behind the endpoints of the event organizers, which allow them to
+// create and monitor the SocialEvents (and their activation codes) without having access to the host cluster.
+package socialevents
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/configuration"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/signup"
+	"github.com/codeready-toolchain/toolchain-common/pkg/hash"
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// OrganizerAnnotationKey is the annotation recording the username of the organizer who created a SocialEvent
+	OrganizerAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "organizer"
+	// OrganizerHashLabelKey is the label containing the hash of the username of the organizer who created a SocialEvent,
+	// used to list the events of an organizer
+	OrganizerHashLabelKey = toolchainv1alpha1.LabelKeyPrefix + "organizer-hash"
+
+	// CodeLength is the length of the generated activation codes
+	CodeLength = 6
+	// codeAlphabet contains the characters of the generated activation codes. Characters that are easily confused
+	// with each other (such as `0` and `o`, or `1` and `l`) are left out, so that the codes can be read out loud
+	// or copied from a slide without mistakes.
+	codeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	// codeAttempts is the number of codes generated before giving up when the generated codes are already in use
+	codeAttempts = 5
+)
+
+var (
+	// ErrNotFound is returned when the SocialEvent does not exist, or does not belong to the organizer
+	ErrNotFound = errors.New("SocialEvent not found")
+	// ErrInvalidEvent is returned when the settings of a SocialEvent are invalid
+	ErrInvalidEvent = errors.New("invalid event")
+	// ErrForbiddenSetting is returned when an organizer changes a setting which is reserved to the administrators
+	ErrForbiddenSetting = errors.New("only the administrators can change the tiers, the target cluster and the verification requirement of an event")
+)
+
+// Event is the summary of a SocialEvent
+type Event struct {
+	// Code is the activation code of the event, which is also the name of the SocialEvent resource
+	Code string `json:"code"`
+	// Description is the description of the event
+	Description string `json:"description,omitempty"`
+	// StartTime is the time from which the users can activate the code
+	StartTime time.Time `json:"startTime"`
+	// EndTime is the time after which the users can no longer activate the code
+	EndTime time.Time `json:"endTime"`
+	// MaxAttendees is the maximum number of users who can activate the code
+	MaxAttendees int `json:"maxAttendees"`
+	// ActivationCount is the number of users who activated the code so far
+	ActivationCount int `json:"activationCount"`
+	// UserTier is the tier assigned to the users who activate the code
+	UserTier string `json:"userTier"`
+	// SpaceTier is the tier assigned to the spaces of the users who activate the code
+	SpaceTier string `json:"spaceTier"`
+	// TargetCluster is the cluster in which the users are provisioned, if any
+	TargetCluster string `json:"targetCluster,omitempty"`
+	// VerificationRequired is true if the users must also complete the phone verification
+	VerificationRequired bool `json:"verificationRequired"`
+	// PersonalCodes is the number of personal codes of the event, if the event can only be joined with personal codes
+	PersonalCodes int `json:"personalCodes,omitempty"`
+	// UsedPersonalCodes is the number of personal codes which were used
+	UsedPersonalCodes int `json:"usedPersonalCodes,omitempty"`
+	// Organizer is the username of the organizer who created the event
+	Organizer string `json:"organizer,omitempty"`
+	// Closed is true if the code can no longer be activated, because the event is over
+	Closed bool `json:"closed"`
+}
+
+// Settings contains the settings of an event. The tiers, the target cluster and the verification requirement can only
+// be set by the administrators, and the organizers cannot exceed the maximum number of attendees of the configuration.
+// Fields which are nil are left unchanged on update, or set to their default value on creation.
+type Settings struct {
+	Description          *string    `json:"description,omitempty"`
+	StartTime            *time.Time `json:"startTime,omitempty"`
+	EndTime              *time.Time `json:"endTime,omitempty"`
+	MaxAttendees         *int       `json:"maxAttendees,omitempty"`
+	UserTier             *string    `json:"userTier,omitempty"`
+	SpaceTier            *string    `json:"spaceTier,omitempty"`
+	TargetCluster        *string    `json:"targetCluster,omitempty"`
+	VerificationRequired *bool      `json:"verificationRequired,omitempty"`
+}
+
+// Attendee is the summary of a UserSignup created or updated with the activation code of an event
+type Attendee struct {
+	// Name is the name of the UserSignup resource
+	Name string `json:"name"`
+	// Username is the preferred username of the user
+	Username string `json:"username"`
+	// CompliantUsername is the name of the MasterUserRecord of the user, once provisioned
+	CompliantUsername string `json:"compliantUsername,omitempty"`
+	// State is the value of the state label of the UserSignup
+	State string `json:"state,omitempty"`
+	// Created is the creation time of the UserSignup
+	Created time.Time `json:"created"`
+}
+
+// SocialEvents manages the SocialEvents on behalf of the organizers.
+//
+// All the operations on an existing event take the `organizer` whose events can be accessed: the events created
+// by other organizers are reported as not found. An empty organizer gives access to all the events, which is how
+// the administrators see them.
+type SocialEvents struct {
+	namespaced.Client
+}
+
+// NewSocialEvents returns a new SocialEvents instance
+func NewSocialEvents(nsClient namespaced.Client) *SocialEvents {
+	return &SocialEvents{
+		Client: nsClient,
+	}
+}
+
+// Create creates a new SocialEvent with the given settings and a generated activation code, owned by the given
+// organizer, who may also be an administrator. The end time and the maximum number of attendees are required, the event
+// starts immediately unless a start time is given, and the tiers default to the tiers of the new users.
+func (s *SocialEvents) Create(ctx *gin.Context, settings Settings, organizer string, admin bool) (*Event, error) {
+	if settings.EndTime == nil {
+		return nil, fmt.Errorf("%w: the end time is required", ErrInvalidEvent)
+	}
+	if settings.MaxAttendees == nil {
+		return nil, fmt.Errorf("%w: the maximum number of attendees is required", ErrInvalidEvent)
+	}
+	if err := s.checkSettings(ctx, settings, admin); err != nil {
+		return nil, err
+	}
+	tiers := configuration.GetRegistrationServiceConfig().Tiers()
+	event := &toolchainv1alpha1.SocialEvent{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: s.Namespace,
+			Labels: map[string]string{
+				OrganizerHashLabelKey: hash.EncodeString(organizer),
+			},
+			Annotations: map[string]string{
+				OrganizerAnnotationKey: organizer,
+			},
+		},
+		Spec: toolchainv1alpha1.SocialEventSpec{
+			StartTime: metav1.NewTime(time.Now()),
+			UserTier:  tiers.DefaultUserTier(),
+			SpaceTier: tiers.DefaultSpaceTier(),
+		},
+	}
+	applySettings(event, settings)
+	if err := validate(event); err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		code, err := GenerateCode()
+		if err != nil {
+			return nil, err
+		}
+		event.Name = code
+		err = s.Client.Create(ctx, event)
+		if err == nil {
+			return toEvent(event), nil
+		}
+		if !apierrors.IsAlreadyExists(err) || attempt >= codeAttempts {
+			return nil, err
+		}
+	}
+}
+
+// List returns the events of the given organizer (or all the events if the organizer is empty), most recent first
+func (s *SocialEvents) List(ctx *gin.Context, organizer string) ([]Event, error) {
+	opts := []client.ListOption{client.InNamespace(s.Namespace)}
+	if organizer != "" {
+		opts = append(opts, client.MatchingLabels{OrganizerHashLabelKey: hash.EncodeString(organizer)})
+	}
+	events := &toolchainv1alpha1.SocialEventList{}
+	if err := s.Client.List(ctx, events, opts...); err != nil {
+		return nil, err
+	}
+	sort.Slice(events.Items, func(i, j int) bool {
+		return events.Items[i].Spec.StartTime.After(events.Items[j].Spec.StartTime.Time)
+	})
+	result := make([]Event, 0, len(events.Items))
+	for i := range events.Items {
+		if !ownedBy(&events.Items[i], organizer) {
+			continue
+		}
+		result = append(result, *toEvent(&events.Items[i]))
+	}
+	return result, nil
+}
+
+// Get returns the event with the given activation code
+func (s *SocialEvents) Get(ctx *gin.Context, code, organizer string) (*Event, error) {
+	event, err := s.get(ctx, code, organizer)
+	if err != nil {
+		return nil, err
+	}
+	return toEvent(event), nil
+}
+
+// Update applies the given settings on the event with the given activation code.
+// The maximum number of attendees cannot be lowered below the number of users who already activated the code.
+func (s *SocialEvents) Update(ctx *gin.Context, code string, settings Settings, organizer string) (*Event, error) {
+	if err := s.checkSettings(ctx, settings, organizer == ""); err != nil {
+		return nil, err
+	}
+	return s.update(ctx, code, organizer, func(event *toolchainv1alpha1.SocialEvent) error {
+		applySettings(event, settings)
+		return validate(event)
+	})
+}
+
+// Close ends the event with the given activation code now, so that the code can no longer be activated.
+// Closing an event which is already over has no effect.
+func (s *SocialEvents) Close(ctx *gin.Context, code, organizer string) (*Event, error) {
+	return s.update(ctx, code, organizer, func(event *toolchainv1alpha1.SocialEvent) error {
+		now := time.Now()
+		if event.Spec.EndTime.After(now) {
+			event.Spec.EndTime = metav1.NewTime(now)
+		}
+		if event.Spec.StartTime.After(now) {
+			event.Spec.StartTime = event.Spec.EndTime
+		}
+		return nil
+	})
+}
+
+// Attendees returns the users who joined through the event with the given activation code, in order of signup
+func (s *SocialEvents) Attendees(ctx *gin.Context, code, organizer string) ([]Attendee, error) {
+	if _, err := s.get(ctx, code, organizer); err != nil {
+		return nil, err
+	}
+	userSignups := &toolchainv1alpha1.UserSignupList{}
+	if err := s.Client.List(ctx, userSignups, client.InNamespace(s.Namespace),
+		client.MatchingLabels{toolchainv1alpha1.SocialEventUserSignupLabelKey: code}); err != nil {
+		return nil, err
+	}
+	sort.Slice(userSignups.Items, func(i, j int) bool {
+		return userSignups.Items[i].CreationTimestamp.Before(&userSignups.Items[j].CreationTimestamp)
+	})
+	attendees := make([]Attendee, 0, len(userSignups.Items))
+	for _, userSignup := range userSignups.Items {
+		attendees = append(attendees, Attendee{
+			Name:              userSignup.Name,
+			Username:          userSignup.Spec.IdentityClaims.PreferredUsername,
+			CompliantUsername: userSignup.Status.CompliantUsername,
+			State:             userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey],
+			Created:           userSignup.CreationTimestamp.UTC(),
+		})
+	}
+	return attendees, nil
+}
+
+// GenerateCode returns a random activation code of CodeLength characters, made of lower case letters and digits
+// which are easy to tell apart
+func GenerateCode() (string, error) {
+	return randomString(CodeLength)
+}
+
+func (s *SocialEvents) get(ctx *gin.Context, code, organizer string) (*toolchainv1alpha1.SocialEvent, error) {
+	event := &toolchainv1alpha1.SocialEvent{}
+	if err := s.Client.Get(ctx, s.NamespacedName(code), event); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if !ownedBy(event, organizer) {
+		return nil, ErrNotFound
+	}
+	return event, nil
+}
+
+func (s *SocialEvents) update(ctx *gin.Context, code, organizer string, mutate func(*toolchainv1alpha1.SocialEvent) error) (*Event, error) {
+	var event *toolchainv1alpha1.SocialEvent
+	var invalid error
+	err := signup.PollUpdateSignup(ctx, func() error {
+		var err error
+		if event, err = s.get(ctx, code, organizer); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				// no need to retry
+				return nil
+			}
+			return err
+		}
+		if invalid = mutate(event); invalid != nil {
+			return nil
+		}
+		return s.Client.Update(ctx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, ErrNotFound
+	}
+	if invalid != nil {
+		return nil, invalid
+	}
+	return toEvent(event), nil
+}
+
+// checkSettings checks the given settings against the permissions of the caller: the organizers cannot change the
+// settings which are reserved to the administrators, nor exceed the configured maximum number of attendees.
+// The tiers and the cluster set by the administrators must exist.
+func (s *SocialEvents) checkSettings(ctx *gin.Context, settings Settings, admin bool) error {
+	if !admin {
+		if settings.UserTier != nil || settings.SpaceTier != nil || settings.TargetCluster != nil || settings.VerificationRequired != nil {
+			return ErrForbiddenSetting
+		}
+		if maxAttendees := configuration.GetRegistrationServiceConfig().Organizers().MaxAttendees(); settings.MaxAttendees != nil && *settings.MaxAttendees > maxAttendees {
+			return fmt.Errorf("%w: the maximum number of attendees cannot exceed %d", ErrInvalidEvent, maxAttendees)
+		}
+		return nil
+	}
+	for _, ref := range []struct {
+		kind string
+		name *string
+		obj  client.Object
+	}{
+		{kind: "user tier", name: settings.UserTier, obj: &toolchainv1alpha1.UserTier{}},
+		{kind: "space tier", name: settings.SpaceTier, obj: &toolchainv1alpha1.NSTemplateTier{}},
+		{kind: "target cluster", name: settings.TargetCluster, obj: &toolchainv1alpha1.ToolchainCluster{}},
+	} {
+		// an empty tier is rejected when the event is validated, and an empty cluster means no target cluster
+		if ref.name == nil || *ref.name == "" {
+			continue
+		}
+		if err := s.Client.Get(ctx, s.NamespacedName(*ref.name), ref.obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				return fmt.Errorf("%w: unknown %s '%s'", ErrInvalidEvent, ref.kind, *ref.name)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func ownedBy(event *toolchainv1alpha1.SocialEvent, organizer string) bool {
+	return organizer == "" || event.Annotations[OrganizerAnnotationKey] == organizer
+}
+
+func applySettings(event *toolchainv1alpha1.SocialEvent, settings Settings) {
+	if settings.Description != nil {
+		event.Spec.Description = *settings.Description
+	}
+	if settings.StartTime != nil {
+		event.Spec.StartTime = metav1.NewTime(*settings.StartTime)
+	}
+	if settings.EndTime != nil {
+		event.Spec.EndTime = metav1.NewTime(*settings.EndTime)
+	}
+	if settings.MaxAttendees != nil {
+		event.Spec.MaxAttendees = *settings.MaxAttendees
+	}
+	if settings.UserTier != nil {
+		event.Spec.UserTier = *settings.UserTier
+	}
+	if settings.SpaceTier != nil {
+		event.Spec.SpaceTier = *settings.SpaceTier
+	}
+	if settings.TargetCluster != nil {
+		event.Spec.TargetCluster = *settings.TargetCluster
+	}
+	if settings.VerificationRequired != nil {
+		event.Spec.VerificationRequired = *settings.VerificationRequired
+	}
+}
+
+func validate(event *toolchainv1alpha1.SocialEvent) error {
+	switch {
+	case !event.Spec.EndTime.After(event.Spec.StartTime.Time):
+		return fmt.Errorf("%w: the end time must be after the start time", ErrInvalidEvent)
+	case event.Spec.MaxAttendees <= 0:
+		return fmt.Errorf("%w: the maximum number of attendees must be positive", ErrInvalidEvent)
+	case event.Spec.MaxAttendees < event.Status.ActivationCount:
+		return fmt.Errorf("%w: the maximum number of attendees cannot be lower than the number of activations (%d)", ErrInvalidEvent, event.Status.ActivationCount)
+	case event.Spec.UserTier == "" || event.Spec.SpaceTier == "":
+		return fmt.Errorf("%w: the tiers cannot be empty", ErrInvalidEvent)
+	}
+	return nil
+}
+
+func toEvent(event *toolchainv1alpha1.SocialEvent) *Event {
+	e := &Event{
+		Code:                 event.Name,
+		Description:          event.Spec.Description,
+		StartTime:            event.Spec.StartTime.UTC(),
+		EndTime:              event.Spec.EndTime.UTC(),
+		MaxAttendees:         event.Spec.MaxAttendees,
+		ActivationCount:      event.Status.ActivationCount,
+		UserTier:             event.Spec.UserTier,
+		SpaceTier:            event.Spec.SpaceTier,
+		TargetCluster:        event.Spec.TargetCluster,
+		VerificationRequired: event.Spec.VerificationRequired,
+		Organizer:            event.Annotations[OrganizerAnnotationKey],
+		Closed:               !event.Spec.EndTime.After(time.Now()),
+	}
+	// an invalid pool of personal codes is reported when the codes are listed
+	if codes, err := signup.GetPersonalCodes(event); err == nil {
+		e.PersonalCodes = len(codes)
+		for _, usedBy := range codes {
+			if usedBy != "" {
+				e.UsedPersonalCodes++
+			}
+		}
+	}
+	return e
+}
@@ -0,0 +1,510 @@
+package socialevents_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/namespaced"
+	"github.com/codeready-toolchain/registration-service/pkg/socialevents"
+	"github.com/codeready-toolchain/registration-service/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/hash"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	testusersignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type TestSocialEventsSuite struct {
+	test.UnitTestSuite
+}
+
+func TestRunSocialEventsSuite(t *testing.T) {
+	suite.Run(t, &TestSocialEventsSuite{test.UnitTestSuite{}})
+}
+
+func newSocialEvent(code, organizer string, start, end time.Time, maxAttendees, activationCount int) *toolchainv1alpha1.SocialEvent {
+	return &toolchainv1alpha1.SocialEvent{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        code,
+			Namespace:   commontest.HostOperatorNs,
+			Labels:      map[string]string{socialevents.OrganizerHashLabelKey: hash.EncodeString(organizer)},
+			Annotations: map[string]string{socialevents.OrganizerAnnotationKey: organizer},
+		},
+		Spec: toolchainv1alpha1.SocialEventSpec{
+			StartTime:    metav1.NewTime(start),
+			EndTime:      metav1.NewTime(end),
+			MaxAttendees: maxAttendees,
+			UserTier:     "deactivate30",
+			SpaceTier:    "base1ns",
+		},
+		Status: toolchainv1alpha1.SocialEventStatus{
+			ActivationCount: activationCount,
+		},
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func (s *TestSocialEventsSuite) TestGenerateCode() {
+	codes := map[string]bool{}
+	for range 100 {
+		code, err := socialevents.GenerateCode()
+		require.NoError(s.T(), err)
+		assert.Regexp(s.T(), regexp.MustCompile(`^[a-hjkmnp-z2-9]{6}$`), code)
+		codes[code] = true
+	}
+	assert.Greater(s.T(), len(codes), 90)
+}
+
+func (s *TestSocialEventsSuite) TestCreate() {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	end := time.Now().Add(8 * time.Hour).Truncate(time.Second)
+
+	s.Run("with defaults", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(s.T())
+		events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		event, err := events.Create(ctx, socialevents.Settings{
+			Description:  ptr("Workshop"),
+			EndTime:      &end,
+			MaxAttendees: ptr(50),
+		}, "organizer@kubesaw.io", false)
+
+		// then
+		require.NoError(s.T(), err)
+		assert.Len(s.T(), event.Code, socialevents.CodeLength)
+		assert.Equal(s.T(), "Workshop", event.Description)
+		assert.Equal(s.T(), 50, event.MaxAttendees)
+		assert.Equal(s.T(), "deactivate30", event.UserTier)
+		assert.Equal(s.T(), "base1ns", event.SpaceTier)
+		assert.Equal(s.T(), "organizer@kubesaw.io", event.Organizer)
+		assert.False(s.T(), event.Closed)
+		socialEvent := &toolchainv1alpha1.SocialEvent{}
+		require.NoError(s.T(), fakeClient.Get(context.TODO(), client.ObjectKey{Namespace: commontest.HostOperatorNs, Name: event.Code}, socialEvent))
+		assert.Equal(s.T(), hash.EncodeString("organizer@kubesaw.io"), socialEvent.Labels[socialevents.OrganizerHashLabelKey])
+		assert.Equal(s.T(), end.UTC(), socialEvent.Spec.EndTime.UTC())
+		assert.WithinDuration(s.T(), time.Now(), socialEvent.Spec.StartTime.Time, time.Minute)
+	})
+
+	s.Run("by an administrator", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(s.T(),
+			&toolchainv1alpha1.UserTier{ObjectMeta: metav1.ObjectMeta{Name: "deactivate80", Namespace: commontest.HostOperatorNs}},
+			&toolchainv1alpha1.NSTemplateTier{ObjectMeta: metav1.ObjectMeta{Name: "base1ns6didler", Namespace: commontest.HostOperatorNs}},
+			&toolchainv1alpha1.ToolchainCluster{ObjectMeta: metav1.ObjectMeta{Name: "member-2", Namespace: commontest.HostOperatorNs}})
+		events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		event, err := events.Create(ctx, socialevents.Settings{
+			EndTime:              &end,
+			MaxAttendees:         ptr(1000),
+			UserTier:             ptr("deactivate80"),
+			SpaceTier:            ptr("base1ns6didler"),
+			TargetCluster:        ptr("member-2"),
+			VerificationRequired: ptr(true),
+		}, "admin@kubesaw.io", true)
+
+		// then
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), 1000, event.MaxAttendees)
+		assert.Equal(s.T(), "deactivate80", event.UserTier)
+		assert.Equal(s.T(), "base1ns6didler", event.SpaceTier)
+		socialEvent := &toolchainv1alpha1.SocialEvent{}
+		require.NoError(s.T(), fakeClient.Get(context.TODO(), client.ObjectKey{Namespace: commontest.HostOperatorNs, Name: event.Code}, socialEvent))
+		assert.Equal(s.T(), "member-2", socialEvent.Spec.TargetCluster)
+		assert.True(s.T(), socialEvent.Spec.VerificationRequired)
+	})
+
+	for name, settings := range map[string]socialevents.Settings{
+		"user tier":             {EndTime: &end, MaxAttendees: ptr(50), UserTier: ptr("deactivate80")},
+		"space tier":            {EndTime: &end, MaxAttendees: ptr(50), SpaceTier: ptr("base1ns6didler")},
+		"target cluster":        {EndTime: &end, MaxAttendees: ptr(50), TargetCluster: ptr("member-2")},
+		"verification required": {EndTime: &end, MaxAttendees: ptr(50), VerificationRequired: ptr(false)},
+	} {
+		s.Run("organizers cannot set the "+name, func() {
+			// given
+			fakeClient := commontest.NewFakeClient(s.T())
+			events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+			// when
+			_, err := events.Create(ctx, settings, "organizer", false)
+
+			// then
+			require.ErrorIs(s.T(), err, socialevents.ErrForbiddenSetting)
+			list := &toolchainv1alpha1.SocialEventList{}
+			require.NoError(s.T(), fakeClient.List(context.TODO(), list))
+			assert.Empty(s.T(), list.Items)
+		})
+	}
+
+	s.Run("retries when the code is already in use", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(s.T())
+		attempts := 0
+		fakeClient.MockCreate = func(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+			if attempts++; attempts < 3 {
+				return apierrors.NewAlreadyExists(schema.GroupResource{}, obj.GetName())
+			}
+			return fakeClient.Client.Create(ctx, obj, opts...)
+		}
+		events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		_, err := events.Create(ctx, socialevents.Settings{EndTime: &end, MaxAttendees: ptr(50)}, "organizer", false)
+
+		// then
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), 3, attempts)
+	})
+
+	s.Run("create fails", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(s.T())
+		fakeClient.MockCreate = func(_ context.Context, _ client.Object, _ ...client.CreateOption) error {
+			return errors.New("oopsie woopsie")
+		}
+		events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		_, err := events.Create(ctx, socialevents.Settings{EndTime: &end, MaxAttendees: ptr(50)}, "organizer", false)
+
+		// then
+		require.EqualError(s.T(), err, "oopsie woopsie")
+	})
+
+	for name, tc := range map[string]struct {
+		settings socialevents.Settings
+		admin    bool
+		expected string
+	}{
+		"missing end time": {
+			settings: socialevents.Settings{MaxAttendees: ptr(50)},
+			expected: "invalid event: the end time is required",
+		},
+		"missing max attendees": {
+			settings: socialevents.Settings{EndTime: &end},
+			expected: "invalid event: the maximum number of attendees is required",
+		},
+		"end before start": {
+			settings: socialevents.Settings{StartTime: ptr(end.Add(time.Hour)), EndTime: &end, MaxAttendees: ptr(50)},
+			expected: "invalid event: the end time must be after the start time",
+		},
+		"no attendees": {
+			settings: socialevents.Settings{EndTime: &end, MaxAttendees: ptr(0)},
+			expected: "invalid event: the maximum number of attendees must be positive",
+		},
+		"too many attendees": {
+			settings: socialevents.Settings{EndTime: &end, MaxAttendees: ptr(501)},
+			expected: "invalid event: the maximum number of attendees cannot exceed 500",
+		},
+		"empty tier": {
+			settings: socialevents.Settings{EndTime: &end, MaxAttendees: ptr(50), UserTier: ptr("")},
+			admin:    true,
+			expected: "invalid event: the tiers cannot be empty",
+		},
+		"unknown user tier": {
+			settings: socialevents.Settings{EndTime: &end, MaxAttendees: ptr(50), UserTier: ptr("deactivate365")},
+			admin:    true,
+			expected: "invalid event: unknown user tier 'deactivate365'",
+		},
+		"unknown space tier": {
+			settings: socialevents.Settings{EndTime: &end, MaxAttendees: ptr(50), SpaceTier: ptr("unlimited")},
+			admin:    true,
+			expected: "invalid event: unknown space tier 'unlimited'",
+		},
+		"unknown target cluster": {
+			settings: socialevents.Settings{EndTime: &end, MaxAttendees: ptr(50), TargetCluster: ptr("member-3")},
+			admin:    true,
+			expected: "invalid event: unknown target cluster 'member-3'",
+		},
+	} {
+		s.Run(name, func() {
+			// given
+			events := socialevents.NewSocialEvents(namespaced.NewClient(commontest.NewFakeClient(s.T()), commontest.HostOperatorNs))
+
+			// when
+			_, err := events.Create(ctx, tc.settings, "organizer", tc.admin)
+
+			// then
+			require.ErrorIs(s.T(), err, socialevents.ErrInvalidEvent)
+			require.EqualError(s.T(), err, tc.expected)
+		})
+	}
+}
+
+func (s *TestSocialEventsSuite) TestListAndGet() {
+	// given
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	now := time.Now()
+	fakeClient := commontest.NewFakeClient(s.T(),
+		newSocialEvent("past01", "johnny", now.Add(-48*time.Hour), now.Add(-24*time.Hour), 10, 8),
+		newSocialEvent("next01", "johnny", now.Add(time.Hour), now.Add(2*time.Hour), 10, 0),
+		newSocialEvent("other1", "jane", now, now.Add(time.Hour), 10, 0),
+	)
+	events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+	s.Run("list the events of the organizer", func() {
+		// when
+		result, err := events.List(ctx, "johnny")
+
+		// then
+		require.NoError(s.T(), err)
+		require.Len(s.T(), result, 2)
+		assert.Equal(s.T(), "next01", result[0].Code) // most recent first
+		assert.False(s.T(), result[0].Closed)
+		assert.Equal(s.T(), "past01", result[1].Code)
+		assert.Equal(s.T(), 8, result[1].ActivationCount)
+		assert.True(s.T(), result[1].Closed)
+	})
+
+	s.Run("list all the events", func() {
+		// when
+		result, err := events.List(ctx, "")
+
+		// then
+		require.NoError(s.T(), err)
+		assert.Len(s.T(), result, 3)
+	})
+
+	s.Run("get own event", func() {
+		// when
+		result, err := events.Get(ctx, "next01", "johnny")
+
+		// then
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), "johnny", result.Organizer)
+	})
+
+	s.Run("get event of another organizer", func() {
+		// when
+		_, err := events.Get(ctx, "other1", "johnny")
+
+		// then
+		require.ErrorIs(s.T(), err, socialevents.ErrNotFound)
+	})
+
+	s.Run("get unknown event", func() {
+		// when
+		_, err := events.Get(ctx, "unknown", "")
+
+		// then
+		require.ErrorIs(s.T(), err, socialevents.ErrNotFound)
+	})
+}
+
+func (s *TestSocialEventsSuite) TestUpdateAndClose() {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	now := time.Now()
+	getSocialEvent := func(cl client.Client, code string) *toolchainv1alpha1.SocialEvent {
+		event := &toolchainv1alpha1.SocialEvent{}
+		require.NoError(s.T(), cl.Get(context.TODO(), client.ObjectKey{Namespace: commontest.HostOperatorNs, Name: code}, event))
+		return event
+	}
+
+	s.Run("update", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(s.T(), newSocialEvent("abcdef", "johnny", now, now.Add(time.Hour), 10, 5))
+		events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		event, err := events.Update(ctx, "abcdef", socialevents.Settings{
+			Description:  ptr("Workshop"),
+			MaxAttendees: ptr(20),
+		}, "johnny")
+
+		// then
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), 20, event.MaxAttendees)
+		socialEvent := getSocialEvent(fakeClient, "abcdef")
+		assert.Equal(s.T(), 20, socialEvent.Spec.MaxAttendees)
+		assert.Equal(s.T(), "Workshop", socialEvent.Spec.Description)
+		assert.Equal(s.T(), "deactivate30", socialEvent.Spec.UserTier) // unchanged
+	})
+
+	s.Run("update by an administrator", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(s.T(), newSocialEvent("abcdef", "johnny", now, now.Add(time.Hour), 10, 5),
+			&toolchainv1alpha1.UserTier{ObjectMeta: metav1.ObjectMeta{Name: "deactivate80", Namespace: commontest.HostOperatorNs}})
+		events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		_, err := events.Update(ctx, "abcdef", socialevents.Settings{
+			MaxAttendees:         ptr(1000),
+			UserTier:             ptr("deactivate80"),
+			VerificationRequired: ptr(true),
+		}, "")
+
+		// then
+		require.NoError(s.T(), err)
+		socialEvent := getSocialEvent(fakeClient, "abcdef")
+		assert.Equal(s.T(), 1000, socialEvent.Spec.MaxAttendees)
+		assert.Equal(s.T(), "deactivate80", socialEvent.Spec.UserTier)
+		assert.True(s.T(), socialEvent.Spec.VerificationRequired)
+	})
+
+	s.Run("organizers cannot change the settings reserved to the administrators", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(s.T(), newSocialEvent("abcdef", "johnny", now, now.Add(time.Hour), 10, 5))
+		events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		_, err := events.Update(ctx, "abcdef", socialevents.Settings{VerificationRequired: ptr(true)}, "johnny")
+
+		// then
+		require.ErrorIs(s.T(), err, socialevents.ErrForbiddenSetting)
+		assert.False(s.T(), getSocialEvent(fakeClient, "abcdef").Spec.VerificationRequired)
+	})
+
+	s.Run("organizers cannot exceed the configured max attendees", func() {
+		// given
+		test.SetSettings(s.T(), "organizers: {maxAttendees: 15}")
+		fakeClient := commontest.NewFakeClient(s.T(), newSocialEvent("abcdef", "johnny", now, now.Add(time.Hour), 10, 5))
+		events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		_, err := events.Update(ctx, "abcdef", socialevents.Settings{MaxAttendees: ptr(20)}, "johnny")
+
+		// then
+		require.EqualError(s.T(), err, "invalid event: the maximum number of attendees cannot exceed 15")
+		assert.Equal(s.T(), 10, getSocialEvent(fakeClient, "abcdef").Spec.MaxAttendees)
+	})
+
+	s.Run("cannot lower the max attendees below the activation count", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(s.T(), newSocialEvent("abcdef", "johnny", now, now.Add(time.Hour), 10, 5))
+		events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		_, err := events.Update(ctx, "abcdef", socialevents.Settings{MaxAttendees: ptr(4)}, "johnny")
+
+		// then
+		require.EqualError(s.T(), err, "invalid event: the maximum number of attendees cannot be lower than the number of activations (5)")
+		assert.Equal(s.T(), 10, getSocialEvent(fakeClient, "abcdef").Spec.MaxAttendees)
+	})
+
+	s.Run("cannot update the event of another organizer", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(s.T(), newSocialEvent("abcdef", "jane", now, now.Add(time.Hour), 10, 5))
+		events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		_, err := events.Update(ctx, "abcdef", socialevents.Settings{MaxAttendees: ptr(20)}, "johnny")
+
+		// then
+		require.ErrorIs(s.T(), err, socialevents.ErrNotFound)
+		assert.Equal(s.T(), 10, getSocialEvent(fakeClient, "abcdef").Spec.MaxAttendees)
+	})
+
+	s.Run("update fails", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(s.T(), newSocialEvent("abcdef", "johnny", now, now.Add(time.Hour), 10, 5))
+		fakeClient.MockUpdate = func(_ context.Context, _ client.Object, _ ...client.UpdateOption) error {
+			return errors.New("oopsie woopsie")
+		}
+		events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		_, err := events.Update(ctx, "abcdef", socialevents.Settings{MaxAttendees: ptr(20)}, "johnny")
+
+		// then
+		require.EqualError(s.T(), err, "oopsie woopsie")
+	})
+
+	s.Run("close", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(s.T(), newSocialEvent("abcdef", "johnny", now.Add(-time.Hour), now.Add(time.Hour), 10, 5))
+		events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		event, err := events.Close(ctx, "abcdef", "johnny")
+
+		// then
+		require.NoError(s.T(), err)
+		assert.True(s.T(), event.Closed)
+		assert.False(s.T(), getSocialEvent(fakeClient, "abcdef").Spec.EndTime.After(time.Now()))
+	})
+
+	s.Run("close an event which is not started yet", func() {
+		// given
+		fakeClient := commontest.NewFakeClient(s.T(), newSocialEvent("abcdef", "johnny", now.Add(time.Hour), now.Add(2*time.Hour), 10, 0))
+		events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		event, err := events.Close(ctx, "abcdef", "")
+
+		// then
+		require.NoError(s.T(), err)
+		assert.True(s.T(), event.Closed)
+		assert.Equal(s.T(), event.StartTime, event.EndTime)
+	})
+
+	s.Run("closing a past event has no effect", func() {
+		// given
+		end := now.Add(-time.Hour).Truncate(time.Second)
+		fakeClient := commontest.NewFakeClient(s.T(), newSocialEvent("abcdef", "johnny", now.Add(-2*time.Hour), end, 10, 5))
+		events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+		// when
+		_, err := events.Close(ctx, "abcdef", "johnny")
+
+		// then
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), end.UTC(), getSocialEvent(fakeClient, "abcdef").Spec.EndTime.UTC())
+	})
+}
+
+func (s *TestSocialEventsSuite) TestAttendees() {
+	// given
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	now := time.Now()
+	newUserSignup := func(name, event string, created time.Time) *toolchainv1alpha1.UserSignup {
+		userSignup := testusersignup.NewUserSignup(testusersignup.WithName(name),
+			testusersignup.WithLabel(toolchainv1alpha1.SocialEventUserSignupLabelKey, event),
+			testusersignup.WithStateLabel(toolchainv1alpha1.UserSignupStateLabelValueApproved))
+		userSignup.CreationTimestamp = metav1.NewTime(created)
+		return userSignup
+	}
+	fakeClient := commontest.NewFakeClient(s.T(),
+		newSocialEvent("abcdef", "johnny", now.Add(-time.Hour), now.Add(time.Hour), 10, 2),
+		newSocialEvent("other1", "jane", now.Add(-time.Hour), now.Add(time.Hour), 10, 1),
+		newUserSignup("ted", "abcdef", now),
+		newUserSignup("jack", "abcdef", now.Add(-time.Minute)),
+		newUserSignup("jill", "other1", now),
+	)
+	events := socialevents.NewSocialEvents(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
+
+	s.Run("attendees of own event", func() {
+		// when
+		attendees, err := events.Attendees(ctx, "abcdef", "johnny")
+
+		// then
+		require.NoError(s.T(), err)
+		require.Len(s.T(), attendees, 2)
+		assert.Equal(s.T(), "jack", attendees[0].Name)
+		assert.Equal(s.T(), "ted", attendees[1].Name)
+		assert.Equal(s.T(), toolchainv1alpha1.UserSignupStateLabelValueApproved, attendees[1].State)
+	})
+
+	s.Run("attendees of the event of another organizer", func() {
+		// when
+		_, err := events.Attendees(ctx, "other1", "johnny")
+
+		// then
+		require.ErrorIs(s.T(), err, socialevents.ErrNotFound)
+	})
+}
@@ -2,6 +2,30 @@ package username
 
 // Response represents the result of a search request done by using the MUR name or an email address.
 // Based on the query string the response might contain multiple usernames or no username at all.
-type Response []struct {
+type Response []Username
+
+// Username is a username found by a search request
+type Username struct {
 	Username string `json:"username,omitempty"`
 }
+
+// Availability represents the result of the check of the availability of a username, done before signing up
+type Availability struct {
+	// The requested username
+	Name string `json:"name"`
+	// The compliant username that would be derived from the requested username
+	CompliantUsername string `json:"compliantUsername,omitempty"`
+	// Whether the compliant username is available
+	Available bool `json:"available"`
+	// The reason why the username is not available
+	Reason string `json:"reason,omitempty"`
+	// Available alternatives, when the compliant username is already taken
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+const (
+	// ReasonTaken is the reason returned when the compliant username is already used by another user
+	ReasonTaken = "Taken"
+	// ReasonForbidden is the reason returned when the username is not allowed to sign up
+	ReasonForbidden = "Forbidden"
+)
@@ -92,11 +92,11 @@ func (s *ServiceImpl) InitVerification(ctx *gin.Context, username, e164PhoneNumb
 	// check that verification is required before proceeding
 	if !states.VerificationRequired(signup) {
 		log.Info(ctx, fmt.Sprintf("phone verification attempted for user without verification requirement: '%s'", signup.Name))
-		return crterrors.NewBadRequest("forbidden request", "verification code will not be sent")
+		return crterrors.WithErrorCode(crterrors.NewBadRequest("forbidden request", "verification code will not be sent"), crterrors.VerificationNotRequired)
 	}
 
 	if states.Rejected(signup) {
-		return crterrors.NewForbiddenError("phone verification rejected", "cannot proceed with verification")
+		return crterrors.WithErrorCode(crterrors.NewForbiddenError("phone verification rejected", "cannot proceed with verification"), crterrors.VerificationRejected)
 	}
 
 	// Check if the provided phone number is already being used by another user
@@ -106,7 +106,8 @@ func (s *ServiceImpl) InitVerification(ctx *gin.Context, username, e164PhoneNumb
 		switch {
 		case errors.As(err, &e) && e.Code == http.StatusForbidden:
 			log.Errorf(ctx, err, "phone number already in use, cannot register using phone number: %s", e164PhoneNumber)
-			return crterrors.NewForbiddenError("phone number already in use", fmt.Sprintf("cannot register using phone number: %s", e164PhoneNumber))
+			return crterrors.WithErrorCode(crterrors.NewForbiddenError("phone number already in use", fmt.Sprintf("cannot register using phone number: %s", e164PhoneNumber)),
+				crterrors.VerificationPhoneInUse)
 		default:
 			log.Error(ctx, err, "error while looking up users by phone number")
 			return crterrors.NewInternalError(err, "could not lookup users by phone number")
@@ -157,7 +158,9 @@ func (s *ServiceImpl) InitVerification(ctx *gin.Context, username, e164PhoneNumb
 	// check if counter has exceeded the limit of daily limit - if at limit error out
 	if counter >= dailyLimit {
 		log.Error(ctx, err, fmt.Sprintf("%d attempts made. the daily limit of %d has been exceeded", counter, dailyLimit))
-		initError = crterrors.NewForbiddenError("daily limit exceeded", "cannot generate new verification code")
+		initError = crterrors.WithErrorCode(crterrors.NewForbiddenError("daily limit exceeded", "cannot generate new verification code"), crterrors.VerificationDailyLimit)
+		// the counter is reset 24 hours after the first verification
+		initError = crterrors.WithRetryAfter(initError, ts.Add(24*time.Hour).Sub(now))
 	} else {
 		rejectSignup, initError = s.performPhoneLookup(ctx, cfg, signup, e164PhoneNumber, phoneHash, annotationValues)
 
@@ -264,7 +267,7 @@ func (s *ServiceImpl) performPhoneLookup(ctx *gin.Context, cfg configuration.Reg
 		log.Info(ctx, fmt.Sprintf("high risk phone detected (carrier_risk=%s, blocked=%t, phone_lookup_mode=%s)",
 			result.CarrierRiskCategory, result.NumberBlocked, mode))
 		if mode == toolchainv1alpha1.PhoneLookupModeEnabled {
-			return true, crterrors.NewForbiddenError("phone verification rejected", "cannot proceed with verification")
+			return true, crterrors.WithErrorCode(crterrors.NewForbiddenError("phone verification rejected", "cannot proceed with verification"), crterrors.VerificationRejected)
 		}
 	}
 	return false, nil
@@ -345,8 +348,8 @@ func (s *ServiceImpl) VerifyPhoneCode(ctx *gin.Context, username, code string) (
 	err := PhoneNumberAlreadyInUse(s.Client, username, signup.Labels[toolchainv1alpha1.UserSignupUserPhoneHashLabelKey])
 	if err != nil {
 		log.Error(ctx, err, "phone number to verify already in use")
-		return crterrors.NewBadRequest("phone number already in use",
-			"the phone number provided for this signup is already in use by an active account")
+		return crterrors.WithErrorCode(crterrors.NewBadRequest("phone number already in use",
+			"the phone number provided for this signup is already in use by an active account"), crterrors.VerificationPhoneInUse)
 	}
 
 	now := time.Now()
@@ -365,7 +368,8 @@ func (s *ServiceImpl) VerifyPhoneCode(ctx *gin.Context, username, code string) (
 
 	// If the user has made more attempts than is allowed per generated verification code, return an error
 	if attemptsMade >= cfg.Verification().AttemptsAllowed() {
-		verificationErr = crterrors.NewTooManyRequestsError("too many verification attempts", "")
+		verificationErr = crterrors.WithErrorCode(crterrors.NewTooManyRequestsError("too many verification attempts", ""), crterrors.VerificationTooManyAttempts)
+		verificationErr = crterrors.WithAttemptsRemaining(verificationErr, 0)
 	}
 
 	if verificationErr == nil {
@@ -376,7 +380,7 @@ func (s *ServiceImpl) VerifyPhoneCode(ctx *gin.Context, username, code string) (
 			verificationErr = crterrors.NewInternalError(parseErr, "error parsing expiry timestamp")
 		} else if now.After(exp) {
 			// If it is now past the expiry timestamp for the verification code, return a 403 Forbidden error
-			verificationErr = crterrors.NewForbiddenError("expired", "verification code expired")
+			verificationErr = crterrors.WithErrorCode(crterrors.NewForbiddenError("expired", "verification code expired"), crterrors.VerificationCodeExpired)
 		}
 	}
 
@@ -385,7 +389,8 @@ func (s *ServiceImpl) VerifyPhoneCode(ctx *gin.Context, username, code string) (
 			// The code doesn't match
 			attemptsMade++
 			annotationValues[toolchainv1alpha1.UserVerificationAttemptsAnnotationKey] = strconv.Itoa(attemptsMade)
-			verificationErr = crterrors.NewForbiddenError("invalid code", "the provided code is invalid")
+			verificationErr = crterrors.WithErrorCode(crterrors.NewForbiddenError("invalid code", "the provided code is invalid"), crterrors.VerificationCodeInvalid)
+			verificationErr = crterrors.WithAttemptsRemaining(verificationErr, max(cfg.Verification().AttemptsAllowed()-attemptsMade, 0))
 		}
 	}
 
@@ -456,7 +461,7 @@ func checkRequiredManualApproval(ctx *gin.Context, signup *toolchainv1alpha1.Use
 		}
 		if parseErr == nil && float32(fscore) < cfg.Verification().CaptchaRequiredScore() {
 			log.Info(ctx, fmt.Sprintf("captcha score %v is too low, automatic verification disabled, manual approval required for user", float32(fscore)))
-			return crterrors.NewForbiddenError("verification failed", "verification is not available at this time")
+			return crterrors.WithErrorCode(crterrors.NewForbiddenError("verification failed", "verification is not available at this time"), crterrors.VerificationRejected)
 		}
 	}
 	return nil
@@ -493,6 +498,10 @@ func (s *ServiceImpl) VerifyActivationCode(ctx *gin.Context, username, code stri
 			signup.Annotations = map[string]string{}
 		}
 		event, err := signuppkg.GetAndValidateSocialEvent(ctx, s.Client, code)
+		if err == nil {
+			// consume the personal code, if the event has personal codes
+			err = signuppkg.ConsumePersonalCode(ctx, s.Client, event, code, signup)
+		}
 		if err != nil {
 			attemptsMade++
 			signup.Annotations[toolchainv1alpha1.UserVerificationAttemptsAnnotationKey] = strconv.Itoa(attemptsMade)
@@ -540,7 +549,7 @@ func PhoneNumberAlreadyInUse(cl namespaced.Client, username, phoneNumberOrHash s
 	}
 
 	if len(bannedUserList.Items) > 0 {
-		return crterrors.NewForbiddenError("cannot re-register with phone number", "phone number already in use")
+		return crterrors.WithErrorCode(crterrors.NewForbiddenError("cannot re-register with phone number", "phone number already in use"), crterrors.VerificationPhoneInUse)
 	}
 
 	labelSelector := client.MatchingLabels{
@@ -554,8 +563,8 @@ func PhoneNumberAlreadyInUse(cl namespaced.Client, username, phoneNumberOrHash s
 
 	for _, signup := range userSignups.Items {
 		if signup.Spec.IdentityClaims.PreferredUsername != username && !states.Deactivated(&signup) {
-			return crterrors.NewForbiddenError("cannot re-register with phone number",
-				"phone number already in use")
+			return crterrors.WithErrorCode(crterrors.NewForbiddenError("cannot re-register with phone number",
+				"phone number already in use"), crterrors.VerificationPhoneInUse)
 		}
 	}
 
@@ -576,7 +585,15 @@ func checkAttempts(signup *toolchainv1alpha1.UserSignup) (int, error) {
 	}
 	// If the user has made more attempts than is allowed per generated verification code, return an error
 	if attemptsMade >= cfg.Verification().AttemptsAllowed() {
-		return attemptsMade, crterrors.NewTooManyRequestsError("too many verification attempts", signup.Annotations[toolchainv1alpha1.UserVerificationAttemptsAnnotationKey])
+		return attemptsMade, crterrors.WithErrorCode(crterrors.NewTooManyRequestsError("too many verification attempts",
+			signup.Annotations[toolchainv1alpha1.UserVerificationAttemptsAnnotationKey]), crterrors.VerificationTooManyAttempts)
 	}
 	return attemptsMade, nil
 }
+
+// CheckActivationCode returns the status of the SocialEvent of the given activation code.
+// Unlike VerifyActivationCode, it has no side effect: the UserSignup is neither created nor updated,
+// and no verification attempt is counted.
+func (s *ServiceImpl) CheckActivationCode(ctx *gin.Context, code string) (*signuppkg.ActivationCode, error) {
+	return signuppkg.CheckActivationCode(ctx, s.Client, code)
+}
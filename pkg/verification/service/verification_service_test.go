@@ -440,6 +440,10 @@ func (s *TestVerificationServiceSuite) TestInitVerificationFailsDailyCounterExce
 	err := application.VerificationService().InitVerification(ctx, userSignup.Spec.IdentityClaims.PreferredUsername, "+1NUMBER", "1")
 	require.EqualError(s.T(), err, "daily limit exceeded: cannot generate new verification code", err.Error())
 	require.Empty(s.T(), userSignup.Annotations[toolchainv1alpha1.UserSignupVerificationCodeAnnotationKey])
+	problem := crterrors.NewProblem(http.StatusForbidden, err)
+	assert.Equal(s.T(), crterrors.VerificationDailyLimit, problem.ErrorCode)
+	require.NotNil(s.T(), problem.RetryAfter)
+	assert.InDelta(s.T(), (24 * time.Hour).Seconds(), float64(*problem.RetryAfter), 5) // the counter is reset 24 hours after the first verification
 }
 
 func (s *TestVerificationServiceSuite) TestInitVerificationFailsWhenPhoneNumberInUse() {
@@ -592,6 +596,10 @@ func (s *TestVerificationServiceSuite) TestVerifyPhoneCode() {
 		require.ErrorAs(s.T(), err, &e)
 		require.Equal(s.T(), "invalid code: the provided code is invalid", e.Error())
 		require.Equal(s.T(), http.StatusForbidden, int(e.Code))
+		problem := crterrors.NewProblem(http.StatusForbidden, err)
+		assert.Equal(s.T(), crterrors.VerificationCodeInvalid, problem.ErrorCode)
+		require.NotNil(s.T(), problem.AttemptsRemaining)
+		assert.Equal(s.T(), configuration.GetRegistrationServiceConfig().Verification().AttemptsAllowed()-1, *problem.AttemptsRemaining)
 	})
 
 	s.Run("when verification code has expired", func() {
@@ -612,6 +620,7 @@ func (s *TestVerificationServiceSuite) TestVerifyPhoneCode() {
 		require.ErrorAs(s.T(), err, &e)
 		require.Equal(s.T(), "expired: verification code expired", e.Error())
 		require.Equal(s.T(), http.StatusForbidden, int(e.Code))
+		assert.Equal(s.T(), crterrors.VerificationCodeExpired, crterrors.NewProblem(http.StatusForbidden, err).ErrorCode)
 	})
 
 	s.Run("when verifications exceeded maximum attempts", func() {
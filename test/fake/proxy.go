@@ -45,6 +45,14 @@ func (m *SignupService) GetSignup(_ *gin.Context, username string, _ bool) (*sig
 	return m.MockGetSignup(username)
 }
 
+func (m *SignupService) DeactivateSignup(_ *gin.Context, _ string) (bool, error) {
+	return false, nil
+}
+
+func (m *SignupService) RequestTrialExtension(_ *gin.Context, _, _ string) (*signup.TrialExtension, error) {
+	return nil, nil
+}
+
 func (m *SignupService) Signup(_ *gin.Context) (*toolchainv1alpha1.UserSignup, error) {
 	return nil, nil
 }
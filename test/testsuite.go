@@ -2,6 +2,7 @@ package test
 
 import (
 	"context"
+	"testing"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	"github.com/codeready-toolchain/registration-service/pkg/configuration"
@@ -86,6 +87,7 @@ func (s *UnitTestSuite) DefaultConfig() configuration.RegistrationServiceConfig
 	// use a new configuration client to fully reset configuration
 	s.ConfigClient = test.NewFakeClient(s.T())
 	commonconfig.ResetCache()
+	configuration.SetSettings(&configuration.Settings{})
 	obj := testconfig.NewToolchainConfigObj(s.T(), testconfig.RegistrationService().Environment("unit-tests"))
 	err := s.ConfigClient.Create(context.TODO(), obj)
 	require.NoError(s.T(), err)
@@ -94,6 +96,16 @@ func (s *UnitTestSuite) DefaultConfig() configuration.RegistrationServiceConfig
 	return configuration.GetRegistrationServiceConfig()
 }
 
+// SetSettings sets the settings of the registration service from the given YAML document, until the end of the test
+func SetSettings(t *testing.T, document string) {
+	settings, err := configuration.ParseSettings([]byte(document))
+	require.NoError(t, err)
+	previous := configuration.SetSettings(settings)
+	t.Cleanup(func() {
+		configuration.SetSettings(previous)
+	})
+}
+
 // TearDownSuite tears down the test suite.
 func (s *UnitTestSuite) TearDownSuite() {
 	// summon the GC!
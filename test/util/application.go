@@ -16,7 +16,7 @@ func PrepareInClusterApplication(t *testing.T, objects ...client.Object) applica
 }
 
 func PrepareInClusterApp(t *testing.T, objects ...client.Object) (*commontest.FakeClient, application.Application) {
-	fakeClient := commontest.NewFakeClient(t, objects...)
+	fakeClient := NewFakeClient(t, objects...)
 	app := server.NewInClusterApplication(namespaced.NewClient(fakeClient, commontest.HostOperatorNs))
 	return fakeClient, app
 }
@@ -0,0 +1,39 @@
+package util
+
+import (
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/registration-service/pkg/indexes"
+	commontest "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake" //nolint: staticcheck // not deprecated anymore: see https://github.com/kubernetes-sigs/controller-runtime/pull/1101
+)
+
+// NewFakeClient returns a fake client which, unlike the one returned by `commontest.NewFakeClient`,
+// supports the field indexes registered in the informer cache at runtime
+func NewFakeClient(t *testing.T, initObjs ...client.Object) *commontest.FakeClient {
+	s := scheme.Scheme
+	err := toolchainv1alpha1.AddToScheme(s)
+	require.NoError(t, err)
+
+	kinds := s.KnownTypes(toolchainv1alpha1.GroupVersion)
+	toolchainObjs := make([]client.Object, 0, len(kinds))
+	for kind := range kinds {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(toolchainv1alpha1.GroupVersion.WithKind(kind))
+		toolchainObjs = append(toolchainObjs, obj)
+	}
+
+	builder := fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(initObjs...).
+		WithStatusSubresource(toolchainObjs...)
+	for _, idx := range indexes.All() {
+		builder = builder.WithIndex(idx.Object, idx.Field, idx.Extract)
+	}
+	return &commontest.FakeClient{Client: builder.Build(), T: t}
+}